@@ -0,0 +1,118 @@
+// Command import fetches movies, along with their cast and crew, from TMDB and merges
+// them into the movie graph, e.g. to import a few movies by TMDB ID:
+//
+//	go run ./cmd/import -ids 603,604,605
+//
+// to import the popular movies of TMDB:
+//
+//	go run ./cmd/import -popular 5
+//
+// or to import again the movies of the graph edited on TMDB since the previous sync,
+// as the nightly sync of the app does:
+//
+//	go run ./cmd/import -sync
+//
+// It connects with the settings of the app, TMDB_API_KEY included.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/config"
+	"github.com/neo4j-graphacademy/neoflix/pkg/importer"
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+)
+
+func main() {
+	configFile := flag.String("config", "config.json",
+		"JSON or YAML file of the settings, overridden by the environment variables of the same name")
+	ids := flag.String("ids", "", "comma-separated TMDB IDs of the movies to import")
+	popular := flag.Int("popular", 0, "number of pages of the popular movies of TMDB to import, 20 movies per page")
+	sync := flag.Bool("sync", false, "import again the movies of the graph edited on TMDB since the previous sync")
+	batchSize := flag.Int("batch-size", importer.DefaultBatchSize, "number of movies written per transaction")
+	flag.Parse()
+	ctx := context.Background()
+
+	settings, err := config.Load(*configFile, os.Getenv)
+	ioutils.PanicOnError(err)
+	if settings.TMDBApiKey == "" {
+		ioutils.PanicOnError(fmt.Errorf("TMDB_API_KEY is required"))
+	}
+	movieIds, err := parseIds(*ids)
+	ioutils.PanicOnError(err)
+	if len(movieIds) == 0 && *popular <= 0 && !*sync {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	driver, err := config.NewDriver(ctx, settings)
+	ioutils.PanicOnError(err)
+	defer func() {
+		ioutils.PanicOnError(driver.Close(ctx))
+	}()
+	tmdb := importer.New(importer.NewTMDBClient(importer.TMDBConfig{
+		ApiKey:            settings.TMDBApiKey,
+		BaseUrl:           settings.TMDBBaseUrl,
+		RequestsPerSecond: settings.TMDBRequestsPerSecond,
+		Timeout:           time.Duration(settings.TMDBTimeoutMs) * time.Millisecond,
+	}), driver, importer.Options{BatchSize: *batchSize})
+
+	if len(movieIds) > 0 {
+		report, err := tmdb.Import(ctx, movieIds)
+		ioutils.PanicOnError(err)
+		printReport("Imported the movies", report)
+	}
+	if *popular > 0 {
+		report, err := tmdb.ImportPopular(ctx, *popular)
+		ioutils.PanicOnError(err)
+		printReport("Imported the popular movies", report)
+	}
+	if *sync {
+		report, err := tmdb.Sync(ctx, time.Now())
+		ioutils.PanicOnError(err)
+		printReport("Synced the movies edited on TMDB", report)
+	}
+}
+
+func parseIds(value string) ([]int, error) {
+	var ids []int
+	for _, field := range strings.Split(value, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		id, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a TMDB ID", field)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func printReport(title string, report importer.Report) {
+	fmt.Printf("%s: fetched %d, wrote %d\n", title, report.Fetched, report.Written)
+	for _, id := range report.NotFound {
+		fmt.Printf("Movie %d not found on TMDB\n", id)
+	}
+	printCounts("Normalized %d values of %s\n", report.Normalized.Normalized)
+	printCounts("Left %d invalid values of %s as is\n", report.Normalized.Invalid)
+}
+
+// printCounts prints the counts per property, sorted by property
+func printCounts(format string, counts map[string]int) {
+	properties := make([]string, 0, len(counts))
+	for property := range counts {
+		properties = append(properties, property)
+	}
+	sort.Strings(properties)
+	for _, property := range properties {
+		fmt.Printf(format, counts[property], property)
+	}
+}
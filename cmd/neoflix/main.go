@@ -1,71 +1,714 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"io/fs"
+	"log/slog"
 	"net/http"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/neo4j-graphacademy/neoflix"
+	"github.com/neo4j-graphacademy/neoflix/pkg/bookmarks"
+	"github.com/neo4j-graphacademy/neoflix/pkg/embeddings"
 	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
+	"github.com/neo4j-graphacademy/neoflix/pkg/importer"
 
 	config "github.com/neo4j-graphacademy/neoflix/pkg/config"
 
 	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j-graphacademy/neoflix/pkg/jobs"
+	"github.com/neo4j-graphacademy/neoflix/pkg/logging"
+	"github.com/neo4j-graphacademy/neoflix/pkg/mail"
+	"github.com/neo4j-graphacademy/neoflix/pkg/metrics"
+	"github.com/neo4j-graphacademy/neoflix/pkg/migrations"
+	"github.com/neo4j-graphacademy/neoflix/pkg/queries"
 	"github.com/neo4j-graphacademy/neoflix/pkg/routes"
+	"github.com/neo4j-graphacademy/neoflix/pkg/routes/paging"
 	"github.com/neo4j-graphacademy/neoflix/pkg/services"
+	"github.com/neo4j-graphacademy/neoflix/pkg/services/flags"
+	"github.com/neo4j-graphacademy/neoflix/pkg/services/sharetokens"
+	"github.com/neo4j-graphacademy/neoflix/pkg/signing"
+	"github.com/neo4j-graphacademy/neoflix/pkg/storage"
+	"github.com/neo4j-graphacademy/neoflix/pkg/tracing"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
 func main() {
-	settings, err := config.ReadConfig("config.json")
+	verifyQueries := flag.Bool("verify-queries", false,
+		"EXPLAIN all the Cypher statements of the catalog against the database, then exit")
+	selfTestMode := flag.Bool("selftest", false,
+		"check the queries, the database schema and the authentication settings, then exit")
+	configFile := flag.String("config", "config.json",
+		"JSON or YAML file of the settings, overridden by the environment variables of the same name")
+	flag.Parse()
+	ctx := context.Background()
+
+	settings, err := config.Load(*configFile, os.Getenv)
+	ioutils.PanicOnError(err)
+	logger, err := logging.New(os.Stderr, logging.Config{Level: settings.LogLevel, Format: settings.LogFormat})
+	ioutils.PanicOnError(err)
+	catalog, err := queries.Embedded()
 	ioutils.PanicOnError(err)
 	// tag::useDriver[]
 	// tag::driver[]
-	driver, err := config.NewDriver(settings)
+	driver, err := config.NewDriver(ctx, settings)
 	// end::driver[]
 	ioutils.PanicOnError(err)
 	defer func() {
-		ioutils.PanicOnError(driver.Close())
+		ioutils.PanicOnError(driver.Close(ctx))
 	}()
 
+	dialect, err := queries.DetectDialect(ctx, driver)
+	ioutils.PanicOnError(err)
+	catalog = catalog.ForDialect(dialect)
+	fmt.Printf("Using the %s Cypher dialect\n", dialect)
+	gds, err := queries.DetectGds(ctx, driver)
+	ioutils.PanicOnError(err)
+	if !gds {
+		fmt.Println("The Graph Data Science library is not available, similar movies are scored with Cypher only")
+	}
+	database, err := config.DefaultDatabase(ctx, driver)
+	ioutils.PanicOnError(err)
+	runtime := config.NewRuntime(settings, config.DatabaseRuntime{Name: database, Dialect: string(dialect), Gds: gds})
+
+	if *verifyQueries {
+		code := verify(ctx, catalog, driver)
+		ioutils.PanicOnError(driver.Close(ctx))
+		os.Exit(code)
+	}
+	if *selfTestMode {
+		code := selfTest(ctx, settings, catalog, driver)
+		ioutils.PanicOnError(driver.Close(ctx))
+		os.Exit(code)
+	}
+
+	applied, err := migrations.Run(ctx, driver)
+	for _, name := range applied {
+		fmt.Printf("Applied the %s migration\n", name)
+	}
+	if migrationErr, ok := err.(migrations.MigrationError); ok {
+		// the app runs without the missing schema, only slower, until the data is fixed
+		fmt.Printf("%v, it will be retried on the next start\n", migrationErr)
+	} else {
+		ioutils.PanicOnError(err)
+	}
+	embedder := plotIndexEmbedder(ctx, driver, settings)
+
+	retryMetrics := services.NewRetryMetrics()
+	appMetrics := metrics.New()
+	driver = appMetrics.InstrumentDriver(driver)
+	if settings.TracingEnabled {
+		shutdown, err := tracing.Setup(ctx, tracing.Config{
+			ServiceName: services.AppName,
+			SampleRate:  tracingSampleRate(settings),
+		})
+		ioutils.PanicOnError(err)
+		defer func() {
+			_ = shutdown(context.Background())
+		}()
+	}
+	// the transactions of each service are retried according to its own policy, and
+	// their attempts counted across the retries of the policy, to report them
+	sessions := func(service string) neo4j.DriverWithContext {
+		sessions := services.NewRetryCountingDriver(
+			services.NewRetryingDriver(driver, retryPolicy(settings, service)), retryMetrics)
+		if settings.TracingEnabled {
+			// the sessions are named after the service method opening them, so the
+			// tracing driver must be the last decorator
+			sessions = tracing.NewTracingDriver(sessions)
+		}
+		return sessions
+	}
+
 	fixtureLoader := &fixtures.FixtureLoader{Prefix: "."}
+	opts := []services.Option{
+		services.WithDeadlines(deadlines(settings)),
+		services.WithCatalog(catalog),
+		services.WithPropertyMapping(settings.PropertyMapping),
+		services.WithSimilarityWeights(similarityWeights(settings)),
+		services.WithGds(gds),
+		// the failures the services recover from are logged as warnings
+		services.WithLogger(slog.NewLogLogger(logger.Handler(), slog.LevelWarn)),
+	}
+	if embedder != nil {
+		opts = append(opts, services.WithEmbedder(embedder))
+	}
+	if settings.RecommendationMinRating > 0 {
+		opts = append(opts, services.WithRecommendationMinRating(settings.RecommendationMinRating))
+	}
+	if settings.SimilarityMaxFanOut > 0 {
+		opts = append(opts, services.WithSimilarityMaxFanOut(settings.SimilarityMaxFanOut))
+	}
+	if settings.ScoreScale > 0 {
+		opts = append(opts, services.WithScoreScale(settings.ScoreScale))
+	}
+	if settings.RatingAnomalyExcludeFlagged {
+		opts = append(opts, services.WithFlaggedRatingsExcluded())
+	}
+	if settings.LenientFavorites {
+		opts = append(opts, services.WithLenientFavorites(true))
+	}
+	if settings.ShadowReadSampleRate > 0 {
+		opts = append(opts, services.WithShadowReads(sessions("shadowReads"), settings.ShadowReadSampleRate))
+	}
+	authService := services.NewAuthService(fixtureLoader, sessions("auth"), settings.JwtSecret, settings.SaltRounds, opts...)
+	digestService := services.NewDigestService(fixtureLoader, sessions("digests"), opts...)
+	savedSearchService := services.NewSavedSearchService(fixtureLoader, sessions("savedSearches"), opts...)
+	maintenanceService := services.NewMaintenanceService(fixtureLoader, sessions("maintenance"), opts...)
+	ratingFlagService := services.NewRatingFlagService(fixtureLoader, sessions("ratingFlags"), ratingAnomalyThresholds(settings), opts...)
+	cacheOptions := services.CacheOptions{
+		TTL:        time.Duration(settings.CacheTtlMs) * time.Millisecond,
+		StaleTTL:   time.Duration(settings.CacheStaleTtlMs) * time.Millisecond,
+		MaxEntries: settings.CacheMaxEntries,
+		Logger:     slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+	movieService := services.NewMovieService(fixtureLoader, sessions("movies"), opts...)
+	if settings.CacheUserDataVersions {
+		movieService = services.NewVersionedCachedMovieService(movieService,
+			services.NewDataVersionService(fixtureLoader, sessions("dataVersions"), opts...), cacheOptions)
+	} else {
+		movieService = services.NewCachedMovieService(movieService, cacheOptions)
+	}
+	// the personalized lists are dropped from the cache once the favorites, ratings or
+	// excluded content warnings they depend on change
+	userCache := movieService.(services.UserCache)
+	ratingService := services.NewCacheInvalidatingRatingService(
+		services.NewRatingService(fixtureLoader, sessions("ratings"), opts...), userCache)
+	favoriteService := services.NewCacheInvalidatingFavoriteService(
+		services.NewFavoriteService(fixtureLoader, sessions("favorites"), opts...), userCache)
+	contentWarningService := services.NewCacheInvalidatingContentWarningService(
+		services.NewContentWarningService(fixtureLoader, sessions("contentWarnings"), opts...), userCache)
+
+	lookupCacheOptions := services.CacheOptions{
+		TTL:        time.Duration(settings.LookupCacheTtlMs) * time.Millisecond,
+		MaxEntries: settings.LookupCacheMaxEntries,
+	}
+	genreService := services.NewCachedGenreService(
+		services.NewGenreService(fixtureLoader, sessions("genres"), opts...), lookupCacheOptions)
+	peopleService := services.NewCachedPeopleService(
+		services.NewPeopleService(fixtureLoader, sessions("people"), opts...), lookupCacheOptions)
+	caches := cachedServices(map[string]interface{}{
+		"movies": movieService,
+		"genres": genreService,
+		"people": peopleService,
+	})
 
+	searchAnalyticsService := services.NewSearchAnalyticsService(fixtureLoader, sessions("searchAnalytics"), opts...)
+	if settings.SearchAnalyticsEnabled {
+		movieService = services.NewSearchRecordingMovieService(movieService, searchAnalyticsService)
+		peopleService = services.NewSearchRecordingPeopleService(peopleService, searchAnalyticsService)
+	}
+	movieService = appMetrics.InstrumentMovieService(movieService)
+	peopleService = appMetrics.InstrumentPeopleService(peopleService)
+	appMetrics.WatchCaches(caches)
+
+	aggregateCheckMetrics := services.NewAggregateCheckMetrics()
+	experiment := recommendationExperiment(settings, opts)
+	recommendationService := services.NewRecommendationService(fixtureLoader, sessions("recommendations"), experiment, opts...)
 	allRoutes := allRoutes(
-		services.NewMovieService(fixtureLoader, driver),
-		services.NewGenreService(fixtureLoader, driver),
-		services.NewRatingService(fixtureLoader, driver),
-		services.NewPeopleService(fixtureLoader, driver),
-		services.NewAuthService(fixtureLoader, driver, settings.JwtSecret, settings.SaltRounds),
-		services.NewFavoriteService(fixtureLoader, driver))
+		movieService,
+		genreService,
+		ratingService,
+		peopleService,
+		authService,
+		favoriteService,
+		services.NewSitemapService(fixtureLoader, sessions("sitemap"), opts...),
+		services.NewAvatarService(fixtureLoader, sessions("avatars"), avatarStorage(settings), opts...),
+		contentWarningService,
+		services.NewFollowService(fixtureLoader, sessions("follows"), opts...),
+		services.NewBlockService(fixtureLoader, sessions("blocks"), opts...),
+		services.NewCatalogService(fixtureLoader, sessions("catalog"), opts...),
+		settings.PartnerApiKeys,
+		digestService,
+		savedSearchService,
+		services.NewNotificationService(fixtureLoader, sessions("notifications"), opts...),
+		maintenanceService,
+		ratingFlagService,
+		services.NewReportService(fixtureLoader, sessions("reports"), opts...),
+		recommendationService,
+		services.NewOnboardingService(fixtureLoader, sessions("onboarding"), recommendationService, opts...),
+		services.NewEmailChangeService(fixtureLoader, sessions("emailChanges"), mailSender(settings), settings.MailFrom, opts...),
+		services.NewReviewService(fixtureLoader, sessions("reviews"), opts...),
+		shareTokens(settings),
+		featureFlags(settings, experiment),
+		services.NewDryRunService(fixtureLoader, sessions("dryRuns"), opts...),
+		searchAnalyticsService,
+		caches,
+		retryMetrics,
+		services.NewSupportService(fixtureLoader, sessions("support"), opts...),
+		aggregateCheckMetrics,
+		logger,
+		runtime,
+		publicUrl(settings))
 	// end::useDriver[]
 
-	server := newHttpServer()
+	go func() {
+		job := jobs.NewMovieStatusJob(movieService)
+		onError := func(err error) {
+			fmt.Printf("Movie status update failed: %v\n", err)
+		}
+		// label the movies imported since the last run right away
+		if err := job(context.Background(), time.Now()); err != nil {
+			onError(err)
+		}
+		jobs.Daily(context.Background(), 0, job, onError)
+	}()
+
+	go func() {
+		job := jobs.NewSortTitleJob(movieService)
+		onError := func(err error) {
+			fmt.Printf("Sort title update failed: %v\n", err)
+		}
+		// collate the titles of the movies imported since the last run right away
+		if err := job(context.Background(), time.Now()); err != nil {
+			onError(err)
+		}
+		jobs.Daily(context.Background(), 0, job, onError)
+	}()
+
+	go func() {
+		job := jobs.NewScoreJob(movieService)
+		onError := func(err error) {
+			fmt.Printf("Score update failed: %v\n", err)
+		}
+		// score the movies imported or rated since the last run right away
+		if err := job(context.Background(), time.Now()); err != nil {
+			onError(err)
+		}
+		jobs.Daily(context.Background(), 0, job, onError)
+	}()
+
+	if embedder != nil {
+		go func() {
+			job := jobs.NewPlotEmbeddingJob(movieService)
+			onError := func(err error) {
+				fmt.Printf("Plot embedding failed: %v\n", err)
+			}
+			// embed the plots of the movies imported since the last run right away
+			if err := job(context.Background(), time.Now()); err != nil {
+				onError(err)
+			}
+			jobs.Every(context.Background(), time.Hour, job, onError)
+		}()
+	}
+
+	if settings.AggregateCheckSample > 0 {
+		job := jobs.NewAggregateCheckJob(movieService, settings.AggregateCheckSample, aggregateCheckMetrics)
+		go jobs.Every(context.Background(), time.Hour, job, func(err error) {
+			fmt.Printf("Aggregate check failed: %v\n", err)
+		})
+	}
+
+	go jobs.Every(context.Background(), time.Hour, jobs.NewRatingAnomalyJob(ratingFlagService), func(err error) {
+		fmt.Printf("Rating anomaly detection failed: %v\n", err)
+	})
+
+	// notify the matches of the saved searches once the day's releases are labelled
+	go jobs.Daily(context.Background(), time.Hour, jobs.NewSavedSearchJob(savedSearchService), func(err error) {
+		fmt.Printf("Saved search notifications failed: %v\n", err)
+	})
+
+	if settings.DigestEnabled {
+		job := jobs.NewDigestJob(digestService, mailSender(settings), settings.MailFrom)
+		go jobs.Daily(context.Background(), time.Duration(settings.DigestHour)*time.Hour, job.Run, func(err error) {
+			fmt.Printf("Daily digest failed: %v\n", err)
+		})
+	}
+
+	if settings.TMDBSyncEnabled {
+		// re-imported plots are embedded again by the plot embedding job
+		job := jobs.NewTMDBSyncJob(importer.New(tmdbClient(settings), driver, importer.Options{}))
+		go jobs.Daily(context.Background(), time.Duration(settings.TMDBSyncHour)*time.Hour, job, func(err error) {
+			logger.Error("TMDB sync failed", "error", err)
+		})
+	}
+
+	if settings.WarmUpQueries > 0 {
+		warmUp(context.Background(), settings.WarmUpQueries, warmUpQueries(movieService, genreService, peopleService))
+	}
+
+	server := newHttpServer(settings)
 	for _, route := range allRoutes {
 		route.Register(server)
 	}
+	server.Handle("/metrics", appMetrics.Registry.Handler())
+
+	handler := routes.WithMaintenanceMode(server, maintenanceService)
+	handler = routes.WithRetryReporting(handler)
+	handler = routes.WithBookmarks(handler, bookmarkStore(settings), logger)
+	handler = routes.WithRequestLogging(handler, logger)
+	handler = routes.WithRequestMetadata(handler, authService)
+	if settings.TracingEnabled {
+		handler = routes.WithTracing(handler)
+	}
+	// the bearer token is verified once, for the other middlewares and the routes
+	handler = routes.WithAuthentication(handler, authService)
+	handler = routes.WithPagingOptions(handler, pagingOptions(settings))
+	handler = routes.WithQueryParameterAliases(handler, queryParameterAliases(settings))
+	handler = routes.WithBasePath(handler, settings.BasePath)
 
-	fmt.Printf("Server listening on http://localhost:%d\n", settings.Port)
-	if err := http.ListenAndServe(fmt.Sprintf(":%d", settings.Port), server); err != nil {
+	fmt.Printf("Server listening on http://localhost:%d%s\n", settings.Port, settings.BasePath)
+	if err := http.ListenAndServe(fmt.Sprintf(":%d", settings.Port), handler); err != nil {
 		ioutils.PanicOnError(err)
 	}
 }
 
-func newHttpServer() *http.ServeMux {
+// queryParameterAliases returns the default legacy names of the query parameters,
+// along with the configured ones
+func queryParameterAliases(settings *config.Config) routes.QueryParameterAliases {
+	aliases := routes.DefaultQueryParameterAliases()
+	for alias, name := range settings.QueryParameterAliases {
+		aliases[alias] = name
+	}
+	return aliases
+}
+
+// verify reports the catalog statements the database fails to plan and
+// returns the process exit code
+func verify(ctx context.Context, catalog *queries.Catalog, driver neo4j.DriverWithContext) int {
+	failures, err := catalog.Verify(ctx, driver)
+	ioutils.PanicOnError(err)
+	for _, failure := range failures {
+		fmt.Printf("FAIL %s\n", failure.Error())
+	}
+	fmt.Printf("%d/%d statements verified\n", len(catalog.All())-len(failures), len(catalog.All()))
+	if len(failures) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func newHttpServer(settings *config.Config) *http.ServeMux {
 	server := http.NewServeMux()
-	server.Handle("/", http.FileServer(http.Dir("public")))
+	server.Handle("/", routes.NewFrontendHandler(frontend(settings)))
+	if settings.AvatarStorage != "s3" {
+		server.Handle(avatarUrlPrefix+"/",
+			http.StripPrefix(avatarUrlPrefix, http.FileServer(http.Dir(settings.AvatarDirectory))))
+	}
 	return server
 }
 
+// pagingOptions returns the configured paging options, the default quota when unset,
+// the cursors being signed under a key of their own derived from JWT_SECRET
+func pagingOptions(settings *config.Config) paging.Options {
+	options := paging.DefaultOptions()
+	options.Cursors = signing.New(settings.JwtSecret, paging.CursorPurpose)
+	if settings.AnonymousPagingQuota != 0 {
+		options.AnonymousQuota = settings.AnonymousPagingQuota
+	}
+	return options
+}
+
+// publicUrl returns the URL the app is publicly reachable at, the local one when unset
+func publicUrl(settings *config.Config) string {
+	if settings.PublicUrl != "" {
+		return settings.PublicUrl
+	}
+	return fmt.Sprintf("http://localhost:%d%s", settings.Port, strings.TrimSuffix(settings.BasePath, "/"))
+}
+
+// frontend returns the front-end embedded in the binary, unless a directory to serve
+// it from is configured
+func frontend(settings *config.Config) fs.FS {
+	if settings.FrontendDirectory != "" {
+		return os.DirFS(settings.FrontendDirectory)
+	}
+	return neoflix.Frontend()
+}
+
+const avatarUrlPrefix = "/avatars"
+
+func avatarStorage(settings *config.Config) storage.Storage {
+	if settings.AvatarStorage == "s3" {
+		return storage.NewS3Storage(storage.S3Config{
+			Bucket:          settings.AvatarS3Bucket,
+			Region:          settings.AvatarS3Region,
+			AccessKeyId:     settings.AwsAccessKeyId,
+			SecretAccessKey: settings.AwsSecretAccessKey,
+			Endpoint:        settings.AvatarS3Endpoint,
+			PublicUrl:       settings.AvatarS3PublicUrl,
+		})
+	}
+	// the URLs of the avatars are stored, they include the base path
+	return storage.NewLocalStorage(settings.AvatarDirectory, strings.TrimSuffix(settings.BasePath, "/")+avatarUrlPrefix)
+}
+
+func tmdbClient(settings *config.Config) *importer.TMDBClient {
+	return importer.NewTMDBClient(importer.TMDBConfig{
+		ApiKey:            settings.TMDBApiKey,
+		BaseUrl:           settings.TMDBBaseUrl,
+		RequestsPerSecond: settings.TMDBRequestsPerSecond,
+		Timeout:           time.Duration(settings.TMDBTimeoutMs) * time.Millisecond,
+	})
+}
+
+// plotIndexEmbedder returns the configured embedder once the vector index of the plots
+// of the movies exists, nil when the semantic search falls back to the full-text search
+func plotIndexEmbedder(ctx context.Context, driver neo4j.DriverWithContext, settings *config.Config) embeddings.Embedder {
+	var embedder embeddings.Embedder
+	switch settings.Embedder {
+	case "":
+		return nil
+	case "openai":
+		embedder = embeddings.NewOpenAIEmbedder(embeddings.OpenAIConfig{
+			ApiKey:     settings.OpenAIApiKey,
+			Model:      settings.OpenAIEmbeddingModel,
+			Dimensions: settings.EmbeddingDimensions,
+			BaseUrl:    settings.OpenAIBaseUrl,
+		})
+	case "local":
+		embedder = embeddings.NewLocalEmbedder(settings.EmbeddingDimensions)
+	default:
+		ioutils.PanicOnError(fmt.Errorf("unsupported embedder %q", settings.Embedder))
+	}
+	vector, err := queries.DetectVectorIndexes(ctx, driver)
+	ioutils.PanicOnError(err)
+	if !vector {
+		fmt.Println("The database does not support vector indexes, movies are searched by full-text only")
+		return nil
+	}
+	err = migrations.EnsurePlotIndex(ctx, driver, embedder.Dimensions())
+	if migrationErr, ok := err.(migrations.MigrationError); ok {
+		fmt.Printf("%v, movies are searched by full-text only\n", migrationErr)
+		return nil
+	}
+	ioutils.PanicOnError(err)
+	return embedder
+}
+
+func mailSender(settings *config.Config) mail.Sender {
+	if settings.SmtpHost == "" {
+		return mail.NewWriterSender(os.Stdout)
+	}
+	return mail.NewSmtpSender(mail.SmtpConfig{
+		Host:     settings.SmtpHost,
+		Port:     settings.SmtpPort,
+		Username: settings.SmtpUsername,
+		Password: settings.SmtpPassword,
+	})
+}
+
+func similarityWeights(settings *config.Config) services.SimilarityWeights {
+	weights := services.DefaultSimilarityWeights()
+	for name, weight := range map[string]*float64{
+		"genre":    &weights.Genre,
+		"actor":    &weights.Actor,
+		"director": &weights.Director,
+		"rating":   &weights.Rating,
+		"taste":    &weights.Taste,
+	} {
+		if value, found := settings.SimilarityWeights[name]; found {
+			*weight = value
+		}
+	}
+	return weights
+}
+
+// bookmarkStore returns the store of the bookmarks of the users of BOOKMARK_STORE, nil
+// when unset
+func bookmarkStore(settings *config.Config) bookmarks.Store {
+	ttl := time.Duration(settings.BookmarkTtlMs) * time.Millisecond
+	switch settings.BookmarkStore {
+	case "memory":
+		return bookmarks.NewMemoryStore(ttl)
+	case "redis":
+		timeout := time.Duration(settings.RedisTimeoutMs) * time.Millisecond
+		return bookmarks.NewRedisStore(bookmarks.RedisConfig{
+			Addr:         settings.RedisAddr,
+			Password:     settings.RedisPassword,
+			DB:           settings.RedisDatabase,
+			TTL:          ttl,
+			DialTimeout:  timeout,
+			ReadTimeout:  timeout,
+			WriteTimeout: timeout,
+		})
+	}
+	return nil
+}
+
+// retryPolicy returns the retry policy of the service, the RETRY_* settings overridden
+// by the ones of the service in RETRY_POLICIES
+func retryPolicy(settings *config.Config, service string) services.RetryPolicy {
+	policy := services.DefaultRetryPolicy()
+	maxAttempts, initialBackoffMs, maxBackoffMs := settings.RetryMaxAttempts, settings.RetryInitialBackoffMs, settings.RetryMaxBackoffMs
+	if overrides, found := settings.RetryPolicies[service]; found {
+		if value, found := overrides["maxAttempts"]; found {
+			maxAttempts = value
+		}
+		if value, found := overrides["initialBackoffMs"]; found {
+			initialBackoffMs = value
+		}
+		if value, found := overrides["maxBackoffMs"]; found {
+			maxBackoffMs = value
+		}
+	}
+	if maxAttempts > 0 {
+		policy.MaxAttempts = maxAttempts
+	}
+	if initialBackoffMs > 0 {
+		policy.InitialBackoff = time.Duration(initialBackoffMs) * time.Millisecond
+	}
+	if maxBackoffMs > 0 {
+		policy.MaxBackoff = time.Duration(maxBackoffMs) * time.Millisecond
+	}
+	return policy
+}
+
+func ratingAnomalyThresholds(settings *config.Config) services.RatingAnomalyThresholds {
+	thresholds := services.DefaultRatingAnomalyThresholds()
+	if settings.RatingAnomalyWindowHours > 0 {
+		thresholds.Window = time.Duration(settings.RatingAnomalyWindowHours) * time.Hour
+	}
+	if settings.RatingAnomalyMinRatings > 0 {
+		thresholds.MinRatings = settings.RatingAnomalyMinRatings
+	}
+	if settings.RatingAnomalyMinShift > 0 {
+		thresholds.MinShift = settings.RatingAnomalyMinShift
+	}
+	return thresholds
+}
+
+func shareTokens(settings *config.Config) *sharetokens.Signer {
+	if settings.ShareSecret != "" {
+		return sharetokens.New(settings.ShareSecret)
+	}
+	return sharetokens.New(settings.JwtSecret)
+}
+
+// featureFlags evaluates the configured flags and the recommendation experiment,
+// whose buckets are the ones the server assigns
+func featureFlags(settings *config.Config, experiment services.RecommendationExperiment) *flags.Evaluator {
+	secret := settings.FlagsSecret
+	if secret == "" {
+		secret = settings.JwtSecret
+	}
+	return flags.New(secret, settings.FeatureFlags, map[string]flags.Bucketer{
+		experiment.Name: experiment.Bucket,
+	})
+}
+
+// recommendationExperiment splits the users between the content-based and collaborative
+// strategies, unless weights are configured
+func recommendationExperiment(settings *config.Config, opts []services.Option) services.RecommendationExperiment {
+	name := settings.RecommendationExperiment
+	if name == "" {
+		name = "recommendations"
+	}
+	weights := settings.RecommendationWeights
+	if len(weights) == 0 {
+		weights = map[string]int{"content": 1, "collaborative": 1}
+	}
+	experiment := services.RecommendationExperiment{Name: name}
+	// arms are listed in a fixed order for the assignment to be stable
+	for _, strategy := range []services.RecommendationStrategy{
+		services.NewContentBasedStrategy(opts...),
+		services.NewCollaborativeStrategy(opts...),
+		services.NewGdsStrategy(opts...),
+	} {
+		experiment.Arms = append(experiment.Arms, services.RecommendationArm{
+			Strategy: strategy,
+			Weight:   weights[strategy.Name()],
+		})
+	}
+	return experiment
+}
+
+func tracingSampleRate(settings *config.Config) float64 {
+	if settings.TracingSampleRate > 0 {
+		return settings.TracingSampleRate
+	}
+	return 1
+}
+
+// deadlines returns the configured deadlines, the default ones for the classes left unset
+func deadlines(settings *config.Config) services.Deadlines {
+	defaults := services.DefaultDeadlines()
+	// 0 keeps the default deadline of the class, and -1 disables it
+	orDefault := func(ms int, fallback time.Duration) time.Duration {
+		switch {
+		case ms == 0:
+			return fallback
+		case ms < 0:
+			return 0
+		}
+		return time.Duration(ms) * time.Millisecond
+	}
+	return services.Deadlines{
+		FastLookup: orDefault(settings.FastLookupDeadlineMs, defaults.FastLookup),
+		List:       orDefault(settings.ListDeadlineMs, defaults.List),
+		Similarity: orDefault(settings.SimilarityDeadlineMs, defaults.Similarity),
+		Export:     orDefault(settings.ExportDeadlineMs, defaults.Export),
+	}
+}
+
+// cachedServices keeps the services decorated with an in-process cache
+func cachedServices(candidates map[string]interface{}) map[string]services.CachedService {
+	result := map[string]services.CachedService{}
+	for name, candidate := range candidates {
+		if cached, ok := candidate.(services.CachedService); ok {
+			result[name] = cached
+		}
+	}
+	return result
+}
+
 func allRoutes(
 	movieService services.MovieService,
 	genreService services.GenreService,
 	ratingService services.RatingService,
 	peopleService services.PeopleService,
 	authService services.AuthService,
-	favoriteService services.FavoriteService) []routes.Routable {
+	favoriteService services.FavoriteService,
+	sitemapService services.SitemapService,
+	avatarService services.AvatarService,
+	contentWarningService services.ContentWarningService,
+	followService services.FollowService,
+	blockService services.BlockService,
+	catalogService services.CatalogService,
+	partnerApiKeys []string,
+	digestService services.DigestService,
+	savedSearchService services.SavedSearchService,
+	notificationService services.NotificationService,
+	maintenanceService services.MaintenanceService,
+	ratingFlagService services.RatingFlagService,
+	reportService services.ReportService,
+	recommendationService services.RecommendationService,
+	onboardingService services.OnboardingService,
+	emailChangeService services.EmailChangeService,
+	reviewService services.ReviewService,
+	shareTokens *sharetokens.Signer,
+	flagEvaluator *flags.Evaluator,
+	dryRunService services.DryRunService,
+	searchAnalyticsService services.SearchAnalyticsService,
+	caches map[string]services.CachedService,
+	retryMetrics *services.RetryMetrics,
+	supportService services.SupportService,
+	aggregateCheckMetrics *services.AggregateCheckMetrics,
+	logger *slog.Logger,
+	runtime *config.Runtime,
+	publicUrl string) []routes.Routable {
 
 	return []routes.Routable{
-		routes.NewGenreRoutes(genreService, movieService, authService),
-		routes.NewMovieRoutes(movieService, ratingService, authService),
+		routes.NewGenreRoutes(genreService, movieService, peopleService, authService),
+		routes.NewMovieRoutes(movieService, ratingService, reportService, authService),
 		routes.NewPeopleRoutes(peopleService, movieService, authService),
-		routes.NewAuthRoutes(authService),
-		routes.NewAccountRoutes(ratingService, authService, favoriteService),
+		routes.NewAuthRoutes(authService, emailChangeService),
+		routes.NewAccountRoutes(ratingService, authService, favoriteService, avatarService, contentWarningService, digestService,
+			savedSearchService, notificationService, recommendationService, onboardingService, emailChangeService,
+			reviewService, publicUrl),
+		routes.NewShareRoutes(movieService, publicUrl),
+		routes.NewListShareRoutes(favoriteService, ratingService, authService, shareTokens, publicUrl),
+		routes.NewFlagRoutes(authService, flagEvaluator),
+		routes.NewSitemapRoutes(sitemapService, publicUrl),
+		routes.NewFeedRoutes(movieService, publicUrl),
+		routes.NewPartnerRoutes(catalogService, partnerApiKeys),
+		routes.NewAdminRoutes(authService, genreService, contentWarningService, movieService, peopleService, maintenanceService, ratingFlagService,
+			reportService, recommendationService, dryRunService, searchAnalyticsService, caches, retryMetrics, supportService,
+			aggregateCheckMetrics, logger, runtime),
+		routes.NewUserRoutes(ratingService, followService, blockService, authService),
 	}
 }
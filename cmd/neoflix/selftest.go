@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/neo4j-graphacademy/neoflix/pkg/config"
+	"github.com/neo4j-graphacademy/neoflix/pkg/queries"
+	"github.com/neo4j-graphacademy/neoflix/pkg/services/jwtutils"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// selfTest checks the environment the server is about to run in: the catalog statements
+// plan against the database, the required indexes and constraints exist, and the
+// authentication settings are usable. It prints a report and returns the process exit code.
+func selfTest(ctx context.Context, settings *config.Config, catalog *queries.Catalog, driver neo4j.DriverWithContext) int {
+	failed := false
+	check := func(name string, problems []string) {
+		if len(problems) == 0 {
+			fmt.Printf("PASS %s\n", name)
+			return
+		}
+		failed = true
+		for _, problem := range problems {
+			fmt.Printf("FAIL %s: %s\n", name, problem)
+		}
+	}
+
+	check("queries", queryProblems(ctx, catalog, driver))
+	check("schema", schemaProblems(ctx, driver))
+	check("auth", authProblems(settings))
+
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+func queryProblems(ctx context.Context, catalog *queries.Catalog, driver neo4j.DriverWithContext) []string {
+	failures, err := catalog.Verify(ctx, driver)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	var problems []string
+	for _, failure := range failures {
+		problems = append(problems, failure.Error())
+	}
+	return problems
+}
+
+func schemaProblems(ctx context.Context, driver neo4j.DriverWithContext) []string {
+	missing, err := queries.MissingSchema(ctx, driver)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	var problems []string
+	for _, requirement := range missing {
+		problems = append(problems, "missing "+requirement.String())
+	}
+	return problems
+}
+
+func authProblems(settings *config.Config) []string {
+	var problems []string
+	if settings.JwtSecret == "" {
+		problems = append(problems, "JWT_SECRET is not set")
+	} else if settings.JwtSecret == "secret" {
+		fmt.Println("WARN auth: JWT_SECRET still has its default value")
+	}
+	if settings.SaltRounds < bcrypt.MinCost || settings.SaltRounds > bcrypt.MaxCost {
+		problems = append(problems, fmt.Sprintf("SALT_ROUNDS must be between %d and %d, got %d",
+			bcrypt.MinCost, bcrypt.MaxCost, settings.SaltRounds))
+	}
+	if len(problems) > 0 {
+		return problems
+	}
+	token, err := jwtutils.Sign("selftest", map[string]interface{}{}, settings.JwtSecret)
+	if err == nil {
+		_, err = jwtutils.ExtractToken(token, settings.JwtSecret, func(*jwt.Token) interface{} { return nil })
+	}
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("could not sign and verify a token: %v", err))
+	}
+	return problems
+}
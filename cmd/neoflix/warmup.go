@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/routes/paging"
+	"github.com/neo4j-graphacademy/neoflix/pkg/services"
+)
+
+// warmUpPageSize is the page size requested by the front-end
+const warmUpPageSize = 6
+
+type warmUpQuery struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// warmUpQueries returns the queries of the landing pages, most common first, as
+// requested by anonymous visitors so that their results land in the movie cache
+func warmUpQueries(movies services.MovieService, genres services.GenreService, people services.PeopleService) []warmUpQuery {
+	moviesBy := func(sort string) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			_, err := movies.FindAll(ctx, "", paging.NewPaging("", sort, paging.Desc, 0, warmUpPageSize))
+			return err
+		}
+	}
+	return []warmUpQuery{
+		{name: "top rated movies", run: moviesBy("imdbRating")},
+		{name: "genres", run: func(ctx context.Context) error {
+			_, err := genres.FindAll(ctx)
+			return err
+		}},
+		{name: "latest releases", run: moviesBy("released")},
+		{name: "popular people", run: func(ctx context.Context) error {
+			_, err := people.FindAll(ctx, paging.NewPaging("", "movieCount", paging.Desc, 0, warmUpPageSize))
+			return err
+		}},
+		{name: "upcoming movies", run: func(ctx context.Context) error {
+			_, err := movies.FindAllUpcoming(ctx, "", paging.NewPaging("", "released", paging.Asc, 0, warmUpPageSize))
+			return err
+		}},
+	}
+}
+
+// warmUp runs the `count` most common queries once, which populates the movie cache and
+// the query plan cache of Neo4j before the server starts listening.
+// Failures are reported and do not prevent the server from starting.
+func warmUp(ctx context.Context, count int, queries []warmUpQuery) {
+	if count > len(queries) {
+		count = len(queries)
+	}
+	start := time.Now()
+	for _, query := range queries[:count] {
+		if err := query.run(ctx); err != nil {
+			fmt.Printf("Warm-up of the %s failed: %v\n", query.name, err)
+		}
+	}
+	fmt.Printf("Warmed up %d queries in %s\n", count, time.Since(start).Round(time.Millisecond))
+}
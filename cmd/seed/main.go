@@ -0,0 +1,87 @@
+// Command seed loads the movie graph of the fixtures into Neo4j, e.g. to develop
+// against a fresh database:
+//
+//	go run ./cmd/seed -clear
+//
+// It connects with the settings of the app, and applies its schema migrations first.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/config"
+	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j-graphacademy/neoflix/pkg/migrations"
+)
+
+func main() {
+	configFile := flag.String("config", "config.json",
+		"JSON or YAML file of the settings, overridden by the environment variables of the same name")
+	directory := flag.String("fixtures", "fixtures/seed",
+		"directory of the genres, people, movies, users and ratings fixtures, as JSON or CSV files")
+	clear := flag.Bool("clear", false, "delete the existing graph before seeding it")
+	batchSize := flag.Int("batch-size", fixtures.DefaultBatchSize, "number of records written per transaction")
+	dryRun := flag.Bool("dry-run", false,
+		"run the seed in a transaction rolled back at the end, to check the fixtures without writing them")
+	flag.Parse()
+	ctx := context.Background()
+
+	settings, err := config.Load(*configFile, os.Getenv)
+	ioutils.PanicOnError(err)
+	dataset, err := (&fixtures.FixtureLoader{Prefix: "."}).ReadDataset(*directory)
+	ioutils.PanicOnError(err)
+	driver, err := config.NewDriver(ctx, settings)
+	ioutils.PanicOnError(err)
+	defer func() {
+		ioutils.PanicOnError(driver.Close(ctx))
+	}()
+
+	if !*dryRun {
+		// the seed merges on the IDs the constraints of the migrations index
+		applied, err := migrations.Run(ctx, driver)
+		ioutils.PanicOnError(err)
+		for _, name := range applied {
+			fmt.Printf("Applied the %s migration\n", name)
+		}
+	}
+
+	report, err := fixtures.Seed(ctx, driver, dataset, fixtures.SeedOptions{
+		BatchSize:  *batchSize,
+		Clear:      *clear,
+		DryRun:     *dryRun,
+		SaltRounds: settings.SaltRounds,
+	})
+	ioutils.PanicOnError(err)
+	printReport(report, *dryRun)
+}
+
+func printReport(report fixtures.SeedReport, dryRun bool) {
+	if dryRun {
+		fmt.Println("Dry run, nothing was written:")
+	}
+	if report.Deleted > 0 {
+		fmt.Printf("Deleted %d nodes\n", report.Deleted)
+	}
+	for _, entity := range []string{"genres", "people", "movies", "users", "ratings"} {
+		fmt.Printf("Wrote %d %s\n", report.Written[entity], entity)
+	}
+	printCounts("Normalized %d values of %s\n", report.Normalized.Normalized)
+	printCounts("Left %d invalid values of %s as is\n", report.Normalized.Invalid)
+}
+
+// printCounts prints the counts per property, sorted by property
+func printCounts(format string, counts map[string]int) {
+	properties := make([]string, 0, len(counts))
+	for property := range counts {
+		properties = append(properties, property)
+	}
+	sort.Strings(properties)
+	for _, property := range properties {
+		fmt.Printf(format, counts[property], property)
+	}
+}
@@ -2,8 +2,9 @@ package main
 
 // tag::import[]
 import (
+	"context"
 	"fmt"
-	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
 // end::import[]
@@ -14,7 +15,7 @@ func main() {
 
 /*
 // tag::pseudo[]
-driver = neo4j.NewDriver(
+driver = neo4j.NewDriverWithContext(
   connectionString, // <1>
   neo4j.BasicAuth(username, password, ""). // <2>
   configurers ...func(*Config) // <3>
@@ -44,9 +45,10 @@ func basicAuth() (neo4j.AuthToken, error) {
 
 // tag::createPerson[]
 func helloWorld(name string) (string, error) {
+	ctx := context.Background()
 	// tag::driver[]
 	// Create Driver
-	driver, err := neo4j.NewDriver("neo4j+s://dbhash.databases.neo4j.io",
+	driver, err := neo4j.NewDriverWithContext("neo4j+s://dbhash.databases.neo4j.io",
 		neo4j.BasicAuth("neo4j", "letmein", ""))
 
 	// Handle any driver creation errors
@@ -61,31 +63,31 @@ func helloWorld(name string) (string, error) {
 	// may happen upon Close call. Functions like `ioutils.DeferredClose` makes
 	// this error handling easier.
 	// This also applies to the closure of sessions.
-	defer driver.Close()
+	defer driver.Close(ctx)
 	// end::close[]
 
 	// tag::verifyConnectivity[]
-	err = driver.VerifyConnectivity()
+	err = driver.VerifyConnectivity(ctx)
 	if err != nil {
 		return "", err
 	}
 	// end::verifyConnectivity[]
 
 	// tag::session[]
-	session := driver.NewSession(neo4j.SessionConfig{})
-	defer session.Close()
+	session := driver.NewSession(ctx, neo4j.SessionConfig{})
+	defer session.Close(ctx)
 	// end::session[]
 
 	// tag::session.writeTransaction[]
-	rawName, err := session.WriteTransaction(func(transaction neo4j.Transaction) (interface{}, error) {
-		result, err := transaction.Run(
+	rawName, err := session.ExecuteWrite(ctx, func(transaction neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := transaction.Run(ctx,
 			"CREATE (p:Person {name: $name}) RETURN p",
 			map[string]interface{}{"name": name})
 		if err != nil {
 			return nil, err
 		}
 
-		personRecord, err := result.Single()
+		personRecord, err := result.Single(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -105,24 +107,25 @@ func helloWorld(name string) (string, error) {
 // end::createPerson[]
 
 func SessionRunExample() (string, error) {
-	driver, err := neo4j.NewDriver("neo4j://localhost:7687",
+	ctx := context.Background()
+	driver, err := neo4j.NewDriverWithContext("neo4j://localhost:7687",
 		neo4j.BasicAuth("neo4j", "letmein", ""))
 	if err != nil {
 		return "", err
 	}
 
 	// tag::sessionWithArgs[]
-	session := driver.NewSession(neo4j.SessionConfig{DatabaseName: "movies", AccessMode: neo4j.AccessModeWrite})
-	defer session.Close()
+	session := driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: "movies", AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
 	// end::sessionWithArgs[]
 
 	// tag::session.run[]
-	result, err := session.Run(
+	result, err := session.Run(ctx,
 		"MATCH (p:Person {name: $name}) RETURN p",
 		map[string]interface{}{"name": "Tom Hanks"})
 	// end::session.run[]
 
-	record, err := result.Single()
+	record, err := result.Single(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -132,22 +135,23 @@ func SessionRunExample() (string, error) {
 }
 
 func ReadTransactionExample() (string, error) {
-	driver, err := neo4j.NewDriver("neo4j://localhost:7687",
+	ctx := context.Background()
+	driver, err := neo4j.NewDriverWithContext("neo4j://localhost:7687",
 		neo4j.BasicAuth("neo4j", "letmein", ""))
 	if err != nil {
 		return "", err
 	}
 
-	session := driver.NewSession(neo4j.SessionConfig{DatabaseName: "reviews", AccessMode: neo4j.AccessModeWrite})
+	session := driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: "reviews", AccessMode: neo4j.AccessModeWrite})
 
 	// tag::session.readTransaction[]
-	result, err := session.ReadTransaction(func(transaction neo4j.Transaction) (interface{}, error) {
-		result, err := transaction.Run(
+	result, err := session.ExecuteRead(ctx, func(transaction neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := transaction.Run(ctx,
 			"MATCH (n) RETURN count(n) AS count", map[string]interface{}{})
 		if err != nil {
 			return nil, err
 		}
-		record, err := result.Single()
+		record, err := result.Single(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -160,18 +164,19 @@ func ReadTransactionExample() (string, error) {
 }
 
 func ExplicitTransactionExample() (string, error) {
-	driver, err := neo4j.NewDriver("neo4j://localhost:7687",
+	ctx := context.Background()
+	driver, err := neo4j.NewDriverWithContext("neo4j://localhost:7687",
 		neo4j.BasicAuth("neo4j", "letmein", ""))
 	if err != nil {
 		return "", err
 	}
 
-	defer driver.Close()
+	defer driver.Close(ctx)
 
-	session := driver.NewSession(neo4j.SessionConfig{DatabaseName: "reviews", AccessMode: neo4j.AccessModeWrite})
+	session := driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: "reviews", AccessMode: neo4j.AccessModeWrite})
 
 	// tag::session.close[]
-	defer session.Close()
+	defer session.Close(ctx)
 	// end::session.close[]
 
 	cypher := "RETURN 42"
@@ -179,18 +184,18 @@ func ExplicitTransactionExample() (string, error) {
 	// tag::session.beginTransaction.Try[]
 	// tag::session.beginTransaction[]
 	// Begin Transaction
-	tx, err := session.BeginTransaction()
+	tx, err := session.BeginTransaction(ctx)
 	// end::session.beginTransaction[]
 	if err != nil {
 		return "", err
 	}
 
 	// Run a Cypher Query
-	result, err := tx.Run(cypher, params)
+	result, err := tx.Run(ctx, cypher, params)
 
 	// If something goes wrong then roll back the transaction
 	if err != nil {
-		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
 			// Go 1.13 %w feature to wrap errors
 			return "", fmt.Errorf("rollback error (%v) happened after %w", rollbackErr, err)
 		}
@@ -198,12 +203,12 @@ func ExplicitTransactionExample() (string, error) {
 	}
 
 	// Otherwise, commit the transaction
-	if err = tx.Commit(); err != nil {
+	if err = tx.Commit(ctx); err != nil {
 		return "", err
 	}
 	// end::session.beginTransaction.Try[]
 
-	record, err := result.Single()
+	record, err := result.Single(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -213,8 +218,9 @@ func ExplicitTransactionExample() (string, error) {
 
 // tag::getActors[]
 func getActors() (string, error) {
+	ctx := context.Background()
 	// <1> Initiate Driver
-	driver, err := neo4j.NewDriver("neo4j://localhost:7687",
+	driver, err := neo4j.NewDriverWithContext("neo4j://localhost:7687",
 		neo4j.BasicAuth("neo4j", "letmein", ""))
 
 	// <2> Check for driver instantiation error
@@ -223,17 +229,17 @@ func getActors() (string, error) {
 	}
 
 	// <3> Defer closing of the driver
-	defer driver.Close()
+	defer driver.Close(ctx)
 
 	// <4> Create a new Session
-	session := driver.NewSession(neo4j.SessionConfig{DatabaseName: "movies", AccessMode: neo4j.AccessModeWrite})
+	session := driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: "movies", AccessMode: neo4j.AccessModeWrite})
 
 	// <5> Defer closing the session
-	defer session.Close()
+	defer session.Close(ctx)
 
 	// <6> Execute Cypher and get Result
 	// tag::run[]
-	result, queryErr := session.Run(
+	result, queryErr := session.Run(ctx,
 		"MATCH (p:Person)-[r:ACTED_IN]->(m:Movie {title: $title}) RETURN p, r, m",
 		map[string]interface{}{"title": "Arthur"})
 	// end::run[]
@@ -245,7 +251,7 @@ func getActors() (string, error) {
 	}
 
 	// <8> For each Record in the Result
-	for result.Next() {
+	for result.Next(ctx) {
 		// <9> Get the next record
 		record := result.Record()
 
@@ -275,13 +281,13 @@ Shortform examples
 
 // tag::Single[]
 // Get the first and only result from the stream.
-first, err := record.Single()
+first, err := record.Single(ctx)
 // end::Single[]
 
 // tag::Next[]
 
-// .Next() returns false upon error
-for result.Next() {
+// .Next(ctx) returns false upon error
+for result.Next(ctx) {
     record := result.Record()
     handleRecord(record)
 }
@@ -294,13 +300,13 @@ if err = result.Err(); err != nil {
 
 
 // tag::NextRecord[]
-for result.NextRecord(&record) {
+for result.NextRecord(ctx, &record) {
     fmf.Println(record.Keys)
 }
 // end::NextRecord[]
 
 // tag::Consume[]
-summary := result.Consume()
+summary := result.Consume(ctx)
 
 // Time in milliseconds before receiving the first result
 fmt.Println(summary.ResultAvailableAfter())
@@ -311,7 +317,7 @@ fmt.Println(summary.ResultConsumedAfter())
 
 
 // tag::Collect[]
-remaining, remainingErr := result.Collect()
+remaining, remainingErr := result.Collect(ctx)
 // end::Collect[]
 
 
@@ -1,15 +1,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 
-	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
 // tag::single[]
-func recordExtractSingleExample(queryResult neo4j.Result) (string, error) {
-	singleRecord, err := queryResult.Single()
+func recordExtractSingleExample(queryResult neo4j.ResultWithContext) (string, error) {
+	ctx := context.Background()
+	singleRecord, err := queryResult.Single(ctx)
 	if err != nil {
 		// oh no! 0 or 2+ results
 		return "", err
@@ -31,9 +33,10 @@ func recordExtractSingleExample(queryResult neo4j.Result) (string, error) {
 // end::single[]
 
 // tag::collect[]
-func recordExtractCollectExample(queryResult neo4j.Result) ([]bool, error) {
+func recordExtractCollectExample(queryResult neo4j.ResultWithContext) ([]bool, error) {
+	ctx := context.Background()
 	// buffers everything in memory
-	records, err := queryResult.Collect()
+	records, err := queryResult.Collect(ctx)
 	if err != nil {
 		// oh no! sth went wrong when fetching one of the results
 		return nil, err
@@ -60,16 +63,17 @@ func recordExtractCollectExample(queryResult neo4j.Result) ([]bool, error) {
 // end::collect[]
 
 // tag::next[]
-func recordExtractNextRecordExample(queryResult neo4j.Result) ([]neo4j.Duration, error) {
+func recordExtractNextRecordExample(queryResult neo4j.ResultWithContext) ([]neo4j.Duration, error) {
+	ctx := context.Background()
 	// this time, we do not know the size in advance, we'll allocate and grow the slice as we go
 	var results []neo4j.Duration
 	// alternatively to loop below:
 	//var record *neo4j.Record
-	//for queryResult.NextRecord(&record) {
+	//for queryResult.NextRecord(ctx, &record) {
 	//	// ...
 	//}
 	var i int
-	for queryResult.Next() {
+	for queryResult.Next(ctx) {
 		i++
 		// get the current record
 		record := queryResult.Record()
@@ -1,13 +1,15 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 	"reflect"
 )
 
 func GetActorsForMovie(movie string) ([]string, error) {
-	driver, err := neo4j.NewDriver("neo4j+s://dbhash.databases.neo4j.io",
+	ctx := context.Background()
+	driver, err := neo4j.NewDriverWithContext("neo4j+s://dbhash.databases.neo4j.io",
 		neo4j.BasicAuth("neo4j", "letmein", ""))
 	if err != nil {
 		return nil, err
@@ -16,23 +18,23 @@ func GetActorsForMovie(movie string) ([]string, error) {
 
 	// tag::close[]
 	// Defer the closing of the Driver
-	defer driver.Close()
+	defer driver.Close(ctx)
 	// end::close[]
 
 	// tag::verifyConnectivity[]
-	err = driver.VerifyConnectivity()
+	err = driver.VerifyConnectivity(ctx)
 	if err != nil {
 		return nil, err
 	}
 	// end::verifyConnectivity[]
 
 	// tag::get_actors[]
-	session := driver.NewSession(neo4j.SessionConfig{})
-	defer session.Close()
+	session := driver.NewSession(ctx, neo4j.SessionConfig{})
+	defer session.Close(ctx)
 
-	names, err := session.WriteTransaction(func(transaction neo4j.Transaction) (interface{}, error) {
+	names, err := session.ExecuteWrite(ctx, func(transaction neo4j.ManagedTransaction) (interface{}, error) {
 		// tag::run[]
-		result, err := transaction.Run(
+		result, err := transaction.Run(ctx,
 			"MATCH path = (p:Person)-[r:ACTED_IN]->(m:Movie {title: $title}) RETURN p, r, m, path",
 			map[string]interface{}{"title": "Arthur"})
 
@@ -52,7 +54,7 @@ func GetActorsForMovie(movie string) ([]string, error) {
 		// end::keys[]
 
 		// tag::nextrecord[]
-		for result.NextRecord(&record) {
+		for result.NextRecord(ctx, &record) {
 			// end::nextrecord[]
 			i++
 
@@ -134,7 +136,7 @@ func GetActorsForMovie(movie string) ([]string, error) {
 
 }
 
-func TimeExamples(result neo4j.Result) {
+func TimeExamples(result neo4j.ResultWithContext) {
 	record := result.Record()
 
 	// tag::time[]
@@ -152,20 +154,21 @@ func TimeExamples(result neo4j.Result) {
 }
 
 func DurationExample() (neo4j.Duration, error) {
-	driver, err := neo4j.NewDriver("neo4j+s://dbhash.databases.neo4j.io",
+	ctx := context.Background()
+	driver, err := neo4j.NewDriverWithContext("neo4j+s://dbhash.databases.neo4j.io",
 		neo4j.BasicAuth("neo4j", "letmein", ""))
 
 	if err != nil {
 		return neo4j.Duration{}, err
 	}
 
-	defer driver.Close()
+	defer driver.Close(ctx)
 
-	session := driver.NewSession(neo4j.SessionConfig{})
+	session := driver.NewSession(ctx, neo4j.SessionConfig{})
 
-	defer session.Close()
+	defer session.Close(ctx)
 
-	result, err := session.Run("RETURN duration('P1Y2M3DT12H34M56S.9876') AS duration", map[string]interface{}{})
+	result, err := session.Run(ctx, "RETURN duration('P1Y2M3DT12H34M56S.9876') AS duration", map[string]interface{}{})
 	if err != nil {
 		return neo4j.Duration{}, err
 	}
@@ -187,17 +190,18 @@ func DurationExample() (neo4j.Duration, error) {
 }
 
 func PointExample() error {
-	driver, err := neo4j.NewDriver("neo4j+s://dbhash.databases.neo4j.io",
+	ctx := context.Background()
+	driver, err := neo4j.NewDriverWithContext("neo4j+s://dbhash.databases.neo4j.io",
 		neo4j.BasicAuth("neo4j", "letmein", ""))
 	if err != nil {
 		return err
 	}
-	defer driver.Close()
+	defer driver.Close(ctx)
 
-	session := driver.NewSession(neo4j.SessionConfig{})
-	defer session.Close()
+	session := driver.NewSession(ctx, neo4j.SessionConfig{})
+	defer session.Close(ctx)
 
-	result, err := session.Run(`RETURN
+	result, err := session.Run(ctx, `RETURN
 		point({longitude:20, latitude:10}) AS wgs842D,
 		point({longitude:20, latitude:10, height:30}) AS wgs843D,
 		point({x:20, y:10}) AS cartesian2D,
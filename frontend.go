@@ -0,0 +1,21 @@
+// Package neoflix embeds the built front-end of the application, so that the server
+// can be deployed as a single binary
+package neoflix
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed public
+var public embed.FS
+
+// Frontend returns the files of the built front-end, index.html being at its root
+func Frontend() fs.FS {
+	files, err := fs.Sub(public, "public")
+	if err != nil {
+		// the embedded directory is known at compile time
+		panic(err)
+	}
+	return files
+}
@@ -0,0 +1,24 @@
+// Package bookmarks keeps the bookmarks of the last writes of every user between their
+// requests, so that their next reads wait for the servers of a cluster to catch up with
+// them, and they read their own writes.
+package bookmarks
+
+import (
+	"context"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// DefaultTTL is the time the bookmarks of a user are kept after their last write, long
+// enough for the followers of a cluster to catch up with it
+const DefaultTTL = 5 * time.Minute
+
+// Store keeps the bookmarks of the last writes of every user
+type Store interface {
+	// Get returns the bookmarks of the user, none when they did not write recently
+	Get(ctx context.Context, userId string) (neo4j.Bookmarks, error)
+
+	// Set replaces the bookmarks of the user
+	Set(ctx context.Context, userId string, bookmarks neo4j.Bookmarks) error
+}
@@ -0,0 +1,159 @@
+package bookmarks
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+func TestMemoryStoreExpiresBookmarks(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore(time.Minute).(*memoryStore)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if err := store.Set(ctx, "user", neo4j.Bookmarks{"FB:1"}); err != nil {
+		t.Fatal(err)
+	}
+	if bookmarks, _ := store.Get(ctx, "user"); !reflect.DeepEqual(bookmarks, neo4j.Bookmarks{"FB:1"}) {
+		t.Errorf("expected the bookmarks of the user, got %v", bookmarks)
+	}
+	now = now.Add(time.Minute)
+	if bookmarks, _ := store.Get(ctx, "user"); bookmarks != nil {
+		t.Errorf("expected the bookmarks to expire, got %v", bookmarks)
+	}
+}
+
+func TestRedisStore(t *testing.T) {
+	server := newFakeRedis(t)
+	store := NewRedisStore(RedisConfig{Addr: server.addr, Password: "secret", TTL: time.Minute})
+	ctx := context.Background()
+
+	if bookmarks, err := store.Get(ctx, "user"); err != nil || bookmarks != nil {
+		t.Fatalf("expected no bookmarks, got %v, %v", bookmarks, err)
+	}
+	if err := store.Set(ctx, "user", neo4j.Bookmarks{"FB:1", "FB:2"}); err != nil {
+		t.Fatal(err)
+	}
+	bookmarks, err := store.Get(ctx, "user")
+	if err != nil || !reflect.DeepEqual(bookmarks, neo4j.Bookmarks{"FB:1", "FB:2"}) {
+		t.Errorf("expected the bookmarks of the user, got %v, %v", bookmarks, err)
+	}
+	if ttl := server.ttls["neoflix:bookmarks:user"]; ttl != "EX 60" && ttl != "PX 60000" {
+		t.Errorf("expected the bookmarks to expire after a minute, got %q", ttl)
+	}
+	if server.connections != 1 {
+		t.Errorf("expected the connection to be reused, got %d connections", server.connections)
+	}
+}
+
+func TestRedisStoreTimesOutOnStalledServers(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = listener.Close() }()
+	go func() {
+		// accepts the connections but never replies
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer func() { _ = conn.Close() }()
+		}
+	}()
+	store := NewRedisStore(RedisConfig{Addr: listener.Addr().String(), ReadTimeout: 50 * time.Millisecond})
+
+	start := time.Now()
+	_, err = store.Get(context.Background(), "user")
+
+	if err == nil || time.Since(start) > time.Second {
+		t.Errorf("expected the command to time out, got %v after %v", err, time.Since(start))
+	}
+}
+
+// fakeRedis serves the AUTH, GET and SET commands of a single client at a time
+type fakeRedis struct {
+	addr        string
+	values      map[string]string
+	ttls        map[string]string
+	connections int
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	server := &fakeRedis{addr: listener.Addr().String(), values: map[string]string{}, ttls: map[string]string{}}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			server.connections++
+			server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (fr *fakeRedis) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		switch strings.ToUpper(args[0]) {
+		case "AUTH":
+			_, _ = io.WriteString(conn, "+OK\r\n")
+		case "GET":
+			value, found := fr.values[args[1]]
+			if !found {
+				_, _ = io.WriteString(conn, "$-1\r\n")
+				continue
+			}
+			_, _ = fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(value), value)
+		case "SET":
+			fr.values[args[1]] = args[2]
+			fr.ttls[args[1]] = strings.ToUpper(args[3]) + " " + args[4]
+			_, _ = io.WriteString(conn, "+OK\r\n")
+		default:
+			_, _ = io.WriteString(conn, "-ERR unknown command\r\n")
+		}
+	}
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		if _, err := reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, strings.TrimSuffix(arg, "\r\n"))
+	}
+	return args, nil
+}
@@ -0,0 +1,67 @@
+package bookmarks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+type memoryStore struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mutex     sync.Mutex
+	entries   map[string]memoryEntry
+	lastSweep time.Time
+}
+
+type memoryEntry struct {
+	bookmarks neo4j.Bookmarks
+	expiresAt time.Time
+}
+
+// NewMemoryStore keeps the bookmarks in memory for the TTL, DefaultTTL when 0.
+// The bookmarks are only shared by the requests served by the same instance of the app,
+// so that instances behind a load balancer should share a Redis store instead.
+func NewMemoryStore(ttl time.Duration) Store {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &memoryStore{ttl: ttl, now: time.Now, entries: map[string]memoryEntry{}}
+}
+
+func (ms *memoryStore) Get(_ context.Context, userId string) (neo4j.Bookmarks, error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	entry, found := ms.entries[userId]
+	if !found {
+		return nil, nil
+	}
+	if !ms.now().Before(entry.expiresAt) {
+		delete(ms.entries, userId)
+		return nil, nil
+	}
+	return entry.bookmarks, nil
+}
+
+func (ms *memoryStore) Set(_ context.Context, userId string, bookmarks neo4j.Bookmarks) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	now := ms.now()
+	// expired entries are swept once per TTL, so that the users who left do not pile up
+	if now.Sub(ms.lastSweep) >= ms.ttl {
+		for id, entry := range ms.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(ms.entries, id)
+			}
+		}
+		ms.lastSweep = now
+	}
+	ms.entries[userId] = memoryEntry{
+		bookmarks: append(neo4j.Bookmarks(nil), bookmarks...),
+		expiresAt: now.Add(ms.ttl),
+	}
+	return nil
+}
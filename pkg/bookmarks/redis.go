@@ -0,0 +1,96 @@
+package bookmarks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultRedisTimeout bounds the connections to Redis and the commands sent to it, unless
+// configured otherwise, so that a stalled server does not hold the requests
+const DefaultRedisTimeout = time.Second
+
+type RedisConfig struct {
+	// Addr is the host and port of the Redis server, e.g. "localhost:6379"
+	Addr     string
+	Password string
+	DB       int
+	// KeyPrefix prefixes the keys of the bookmarks, followed by the ID of the user,
+	// "neoflix:bookmarks:" by default
+	KeyPrefix string
+	// TTL is the time the bookmarks are kept, DefaultTTL when 0
+	TTL time.Duration
+	// MaxIdleConnections is the number of connections kept open between the commands,
+	// 4 by default
+	MaxIdleConnections int
+	// DialTimeout, ReadTimeout and WriteTimeout bound the connections and the commands
+	// whose context has no earlier deadline, DefaultRedisTimeout when 0
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+}
+
+type redisStore struct {
+	config RedisConfig
+	client *redis.Client
+}
+
+// NewRedisStore keeps the bookmarks in Redis, as JSON arrays expiring after the TTL, so
+// that all the instances of the app share them
+func NewRedisStore(config RedisConfig) Store {
+	if config.KeyPrefix == "" {
+		config.KeyPrefix = "neoflix:bookmarks:"
+	}
+	if config.TTL <= 0 {
+		config.TTL = DefaultTTL
+	}
+	if config.MaxIdleConnections <= 0 {
+		config.MaxIdleConnections = 4
+	}
+	for _, timeout := range []*time.Duration{&config.DialTimeout, &config.ReadTimeout, &config.WriteTimeout} {
+		if *timeout <= 0 {
+			*timeout = DefaultRedisTimeout
+		}
+	}
+	return &redisStore{
+		config: config,
+		client: redis.NewClient(&redis.Options{
+			Addr:                  config.Addr,
+			Password:              config.Password,
+			DB:                    config.DB,
+			MaxIdleConns:          config.MaxIdleConnections,
+			DialTimeout:           config.DialTimeout,
+			ReadTimeout:           config.ReadTimeout,
+			WriteTimeout:          config.WriteTimeout,
+			ContextTimeoutEnabled: true,
+		}),
+	}
+}
+
+func (rs *redisStore) Get(ctx context.Context, userId string) (neo4j.Bookmarks, error) {
+	reply, err := rs.client.Get(ctx, rs.config.KeyPrefix+userId).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var bookmarks neo4j.Bookmarks
+	if err := json.Unmarshal([]byte(reply), &bookmarks); err != nil {
+		return nil, fmt.Errorf("invalid bookmarks of user %s: %w", userId, err)
+	}
+	return bookmarks, nil
+}
+
+func (rs *redisStore) Set(ctx context.Context, userId string, bookmarks neo4j.Bookmarks) error {
+	value, err := json.Marshal(bookmarks)
+	if err != nil {
+		return err
+	}
+	return rs.client.Set(ctx, rs.config.KeyPrefix+userId, value, rs.config.TTL).Err()
+}
@@ -0,0 +1,200 @@
+package cache
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Options configures the freshness of cached entries
+type Options struct {
+	// TTL is the duration during which entries are served without being refreshed
+	TTL time.Duration
+	// StaleTTL is the additional duration during which expired entries are still served
+	// immediately, while being refreshed in the background (stale-while-revalidate)
+	StaleTTL time.Duration
+	// MaxEntries bounds the number of entries, the least recently used ones being evicted
+	// first. Zero means no bound.
+	MaxEntries int
+}
+
+// Cache is an in-process cache with stale-while-revalidate semantics.
+// Concurrent loads of the same key are deduplicated, so that a single call
+// to the underlying loader is in flight per key at any time.
+type Cache struct {
+	options Options
+	now     func() time.Time
+
+	mutex    sync.Mutex
+	entries  map[string]*list.Element
+	recency  *list.List
+	inFlight map[string]*call
+
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+// Stats counts the lookups served from the cache, stale entries included,
+// the ones waiting for a load, and the entries evicted to bound the size of the cache
+type Stats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+}
+
+// HitRate returns the share of lookups served from the cache, 0 before any lookup
+func (s Stats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+type entry struct {
+	key      string
+	value    interface{}
+	storedAt time.Time
+}
+
+type call struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+	// discarded loads were invalidated while in flight, their value is not stored
+	discarded bool
+}
+
+func New(options Options) *Cache {
+	return &Cache{
+		options:  options,
+		now:      time.Now,
+		entries:  map[string]*list.Element{},
+		recency:  list.New(),
+		inFlight: map[string]*call{},
+	}
+}
+
+// Get returns the cached value of the key, calling load when the key is missing
+// or its value is too old to be served.
+// Values older than TTL but within the StaleTTL window are returned right away
+// while a background call to load refreshes them.
+// Errors returned by load are never cached.
+func (c *Cache) Get(key string, load func() (interface{}, error)) (interface{}, error) {
+	c.mutex.Lock()
+	if element, found := c.entries[key]; found {
+		c.recency.MoveToFront(element)
+		cached := element.Value.(*entry)
+		age := c.now().Sub(cached.storedAt)
+		if age < c.options.TTL {
+			c.mutex.Unlock()
+			atomic.AddUint64(&c.hits, 1)
+			return cached.value, nil
+		}
+		if age < c.options.TTL+c.options.StaleTTL {
+			if _, loading := c.inFlight[key]; !loading {
+				c.startLoad(key, load)
+			}
+			c.mutex.Unlock()
+			atomic.AddUint64(&c.hits, 1)
+			return cached.value, nil
+		}
+	}
+	atomic.AddUint64(&c.misses, 1)
+	pending, loading := c.inFlight[key]
+	if !loading {
+		pending = c.startLoad(key, load)
+	}
+	c.mutex.Unlock()
+
+	<-pending.done
+	return pending.value, pending.err
+}
+
+// Invalidate removes the key from the cache.
+// A load of the key in flight is not stored once done, as it may predate the change
+// which outdated the key.
+func (c *Cache) Invalidate(key string) {
+	c.invalidate(func(candidate string) bool {
+		return candidate == key
+	})
+}
+
+// InvalidatePrefix removes the keys starting with the prefix from the cache, e.g. all the
+// keys of a user, along with their loads in flight
+func (c *Cache) InvalidatePrefix(prefix string) {
+	c.invalidate(func(candidate string) bool {
+		return strings.HasPrefix(candidate, prefix)
+	})
+}
+
+// Clear removes all the entries from the cache, along with the loads in flight
+func (c *Cache) Clear() {
+	c.invalidate(func(string) bool {
+		return true
+	})
+}
+
+func (c *Cache) invalidate(matches func(key string) bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for key, element := range c.entries {
+		if matches(key) {
+			c.recency.Remove(element)
+			delete(c.entries, key)
+		}
+	}
+	for key, pending := range c.inFlight {
+		if matches(key) {
+			pending.discarded = true
+			delete(c.inFlight, key)
+		}
+	}
+}
+
+// Stats returns the hits, misses and evictions counted since the cache was created
+func (c *Cache) Stats() Stats {
+	return Stats{
+		Hits:      atomic.LoadUint64(&c.hits),
+		Misses:    atomic.LoadUint64(&c.misses),
+		Evictions: atomic.LoadUint64(&c.evictions),
+	}
+}
+
+// startLoad must be called with the mutex held
+func (c *Cache) startLoad(key string, load func() (interface{}, error)) *call {
+	pending := &call{done: make(chan struct{})}
+	c.inFlight[key] = pending
+	go func() {
+		value, err := load()
+
+		c.mutex.Lock()
+		if err == nil && !pending.discarded {
+			c.store(key, value)
+		}
+		if c.inFlight[key] == pending {
+			delete(c.inFlight, key)
+		}
+		c.mutex.Unlock()
+
+		pending.value, pending.err = value, err
+		close(pending.done)
+	}()
+	return pending
+}
+
+// store must be called with the mutex held
+func (c *Cache) store(key string, value interface{}) {
+	if element, found := c.entries[key]; found {
+		c.recency.Remove(element)
+	}
+	c.entries[key] = c.recency.PushFront(&entry{key: key, value: value, storedAt: c.now()})
+	for c.options.MaxEntries > 0 && c.recency.Len() > c.options.MaxEntries {
+		oldest := c.recency.Back()
+		c.recency.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry).key)
+		atomic.AddUint64(&c.evictions, 1)
+	}
+}
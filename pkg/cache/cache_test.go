@@ -0,0 +1,198 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestServesFreshEntriesFromCache(t *testing.T) {
+	cache := New(Options{TTL: time.Minute})
+	var loads int32
+	load := func() (interface{}, error) {
+		return atomic.AddInt32(&loads, 1), nil
+	}
+
+	first, _ := cache.Get("key", load)
+	second, _ := cache.Get("key", load)
+
+	if first != int32(1) || second != int32(1) || atomic.LoadInt32(&loads) != 1 {
+		t.Fatalf("expected a single load, got %v, %v after %d loads", first, second, loads)
+	}
+}
+
+func TestServesStaleEntriesWhileRevalidating(t *testing.T) {
+	now := time.Now()
+	cache := New(Options{TTL: time.Minute, StaleTTL: time.Minute})
+	cache.now = func() time.Time { return now }
+	var loads int32
+	refreshed := make(chan struct{})
+	load := func() (interface{}, error) {
+		count := atomic.AddInt32(&loads, 1)
+		if count == 2 {
+			defer close(refreshed)
+		}
+		return count, nil
+	}
+	_, _ = cache.Get("key", load)
+
+	now = now.Add(90 * time.Second)
+	stale, _ := cache.Get("key", load)
+	if stale != int32(1) {
+		t.Fatalf("expected the stale value, got %v", stale)
+	}
+	<-refreshed
+	waitFor(t, func() bool {
+		value, _ := cache.Get("key", load)
+		return value == int32(2)
+	})
+}
+
+func TestReloadsExpiredEntries(t *testing.T) {
+	now := time.Now()
+	cache := New(Options{TTL: time.Minute, StaleTTL: time.Minute})
+	cache.now = func() time.Time { return now }
+	var loads int32
+	load := func() (interface{}, error) {
+		return atomic.AddInt32(&loads, 1), nil
+	}
+	_, _ = cache.Get("key", load)
+
+	now = now.Add(3 * time.Minute)
+	value, _ := cache.Get("key", load)
+	if value != int32(2) {
+		t.Fatalf("expected a reloaded value, got %v", value)
+	}
+}
+
+func TestDeduplicatesConcurrentLoads(t *testing.T) {
+	cache := New(Options{TTL: time.Minute})
+	var loads int32
+	release := make(chan struct{})
+	load := func() (interface{}, error) {
+		atomic.AddInt32(&loads, 1)
+		<-release
+		return "value", nil
+	}
+
+	var group sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			_, _ = cache.Get("key", load)
+		}()
+	}
+	waitFor(t, func() bool { return atomic.LoadInt32(&loads) == 1 })
+	close(release)
+	group.Wait()
+
+	if atomic.LoadInt32(&loads) != 1 {
+		t.Fatalf("expected a single load, got %d", loads)
+	}
+}
+
+func TestDoesNotCacheErrors(t *testing.T) {
+	cache := New(Options{TTL: time.Minute})
+	_, err := cache.Get("key", func() (interface{}, error) {
+		return nil, fmt.Errorf("boom")
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	value, err := cache.Get("key", func() (interface{}, error) {
+		return "value", nil
+	})
+	if err != nil || value != "value" {
+		t.Fatalf("expected value, got %v, %v", value, err)
+	}
+}
+
+func TestStats(t *testing.T) {
+	cache := New(Options{TTL: time.Minute})
+	if rate := cache.Stats().HitRate(); rate != 0 {
+		t.Fatalf("expected no hit rate before any lookup, got %v", rate)
+	}
+	load := func() (interface{}, error) {
+		return "value", nil
+	}
+	for i := 0; i < 4; i++ {
+		_, _ = cache.Get("key", load)
+	}
+	cache.Invalidate("key")
+	_, _ = cache.Get("key", load)
+
+	stats := cache.Stats()
+	if stats.Hits != 3 || stats.Misses != 2 || stats.HitRate() != 0.6 {
+		t.Fatalf("expected 3 hits and 2 misses, got %+v", stats)
+	}
+}
+
+func TestEvictsLeastRecentlyUsedEntries(t *testing.T) {
+	cache := New(Options{TTL: time.Minute, MaxEntries: 2})
+	var loads int32
+	load := func() (interface{}, error) {
+		return atomic.AddInt32(&loads, 1), nil
+	}
+	_, _ = cache.Get("a", load)
+	_, _ = cache.Get("b", load)
+	_, _ = cache.Get("a", load)
+	_, _ = cache.Get("c", load)
+
+	if value, _ := cache.Get("a", load); value != int32(1) {
+		t.Fatalf("expected the recently used entry to be kept, got %v", value)
+	}
+	if value, _ := cache.Get("b", load); value != int32(4) {
+		t.Fatalf("expected the least recently used entry to be reloaded, got %v", value)
+	}
+	if evictions := cache.Stats().Evictions; evictions != 2 {
+		t.Fatalf("expected 2 evictions, got %d", evictions)
+	}
+}
+
+func TestInvalidatesPrefixesAndTheirLoadsInFlight(t *testing.T) {
+	cache := New(Options{TTL: time.Minute})
+	release := make(chan struct{})
+	_, _ = cache.Get("user-1|favorites", func() (interface{}, error) {
+		return "before", nil
+	})
+	loaded := make(chan struct{})
+	go func() {
+		defer close(loaded)
+		_, _ = cache.Get("user-1|ratings", func() (interface{}, error) {
+			<-release
+			return "before", nil
+		})
+	}()
+	waitFor(t, func() bool {
+		cache.mutex.Lock()
+		defer cache.mutex.Unlock()
+		return len(cache.inFlight) == 1
+	})
+
+	cache.InvalidatePrefix("user-1|")
+	close(release)
+	<-loaded
+
+	for _, key := range []string{"user-1|favorites", "user-1|ratings"} {
+		value, _ := cache.Get(key, func() (interface{}, error) {
+			return "after", nil
+		})
+		if value != "after" {
+			t.Fatalf("expected %s to be reloaded, got %v", key, value)
+		}
+	}
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -1,6 +1,7 @@
 package challenges_test
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"testing"
@@ -9,13 +10,15 @@ import (
 )
 
 func TestNeo4jConnection(outer *testing.T) {
+	ctx := context.Background()
+
 	settings, err := config.ReadConfig("../../config.json")
 	assertNilError(outer, err)
 
-	driver, err := config.NewDriver(settings)
+	driver, err := config.NewDriver(ctx, settings)
 	assertNilError(outer, err)
 	defer func() {
-		assertNilError(outer, driver.Close())
+		assertNilError(outer, driver.Close(ctx))
 	}()
 
 	outer.Run("Should create a driver instance and connect to server", func(t *testing.T) {
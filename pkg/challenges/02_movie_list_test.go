@@ -1,6 +1,7 @@
 package challenges_test
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -12,16 +13,18 @@ import (
 )
 
 func TestMovieList(outer *testing.T) {
+	ctx := context.Background()
+
 	// Load Settings
 	settings, err := config.ReadConfig("../../config.json")
 	assertNilError(outer, err)
 
 	// Init Driver
-	driver, err := config.NewDriver(settings)
+	driver, err := config.NewDriver(ctx, settings)
 	assertNilError(outer, err)
 
 	defer func() {
-		assertNilError(outer, driver.Close())
+		assertNilError(outer, driver.Close(ctx))
 	}()
 
 	service := services.NewMovieService(
@@ -30,28 +33,38 @@ func TestMovieList(outer *testing.T) {
 
 	limit := 1
 
-	output, err := service.FindAll("", paging.NewPaging("", "title", "ASC", 0, limit))
+	output, err := service.FindAll(context.Background(), "", paging.NewPaging("", "title", "ASC", 0, limit))
 	assertNilError(outer, err)
 
-	assertEquals(outer, len(output), limit)
+	assertEquals(outer, len(output.Items), limit)
+	assertNotEquals(outer, output.Total, int64(0))
 
 	// Test Pagination
-	next, err := service.FindAll("", paging.NewPaging("", "title", "ASC", 1, limit))
+	next, err := service.FindAll(context.Background(), "", paging.NewPaging("", "title", "ASC", 1, limit))
+
+	assertNilError(outer, err)
+	assertEquals(outer, len(output.Items), limit)
+	assertNotEquals(outer, next.Items[0]["title"], output.Items[0]["title"])
 
+	// Test Pagination past the first pages, skipping at least a full page
+	first, err := service.FindAll(context.Background(), "", paging.NewPaging("", "title", "ASC", 0, 6))
+	assertNilError(outer, err)
+	second, err := service.FindAll(context.Background(), "", paging.NewPaging("", "title", "ASC", 6, 6))
 	assertNilError(outer, err)
-	assertEquals(outer, len(output), limit)
-	assertNotEquals(outer, next[0]["title"], output[0]["title"])
+	assertEquals(outer, len(second.Items), 6)
+	assertEquals(outer, second.Skip, 6)
+	assertNotEquals(outer, second.Items[0]["title"], first.Items[5]["title"])
 
 	// Test Ordering
-	ordered, err := service.FindAll("", paging.NewPaging("", "imdbRating", "DESC", 0, limit))
+	ordered, err := service.FindAll(context.Background(), "", paging.NewPaging("", "imdbRating", "DESC", 0, limit))
 
 	assertNilError(outer, err)
-	assertEquals(outer, len(output), limit)
-	assertNotEquals(outer, ordered[0]["title"], output[0]["title"])
+	assertEquals(outer, len(output.Items), limit)
+	assertNotEquals(outer, ordered.Items[0]["title"], output.Items[0]["title"])
 
 	fmt.Println("Here is the answer to the quiz question on the lesson:")
 	fmt.Println("What is the title of the highest rated movie in the recommendations dataset?")
 	fmt.Println("Copy and paste the following answer into the text box:")
 
-	fmt.Println(ordered[0]["title"])
+	fmt.Println(ordered.Items[0]["title"])
 }
@@ -1,25 +1,29 @@
 package challenges_test
 
 import (
+	"context"
+
 	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
 	"testing"
 
 	"github.com/neo4j-graphacademy/neoflix/pkg/config"
 	"github.com/neo4j-graphacademy/neoflix/pkg/services"
-	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
 func TestRegisterUser(outer *testing.T) {
+	ctx := context.Background()
+
 	// Load Settings
 	settings, err := config.ReadConfig("../../config.json")
 	assertNilError(outer, err)
 
 	// Init Driver
-	driver, err := config.NewDriver(settings)
+	driver, err := config.NewDriver(ctx, settings)
 	assertNilError(outer, err)
 
 	defer func() {
-		assertNilError(outer, driver.Close())
+		assertNilError(outer, driver.Close(ctx))
 	}()
 
 	// Create Service
@@ -31,7 +35,7 @@ func TestRegisterUser(outer *testing.T) {
 	password := "notletmein"
 	name := "Graph Academy"
 
-	user, err := service.Save(email, password, name)
+	user, err := service.Save(context.Background(), email, password, name)
 
 	assertNilError(outer, err)
 
@@ -41,15 +45,15 @@ func TestRegisterUser(outer *testing.T) {
 	assertNil(outer, user["password"])
 
 	// Check user in database
-	session := driver.NewSession(neo4j.SessionConfig{})
+	session := driver.NewSession(ctx, neo4j.SessionConfig{})
 
-	result, err := session.Run(
+	result, err := session.Run(ctx,
 		"MATCH (u:User {email: $email}) RETURN u",
 		map[string]interface{}{"email": email})
 
 	assertNilError(outer, err)
 
-	assertResultHasNextRecord(outer, result)
+	assertResultHasNextRecord(outer, ctx, result)
 
 	node := result.Record().Values[0].(neo4j.Node)
 
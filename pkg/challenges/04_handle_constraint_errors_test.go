@@ -1,38 +1,42 @@
 package challenges_test
 
 import (
+	"context"
+
 	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
 	"testing"
 
 	"github.com/neo4j-graphacademy/neoflix/pkg/config"
 	"github.com/neo4j-graphacademy/neoflix/pkg/services"
-	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
 func TestHandleUniqueConstraints(t *testing.T) {
+	ctx := context.Background()
+
 	// Load Settings
 	settings, err := config.ReadConfig("../../config.json")
 	assertNilError(t, err)
 
 	// Init Driver
-	driver, err := config.NewDriver(settings)
+	driver, err := config.NewDriver(ctx, settings)
 	assertNilError(t, err)
 
 	defer func() {
-		assertNilError(t, driver.Close())
+		assertNilError(t, driver.Close(ctx))
 	}()
 
-	session := driver.NewSession(neo4j.SessionConfig{})
+	session := driver.NewSession(ctx, neo4j.SessionConfig{})
 
 	// Check Constraint exists
-	result, err := session.Run(`SHOW CONSTRAINTS
+	result, err := session.Run(ctx, `SHOW CONSTRAINTS
 		YIELD entityType, labelsOrTypes, properties
 		WHERE entityType = 'NODE' AND labelsOrTypes = ['User'] AND properties = ['email']
 		RETURN count(*) AS count`, map[string]interface{}{})
 
 	assertNilError(t, err)
 
-	first, err := result.Single()
+	first, err := result.Single(ctx)
 	assertNilError(t, err)
 
 	assertEquals(t, first.Values[0], int64(1))
@@ -43,7 +47,7 @@ func TestHandleUniqueConstraints(t *testing.T) {
 	name := "Graph Academy"
 
 	// Delete any existing user
-	session.Run("MATCH (u:User {email: $email}) DETACH DELETE u", map[string]interface{}{"email": email})
+	session.Run(ctx, "MATCH (u:User {email: $email}) DETACH DELETE u", map[string]interface{}{"email": email})
 
 	// Create Service
 	service := services.NewAuthService(
@@ -51,13 +55,13 @@ func TestHandleUniqueConstraints(t *testing.T) {
 		driver, "secret", 10)
 
 	// Create the user
-	user, err := service.Save(email, password, name)
+	user, err := service.Save(context.Background(), email, password, name)
 
 	assertNilError(t, err)
 	assertFalse(t, user == nil)
 
 	// Attempt to create the user again
-	other, err := service.Save(email, password, name)
+	other, err := service.Save(context.Background(), email, password, name)
 	assertTrue(t, other == nil)
 	assertNotNil(t, err)
 
@@ -1,25 +1,29 @@
 package challenges_test
 
 import (
+	"context"
+
 	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
 	"testing"
 
 	"github.com/neo4j-graphacademy/neoflix/pkg/config"
 	"github.com/neo4j-graphacademy/neoflix/pkg/services"
-	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
 func TestAuthentication(t *testing.T) {
+	ctx := context.Background()
+
 	// Load Settings
 	settings, err := config.ReadConfig("../../config.json")
 	assertNilError(t, err)
 
 	// Init Driver
-	driver, err := config.NewDriver(settings)
+	driver, err := config.NewDriver(ctx, settings)
 	assertNilError(t, err)
 
 	defer func() {
-		assertNilError(t, driver.Close())
+		assertNilError(t, driver.Close(ctx))
 	}()
 
 	// Create Service
@@ -32,27 +36,27 @@ func TestAuthentication(t *testing.T) {
 	name := "Authenticated User"
 
 	// Delete any existing User
-	session := driver.NewSession(neo4j.SessionConfig{})
-	session.Run("MATCH (u:User {email: $email}) DETACH DELETE u", map[string]interface{}{"email": email})
+	session := driver.NewSession(ctx, neo4j.SessionConfig{})
+	session.Run(ctx, "MATCH (u:User {email: $email}) DETACH DELETE u", map[string]interface{}{"email": email})
 
 	// Create User
-	user, err := service.Save(email, password, name)
+	user, err := service.Save(context.Background(), email, password, name)
 
 	assertNilError(t, err)
 	assertEquals(t, email, user["email"])
 
 	// Incorrect Username
-	incorrectUsername, err := service.FindOneByEmailAndPassword("unknown", "password")
+	incorrectUsername, err := service.FindOneByEmailAndPassword(context.Background(), "unknown", "password")
 	assertTrue(t, incorrectUsername == nil)
 	assertNotNil(t, err)
 
 	// Incorrect Password
-	incorrectPassword, err := service.FindOneByEmailAndPassword(email, "incorrectpassword")
+	incorrectPassword, err := service.FindOneByEmailAndPassword(context.Background(), email, "incorrectpassword")
 	assertTrue(t, incorrectPassword == nil)
 	assertNotNil(t, err)
 
 	// Correct
-	correct, err := service.FindOneByEmailAndPassword(email, password)
+	correct, err := service.FindOneByEmailAndPassword(context.Background(), email, password)
 
 	assertNilError(t, err)
 	assertEquals(t, correct["email"], email)
@@ -60,6 +64,6 @@ func TestAuthentication(t *testing.T) {
 	assertNotNil(t, correct["token"])
 
 	// GA: set a timestamp to verify that the tests have passed
-	session.Run("MATCH (u:User {email: $email}) SET u.authenticatedAt = datetime()", map[string]interface{}{"email": email})
+	session.Run(ctx, "MATCH (u:User {email: $email}) SET u.authenticatedAt = datetime()", map[string]interface{}{"email": email})
 
 }
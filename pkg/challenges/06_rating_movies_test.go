@@ -1,25 +1,29 @@
 package challenges_test
 
 import (
+	"context"
+
 	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
 	"testing"
 
 	"github.com/neo4j-graphacademy/neoflix/pkg/config"
 	"github.com/neo4j-graphacademy/neoflix/pkg/services"
-	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
 func TestRatingMovies(t *testing.T) {
+	ctx := context.Background()
+
 	// Load Settings
 	settings, err := config.ReadConfig("../../config.json")
 	assertNilError(t, err)
 
 	// Init Driver
-	driver, err := config.NewDriver(settings)
+	driver, err := config.NewDriver(ctx, settings)
 	assertNilError(t, err)
 
 	defer func() {
-		assertNilError(t, driver.Close())
+		assertNilError(t, driver.Close(ctx))
 	}()
 
 	// Create Services
@@ -33,11 +37,11 @@ func TestRatingMovies(t *testing.T) {
 	rating := 5
 
 	// Create the User
-	session := driver.NewSession(neo4j.SessionConfig{})
-	session.Run("MERGE (u:User {userId: $userId}) SET u.email = $email", map[string]interface{}{"userId": userId, "email": email})
+	session := driver.NewSession(ctx, neo4j.SessionConfig{})
+	session.Run(ctx, "MERGE (u:User {userId: $userId}) SET u.email = $email", map[string]interface{}{"userId": userId, "email": email})
 
 	// Create the rating
-	output, err := service.Save(rating, movieId, userId)
+	output, err := service.Save(context.Background(), rating, movieId, userId)
 
 	assertNilError(t, err)
 	assertEquals(t, movieId, output["tmdbId"])
@@ -1,26 +1,30 @@
 package challenges_test
 
 import (
+	"context"
+
 	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
 	"testing"
 
 	"github.com/neo4j-graphacademy/neoflix/pkg/config"
 	"github.com/neo4j-graphacademy/neoflix/pkg/routes/paging"
 	"github.com/neo4j-graphacademy/neoflix/pkg/services"
-	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
 func TestFavoritesFlag(t *testing.T) {
+	ctx := context.Background()
+
 	// Load Settings
 	settings, err := config.ReadConfig("../../config.json")
 	assertNilError(t, err)
 
 	// Init Driver
-	driver, err := config.NewDriver(settings)
+	driver, err := config.NewDriver(ctx, settings)
 	assertNilError(t, err)
 
 	defer func() {
-		assertNilError(t, driver.Close())
+		assertNilError(t, driver.Close(ctx))
 	}()
 
 	fixtureLoader := &fixtures.FixtureLoader{Prefix: "../.."}
@@ -39,23 +43,23 @@ func TestFavoritesFlag(t *testing.T) {
 	email := "graphacademy.flag@neo4j.com"
 
 	// Create the User
-	session := driver.NewSession(neo4j.SessionConfig{})
-	session.Run(`
+	session := driver.NewSession(ctx, neo4j.SessionConfig{})
+	session.Run(ctx, `
 		MERGE (u:User {userId: $userId}) SET u.email = $email
 		FOREACH (r IN [(u)-[r:HAS_FAVORITE]->() | r ] | DELETE r)
 	`, map[string]interface{}{"userId": userId, "email": email})
 
 	// Get the most popular movie
-	firstCall, err := movieService.FindAll(userId, paging.NewPaging("", "imdbRating", "DESC", 0, 1))
+	firstCall, err := movieService.FindAll(context.Background(), userId, paging.NewPaging("", "imdbRating", "DESC", 0, 1))
 
 	assertNilError(t, err)
-	assertNotNil(t, firstCall)
+	assertNotNil(t, firstCall.Items)
 
-	movieId := firstCall[0]["tmdbId"].(string)
-	assertEquals(t, false, firstCall[0]["favorite"])
+	movieId := firstCall.Items[0]["tmdbId"].(string)
+	assertEquals(t, false, firstCall.Items[0]["favorite"])
 
 	// Add it to user favorites
-	favorite, err := favoriteService.Save(userId, movieId)
+	favorite, err := favoriteService.Save(context.Background(), userId, movieId)
 
 	assertNilError(t, err)
 
@@ -63,11 +67,11 @@ func TestFavoritesFlag(t *testing.T) {
 	assertEquals(t, true, favorite["favorite"])
 
 	// Get most popular movie again
-	secondCall, err := movieService.FindAll(userId, paging.NewPaging("", "imdbRating", "DESC", 0, 1))
+	secondCall, err := movieService.FindAll(context.Background(), userId, paging.NewPaging("", "imdbRating", "DESC", 0, 1))
 
 	assertNilError(t, err)
-	assertNotNil(t, secondCall)
+	assertNotNil(t, secondCall.Items)
 
-	assertEquals(t, movieId, secondCall[0]["tmdbId"])
-	assertEquals(t, true, secondCall[0]["favorite"])
+	assertEquals(t, movieId, secondCall.Items[0]["tmdbId"])
+	assertEquals(t, true, secondCall.Items[0]["favorite"])
 }
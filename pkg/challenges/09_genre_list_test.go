@@ -1,6 +1,7 @@
 package challenges_test
 
 import (
+	"context"
 	"fmt"
 	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
 	"sort"
@@ -11,16 +12,18 @@ import (
 )
 
 func TestGenreList(t *testing.T) {
+	ctx := context.Background()
+
 	// Load Settings
 	settings, err := config.ReadConfig("../../config.json")
 	assertNilError(t, err)
 
 	// Init Driver
-	driver, err := config.NewDriver(settings)
+	driver, err := config.NewDriver(ctx, settings)
 	assertNilError(t, err)
 
 	defer func() {
-		assertNilError(t, driver.Close())
+		assertNilError(t, driver.Close(ctx))
 	}()
 
 	service := services.NewGenreService(
@@ -28,7 +31,7 @@ func TestGenreList(t *testing.T) {
 		driver)
 
 	// Should retrieve a list of genres
-	output, err := service.FindAll()
+	output, err := service.FindAll(context.Background())
 
 	assertNilError(t, err)
 
@@ -1,6 +1,7 @@
 package challenges_test
 
 import (
+	"context"
 	"fmt"
 	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
 	"testing"
@@ -10,16 +11,18 @@ import (
 )
 
 func TestGenreDetails(t *testing.T) {
+	ctx := context.Background()
+
 	// Load Settings
 	settings, err := config.ReadConfig("../../config.json")
 	assertNilError(t, err)
 
 	// Init Driver
-	driver, err := config.NewDriver(settings)
+	driver, err := config.NewDriver(ctx, settings)
 	assertNilError(t, err)
 
 	defer func() {
-		assertNilError(t, driver.Close())
+		assertNilError(t, driver.Close(ctx))
 	}()
 
 	service := services.NewGenreService(
@@ -30,7 +33,7 @@ func TestGenreDetails(t *testing.T) {
 	// Get Genre by Name
 	name := "Action"
 
-	genre, err := service.FindOneByName(name)
+	genre, err := service.FindOneByName(context.Background(), name)
 
 	assertNilError(t, err)
 	assertNotNil(t, genre)
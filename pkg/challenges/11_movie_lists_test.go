@@ -1,6 +1,7 @@
 package challenges_test
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -12,16 +13,18 @@ import (
 )
 
 func TestMoviePagination(t *testing.T) {
+	ctx := context.Background()
+
 	// Load Settings
 	settings, err := config.ReadConfig("../../config.json")
 	assertNilError(t, err)
 
 	// Init Driver
-	driver, err := config.NewDriver(settings)
+	driver, err := config.NewDriver(ctx, settings)
 	assertNilError(t, err)
 
 	defer func() {
-		assertNilError(t, driver.Close())
+		assertNilError(t, driver.Close(ctx))
 	}()
 
 	service := services.NewMovieService(
@@ -36,75 +39,75 @@ func TestMoviePagination(t *testing.T) {
 	movieLimit := 10
 
 	// return a paginated list of movies by Genre
-	firstByGenre, err := service.FindAllByGenre(genre, "", paging.NewPaging("", "title", "ASC", 0, movieLimit))
+	firstByGenre, err := service.FindAllByGenre(context.Background(), genre, "", paging.NewPaging("", "title", "ASC", 0, movieLimit))
 
 	assertNilError(t, err)
-	assertNotNil(t, firstByGenre)
-	assertEquals(t, movieLimit, len(firstByGenre))
+	assertNotNil(t, firstByGenre.Items)
+	assertEquals(t, movieLimit, len(firstByGenre.Items))
 
 	// Second Page
-	secondByGenre, err := service.FindAllByGenre(genre, "", paging.NewPaging("", "title", "ASC", movieLimit, movieLimit))
+	secondByGenre, err := service.FindAllByGenre(context.Background(), genre, "", paging.NewPaging("", "title", "ASC", movieLimit, movieLimit))
 
 	assertNilError(t, err)
-	assertNotNil(t, secondByGenre)
-	assertEquals(t, movieLimit, len(secondByGenre))
-	assertNotEquals(t, firstByGenre[0]["title"], secondByGenre[0]["title"])
+	assertNotNil(t, secondByGenre.Items)
+	assertEquals(t, movieLimit, len(secondByGenre.Items))
+	assertNotEquals(t, firstByGenre.Items[0]["title"], secondByGenre.Items[0]["title"])
 
 	// Reordered
-	reorderedByGenre, err := service.FindAllByGenre(genre, "", paging.NewPaging("", "released", "ASC", movieLimit, movieLimit))
+	reorderedByGenre, err := service.FindAllByGenre(context.Background(), genre, "", paging.NewPaging("", "released", "ASC", movieLimit, movieLimit))
 
 	assertNilError(t, err)
-	assertEquals(t, movieLimit, len(reorderedByGenre))
-	assertNotEquals(t, firstByGenre[0]["title"], reorderedByGenre[0]["title"])
+	assertEquals(t, movieLimit, len(reorderedByGenre.Items))
+	assertNotEquals(t, firstByGenre.Items[0]["title"], reorderedByGenre.Items[0]["title"])
 
 	// return a paginated list of movies by Actor
 	actorLimit := 2
 
-	firstByActor, err := service.FindAllByActorId(tomHanks, "", paging.NewPaging("", "title", "ASC", 0, actorLimit))
+	firstByActor, err := service.FindAllByActorId(context.Background(), tomHanks, "", paging.NewPaging("", "title", "ASC", 0, actorLimit))
 
 	assertNilError(t, err)
-	assertNotNil(t, firstByActor)
-	assertEquals(t, actorLimit, len(firstByActor))
+	assertNotNil(t, firstByActor.Items)
+	assertEquals(t, actorLimit, len(firstByActor.Items))
 
-	secondByActor, err := service.FindAllByActorId(tomHanks, "", paging.NewPaging("", "title", "ASC", actorLimit, actorLimit))
+	secondByActor, err := service.FindAllByActorId(context.Background(), tomHanks, "", paging.NewPaging("", "title", "ASC", actorLimit, actorLimit))
 
-	assertNotNil(t, secondByActor)
-	assertEquals(t, actorLimit, len(firstByActor))
-	assertNotEquals(t, firstByActor[0]["title"], secondByActor[0]["title"])
+	assertNotNil(t, secondByActor.Items)
+	assertEquals(t, actorLimit, len(firstByActor.Items))
+	assertNotEquals(t, firstByActor.Items[0]["title"], secondByActor.Items[0]["title"])
 
 	// Reordered
-	reorderedByActor, err := service.FindAllByActorId(tomHanks, "", paging.NewPaging("", "released", "ASC", 0, actorLimit))
+	reorderedByActor, err := service.FindAllByActorId(context.Background(), tomHanks, "", paging.NewPaging("", "released", "ASC", 0, actorLimit))
 
 	assertNilError(t, err)
-	assertEquals(t, actorLimit, len(reorderedByActor))
-	assertNotEquals(t, firstByActor[0]["title"], reorderedByActor[0]["title"])
+	assertEquals(t, actorLimit, len(reorderedByActor.Items))
+	assertNotEquals(t, firstByActor.Items[0]["title"], reorderedByActor.Items[0]["title"])
 
 	// return a paginated list of movies by Director
 	directorLimit := 1
 
-	firstByDirector, err := service.FindAllByDirectorId(tomHanks, "", paging.NewPaging("", "title", "ASC", 0, directorLimit))
+	firstByDirector, err := service.FindAllByDirectorId(context.Background(), tomHanks, "", paging.NewPaging("", "title", "ASC", 0, directorLimit))
 
 	assertNilError(t, err)
-	assertNotNil(t, firstByDirector)
-	assertEquals(t, directorLimit, len(firstByDirector))
+	assertNotNil(t, firstByDirector.Items)
+	assertEquals(t, directorLimit, len(firstByDirector.Items))
 
-	secondByDirector, err := service.FindAllByDirectorId(tomHanks, "", paging.NewPaging("", "title", "ASC", directorLimit, directorLimit))
+	secondByDirector, err := service.FindAllByDirectorId(context.Background(), tomHanks, "", paging.NewPaging("", "title", "ASC", directorLimit, directorLimit))
 
-	assertNotNil(t, secondByDirector)
-	assertEquals(t, directorLimit, len(firstByDirector))
-	assertNotEquals(t, firstByDirector[0]["title"], secondByDirector[0]["title"])
+	assertNotNil(t, secondByDirector.Items)
+	assertEquals(t, directorLimit, len(firstByDirector.Items))
+	assertNotEquals(t, firstByDirector.Items[0]["title"], secondByDirector.Items[0]["title"])
 
 	// Reordered
-	reorderedByDirector, err := service.FindAllByDirectorId(tomHanks, "", paging.NewPaging("", "released", "ASC", 0, directorLimit))
+	reorderedByDirector, err := service.FindAllByDirectorId(context.Background(), tomHanks, "", paging.NewPaging("", "released", "ASC", 0, directorLimit))
 
 	assertNilError(t, err)
-	assertEquals(t, directorLimit, len(reorderedByDirector))
-	assertNotEquals(t, firstByDirector[0]["title"], reorderedByDirector[0]["title"])
+	assertEquals(t, directorLimit, len(reorderedByDirector.Items))
+	assertNotEquals(t, firstByDirector.Items[0]["title"], reorderedByDirector.Items[0]["title"])
 
 	// find films directed by Francis Ford Coppola
-	copollaFilms, err := service.FindAllByDirectorId(coppola, "", paging.NewPaging("", "title", "ASC", 0, 100))
+	copollaFilms, err := service.FindAllByDirectorId(context.Background(), coppola, "", paging.NewPaging("", "title", "ASC", 0, 100))
 
-	assertEquals(t, 16, len(copollaFilms))
+	assertEquals(t, 16, len(copollaFilms.Items))
 
 	fmt.Println()
 	fmt.Println("Here is the answer to the quiz question on the lesson:")
@@ -112,6 +115,6 @@ func TestMoviePagination(t *testing.T) {
 	fmt.Println("Copy and paste the following answer into the text box:")
 	fmt.Println()
 
-	fmt.Println(len(copollaFilms))
+	fmt.Println(len(copollaFilms.Items))
 
 }
@@ -1,6 +1,7 @@
 package challenges_test
 
 import (
+	"context"
 	"fmt"
 	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
 	"testing"
@@ -11,16 +12,18 @@ import (
 )
 
 func TestMovieDetails(t *testing.T) {
+	ctx := context.Background()
+
 	// Load Settings
 	settings, err := config.ReadConfig("../../config.json")
 	assertNilError(t, err)
 
 	// Init Driver
-	driver, err := config.NewDriver(settings)
+	driver, err := config.NewDriver(ctx, settings)
 	assertNilError(t, err)
 
 	defer func() {
-		assertNilError(t, driver.Close())
+		assertNilError(t, driver.Close(ctx))
 	}()
 
 	// get a movie by tmdbId
@@ -31,7 +34,7 @@ func TestMovieDetails(t *testing.T) {
 		driver)
 	assertNotNil(t, service)
 
-	movieById, err := service.FindOneById(lockStock, "")
+	movieById, err := service.FindOneById(context.Background(), lockStock, "")
 
 	assertNilError(t, err)
 	assertEquals(t, movieById["tmdbId"], lockStock)
@@ -40,11 +43,11 @@ func TestMovieDetails(t *testing.T) {
 	// get similar movies ordered by similarity score
 	limit := 1
 
-	output, err := service.FindAllBySimilarity(lockStock, "", paging.NewPaging("", "title", "ASC", 0, limit))
+	output, err := service.FindAllBySimilarity(context.Background(), lockStock, "", paging.NewPaging("", "title", "ASC", 0, limit), services.MovieSimilarityOptions{})
 
 	assertNilError(t, err)
 
-	paginated, err := service.FindAllBySimilarity(lockStock, "", paging.NewPaging("", "title", "ASC", 1, limit))
+	paginated, err := service.FindAllBySimilarity(context.Background(), lockStock, "", paging.NewPaging("", "title", "ASC", 1, limit), services.MovieSimilarityOptions{})
 
 	assertNilError(t, err)
 	assertNotNil(t, output)
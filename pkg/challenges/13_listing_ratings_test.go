@@ -1,6 +1,7 @@
 package challenges_test
 
 import (
+	"context"
 	"fmt"
 	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
 	"testing"
@@ -11,16 +12,18 @@ import (
 )
 
 func TestListingRatings(t *testing.T) {
+	ctx := context.Background()
+
 	// Load Settings
 	settings, err := config.ReadConfig("../../config.json")
 	assertNilError(t, err)
 
 	// Init Driver
-	driver, err := config.NewDriver(settings)
+	driver, err := config.NewDriver(ctx, settings)
 	assertNilError(t, err)
 
 	defer func() {
-		assertNilError(t, driver.Close())
+		assertNilError(t, driver.Close(ctx))
 	}()
 
 	// retrieve a list of ratings from the database
@@ -32,13 +35,13 @@ func TestListingRatings(t *testing.T) {
 		driver)
 	assertNotNil(t, service)
 
-	first, err := service.FindAllByMovieId(pulpFiction, paging.NewPaging("", "timestamp", "ASC", 0, limit))
+	first, err := service.FindAllByMovieId(context.Background(), pulpFiction, "", paging.NewPaging("", "timestamp", "ASC", 0, limit))
 
 	assertNilError(t, err)
 	assertNotNil(t, first)
 	assertEquals(t, limit, len(first))
 
-	paginated, err := service.FindAllByMovieId(pulpFiction, paging.NewPaging("", "timestamp", "ASC", limit, limit))
+	paginated, err := service.FindAllByMovieId(context.Background(), pulpFiction, "", paging.NewPaging("", "timestamp", "ASC", limit, limit))
 
 	assertNilError(t, err)
 	assertNotNil(t, paginated)
@@ -47,7 +50,7 @@ func TestListingRatings(t *testing.T) {
 	assertNotEquals(t, first[0]["rating"], paginated[0]["rating"])
 
 	// apply an ordering and pagination to the query
-	latest, err := service.FindAllByMovieId(pulpFiction, paging.NewPaging("", "timestamp", "DESC", 0, limit))
+	latest, err := service.FindAllByMovieId(context.Background(), pulpFiction, "", paging.NewPaging("", "timestamp", "DESC", 0, limit))
 
 	assertNotEquals(t, latest[0]["rating"], first[0]["rating"])
 
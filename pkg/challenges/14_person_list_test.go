@@ -1,6 +1,7 @@
 package challenges_test
 
 import (
+	"context"
 	"fmt"
 	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
 	"testing"
@@ -11,16 +12,18 @@ import (
 )
 
 func TestPersonList(t *testing.T) {
+	ctx := context.Background()
+
 	// Load Settings
 	settings, err := config.ReadConfig("../../config.json")
 	assertNilError(t, err)
 
 	// Init Driver
-	driver, err := config.NewDriver(settings)
+	driver, err := config.NewDriver(ctx, settings)
 	assertNilError(t, err)
 
 	defer func() {
-		assertNilError(t, driver.Close())
+		assertNilError(t, driver.Close(ctx))
 	}()
 
 	service := services.NewPeopleService(
@@ -31,36 +34,36 @@ func TestPersonList(t *testing.T) {
 	// retrieve a paginated list people from the database
 	limit := 10
 
-	output, err := service.FindAll(paging.NewPaging("", "name", "asc", 0, limit))
+	output, err := service.FindAll(context.Background(), paging.NewPaging("", "name", "asc", 0, limit))
 
 	assertNilError(t, err)
-	assertNotNil(t, output)
-	assertEquals(t, limit, len(output))
+	assertNotNil(t, output.Items)
+	assertEquals(t, limit, len(output.Items))
 
-	paginated, err := service.FindAll(paging.NewPaging("", "name", "asc", limit, limit))
+	paginated, err := service.FindAll(context.Background(), paging.NewPaging("", "name", "asc", limit, limit))
 
 	assertNilError(t, err)
-	assertNotNil(t, paginated)
-	assertEquals(t, limit, len(paginated))
+	assertNotNil(t, paginated.Items)
+	assertEquals(t, limit, len(paginated.Items))
 
-	assertNotEquals(t, output[0]["name"], paginated[0]["name"])
+	assertNotEquals(t, output.Items[0]["name"], paginated.Items[0]["name"])
 
 	// apply a filter, ordering and pagination to the query
 	q := "A"
 
-	filteredFirst, err := service.FindAll(paging.NewPaging(q, "name", "asc", 0, 1))
+	filteredFirst, err := service.FindAll(context.Background(), paging.NewPaging(q, "name", "asc", 0, 1))
 
 	assertNilError(t, err)
-	assertNotNil(t, filteredFirst)
-	assertEquals(t, 1, len(filteredFirst))
+	assertNotNil(t, filteredFirst.Items)
+	assertEquals(t, 1, len(filteredFirst.Items))
 
-	filteredLast, err := service.FindAll(paging.NewPaging(q, "name", "desc", 0, 1))
+	filteredLast, err := service.FindAll(context.Background(), paging.NewPaging(q, "name", "desc", 0, 1))
 
 	assertNilError(t, err)
-	assertNotNil(t, filteredLast)
-	assertEquals(t, 1, len(filteredLast))
+	assertNotNil(t, filteredLast.Items)
+	assertEquals(t, 1, len(filteredLast.Items))
 
-	assertNotEquals(t, filteredLast[0]["name"], filteredFirst[0]["name"])
+	assertNotEquals(t, filteredLast.Items[0]["name"], filteredFirst.Items[0]["name"])
 
 	// Quiz answer
 	fmt.Println()
@@ -69,5 +72,5 @@ func TestPersonList(t *testing.T) {
 	fmt.Println("What is the name of the first person in the database in alphabetical order?")
 	fmt.Println("Copy and paste the following answer into the text box:")
 
-	fmt.Println(output[0]["name"])
+	fmt.Println(output.Items[0]["name"])
 }
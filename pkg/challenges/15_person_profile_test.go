@@ -1,6 +1,7 @@
 package challenges_test
 
 import (
+	"context"
 	"fmt"
 	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
 	"testing"
@@ -11,16 +12,18 @@ import (
 )
 
 func TestPersonProfile(t *testing.T) {
+	ctx := context.Background()
+
 	// Load Settings
 	settings, err := config.ReadConfig("../../config.json")
 	assertNilError(t, err)
 
 	// Init Driver
-	driver, err := config.NewDriver(settings)
+	driver, err := config.NewDriver(ctx, settings)
 	assertNilError(t, err)
 
 	defer func() {
-		assertNilError(t, driver.Close())
+		assertNilError(t, driver.Close(ctx))
 	}()
 
 	service := services.NewPeopleService(
@@ -32,7 +35,7 @@ func TestPersonProfile(t *testing.T) {
 
 	// find a person by their ID
 
-	output, err := service.FindOneById(coppola)
+	output, err := service.FindOneById(context.Background(), coppola)
 
 	assertNilError(t, err)
 	assertNotNil(t, output)
@@ -45,13 +48,13 @@ func TestPersonProfile(t *testing.T) {
 
 	limit := 2
 
-	first, err := service.FindAllBySimilarity(coppola, paging.NewPaging("", "", "", 0, limit))
+	first, err := service.FindAllBySimilarity(context.Background(), coppola, paging.NewPaging("", "", "", 0, limit), services.PersonSimilarityOptions{})
 
 	assertNilError(t, err)
 	assertNotNil(t, first)
 	assertEquals(t, limit, len(first))
 
-	second, err := service.FindAllBySimilarity(coppola, paging.NewPaging("", "", "", limit, limit))
+	second, err := service.FindAllBySimilarity(context.Background(), coppola, paging.NewPaging("", "", "", limit, limit), services.PersonSimilarityOptions{})
 
 	assertNilError(t, err)
 	assertNotNil(t, second)
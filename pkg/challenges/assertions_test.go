@@ -1,11 +1,12 @@
 package challenges_test
 
 import (
+	"context"
 	"reflect"
 	"strings"
 	"testing"
 
-	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
 func assertNilError(t *testing.T, err error) {
@@ -64,10 +65,10 @@ func assertNotEquals(t *testing.T, a interface{}, b interface{}) {
 	}
 }
 
-func assertResultHasNextRecord(t *testing.T, result neo4j.Result) {
+func assertResultHasNextRecord(t *testing.T, ctx context.Context, result neo4j.ResultWithContext) {
 	t.Helper()
-	if !result.Next() {
-		t.Fatalf("Expected `.Next()` to return true on neo4j.Result.  No next record found.")
+	if !result.Next(ctx) {
+		t.Fatalf("Expected `.Next()` to return true on neo4j.ResultWithContext.  No next record found.")
 	}
 }
 
@@ -1,30 +1,33 @@
 package challenges_test
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
 	"github.com/neo4j-graphacademy/neoflix/pkg/config"
-	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
 func TestErrors(outer *testing.T) {
+	ctx := context.Background()
+
 	// Load Settings
 	settings, err := config.ReadConfig("../../config.json")
 	assertNilError(outer, err)
 
 	// Init Driver
-	driver, err := config.NewDriver(settings)
+	driver, err := config.NewDriver(ctx, settings)
 	assertNilError(outer, err)
 
 	defer func() {
-		assertNilError(outer, driver.Close())
+		assertNilError(outer, driver.Close(ctx))
 	}()
 
-	session := driver.NewSession(neo4j.SessionConfig{})
+	session := driver.NewSession(ctx, neo4j.SessionConfig{})
 
 	// tag::handle[]
-	result, err := session.Run(
+	result, err := session.Run(ctx,
 		"MTCH (n) RETURN x(n)", nil)
 	// end::handle[]
 
@@ -53,22 +56,24 @@ func TestErrors(outer *testing.T) {
 }
 
 func TestConstraintErrors(outer *testing.T) {
+	ctx := context.Background()
+
 	// Load Settings
 	settings, err := config.ReadConfig("../../config.json")
 	assertNilError(outer, err)
 
 	// Init Driver
-	driver, err := config.NewDriver(settings)
+	driver, err := config.NewDriver(ctx, settings)
 	assertNilError(outer, err)
 
 	defer func() {
-		assertNilError(outer, driver.Close())
+		assertNilError(outer, driver.Close(ctx))
 	}()
 
-	session := driver.NewSession(neo4j.SessionConfig{})
+	session := driver.NewSession(ctx, neo4j.SessionConfig{})
 
 	// Create a new constraint
-	result, err := session.Run(
+	result, err := session.Run(ctx,
 		"CREATE CONSTRAINT IF NOT EXISTS ON (t:Test) ASSERT t.value IS UNIQUE",
 		map[string]interface{}{})
 
@@ -76,7 +81,7 @@ func TestConstraintErrors(outer *testing.T) {
 	assertNilError(outer, err)
 
 	// Fail constraint validation
-	result, resultErr := session.Run(
+	result, resultErr := session.Run(ctx,
 		"UNWIND range(0, 2) AS row CREATE (t:Test {value: 'notunique'})",
 		map[string]interface{}{})
 
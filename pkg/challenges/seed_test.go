@@ -0,0 +1,45 @@
+package challenges_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/config"
+	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestSeed(t *testing.T) {
+	ctx := context.Background()
+
+	// Load Settings
+	settings, err := config.ReadConfig("../../config.json")
+	assertNilError(t, err)
+
+	// Init Driver
+	driver, err := config.NewDriver(ctx, settings)
+	assertNilError(t, err)
+
+	defer func() {
+		assertNilError(t, driver.Close(ctx))
+	}()
+
+	dataset, err := (&fixtures.FixtureLoader{Prefix: "../.."}).ReadDataset("fixtures/seed")
+	assertNilError(t, err)
+
+	_, err = fixtures.Seed(ctx, driver, dataset, fixtures.SeedOptions{SaltRounds: bcrypt.MinCost})
+	assertNilError(t, err)
+
+	// release dates are stored as the strings the services compare and slice
+	result, err := neo4j.ExecuteQuery(ctx, driver, `
+		MATCH (m:Movie {tmdbId: $id})
+		RETURN m.released AS released, left(m.released, 4) AS year`,
+		map[string]interface{}{"id": "242"}, neo4j.EagerResultTransformer)
+	assertNilError(t, err)
+	assertEquals(t, len(result.Records), 1)
+	released, _ := result.Records[0].Get("released")
+	year, _ := result.Records[0].Get("year")
+	assertEquals(t, released, "1990-12-25")
+	assertEquals(t, year, "1990")
+}
@@ -0,0 +1,107 @@
+// Package collation sorts movie titles the way readers of a language expect,
+// rather than in byte order: case and accents are ignored, and leading articles
+// such as "The" or "Les" are skipped.
+//
+// Neo4j only sorts strings in byte order, so the sort keys of each supported
+// language are stored as movie properties named after SortProperty.
+package collation
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Supported lists the languages titles can be collated for
+var Supported = []string{"de", "en", "es", "fr", "it"}
+
+// articles lists the leading articles skipped when sorting titles, per language.
+// Articles ending with an apostrophe are elided, e.g. "L'Atalante".
+var articles = map[string][]string{
+	"de": {"der ", "die ", "das ", "ein ", "eine "},
+	"en": {"the ", "a ", "an "},
+	"es": {"el ", "la ", "los ", "las ", "un ", "una "},
+	"fr": {"le ", "la ", "les ", "l'", "un ", "une "},
+	"it": {"il ", "lo ", "la ", "i ", "gli ", "le ", "l'", "un ", "uno ", "una "},
+}
+
+// folds maps the accented letters to the letters they sort with
+var folds = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'æ': "ae",
+	'ç': "c", 'è': "e", 'é': "e", 'ê': "e", 'ë': "e",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'œ': "oe",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ý': "y", 'ÿ': "y", 'ß': "ss",
+}
+
+// SortProperty returns the name of the movie property holding the sort keys of the language
+func SortProperty(language string) string {
+	return "sortTitle_" + language
+}
+
+// SortKey returns the key sorting the title in the language, which must be supported
+func SortKey(title, language string) string {
+	var key strings.Builder
+	for _, char := range strings.ToLower(strings.TrimSpace(title)) {
+		switch {
+		// ñ is a letter of its own, sorted right after n
+		case char == 'ñ' && language == "es":
+			key.WriteString("n~")
+		case folds[char] != "":
+			key.WriteString(folds[char])
+		default:
+			key.WriteRune(char)
+		}
+	}
+	folded := strings.TrimLeftFunc(key.String(), func(char rune) bool {
+		return !unicode.IsLetter(char) && !unicode.IsDigit(char)
+	})
+	for _, article := range articles[language] {
+		if stripped := strings.TrimPrefix(folded, article); stripped != folded && stripped != "" {
+			return stripped
+		}
+	}
+	return folded
+}
+
+// FromAcceptLanguage returns the supported language preferred by the Accept-Language
+// header, e.g. "fr" for "fr-CH, fr;q=0.9, en;q=0.8", or "" when none is supported
+func FromAcceptLanguage(header string) string {
+	type preference struct {
+		language string
+		quality  float64
+	}
+	var preferences []preference
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		language, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		quality := 1.0
+		if params = strings.TrimSpace(params); strings.HasPrefix(params, "q=") {
+			parsed, err := strconv.ParseFloat(strings.TrimPrefix(params, "q="), 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality > 0 && isSupported(language) {
+			preferences = append(preferences, preference{language, quality})
+		}
+	}
+	if len(preferences) == 0 {
+		return ""
+	}
+	sort.SliceStable(preferences, func(i, j int) bool {
+		return preferences[i].quality > preferences[j].quality
+	})
+	return preferences[0].language
+}
+
+func isSupported(language string) bool {
+	for _, supported := range Supported {
+		if supported == language {
+			return true
+		}
+	}
+	return false
+}
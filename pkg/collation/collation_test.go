@@ -0,0 +1,44 @@
+package collation
+
+import "testing"
+
+func TestSortKey(t *testing.T) {
+	testCases := []struct {
+		title    string
+		language string
+		expected string
+	}{
+		{title: "The Matrix", language: "en", expected: "matrix"},
+		{title: "The", language: "en", expected: "the"},
+		{title: "Amélie", language: "en", expected: "amelie"},
+		{title: "\"Crocodile\" Dundee", language: "en", expected: "crocodile\" dundee"},
+		{title: "Les Misérables", language: "fr", expected: "miserables"},
+		{title: "L'Atalante", language: "fr", expected: "atalante"},
+		{title: "Les Misérables", language: "en", expected: "les miserables"},
+		{title: "Das Boot", language: "de", expected: "boot"},
+		{title: "El Niño", language: "es", expected: "nin~o"},
+	}
+	for _, testCase := range testCases {
+		actual := SortKey(testCase.title, testCase.language)
+		if actual != testCase.expected {
+			t.Errorf("expected %q sort key in %s to be %q, got %q",
+				testCase.title, testCase.language, testCase.expected, actual)
+		}
+	}
+}
+
+func TestFromAcceptLanguage(t *testing.T) {
+	testCases := map[string]string{
+		"":                          "",
+		"ja":                        "",
+		"fr-CH, fr;q=0.9, en;q=0.8": "fr",
+		"ja, en-GB;q=0.5, de;q=0.7": "de",
+		"en;q=0, it":                "it",
+		"es;q=invalid, en-US;q=0.2": "en",
+	}
+	for header, expected := range testCases {
+		if actual := FromAcceptLanguage(header); actual != expected {
+			t.Errorf("expected %q to select %q, got %q", header, expected, actual)
+		}
+	}
+}
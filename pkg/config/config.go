@@ -2,16 +2,21 @@ package config
 
 // tag::import[]
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
+	"time"
 
-	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
 // end::import[]
 
 /**
  * ReadConfig reads the application settings from config.json
+ *
+ * The app loads them with Load, which layers the environment variables on top of the
+ * file and validates them
  */
 // tag::readConfig[]
 func ReadConfig(path string) (*Config, error) {
@@ -28,27 +33,231 @@ func ReadConfig(path string) (*Config, error) {
 
 // end::readConfig[]
 
+// Config holds the settings of config.json.
+// Settings tagged as secret are redacted from the runtime configuration reported to admins.
 type Config struct {
 	Uri      string `json:"NEO4J_URI"`
 	Username string `json:"NEO4J_USERNAME"`
-	Password string `json:"NEO4J_PASSWORD"`
+	Password string `json:"NEO4J_PASSWORD" secret:"true"`
+	// Database the app reads and writes, the default database of the server when unset
+	Database string `json:"NEO4J_DATABASE"`
 
 	Port       int    `json:"APP_PORT"`
-	JwtSecret  string `json:"JWT_SECRET"`
+	JwtSecret  string `json:"JWT_SECRET" secret:"true"`
 	SaltRounds int    `json:"SALT_ROUNDS"`
+
+	// Path the app is served under, e.g. "/neoflix" to serve the API under /neoflix/api,
+	// when deployed behind a reverse proxy shared with other apps
+	BasePath string `json:"BASE_PATH"`
+
+	// URL the app is publicly reachable at, base path included, e.g.
+	// "https://example.com/neoflix", which the sitemaps and feeds link to,
+	// http://localhost:APP_PORT under the base path when unset
+	PublicUrl string `json:"PUBLIC_URL"`
+
+	// Additional legacy names of query parameters, e.g. {"perPage": "limit"}, on top of the
+	// paging parameters of the Node.js and Java versions of the app
+	QueryParameterAliases map[string]string `json:"QUERY_PARAMETER_ALIASES"`
+
+	// Directory the front-end is served from, the front-end embedded in the binary when unset
+	FrontendDirectory string `json:"FRONTEND_DIRECTORY"`
+
+	// Secret signing the links to shared lists, JWT_SECRET when unset.
+	// Changing it revokes all the links.
+	ShareSecret string `json:"SHARE_SECRET" secret:"true"`
+
+	// Deadlines per endpoint class, in milliseconds (0 keeps the default of the class:
+	// 500, 2000, 5000, and no deadline for exports, while -1 disables the deadline)
+	FastLookupDeadlineMs int `json:"DEADLINE_FAST_LOOKUP_MS"`
+	ListDeadlineMs       int `json:"DEADLINE_LIST_MS"`
+	SimilarityDeadlineMs int `json:"DEADLINE_SIMILARITY_MS"`
+	ExportDeadlineMs     int `json:"DEADLINE_EXPORT_MS"`
+
+	// Time the driver keeps retrying a transaction after transient failures, in
+	// milliseconds (0 keeps the driver default of 30 seconds).
+	// Each attempt of the retry policies below gets the whole budget again.
+	TransactionRetryBudgetMs int `json:"TX_RETRY_BUDGET_MS"`
+
+	// Retries of the transactions of the services still failing with transient errors once
+	// the driver gave up: each transaction is run at most RETRY_MAX_ATTEMPTS times (3 by
+	// default, 1 disables the retries), waiting an exponential backoff from
+	// RETRY_INITIAL_BACKOFF_MS (100) up to RETRY_MAX_BACKOFF_MS (2000) between the attempts
+	RetryMaxAttempts      int `json:"RETRY_MAX_ATTEMPTS"`
+	RetryInitialBackoffMs int `json:"RETRY_INITIAL_BACKOFF_MS"`
+	RetryMaxBackoffMs     int `json:"RETRY_MAX_BACKOFF_MS"`
+	// Overrides of the retries per service, e.g. {"auth": {"maxAttempts": 1}, "movies":
+	// {"maxAttempts": 5, "initialBackoffMs": 50, "maxBackoffMs": 1000}}
+	RetryPolicies map[string]map[string]int `json:"RETRY_POLICIES"`
+
+	// Cache of the movie lists, in milliseconds
+	CacheTtlMs      int `json:"CACHE_TTL_MS"`
+	CacheStaleTtlMs int `json:"CACHE_STALE_TTL_MS"`
+	// Maximum number of cached movie lists, the least recently used being evicted first
+	CacheMaxEntries int `json:"CACHE_MAX_ENTRIES"`
+	// Key the personalized movie lists by a version of the data of the user, bumped on
+	// their writes, so that no instance of the API serves them once stale
+	CacheUserDataVersions bool `json:"CACHE_USER_DATA_VERSIONS"`
+
+	// Memoization of the people and genre details, in milliseconds (0 disables it)
+	LookupCacheTtlMs int `json:"LOOKUP_CACHE_TTL_MS"`
+	// Maximum number of memoized people and genres, the least recently used being
+	// evicted first
+	LookupCacheMaxEntries int `json:"LOOKUP_CACHE_MAX_ENTRIES"`
+
+	// Number of the most common queries run once at startup, before the server listens,
+	// to populate the caches (0 disables the warm-up)
+	WarmUpQueries int `json:"WARMUP_QUERIES"`
+
+	// Maximum number of results anonymous clients can page through, negative to disable
+	AnonymousPagingQuota int `json:"ANONYMOUS_PAGING_QUOTA"`
+
+	// Names of the dataset properties differing from the ones exposed by the API, per label
+	// e.g. {"Movie": {"released": "year", "poster": "posterUrl"}}
+	PropertyMapping map[string]map[string]string `json:"PROPERTY_MAPPING"`
+
+	// Avatar storage, either "local" (default) or "s3"
+	AvatarStorage      string `json:"AVATAR_STORAGE"`
+	AvatarDirectory    string `json:"AVATAR_DIRECTORY"`
+	AvatarS3Bucket     string `json:"AVATAR_S3_BUCKET"`
+	AvatarS3Region     string `json:"AVATAR_S3_REGION"`
+	AvatarS3Endpoint   string `json:"AVATAR_S3_ENDPOINT"`
+	AvatarS3PublicUrl  string `json:"AVATAR_S3_PUBLIC_URL"`
+	AwsAccessKeyId     string `json:"AWS_ACCESS_KEY_ID" secret:"true"`
+	AwsSecretAccessKey string `json:"AWS_SECRET_ACCESS_KEY" secret:"true"`
+
+	// Fraction of the calls running a statement with a shadow rewrite which run the
+	// rewrite as well to compare results, between 0 (default, disabled) and 1
+	ShadowReadSampleRate float64 `json:"SHADOW_READ_SAMPLE_RATE"`
+
+	// List the movies as not favorite, logging a warning, when the favorites of the user
+	// cannot be resolved, rather than failing the request
+	LenientFavorites bool `json:"LENIENT_FAVORITES"`
+
+	// Maximum of the score of movies, which unifies their IMDB rating and the average rating
+	// of the users on a single scale, 10 by default
+	ScoreScale float64 `json:"SCORE_SCALE"`
+
+	// Weights of the similar movies score, e.g. {"genre": 1, "actor": 2, "director": 2, "rating": 1},
+	// and of its personalization for the authenticated users, e.g. {"taste": 0.5}
+	// Missing weights keep their default value of 1
+	SimilarityWeights map[string]float64 `json:"SIMILARITY_WEIGHTS"`
+	// Number of movies each genre, actor and director contributes at most to the similar
+	// movies, the best rated ones, 500 by default
+	SimilarityMaxFanOut int `json:"SIMILARITY_MAX_FAN_OUT"`
+
+	// Semantic movie search: the plots of the movies are embedded, into a vector index, by
+	// EMBEDDER, either "openai" or "local" (hashing their words, for development), the
+	// semantic search falling back to the full-text search when unset
+	Embedder            string `json:"EMBEDDER"`
+	EmbeddingDimensions int    `json:"EMBEDDING_DIMENSIONS"`
+	OpenAIApiKey        string `json:"OPENAI_API_KEY" secret:"true"`
+	// text-embedding-3-small by default
+	OpenAIEmbeddingModel string `json:"OPENAI_EMBEDDING_MODEL"`
+	// Base URL of an OpenAI compatible embeddings endpoint, the OpenAI API by default
+	OpenAIBaseUrl string `json:"OPENAI_BASE_URL"`
+
+	// Import of the movies, their cast and their crew from TMDB, with the v3 API key
+	// TMDB_API_KEY, sending TMDB_REQUESTS_PER_SECOND requests at most (20 by default),
+	// each timing out after TMDB_TIMEOUT_MS (10 seconds by default).
+	// When TMDB_SYNC_ENABLED, the movies of the graph edited on TMDB are imported again
+	// every day at TMDB_SYNC_HOUR UTC.
+	TMDBApiKey            string  `json:"TMDB_API_KEY" secret:"true"`
+	TMDBBaseUrl           string  `json:"TMDB_BASE_URL"`
+	TMDBRequestsPerSecond float64 `json:"TMDB_REQUESTS_PER_SECOND"`
+	TMDBTimeoutMs         int     `json:"TMDB_TIMEOUT_MS"`
+	TMDBSyncEnabled       bool    `json:"TMDB_SYNC_ENABLED"`
+	TMDBSyncHour          int     `json:"TMDB_SYNC_HOUR"`
+
+	// Keep the bookmarks of the writes of the users between their requests, so that they
+	// read their own writes on a cluster: BOOKMARK_STORE is either "memory", for a single
+	// instance of the app, or "redis", for instances sharing the Redis server of REDIS_ADDR.
+	// Bookmarks are kept for BOOKMARK_TTL_MS, 5 minutes by default.
+	BookmarkStore string `json:"BOOKMARK_STORE"`
+	BookmarkTtlMs int    `json:"BOOKMARK_TTL_MS"`
+	RedisAddr     string `json:"REDIS_ADDR"`
+	RedisPassword string `json:"REDIS_PASSWORD" secret:"true"`
+	RedisDatabase int    `json:"REDIS_DB"`
+	// Timeout of the connections to Redis and of its commands, 1 second by default
+	RedisTimeoutMs int `json:"REDIS_TIMEOUT_MS"`
+
+	// Logs, written to stderr: LOG_LEVEL is one of debug, info (default), warn or error,
+	// and LOG_FORMAT either text (default) or json
+	LogLevel  string `json:"LOG_LEVEL"`
+	LogFormat string `json:"LOG_FORMAT"`
+
+	// Trace the requests, the service methods and the Cypher statements, exported over OTLP
+	// to the collector of the standard OTEL_EXPORTER_OTLP_ENDPOINT environment variable
+	TracingEnabled bool `json:"TRACING_ENABLED"`
+	// Fraction of the requests traced, between 0 and 1, all of them when unset
+	TracingSampleRate float64 `json:"TRACING_SAMPLE_RATE"`
+
+	// Review bombing detection: movies receiving at least RATING_ANOMALY_MIN_RATINGS ratings
+	// within RATING_ANOMALY_WINDOW_HOURS, with a share of extreme ratings exceeding the one of
+	// their older ratings by RATING_ANOMALY_MIN_SHIFT, are flagged for review.
+	// Zero values keep the defaults.
+	RatingAnomalyWindowHours int     `json:"RATING_ANOMALY_WINDOW_HOURS"`
+	RatingAnomalyMinRatings  int     `json:"RATING_ANOMALY_MIN_RATINGS"`
+	RatingAnomalyMinShift    float64 `json:"RATING_ANOMALY_MIN_SHIFT"`
+	// Leave the ratings of the flagged periods out of the average rating until resolved
+	RatingAnomalyExcludeFlagged bool `json:"RATING_ANOMALY_EXCLUDE_FLAGGED"`
+
+	// Number of movies whose stored aggregates are checked against their relationships,
+	// and repaired when drifting, every hour. Zero disables the checks.
+	AggregateCheckSample int `json:"AGGREGATE_CHECK_SAMPLE"`
+
+	// A/B test of the recommendation strategies: users are assigned to a strategy proportionally
+	// to its weight, e.g. {"content": 1, "collaborative": 1, "gds": 0}, consistently for a given
+	// RECOMMENDATION_EXPERIMENT name. Renaming the experiment reassigns the users.
+	RecommendationExperiment string         `json:"RECOMMENDATION_EXPERIMENT"`
+	RecommendationWeights    map[string]int `json:"RECOMMENDATION_WEIGHTS"`
+	// Minimum rating, from 1 to 5, of the movies recommendations are based on, 4 by default
+	RecommendationMinRating float64 `json:"RECOMMENDATION_MIN_RATING"`
+
+	// Feature flags exposed by /api/flags, each rolled out to a percentage of the users
+	// and anonymous clients, e.g. {"newPlayer": 10}
+	FeatureFlags map[string]int `json:"FEATURE_FLAGS"`
+	// Secret signing the evaluated flags, JWT_SECRET when unset
+	FlagsSecret string `json:"FLAGS_SECRET" secret:"true"`
+
+	// Record the searches of the movie and people lists, with the users anonymized,
+	// for admins to list the top and the failing searches
+	SearchAnalyticsEnabled bool `json:"SEARCH_ANALYTICS_ENABLED"`
+
+	// API keys of the partners allowed to download the catalog
+	PartnerApiKeys []string `json:"PARTNER_API_KEYS" secret:"true"`
+
+	// Daily digest emails, sent at DIGEST_HOUR UTC when enabled
+	DigestEnabled bool   `json:"DIGEST_ENABLED"`
+	DigestHour    int    `json:"DIGEST_HOUR"`
+	MailFrom      string `json:"MAIL_FROM"`
+	// SMTP server the emails are delivered to, emails are printed to stdout when unset
+	SmtpHost     string `json:"SMTP_HOST"`
+	SmtpPort     int    `json:"SMTP_PORT"`
+	SmtpUsername string `json:"SMTP_USERNAME"`
+	SmtpPassword string `json:"SMTP_PASSWORD" secret:"true"`
+
+	// Configuration the driver was created with, defaults included
+	driver *neo4j.Config
 }
 
 /**
  * Initiate the Neo4j Driver
  *
  * @param {Config} config   Config struct loaded from config.json
- * @returns {neo4j.Driver}	A new Driver instance
+ * @returns {neo4j.DriverWithContext}	A new Driver instance
  */
 // tag::initDriver[]
-func NewDriver(settings *Config) (neo4j.Driver, error) {
+func NewDriver(ctx context.Context, settings *Config) (neo4j.DriverWithContext, error) {
 	// Create Driver
-	driver, err := neo4j.NewDriver(settings.Uri,
-		neo4j.BasicAuth(settings.Username, settings.Password, ""))
+	driver, err := neo4j.NewDriverWithContext(settings.Uri,
+		neo4j.BasicAuth(settings.Username, settings.Password, ""),
+		func(config *neo4j.Config) {
+			if settings.TransactionRetryBudgetMs > 0 {
+				config.MaxTransactionRetryTime = time.Duration(settings.TransactionRetryBudgetMs) * time.Millisecond
+			}
+			effective := *config
+			settings.driver = &effective
+		})
 
 	// Handle any driver creation errors
 	if err != nil {
@@ -56,13 +265,16 @@ func NewDriver(settings *Config) (neo4j.Driver, error) {
 	}
 
 	// Verify Connectivity
-	err = driver.VerifyConnectivity()
+	err = driver.VerifyConnectivity(ctx)
 
 	// If connectivity fails, handle the error
 	if err != nil {
 		return nil, err
 	}
 
+	if settings.Database != "" {
+		return PinDatabase(driver, settings.Database), nil
+	}
 	return driver, nil
 }
 
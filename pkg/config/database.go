@@ -0,0 +1,26 @@
+package config
+
+import (
+	"context"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// PinDatabase decorates the driver so that its sessions which do not name a database
+// target the database, rather than the default database of the server, whether they are
+// opened by the services, the migrations or the detection of the plugins at startup
+func PinDatabase(driver neo4j.DriverWithContext, database string) neo4j.DriverWithContext {
+	return &databaseDriver{DriverWithContext: driver, database: database}
+}
+
+type databaseDriver struct {
+	neo4j.DriverWithContext
+	database string
+}
+
+func (dd *databaseDriver) NewSession(ctx context.Context, config neo4j.SessionConfig) neo4j.SessionWithContext {
+	if config.DatabaseName == "" {
+		config.DatabaseName = dd.database
+	}
+	return dd.DriverWithContext.NewSession(ctx, config)
+}
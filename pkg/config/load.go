@@ -0,0 +1,215 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Load reads the settings in layers: the file at path, if any, either JSON or YAML
+// (.yaml and .yml files), then the environment variables named after the settings,
+// e.g. NEO4J_URI, which override it.
+// Environment variables of lists are comma-separated, e.g. PARTNER_API_KEYS=key1,key2,
+// and those of maps are JSON objects, e.g. FEATURE_FLAGS={"newPlayer": 10}.
+//
+// The settings are validated once loaded, see Validate.
+func Load(path string, getenv func(string) string) (*Config, error) {
+	config := &Config{}
+	if path != "" {
+		if err := readFile(path, config); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	if err := config.applyEnv(getenv); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func readFile(path string, config *Config) error {
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		// decoded as JSON, so that both formats share the names of the settings
+		var values map[string]interface{}
+		if err := yaml.Unmarshal(file, &values); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if file, err = json.Marshal(values); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if err := json.Unmarshal(file, config); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv overrides the settings whose environment variable is set
+func (settings *Config) applyEnv(getenv func(string) string) error {
+	value := reflect.ValueOf(settings).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		name := strings.Split(value.Type().Field(i).Tag.Get("json"), ",")[0]
+		if name == "" {
+			continue
+		}
+		raw := getenv(name)
+		if raw == "" {
+			continue
+		}
+		if err := setField(value.Field(i), raw); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func setField(field reflect.Value, raw string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Int:
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", raw)
+		}
+		field.SetInt(int64(parsed))
+	case reflect.Float64:
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", raw)
+		}
+		field.SetFloat(parsed)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", raw)
+		}
+		field.SetBool(parsed)
+	case reflect.Slice:
+		var values []string
+		for _, value := range strings.Split(raw, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+		field.Set(reflect.ValueOf(values))
+	case reflect.Map:
+		target := reflect.New(field.Type())
+		if err := json.Unmarshal([]byte(raw), target.Interface()); err != nil {
+			return fmt.Errorf("not a JSON object of %s: %w", field.Type(), err)
+		}
+		field.Set(target.Elem())
+	default:
+		return fmt.Errorf("unsupported setting of type %s", field.Type())
+	}
+	return nil
+}
+
+// Validate reports all the invalid settings at once, e.g. a missing NEO4J_URI or a
+// sample rate greater than 1, so that the app fails to start rather than misbehave
+func (settings *Config) Validate() error {
+	var errs []error
+	check := func(valid bool, name, format string, args ...interface{}) {
+		if !valid {
+			errs = append(errs, fmt.Errorf("%s: %s", name, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	uri, err := url.Parse(settings.Uri)
+	check(settings.Uri != "", "NEO4J_URI", "is required")
+	check(settings.Uri == "" || err == nil && uri.Host != "", "NEO4J_URI", "%q is not a URI", settings.Uri)
+	check(settings.Username != "", "NEO4J_USERNAME", "is required")
+	check(settings.JwtSecret != "", "JWT_SECRET", "is required")
+	check(settings.Port >= 0 && settings.Port <= 65535, "APP_PORT", "%d is not a port", settings.Port)
+	check(settings.BasePath == "" || strings.HasPrefix(settings.BasePath, "/"), "BASE_PATH", "must start with /")
+	publicUrl, err := url.Parse(settings.PublicUrl)
+	check(settings.PublicUrl == "" || err == nil && publicUrl.IsAbs() && publicUrl.Host != "",
+		"PUBLIC_URL", "%q is not an absolute URL", settings.PublicUrl)
+
+	for name, value := range map[string]int{
+		"DEADLINE_FAST_LOOKUP_MS": settings.FastLookupDeadlineMs,
+		"DEADLINE_LIST_MS":        settings.ListDeadlineMs,
+		"DEADLINE_SIMILARITY_MS":  settings.SimilarityDeadlineMs,
+		"DEADLINE_EXPORT_MS":      settings.ExportDeadlineMs,
+	} {
+		// 0 keeps the default deadline, and -1 disables it
+		check(value >= -1, name, "%d is neither a duration nor -1", value)
+	}
+	for name, value := range map[string]int{
+		"TX_RETRY_BUDGET_MS":       settings.TransactionRetryBudgetMs,
+		"RETRY_MAX_ATTEMPTS":       settings.RetryMaxAttempts,
+		"RETRY_INITIAL_BACKOFF_MS": settings.RetryInitialBackoffMs,
+		"RETRY_MAX_BACKOFF_MS":     settings.RetryMaxBackoffMs,
+		"CACHE_TTL_MS":             settings.CacheTtlMs,
+		"CACHE_STALE_TTL_MS":       settings.CacheStaleTtlMs,
+		"CACHE_MAX_ENTRIES":        settings.CacheMaxEntries,
+		"LOOKUP_CACHE_TTL_MS":      settings.LookupCacheTtlMs,
+		"LOOKUP_CACHE_MAX_ENTRIES": settings.LookupCacheMaxEntries,
+		"WARMUP_QUERIES":           settings.WarmUpQueries,
+		"SIMILARITY_MAX_FAN_OUT":   settings.SimilarityMaxFanOut,
+		"EMBEDDING_DIMENSIONS":     settings.EmbeddingDimensions,
+		"AGGREGATE_CHECK_SAMPLE":   settings.AggregateCheckSample,
+		"BOOKMARK_TTL_MS":          settings.BookmarkTtlMs,
+		"REDIS_DB":                 settings.RedisDatabase,
+		"REDIS_TIMEOUT_MS":         settings.RedisTimeoutMs,
+		"TMDB_TIMEOUT_MS":          settings.TMDBTimeoutMs,
+	} {
+		check(value >= 0, name, "%d is negative", value)
+	}
+	for name, value := range map[string]float64{
+		"SHADOW_READ_SAMPLE_RATE": settings.ShadowReadSampleRate,
+		"TRACING_SAMPLE_RATE":     settings.TracingSampleRate,
+	} {
+		check(value >= 0 && value <= 1, name, "%v is not between 0 and 1", value)
+	}
+	for service, policy := range settings.RetryPolicies {
+		for name, value := range policy {
+			switch name {
+			case "maxAttempts", "initialBackoffMs", "maxBackoffMs":
+				check(value >= 0, "RETRY_POLICIES", "%s.%s is negative", service, name)
+			default:
+				check(false, "RETRY_POLICIES", "%s.%s is not a retry setting", service, name)
+			}
+		}
+	}
+	for flag, percentage := range settings.FeatureFlags {
+		check(percentage >= 0 && percentage <= 100, "FEATURE_FLAGS", "%s is rolled out to %d%% of the users", flag, percentage)
+	}
+	check(settings.RecommendationMinRating >= 0 && settings.RecommendationMinRating <= 5,
+		"RECOMMENDATION_MIN_RATING", "%v is not between 1 and 5", settings.RecommendationMinRating)
+	check(settings.DigestHour >= 0 && settings.DigestHour <= 23, "DIGEST_HOUR", "%d is not an hour", settings.DigestHour)
+
+	check(settings.AvatarStorage == "" || settings.AvatarStorage == "local" || settings.AvatarStorage == "s3",
+		"AVATAR_STORAGE", "%q is neither local nor s3", settings.AvatarStorage)
+	check(settings.AvatarStorage != "s3" || settings.AvatarS3Bucket != "", "AVATAR_S3_BUCKET", "is required by the s3 storage")
+	check(settings.Embedder == "" || settings.Embedder == "openai" || settings.Embedder == "local",
+		"EMBEDDER", "%q is neither openai nor local", settings.Embedder)
+	check(settings.Embedder != "openai" || settings.OpenAIApiKey != "" || settings.OpenAIBaseUrl != "",
+		"OPENAI_API_KEY", "is required by the openai embedder")
+	check(settings.BookmarkStore == "" || settings.BookmarkStore == "memory" || settings.BookmarkStore == "redis",
+		"BOOKMARK_STORE", "%q is neither memory nor redis", settings.BookmarkStore)
+	check(settings.BookmarkStore != "redis" || settings.RedisAddr != "", "REDIS_ADDR", "is required by the redis bookmark store")
+	check(settings.TMDBRequestsPerSecond >= 0, "TMDB_REQUESTS_PER_SECOND", "%v is negative", settings.TMDBRequestsPerSecond)
+	check(!settings.TMDBSyncEnabled || settings.TMDBApiKey != "", "TMDB_API_KEY", "is required by the TMDB sync")
+	check(settings.TMDBSyncHour >= 0 && settings.TMDBSyncHour <= 23, "TMDB_SYNC_HOUR", "%d is not an hour", settings.TMDBSyncHour)
+	check(settings.LogFormat == "" || settings.LogFormat == "text" || settings.LogFormat == "json",
+		"LOG_FORMAT", "%q is neither text nor json", settings.LogFormat)
+
+	return errors.Join(errs...)
+}
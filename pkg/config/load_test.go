@@ -0,0 +1,64 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadLayersEnvironmentOverFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	file := "NEO4J_URI: neo4j://localhost:7687\nNEO4J_USERNAME: neo4j\nJWT_SECRET: secret\nAPP_PORT: 3000\n"
+	if err := os.WriteFile(path, []byte(file), 0600); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{
+		"APP_PORT":         "8080",
+		"PARTNER_API_KEYS": "key1, key2",
+		"FEATURE_FLAGS":    `{"newPlayer": 10}`,
+	}
+
+	settings, err := Load(path, func(name string) string { return env[name] })
+
+	if err != nil {
+		t.Fatalf("expected settings to load, got %v", err)
+	}
+	if settings.Uri != "neo4j://localhost:7687" {
+		t.Errorf("expected the URI of the file, got %q", settings.Uri)
+	}
+	if settings.Port != 8080 {
+		t.Errorf("expected the port of the environment, got %d", settings.Port)
+	}
+	if !reflect.DeepEqual(settings.PartnerApiKeys, []string{"key1", "key2"}) {
+		t.Errorf("expected comma-separated keys, got %v", settings.PartnerApiKeys)
+	}
+	if settings.FeatureFlags["newPlayer"] != 10 {
+		t.Errorf("expected JSON flags, got %v", settings.FeatureFlags)
+	}
+}
+
+func TestLoadReportsAllInvalidSettings(t *testing.T) {
+	env := map[string]string{
+		"NEO4J_USERNAME":      "neo4j",
+		"APP_PORT":            "70000",
+		"TRACING_SAMPLE_RATE": "2",
+		"DEADLINE_LIST_MS":    "-2",
+		"DEADLINE_EXPORT_MS":  "-1",
+	}
+
+	_, err := Load(filepath.Join(t.TempDir(), "missing.json"), func(name string) string { return env[name] })
+
+	if err == nil {
+		t.Fatal("expected invalid settings to be rejected")
+	}
+	for _, name := range []string{"NEO4J_URI", "JWT_SECRET", "APP_PORT", "TRACING_SAMPLE_RATE", "DEADLINE_LIST_MS"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("expected %s to be reported, got %v", name, err)
+		}
+	}
+	if strings.Contains(err.Error(), "DEADLINE_EXPORT_MS") {
+		t.Errorf("expected -1 to disable the deadline, got %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Redacted replaces the value of the secret settings which are set
+const Redacted = "[redacted]"
+
+// Runtime is the configuration a running instance actually uses, for operators to
+// check it during incidents: its settings, secrets redacted, along with the effective
+// configuration of the driver and the database it connected to
+type Runtime struct {
+	Settings map[string]interface{} `json:"settings"`
+	Driver   DriverRuntime          `json:"driver"`
+	Database DatabaseRuntime        `json:"database"`
+}
+
+// DriverRuntime is the effective configuration of the driver, its defaults included
+type DriverRuntime struct {
+	Version                        string `json:"version"`
+	Uri                            string `json:"uri"`
+	MaxConnectionPoolSize          int    `json:"maxConnectionPoolSize"`
+	MaxConnectionLifetimeMs        int64  `json:"maxConnectionLifetimeMs"`
+	ConnectionAcquisitionTimeoutMs int64  `json:"connectionAcquisitionTimeoutMs"`
+	SocketConnectTimeoutMs         int64  `json:"socketConnectTimeoutMs"`
+	MaxTransactionRetryTimeMs      int64  `json:"maxTransactionRetryTimeMs"`
+	FetchSize                      int    `json:"fetchSize"`
+}
+
+// DatabaseRuntime describes the database the statements run against
+type DatabaseRuntime struct {
+	// Name is the name of the default database of the server, which the sessions use
+	Name    string `json:"name"`
+	Dialect string `json:"dialect"`
+	Gds     bool   `json:"gds"`
+}
+
+// NewRuntime returns the runtime configuration of the settings, once the driver was
+// created from them by NewDriver
+func NewRuntime(settings *Config, database DatabaseRuntime) *Runtime {
+	runtime := &Runtime{
+		Settings: settings.Sanitized(),
+		Driver: DriverRuntime{
+			Version: neo4j.UserAgent,
+			Uri:     sanitizeUri(settings.Uri),
+		},
+		Database: database,
+	}
+	if driver := settings.driver; driver != nil {
+		runtime.Driver.MaxConnectionPoolSize = driver.MaxConnectionPoolSize
+		runtime.Driver.MaxConnectionLifetimeMs = driver.MaxConnectionLifetime.Milliseconds()
+		runtime.Driver.ConnectionAcquisitionTimeoutMs = driver.ConnectionAcquisitionTimeout.Milliseconds()
+		runtime.Driver.SocketConnectTimeoutMs = driver.SocketConnectTimeout.Milliseconds()
+		runtime.Driver.MaxTransactionRetryTimeMs = driver.MaxTransactionRetryTime.Milliseconds()
+		runtime.Driver.FetchSize = driver.FetchSize
+	}
+	return runtime
+}
+
+// Sanitized returns the settings keyed by their name in config.json, the secret ones
+// replaced by Redacted when set, and the credentials of the URI of the database removed
+func (settings *Config) Sanitized() map[string]interface{} {
+	sanitized := map[string]interface{}{}
+	value := reflect.ValueOf(*settings)
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		switch {
+		case field.Tag.Get("secret") == "true":
+			if value.Field(i).IsZero() {
+				sanitized[name] = nil
+			} else {
+				sanitized[name] = Redacted
+			}
+		case name == "NEO4J_URI":
+			sanitized[name] = sanitizeUri(settings.Uri)
+		default:
+			sanitized[name] = value.Field(i).Interface()
+		}
+	}
+	return sanitized
+}
+
+// DefaultDatabase returns the name of the database the sessions of the driver use when
+// they name none, the default database of the server
+func DefaultDatabase(ctx context.Context, driver neo4j.DriverWithContext) (string, error) {
+	result, err := neo4j.ExecuteQuery(ctx, driver, "RETURN 1", nil, neo4j.EagerResultTransformer,
+		neo4j.ExecuteQueryWithReadersRouting())
+	if err != nil {
+		return "", err
+	}
+	if database := result.Summary.Database(); database != nil {
+		return database.Name(), nil
+	}
+	return "", nil
+}
+
+// sanitizeUri drops the password the URI may embed
+func sanitizeUri(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return Redacted
+	}
+	if _, found := parsed.User.Password(); found {
+		parsed.User = url.User(parsed.User.Username())
+	}
+	return parsed.String()
+}
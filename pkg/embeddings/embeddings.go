@@ -0,0 +1,13 @@
+// Package embeddings turns texts, such as the plots of movies, into vectors whose
+// distance reflects the distance of their meaning, for the semantic movie search.
+package embeddings
+
+import "context"
+
+// Embedder returns the embeddings of texts, all of the same number of dimensions
+type Embedder interface {
+	// Embed returns the embedding of each of the texts, in the same order
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+
+	Dimensions() int
+}
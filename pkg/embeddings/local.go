@@ -0,0 +1,72 @@
+package embeddings
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// DefaultLocalDimensions is the number of dimensions of the local embedder, unless
+// configured otherwise
+const DefaultLocalDimensions = 256
+
+type localEmbedder struct {
+	dimensions int
+}
+
+// NewLocalEmbedder embeds texts in process, without any model or external service, by
+// hashing their words into the dimensions of the vectors.
+// Its embeddings only capture the words texts share, not their meaning, which makes it
+// a stand-in for development and tests rather than for production.
+func NewLocalEmbedder(dimensions int) Embedder {
+	if dimensions <= 0 {
+		dimensions = DefaultLocalDimensions
+	}
+	return &localEmbedder{dimensions: dimensions}
+}
+
+func (l *localEmbedder) Dimensions() int {
+	return l.dimensions
+}
+
+func (l *localEmbedder) Embed(_ context.Context, texts []string) ([][]float64, error) {
+	embeddings := make([][]float64, 0, len(texts))
+	for _, text := range texts {
+		embeddings = append(embeddings, l.embed(text))
+	}
+	return embeddings, nil
+}
+
+// embed adds each word of the text to the dimension its hash points to, with the sign
+// of another bit of the hash so that collisions cancel out on average, then normalizes
+// the vector
+func (l *localEmbedder) embed(text string) []float64 {
+	vector := make([]float64, l.dimensions)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for _, word := range words {
+		hash := fnv.New64a()
+		_, _ = hash.Write([]byte(word))
+		sum := hash.Sum64()
+		sign := 1.0
+		if sum>>63 == 1 {
+			sign = -1
+		}
+		vector[sum%uint64(l.dimensions)] += sign
+	}
+	var norm float64
+	for _, value := range vector {
+		norm += value * value
+	}
+	if norm == 0 {
+		return vector
+	}
+	norm = math.Sqrt(norm)
+	for i := range vector {
+		vector[i] /= norm
+	}
+	return vector
+}
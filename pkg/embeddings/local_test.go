@@ -0,0 +1,41 @@
+package embeddings
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestLocalEmbedderNormalizesWordCounts(t *testing.T) {
+	embedder := NewLocalEmbedder(64)
+
+	embeddings, err := embedder.Embed(context.Background(), []string{
+		"A hacker learns the truth about reality",
+		"a HACKER learns... the truth about reality!",
+		"",
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(embeddings) != 3 || len(embeddings[0]) != 64 {
+		t.Fatalf("expected 3 embeddings of 64 dimensions, got %v", embeddings)
+	}
+	var norm float64
+	for i, value := range embeddings[0] {
+		norm += value * value
+		if value != embeddings[1][i] {
+			t.Errorf("expected the case and punctuation to be ignored, got %v and %v", embeddings[0], embeddings[1])
+			break
+		}
+	}
+	if math.Abs(norm-1) > 1e-9 {
+		t.Errorf("expected a unit vector, got a norm of %v", norm)
+	}
+	for _, value := range embeddings[2] {
+		if value != 0 {
+			t.Errorf("expected an empty text to embed as a zero vector, got %v", embeddings[2])
+			break
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// Defaults of the OpenAI embedder
+const (
+	DefaultOpenAIModel      = "text-embedding-3-small"
+	DefaultOpenAIDimensions = 1536
+	DefaultOpenAIBaseUrl    = "https://api.openai.com/v1"
+)
+
+type OpenAIConfig struct {
+	ApiKey string
+	// Model is DefaultOpenAIModel when empty
+	Model string
+	// Dimensions shortens the embeddings of the models supporting it, DefaultOpenAIDimensions
+	// when 0
+	Dimensions int
+	// BaseUrl overrides the OpenAI API, e.g. for servers exposing an OpenAI compatible
+	// embeddings endpoint
+	BaseUrl string
+}
+
+type openAIEmbedder struct {
+	config OpenAIConfig
+	client *http.Client
+}
+
+// NewOpenAIEmbedder embeds texts with the embeddings endpoint of the OpenAI API
+func NewOpenAIEmbedder(config OpenAIConfig) Embedder {
+	if config.Model == "" {
+		config.Model = DefaultOpenAIModel
+	}
+	if config.Dimensions == 0 {
+		config.Dimensions = DefaultOpenAIDimensions
+	}
+	if config.BaseUrl == "" {
+		config.BaseUrl = DefaultOpenAIBaseUrl
+	}
+	return &openAIEmbedder{config: config, client: http.DefaultClient}
+}
+
+func (o *openAIEmbedder) Dimensions() int {
+	return o.config.Dimensions
+}
+
+func (o *openAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"model":      o.config.Model,
+		"input":      texts,
+		"dimensions": o.config.Dimensions,
+	})
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequestWithContext(ctx, "POST",
+		strings.TrimSuffix(o.config.BaseUrl, "/")+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if o.config.ApiKey != "" {
+		request.Header.Set("Authorization", "Bearer "+o.config.ApiKey)
+	}
+
+	response, err := o.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+	if response.StatusCode != http.StatusOK {
+		message, _ := ioutil.ReadAll(response.Body)
+		return nil, fmt.Errorf("could not embed %d texts (status %d): %s", len(texts), response.StatusCode, message)
+	}
+	var payload struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&payload); err != nil {
+		return nil, err
+	}
+	embeddings := make([][]float64, len(texts))
+	for _, data := range payload.Data {
+		if data.Index < 0 || data.Index >= len(texts) || len(data.Embedding) != o.config.Dimensions {
+			return nil, fmt.Errorf("unexpected embedding %d of %d dimensions", data.Index, len(data.Embedding))
+		}
+		embeddings[data.Index] = data.Embedding
+	}
+	for i, embedding := range embeddings {
+		if embedding == nil {
+			return nil, fmt.Errorf("missing embedding %d of %d", i, len(texts))
+		}
+	}
+	return embeddings, nil
+}
@@ -0,0 +1,88 @@
+package fixtures
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+)
+
+// Dataset is a movie graph to seed a database with, each entity being read from its
+// own fixture file
+type Dataset struct {
+	Genres  []map[string]interface{}
+	People  []map[string]interface{}
+	Movies  []map[string]interface{}
+	Users   []map[string]interface{}
+	Ratings []map[string]interface{}
+}
+
+// ReadDataset reads the dataset of the directory: genres, people, movies, users and
+// ratings, each from the JSON array file named after it, e.g. movies.json, or else the
+// CSV file, e.g. ratings.csv, whose first row names the properties.
+// The files are optional, e.g. to seed the users and their ratings only.
+//
+// CSV values are read as strings, and coerced when the dataset is seeded.
+func (fl *FixtureLoader) ReadDataset(directory string) (*Dataset, error) {
+	dataset := &Dataset{}
+	for name, records := range map[string]*[]map[string]interface{}{
+		"genres":  &dataset.Genres,
+		"people":  &dataset.People,
+		"movies":  &dataset.Movies,
+		"users":   &dataset.Users,
+		"ratings": &dataset.Ratings,
+	} {
+		var err error
+		*records, err = fl.ReadArray(filepath.Join(directory, name+".json"))
+		if errors.Is(err, fs.ErrNotExist) {
+			*records, err = fl.ReadCsv(filepath.Join(directory, name+".csv"))
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return dataset, nil
+}
+
+// ReadCsv reads the records of a CSV fixture file, keyed by the names of its first row.
+// Empty values are left out of the records.
+func (fl *FixtureLoader) ReadCsv(fixture string) (_ []map[string]interface{}, err error) {
+	file, err := os.Open(filepath.Join(fl.Prefix, fixture))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = ioutils.DeferredClose(file, err)
+	}()
+
+	reader := csv.NewReader(file)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fixture, err)
+	}
+	var records []map[string]interface{}
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fixture, err)
+		}
+		record := make(map[string]interface{}, len(header))
+		for i, value := range row {
+			if value != "" {
+				record[header[i]] = value
+			}
+		}
+		records = append(records, record)
+	}
+}
@@ -0,0 +1,274 @@
+package fixtures
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j-graphacademy/neoflix/pkg/normalize"
+	"github.com/neo4j-graphacademy/neoflix/pkg/queries"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultBatchSize is the number of records written per transaction by default
+const DefaultBatchSize = 1000
+
+// SeedOptions configures Seed
+type SeedOptions struct {
+	// BatchSize is the number of records written per transaction, DefaultBatchSize when 0
+	BatchSize int
+	// Clear deletes the existing graph before seeding it, the schema version excepted
+	Clear bool
+	// DryRun runs the whole seed in a single transaction, rolled back at the end, so that
+	// the dataset is checked against the database without writing it
+	DryRun bool
+	// SaltRounds is the bcrypt cost the passwords of the users are hashed with,
+	// bcrypt.DefaultCost when 0
+	SaltRounds int
+	// Catalog is the catalog the seed statements are read from, the embedded one when nil
+	Catalog *queries.Catalog
+}
+
+// SeedReport counts the nodes deleted and the records written per entity, along with
+// the values normalized before being written
+type SeedReport struct {
+	Deleted    int64
+	Written    map[string]int64
+	Normalized normalize.Report
+}
+
+// ratingRules coerce the ratings read from CSV files
+var ratingRules = normalize.Rules{
+	{Label: "RATED", Property: "rating", Action: normalize.Float},
+	{Label: "RATED", Property: "timestamp", Action: normalize.Integer},
+}
+
+// Seed writes the dataset with batched UNWIND statements, merging the genres, people,
+// movies, users and ratings in this order, on their IDs, so that seeding the same
+// dataset twice leaves the graph unchanged.
+// The records are normalized with the default rules of pkg/normalize, and the passwords
+// of the users hashed, unless they are bcrypt hashes already.
+//
+// Records missing their ID, or another required property, fail the seed before anything
+// is written.
+func Seed(ctx context.Context, driver neo4j.DriverWithContext, dataset *Dataset, opts SeedOptions) (_ SeedReport, err error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.SaltRounds == 0 {
+		opts.SaltRounds = bcrypt.DefaultCost
+	}
+	if opts.Catalog == nil {
+		opts.Catalog = queries.MustEmbedded()
+	}
+	normalizer := normalize.New(append(normalize.DefaultRules(), ratingRules...))
+	batches, err := seedBatches(dataset, normalizer, opts)
+	if err != nil {
+		return SeedReport{}, err
+	}
+
+	session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+	write := func(name string, params map[string]interface{}) (int64, error) {
+		result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+			return runCount(ctx, tx, opts.Catalog.Get(name).Render(nil), params)
+		})
+		if err != nil {
+			return 0, err
+		}
+		return result.(int64), nil
+	}
+	if opts.DryRun {
+		tx, err := session.BeginTransaction(ctx)
+		if err != nil {
+			return SeedReport{}, err
+		}
+		defer func() {
+			// nothing is ever committed
+			err = ioutils.DeferredContextClose(ctx, tx, err)
+		}()
+		write = func(name string, params map[string]interface{}) (int64, error) {
+			return runCount(ctx, tx, opts.Catalog.Get(name).Render(nil), params)
+		}
+	}
+
+	report := SeedReport{Written: map[string]int64{}}
+	if opts.Clear {
+		for {
+			deleted, err := write("seed/clear", map[string]interface{}{"batchSize": opts.BatchSize})
+			if err != nil {
+				return SeedReport{}, err
+			}
+			report.Deleted += deleted
+			if deleted == 0 {
+				break
+			}
+		}
+	}
+	for _, batch := range batches {
+		count, err := write("seed/"+batch.entity, map[string]interface{}{"rows": batch.rows})
+		if err != nil {
+			return SeedReport{}, fmt.Errorf("%s: %w", batch.entity, err)
+		}
+		report.Written[batch.entity] += count
+	}
+	report.Normalized = normalizer.Report()
+	return report, nil
+}
+
+// runCount runs the statement and returns the count of its single record
+func runCount(ctx context.Context, tx neo4j.ManagedTransaction, cypher string, params map[string]interface{}) (int64, error) {
+	result, err := tx.Run(ctx, cypher, params)
+	if err != nil {
+		return 0, err
+	}
+	record, err := result.Single(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return record.Values[0].(int64), nil
+}
+
+type seedBatch struct {
+	entity string
+	rows   []map[string]interface{}
+}
+
+// seedBatches returns the rows of the statements of the entities of the dataset, in the
+// order they are written, split in batches
+func seedBatches(dataset *Dataset, normalizer *normalize.Normalizer, opts SeedOptions) ([]seedBatch, error) {
+	var batches []seedBatch
+	for _, entity := range []struct {
+		name    string
+		records []map[string]interface{}
+		row     func(record map[string]interface{}) (map[string]interface{}, error)
+	}{
+		{"genres", dataset.Genres, func(record map[string]interface{}) (map[string]interface{}, error) {
+			record = normalizer.Apply("Genre", record)
+			if err := require(record, "name"); err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{
+				"name":       record["name"],
+				"properties": properties(record, "name"),
+			}, nil
+		}},
+		{"people", dataset.People, func(record map[string]interface{}) (map[string]interface{}, error) {
+			record = normalizer.Apply("Person", record)
+			if err := require(record, "tmdbId", "name"); err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{
+				"tmdbId":     record["tmdbId"],
+				"properties": properties(record, "tmdbId"),
+			}, nil
+		}},
+		{"movies", dataset.Movies, func(record map[string]interface{}) (map[string]interface{}, error) {
+			record = normalizer.Apply("Movie", record)
+			if err := require(record, "tmdbId", "title"); err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{
+				"tmdbId":     record["tmdbId"],
+				"genres":     orEmpty(record["genres"]),
+				"actors":     orEmpty(record["actors"]),
+				"directors":  orEmpty(record["directors"]),
+				"properties": properties(record, "tmdbId", "genres", "actors", "directors"),
+			}, nil
+		}},
+		{"users", dataset.Users, func(record map[string]interface{}) (map[string]interface{}, error) {
+			if err := require(record, "userId", "email", "name", "password"); err != nil {
+				return nil, err
+			}
+			password, err := hashPassword(fmt.Sprint(record["password"]), opts.SaltRounds)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{
+				"userId":   fmt.Sprint(record["userId"]),
+				"email":    record["email"],
+				"name":     record["name"],
+				"password": password,
+			}, nil
+		}},
+		{"ratings", dataset.Ratings, func(record map[string]interface{}) (map[string]interface{}, error) {
+			record = normalizer.Apply("RATED", record)
+			if err := require(record, "userId", "movieId", "rating", "timestamp"); err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{
+				"userId":    fmt.Sprint(record["userId"]),
+				"movieId":   fmt.Sprint(record["movieId"]),
+				"rating":    record["rating"],
+				"timestamp": record["timestamp"],
+			}, nil
+		}},
+	} {
+		rows := make([]map[string]interface{}, 0, len(entity.records))
+		for i, record := range entity.records {
+			row, err := entity.row(record)
+			if err != nil {
+				return nil, fmt.Errorf("%s %d: %w", entity.name, i, err)
+			}
+			rows = append(rows, row)
+		}
+		for start := 0; start < len(rows); start += opts.BatchSize {
+			end := start + opts.BatchSize
+			if end > len(rows) {
+				end = len(rows)
+			}
+			batches = append(batches, seedBatch{entity: entity.name, rows: rows[start:end]})
+		}
+	}
+	return batches, nil
+}
+
+func require(record map[string]interface{}, names ...string) error {
+	var missing []string
+	for _, name := range names {
+		if value, found := record[name]; !found || value == nil || value == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// properties returns the properties of the record stored as is, without the excluded
+// ones, which the statements set themselves.
+// Null values are left out, so that they do not erase the ones of the graph, and dates
+// are stored as the "2006-01-02" strings of the normalizer, as the services compare them.
+func properties(record map[string]interface{}, excluded ...string) map[string]interface{} {
+	result := make(map[string]interface{}, len(record))
+	for name, value := range record {
+		if value != nil {
+			result[name] = value
+		}
+	}
+	for _, name := range excluded {
+		delete(result, name)
+	}
+	return result
+}
+
+func orEmpty(value interface{}) interface{} {
+	if value == nil {
+		return []interface{}{}
+	}
+	return value
+}
+
+// hashPassword hashes the password with bcrypt, unless it is a bcrypt hash already
+func hashPassword(password string, cost int) (string, error) {
+	if _, err := bcrypt.Cost([]byte(password)); err == nil {
+		return password, nil
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	return string(hash), err
+}
@@ -0,0 +1,81 @@
+package fixtures
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/normalize"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestSeedBatches(t *testing.T) {
+	dataset, err := (&FixtureLoader{Prefix: "../.."}).ReadDataset("fixtures/seed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	normalizer := normalize.New(append(normalize.DefaultRules(), ratingRules...))
+
+	batches, err := seedBatches(dataset, normalizer, SeedOptions{BatchSize: 5, SaltRounds: bcrypt.MinCost})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	var order []string
+	for _, batch := range batches {
+		if len(batch.rows) > 5 {
+			t.Errorf("expected batches of 5 rows at most, got %d %s", len(batch.rows), batch.entity)
+		}
+		if counts[batch.entity] == 0 {
+			order = append(order, batch.entity)
+		}
+		counts[batch.entity] += len(batch.rows)
+	}
+	if strings.Join(order, ",") != "genres,people,movies,users,ratings" {
+		t.Errorf("expected the entities to be written in dependency order, got %v", order)
+	}
+	if counts["movies"] != len(dataset.Movies) || counts["ratings"] != len(dataset.Ratings) {
+		t.Errorf("expected all the records to be batched, got %v", counts)
+	}
+	for _, batch := range batches {
+		for _, row := range batch.rows {
+			switch batch.entity {
+			case "movies":
+				// dates are stored as the strings the services compare
+				properties := row["properties"].(map[string]interface{})
+				if released, found := properties["released"]; found {
+					if _, ok := released.(string); !ok {
+						t.Errorf("expected a string release date, got %#v", released)
+					}
+				}
+				for name, value := range properties {
+					if value == nil {
+						t.Errorf("expected the null %s of %v to be left out", name, row["tmdbId"])
+					}
+				}
+			case "users":
+				if _, err := bcrypt.Cost([]byte(row["password"].(string))); err != nil {
+					t.Errorf("expected the password of %v to be hashed", row["userId"])
+				}
+			case "ratings":
+				// CSV values are coerced
+				if _, ok := row["rating"].(float64); !ok {
+					t.Errorf("expected a float rating, got %#v", row["rating"])
+				}
+				if _, ok := row["timestamp"].(int64); !ok {
+					t.Errorf("expected an integer timestamp, got %#v", row["timestamp"])
+				}
+			}
+		}
+	}
+}
+
+func TestSeedBatchesRejectsIncompleteRecords(t *testing.T) {
+	dataset := &Dataset{Movies: []map[string]interface{}{{"tmdbId": "1", "title": "Up"}, {"tmdbId": "2"}}}
+
+	_, err := seedBatches(dataset, normalize.New(nil), SeedOptions{BatchSize: 10})
+
+	if err == nil || err.Error() != "movies 1: missing title" {
+		t.Errorf("expected the movie without title to be rejected, got %v", err)
+	}
+}
@@ -1,6 +1,9 @@
 package fixtures
 
 func Slice(slice []map[string]interface{}, skip, limit int) []map[string]interface{} {
+	if len(slice) == 0 {
+		return slice
+	}
 	start, end := sliceBounds(slice, skip, limit)
 	return slice[start:end]
 }
@@ -0,0 +1,272 @@
+// Package importer keeps the movie graph in sync with TMDB: it fetches the movies, their
+// cast and their crew from the TMDB API, and merges them into the graph on their TMDB
+// IDs, so that importing the same movie twice leaves the graph unchanged.
+package importer
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j-graphacademy/neoflix/pkg/normalize"
+	"github.com/neo4j-graphacademy/neoflix/pkg/queries"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// DefaultBatchSize is the number of movies written per transaction by default
+const DefaultBatchSize = 100
+
+// MaxSyncWindow is the longest period TMDB lists the changes of. A sync following a
+// longer pause only catches up with the changes of this last period.
+const MaxSyncWindow = 14 * 24 * time.Hour
+
+// firstSyncWindow is the period the first sync catches up with
+const firstSyncWindow = 24 * time.Hour
+
+// syncSource identifies the import state of TMDB in the graph
+const syncSource = "tmdb"
+
+type Options struct {
+	// BatchSize is the number of movies written per transaction, DefaultBatchSize when 0
+	BatchSize int
+	// Catalog is the catalog the import statements are read from, the embedded one when
+	// nil
+	Catalog *queries.Catalog
+}
+
+// Report counts the movies fetched from TMDB and written to the graph, lists the ones
+// TMDB does not know of, and counts the values normalized before being written
+type Report struct {
+	Fetched    int
+	Written    int64
+	NotFound   []int
+	Normalized normalize.Report
+}
+
+type Importer struct {
+	client  *TMDBClient
+	driver  neo4j.DriverWithContext
+	options Options
+}
+
+func New(client *TMDBClient, driver neo4j.DriverWithContext, options Options) *Importer {
+	if options.BatchSize <= 0 {
+		options.BatchSize = DefaultBatchSize
+	}
+	if options.Catalog == nil {
+		options.Catalog = queries.MustEmbedded()
+	}
+	return &Importer{client: client, driver: driver, options: options}
+}
+
+// Import fetches the movies of the TMDB IDs and merges them, along with their genres,
+// actors and directors, into the graph.
+// The properties of the movies are replaced by the ones of TMDB, while the existing
+// people are left as is, and existing relationships are kept.
+//
+// The movies TMDB does not know of are skipped, and reported as NotFound.
+func (i *Importer) Import(ctx context.Context, ids []int) (Report, error) {
+	normalizer := normalize.New(normalize.DefaultRules())
+	report := Report{}
+	rows := make([]map[string]interface{}, 0, i.options.BatchSize)
+	for _, id := range ids {
+		record, err := i.client.Movie(ctx, id)
+		var notFound *NotFoundError
+		if errors.As(err, &notFound) {
+			report.NotFound = append(report.NotFound, id)
+			continue
+		}
+		if err != nil {
+			return report, err
+		}
+		report.Fetched++
+		rows = append(rows, movieRow(normalizer.Apply("Movie", record)))
+		if len(rows) == i.options.BatchSize {
+			written, err := i.write(ctx, rows)
+			if err != nil {
+				return report, err
+			}
+			report.Written += written
+			rows = rows[:0]
+		}
+	}
+	if len(rows) > 0 {
+		written, err := i.write(ctx, rows)
+		if err != nil {
+			return report, err
+		}
+		report.Written += written
+	}
+	report.Normalized = normalizer.Report()
+	return report, nil
+}
+
+// ImportPopular imports the movies of the first pages of the popular movies of TMDB,
+// 20 per page
+func (i *Importer) ImportPopular(ctx context.Context, pages int) (Report, error) {
+	var ids []int
+	for page := 1; page <= pages; page++ {
+		popular, total, err := i.client.Popular(ctx, page)
+		if err != nil {
+			return Report{}, err
+		}
+		ids = append(ids, popular...)
+		if page >= total {
+			break
+		}
+	}
+	return i.Import(ctx, ids)
+}
+
+// Sync imports again the movies of the graph edited on TMDB since the previous sync,
+// or over the last day on the first sync, and records now as the time of the sync.
+// Movies which are not in the graph yet are left out, as are the changes older than
+// MaxSyncWindow.
+func (i *Importer) Sync(ctx context.Context, now time.Time) (Report, error) {
+	start, err := i.lastSync(ctx)
+	if err != nil {
+		return Report{}, err
+	}
+	if start.IsZero() {
+		start = now.Add(-firstSyncWindow)
+	}
+	if now.Sub(start) > MaxSyncWindow {
+		start = now.Add(-MaxSyncWindow)
+	}
+
+	var changed []int
+	for page, total := 1, 1; page <= total; page++ {
+		ids, pages, err := i.client.Changes(ctx, start, now, page)
+		if err != nil {
+			return Report{}, err
+		}
+		changed = append(changed, ids...)
+		total = pages
+	}
+	existing, err := i.existing(ctx, changed)
+	if err != nil {
+		return Report{}, err
+	}
+	report, err := i.Import(ctx, existing)
+	if err != nil {
+		return report, err
+	}
+	return report, i.saveSync(ctx, now)
+}
+
+// movieRow returns the row of the import statement of the normalized movie record.
+// The release date is stored as the "2006-01-02" string of the normalizer, as the
+// services compare it.
+func movieRow(record map[string]interface{}) map[string]interface{} {
+	properties := make(map[string]interface{}, len(record))
+	for name, value := range record {
+		switch name {
+		case "tmdbId", "genres", "actors", "directors":
+		default:
+			properties[name] = value
+		}
+	}
+	return map[string]interface{}{
+		"tmdbId":     record["tmdbId"],
+		"genres":     record["genres"],
+		"actors":     record["actors"],
+		"directors":  record["directors"],
+		"properties": properties,
+	}
+}
+
+func (i *Importer) write(ctx context.Context, rows []map[string]interface{}) (_ int64, err error) {
+	session := i.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		record, err := i.single(ctx, tx, "importer/movies", map[string]interface{}{"rows": rows})
+		if err != nil {
+			return nil, err
+		}
+		count, _ := record.Get("count")
+		return count, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.(int64), nil
+}
+
+// existing returns the IDs of the movies already in the graph, among the TMDB IDs
+func (i *Importer) existing(ctx context.Context, ids []int) (_ []int, err error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	tmdbIds := make([]string, 0, len(ids))
+	for _, id := range ids {
+		tmdbIds = append(tmdbIds, strconv.Itoa(id))
+	}
+	session := i.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		record, err := i.single(ctx, tx, "importer/find_existing", map[string]interface{}{"ids": tmdbIds})
+		if err != nil {
+			return nil, err
+		}
+		found, _ := record.Get("ids")
+		return found, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	existing := make([]int, 0, len(result.([]interface{})))
+	for _, id := range result.([]interface{}) {
+		if parsed, err := strconv.Atoi(id.(string)); err == nil {
+			existing = append(existing, parsed)
+		}
+	}
+	return existing, nil
+}
+
+// lastSync returns the time of the previous sync, zero if none
+func (i *Importer) lastSync(ctx context.Context) (_ time.Time, err error) {
+	session := i.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		record, err := i.single(ctx, tx, "importer/last_sync", map[string]interface{}{"source": syncSource})
+		if err != nil {
+			return nil, err
+		}
+		syncedAt, _ := record.Get("syncedAt")
+		return syncedAt, nil
+	})
+	if err != nil || result == nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(result.(int64)), nil
+}
+
+func (i *Importer) saveSync(ctx context.Context, now time.Time) (err error) {
+	session := i.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+	_, err = session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		return i.single(ctx, tx, "importer/save_sync", map[string]interface{}{
+			"source":   syncSource,
+			"syncedAt": now.UnixMilli(),
+		})
+	})
+	return err
+}
+
+func (i *Importer) single(ctx context.Context, tx neo4j.ManagedTransaction, name string, params map[string]interface{}) (*neo4j.Record, error) {
+	result, err := tx.Run(ctx, i.options.Catalog.Get(name).Render(nil), params)
+	if err != nil {
+		return nil, err
+	}
+	return result.Single(ctx)
+}
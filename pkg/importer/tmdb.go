@@ -0,0 +1,336 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults of the TMDB client
+const (
+	DefaultTMDBBaseUrl       = "https://api.themoviedb.org/3"
+	DefaultRequestsPerSecond = 20
+	DefaultMaxCast           = 10
+	DefaultTMDBTimeout       = 10 * time.Second
+)
+
+const (
+	tmdbImageUrl = "https://image.tmdb.org/t/p/w440_and_h660_face"
+	tmdbMovieUrl = "https://themoviedb.org/movie/"
+	// maxRateLimitRetries is the number of times a request rejected by the rate limit of
+	// TMDB is retried, after the delay TMDB asks for
+	maxRateLimitRetries = 3
+)
+
+type TMDBConfig struct {
+	// ApiKey is the API key (v3 auth) of the TMDB account
+	ApiKey string
+	// BaseUrl is DefaultTMDBBaseUrl when empty
+	BaseUrl string
+	// RequestsPerSecond is the number of requests sent to TMDB per second at most,
+	// DefaultRequestsPerSecond when 0
+	RequestsPerSecond float64
+	// MaxCast is the number of actors imported per movie, by billing order,
+	// DefaultMaxCast when 0
+	MaxCast int
+	// Timeout bounds each request, its response body included, DefaultTMDBTimeout when 0
+	Timeout time.Duration
+}
+
+// TMDBClient reads the movies, their cast and their crew from the TMDB API, as catalog
+// records, throttling its requests to stay within the rate limit of TMDB
+type TMDBClient struct {
+	config  TMDBConfig
+	client  *http.Client
+	limiter *rateLimiter
+}
+
+func NewTMDBClient(config TMDBConfig) *TMDBClient {
+	if config.BaseUrl == "" {
+		config.BaseUrl = DefaultTMDBBaseUrl
+	}
+	if config.RequestsPerSecond <= 0 {
+		config.RequestsPerSecond = DefaultRequestsPerSecond
+	}
+	if config.MaxCast <= 0 {
+		config.MaxCast = DefaultMaxCast
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTMDBTimeout
+	}
+	return &TMDBClient{
+		config:  config,
+		client:  &http.Client{Timeout: config.Timeout},
+		limiter: &rateLimiter{interval: time.Duration(float64(time.Second) / config.RequestsPerSecond)},
+	}
+}
+
+// NotFoundError is returned for the movies TMDB does not know of, such as deleted ones
+type NotFoundError struct {
+	Id int
+}
+
+func (nf *NotFoundError) Error() string {
+	return fmt.Sprintf("movie %d not found on TMDB", nf.Id)
+}
+
+type tmdbPerson struct {
+	Id          int    `json:"id"`
+	Name        string `json:"name"`
+	ProfilePath string `json:"profile_path"`
+	Character   string `json:"character"`
+	Job         string `json:"job"`
+}
+
+type tmdbMovie struct {
+	Id          int    `json:"id"`
+	ImdbId      string `json:"imdb_id"`
+	Title       string `json:"title"`
+	Tagline     string `json:"tagline"`
+	Overview    string `json:"overview"`
+	ReleaseDate string `json:"release_date"`
+	Runtime     int    `json:"runtime"`
+	Budget      int64  `json:"budget"`
+	Revenue     int64  `json:"revenue"`
+	PosterPath  string `json:"poster_path"`
+	Genres      []struct {
+		Name string `json:"name"`
+	} `json:"genres"`
+	SpokenLanguages []struct {
+		EnglishName string `json:"english_name"`
+	} `json:"spoken_languages"`
+	ProductionCountries []struct {
+		Name string `json:"name"`
+	} `json:"production_countries"`
+	Credits struct {
+		Cast []tmdbPerson `json:"cast"`
+		Crew []tmdbPerson `json:"crew"`
+	} `json:"credits"`
+}
+
+// Movie returns the record of the movie of the TMDB ID, with its genres, its top billed
+// actors and its directors, keyed by the API names of the catalog, e.g. `plot` for the
+// overview of the movie.
+// Empty values are left out, so that they do not erase the ones of the graph.
+//
+// If TMDB does not know of the movie, a NotFoundError is returned.
+func (c *TMDBClient) Movie(ctx context.Context, id int) (map[string]interface{}, error) {
+	var movie tmdbMovie
+	err := c.get(ctx, "/movie/"+strconv.Itoa(id), url.Values{"append_to_response": {"credits"}}, &movie)
+	if err != nil {
+		return nil, err
+	}
+
+	record := map[string]interface{}{
+		"tmdbId": strconv.Itoa(movie.Id),
+		"url":    tmdbMovieUrl + strconv.Itoa(movie.Id),
+	}
+	set := func(name string, value interface{}, present bool) {
+		if present {
+			record[name] = value
+		}
+	}
+	set("title", movie.Title, movie.Title != "")
+	set("tagline", movie.Tagline, movie.Tagline != "")
+	set("plot", movie.Overview, movie.Overview != "")
+	set("imdbId", movie.ImdbId, movie.ImdbId != "")
+	set("released", movie.ReleaseDate, movie.ReleaseDate != "")
+	set("year", strings.Split(movie.ReleaseDate, "-")[0], movie.ReleaseDate != "")
+	set("runtime", movie.Runtime, movie.Runtime > 0)
+	set("budget", movie.Budget, movie.Budget > 0)
+	set("revenue", movie.Revenue, movie.Revenue > 0)
+	set("poster", tmdbImageUrl+movie.PosterPath, movie.PosterPath != "")
+
+	genres := make([]interface{}, 0, len(movie.Genres))
+	for _, genre := range movie.Genres {
+		genres = append(genres, genre.Name)
+	}
+	record["genres"] = genres
+	var languages, countries []interface{}
+	for _, language := range movie.SpokenLanguages {
+		languages = append(languages, language.EnglishName)
+	}
+	for _, country := range movie.ProductionCountries {
+		countries = append(countries, country.Name)
+	}
+	set("languages", languages, len(languages) > 0)
+	set("countries", countries, len(countries) > 0)
+
+	actors := make([]interface{}, 0, c.config.MaxCast)
+	for _, actor := range movie.Credits.Cast {
+		if len(actors) == c.config.MaxCast {
+			break
+		}
+		person := personRecord(actor)
+		person["role"] = actor.Character
+		actors = append(actors, person)
+	}
+	record["actors"] = actors
+	directors := []interface{}{}
+	for _, member := range movie.Credits.Crew {
+		if member.Job == "Director" {
+			directors = append(directors, personRecord(member))
+		}
+	}
+	record["directors"] = directors
+	return record, nil
+}
+
+func personRecord(person tmdbPerson) map[string]interface{} {
+	record := map[string]interface{}{
+		"tmdbId": strconv.Itoa(person.Id),
+		"name":   person.Name,
+	}
+	if person.ProfilePath != "" {
+		record["poster"] = tmdbImageUrl + person.ProfilePath
+	}
+	return record
+}
+
+type tmdbPage struct {
+	Page       int `json:"page"`
+	TotalPages int `json:"total_pages"`
+	Results    []struct {
+		Id    int   `json:"id"`
+		Adult *bool `json:"adult"`
+	} `json:"results"`
+}
+
+func (p tmdbPage) ids() []int {
+	ids := make([]int, 0, len(p.Results))
+	for _, result := range p.Results {
+		if result.Adult == nil || !*result.Adult {
+			ids = append(ids, result.Id)
+		}
+	}
+	return ids
+}
+
+// Popular returns the IDs of the popular movies of the page, from 1, along with the
+// number of pages
+func (c *TMDBClient) Popular(ctx context.Context, page int) ([]int, int, error) {
+	var result tmdbPage
+	err := c.get(ctx, "/movie/popular", url.Values{"page": {strconv.Itoa(page)}}, &result)
+	return result.ids(), result.TotalPages, err
+}
+
+// Changes returns the IDs of the movies of the page, from 1, edited on TMDB between the
+// days of start and end, along with the number of pages.
+// TMDB lists the changes of 14 days at most.
+func (c *TMDBClient) Changes(ctx context.Context, start, end time.Time, page int) ([]int, int, error) {
+	var result tmdbPage
+	err := c.get(ctx, "/movie/changes", url.Values{
+		"start_date": {start.UTC().Format("2006-01-02")},
+		"end_date":   {end.UTC().Format("2006-01-02")},
+		"page":       {strconv.Itoa(page)},
+	}, &result)
+	return result.ids(), result.TotalPages, err
+}
+
+func (c *TMDBClient) get(ctx context.Context, path string, query url.Values, target interface{}) error {
+	query.Set("api_key", c.config.ApiKey)
+	endpoint := strings.TrimSuffix(c.config.BaseUrl, "/") + path + "?" + query.Encode()
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.wait(ctx); err != nil {
+			return err
+		}
+		request, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+		if err != nil {
+			return err
+		}
+		request.Header.Set("Accept", "application/json")
+		response, err := c.client.Do(request)
+		if err != nil {
+			return redactApiKey(err)
+		}
+		if response.StatusCode == http.StatusTooManyRequests && attempt < maxRateLimitRetries {
+			_ = response.Body.Close()
+			if err := sleep(ctx, retryAfter(response)); err != nil {
+				return err
+			}
+			continue
+		}
+		return decodeResponse(response, path, target)
+	}
+}
+
+// redactApiKey removes the API key from the URL of the errors of the HTTP client, so that
+// logging them does not leak it
+func redactApiKey(err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+	if parsed, parseErr := url.Parse(urlErr.URL); parseErr == nil {
+		query := parsed.Query()
+		query.Del("api_key")
+		parsed.RawQuery = query.Encode()
+		urlErr.URL = parsed.String()
+	}
+	return err
+}
+
+func decodeResponse(response *http.Response, path string, target interface{}) error {
+	defer func() {
+		_ = response.Body.Close()
+	}()
+	if response.StatusCode == http.StatusNotFound && strings.HasPrefix(path, "/movie/") {
+		if id, err := strconv.Atoi(strings.TrimPrefix(path, "/movie/")); err == nil {
+			return &NotFoundError{Id: id}
+		}
+	}
+	if response.StatusCode != http.StatusOK {
+		message, _ := ioutil.ReadAll(response.Body)
+		return fmt.Errorf("could not get %s from TMDB (status %d): %s", path, response.StatusCode, message)
+	}
+	return json.NewDecoder(response.Body).Decode(target)
+}
+
+// retryAfter returns the delay of the Retry-After header of the response, in seconds,
+// 1 second when missing
+func retryAfter(response *http.Response) time.Duration {
+	if seconds, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return time.Second
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// rateLimiter spaces the requests by its interval, whichever goroutines send them
+type rateLimiter struct {
+	interval time.Duration
+
+	mutex sync.Mutex
+	next  time.Time
+}
+
+// wait blocks until the next request can be sent, or the context is done
+func (rl *rateLimiter) wait(ctx context.Context) error {
+	rl.mutex.Lock()
+	now := time.Now()
+	slot := rl.next
+	if slot.Before(now) {
+		slot = now
+	}
+	rl.next = slot.Add(rl.interval)
+	rl.mutex.Unlock()
+	return sleep(ctx, time.Until(slot))
+}
@@ -0,0 +1,104 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTMDBClientMapsMoviesToRecords(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("api_key") != "secret" || r.URL.Query().Get("append_to_response") != "credits" {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		switch r.URL.Path {
+		case "/movie/603":
+			_, _ = w.Write([]byte(`{
+				"id": 603, "imdb_id": "tt0133093", "title": "The Matrix", "overview": "Neo learns the truth.",
+				"release_date": "1999-03-30", "runtime": 136, "budget": 63000000, "poster_path": "/matrix.jpg",
+				"genres": [{"name": "Action"}, {"name": "Science Fiction"}],
+				"credits": {
+					"cast": [
+						{"id": 6384, "name": "Keanu Reeves", "character": "Neo", "profile_path": "/keanu.jpg"},
+						{"id": 2975, "name": "Laurence Fishburne", "character": "Morpheus"}
+					],
+					"crew": [
+						{"id": 9339, "name": "Lilly Wachowski", "job": "Director"},
+						{"id": 1091, "name": "Bill Pope", "job": "Director of Photography"}
+					]
+				}
+			}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client := NewTMDBClient(TMDBConfig{ApiKey: "secret", BaseUrl: server.URL, MaxCast: 1})
+
+	movie, err := client.Movie(context.Background(), 603)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if movie["tmdbId"] != "603" || movie["plot"] != "Neo learns the truth." || movie["year"] != "1999" {
+		t.Errorf("unexpected movie %v", movie)
+	}
+	if _, found := movie["tagline"]; found {
+		t.Errorf("expected the empty tagline to be left out, got %v", movie["tagline"])
+	}
+	actors := movie["actors"].([]interface{})
+	if len(actors) != 1 || actors[0].(map[string]interface{})["role"] != "Neo" {
+		t.Errorf("expected the top billed actor only, got %v", actors)
+	}
+	directors := movie["directors"].([]interface{})
+	if len(directors) != 1 || directors[0].(map[string]interface{})["name"] != "Lilly Wachowski" {
+		t.Errorf("expected the directors only, got %v", directors)
+	}
+
+	_, err = client.Movie(context.Background(), 1)
+	var notFound *NotFoundError
+	if !errors.As(err, &notFound) || notFound.Id != 1 {
+		t.Errorf("expected a NotFoundError, got %v", err)
+	}
+}
+
+func TestTMDBClientRetriesRateLimitedRequests(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{"page": 1, "total_pages": 3, "results": [{"id": 603, "adult": false}, {"id": 1, "adult": true}]}`))
+	}))
+	defer server.Close()
+	client := NewTMDBClient(TMDBConfig{BaseUrl: server.URL})
+
+	ids, pages, err := client.Popular(context.Background(), 1)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 {
+		t.Errorf("expected the rate limited request to be retried once, got %d requests", requests)
+	}
+	if len(ids) != 1 || ids[0] != 603 || pages != 3 {
+		t.Errorf("expected the non adult movie of 3 pages, got %v of %d pages", ids, pages)
+	}
+}
+
+func TestTMDBClientRedactsTheApiKeyOfErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+	client := NewTMDBClient(TMDBConfig{ApiKey: "secret", BaseUrl: server.URL})
+
+	_, err := client.Movie(context.Background(), 603)
+
+	if err == nil || strings.Contains(err.Error(), "secret") {
+		t.Errorf("expected an error without the API key, got %v", err)
+	}
+}
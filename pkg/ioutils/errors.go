@@ -1,6 +1,7 @@
 package ioutils
 
 import (
+	"context"
 	"fmt"
 	"io"
 )
@@ -10,7 +11,21 @@ import (
 // Note: since deferred function arguments are evaluated immediately, this
 // function should always be called within an anonymous function.
 func DeferredClose(closer io.Closer, err error) error {
-	closeErr := closer.Close()
+	return closeError(closer.Close(), err)
+}
+
+// ContextCloser is implemented by the sessions and transactions of the Neo4j driver
+type ContextCloser interface {
+	Close(ctx context.Context) error
+}
+
+// DeferredContextClose handles errors that happen with deferred calls
+// to Close on the provided closer, as DeferredClose does.
+func DeferredContextClose(ctx context.Context, closer ContextCloser, err error) error {
+	return closeError(closer.Close(ctx), err)
+}
+
+func closeError(closeErr, err error) error {
 	if closeErr == nil {
 		return err
 	}
@@ -0,0 +1,21 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/services"
+)
+
+// NewAggregateCheckJob returns a Job checking the aggregates of a random sample of
+// movies, repairing the drifting ones, and recording the results in the metrics
+func NewAggregateCheckJob(movies services.MovieService, sample int, metrics *services.AggregateCheckMetrics) Job {
+	return func(ctx context.Context, now time.Time) error {
+		check, err := movies.CheckAggregates(ctx, sample, now)
+		if err != nil {
+			return err
+		}
+		metrics.Record(now, check)
+		return nil
+	}
+}
@@ -0,0 +1,89 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/mail"
+	"github.com/neo4j-graphacademy/neoflix/pkg/services"
+)
+
+const digestBatchSize = 100
+
+var digestTemplate = template.Must(template.New("digest").Parse(
+	`Hi {{.User.name}},
+{{if .Activity}}
+Here is what the people you follow rated today:
+{{range .Activity}}
+  * {{.user.name}} rated {{.movie.title}} {{.rating}}/5{{end}}
+{{end}}{{if .NewMovies}}
+New releases you may like:
+{{range .NewMovies}}
+  * {{.title}} ({{.genre}}){{end}}
+{{end}}
+You receive this email because you opted in the daily digest,
+you can opt out from your account settings.
+`))
+
+// DigestJob emails their daily digest to the users who opted in
+type DigestJob struct {
+	digests services.DigestService
+	sender  mail.Sender
+	from    string
+}
+
+func NewDigestJob(digests services.DigestService, sender mail.Sender, from string) *DigestJob {
+	return &DigestJob{
+		digests: digests,
+		sender:  sender,
+		from:    from,
+	}
+}
+
+// Run sends the digests covering the day preceding now, skipping empty ones.
+// Delivery failures do not prevent the other digests from being sent, and
+// are reported once all digests have been processed.
+func (dj *DigestJob) Run(ctx context.Context, now time.Time) error {
+	since := now.Add(-24 * time.Hour)
+	var failures []string
+	after := ""
+	for {
+		digests, err := dj.digests.FindAll(ctx, since, after, digestBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, digest := range digests {
+			if digest.IsEmpty() {
+				continue
+			}
+			if err := dj.send(digest); err != nil {
+				failures = append(failures, fmt.Sprintf("%v: %v", digest.User["userId"], err))
+			}
+		}
+		if len(digests) < digestBatchSize {
+			break
+		}
+		after, _ = digests[len(digests)-1].User["userId"].(string)
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("could not send %d digest(s): %s", len(failures), strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+func (dj *DigestJob) send(digest services.Digest) error {
+	var body strings.Builder
+	if err := digestTemplate.Execute(&body, digest); err != nil {
+		return err
+	}
+	email, _ := digest.User["email"].(string)
+	return dj.sender.Send(mail.Message{
+		From:    dj.from,
+		To:      email,
+		Subject: "Your Neoflix daily digest",
+		Body:    body.String(),
+	})
+}
@@ -0,0 +1,17 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/services"
+)
+
+// NewMovieStatusJob returns a Job flipping the upcoming movies to released once
+// their release date is passed
+func NewMovieStatusJob(movies services.MovieService) Job {
+	return func(ctx context.Context, now time.Time) error {
+		_, err := movies.UpdateStatuses(ctx, now)
+		return err
+	}
+}
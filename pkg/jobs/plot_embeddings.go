@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/services"
+)
+
+const plotEmbeddingBatchSize = 100
+
+// NewPlotEmbeddingJob returns a Job embedding the plots of the movies imported or edited
+// since the previous run, for the semantic search
+func NewPlotEmbeddingJob(movies services.MovieService) Job {
+	return func(ctx context.Context, now time.Time) error {
+		for {
+			updated, err := movies.UpdatePlotEmbeddings(ctx, plotEmbeddingBatchSize)
+			if err != nil {
+				return err
+			}
+			if updated < plotEmbeddingBatchSize {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/services"
+)
+
+// NewRatingAnomalyJob returns a Job flagging the movies being review bombed for moderator review
+func NewRatingAnomalyJob(flags services.RatingFlagService) Job {
+	return func(ctx context.Context, now time.Time) error {
+		_, err := flags.Detect(ctx, now)
+		return err
+	}
+}
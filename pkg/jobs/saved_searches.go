@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/services"
+)
+
+const savedSearchBatchSize = 100
+
+// NewSavedSearchJob returns a Job notifying the users of the movies released since
+// the previous run matching their saved searches
+func NewSavedSearchJob(searches services.SavedSearchService) Job {
+	return func(ctx context.Context, now time.Time) error {
+		for {
+			checked, _, err := searches.NotifyNewMatches(ctx, now, savedSearchBatchSize)
+			if err != nil {
+				return err
+			}
+			if checked < savedSearchBatchSize {
+				return nil
+			}
+		}
+	}
+}
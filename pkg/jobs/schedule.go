@@ -0,0 +1,54 @@
+package jobs
+
+import (
+	"context"
+	"time"
+)
+
+// Job is run periodically with the time it was scheduled at
+type Job func(ctx context.Context, now time.Time) error
+
+// Daily runs the job every day at the provided time of day, in UTC, until the
+// context is done. Errors are reported to onError and do not stop the schedule.
+func Daily(ctx context.Context, at time.Duration, job Job, onError func(error)) {
+	for {
+		next := nextRun(time.Now(), at)
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			if err := job(ctx, next); err != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// Every runs the job at the provided interval until the context is done.
+// Errors are reported to onError and do not stop the schedule.
+func Every(ctx context.Context, interval time.Duration, job Job, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := job(ctx, now); err != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// nextRun returns the first time strictly after now matching the time of day
+func nextRun(now time.Time, at time.Duration) time.Time {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).Add(at)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextRun(t *testing.T) {
+	at := 7 * time.Hour
+	testCases := []struct {
+		now      time.Time
+		expected time.Time
+	}{
+		{
+			now:      time.Date(2022, 3, 1, 6, 59, 0, 0, time.UTC),
+			expected: time.Date(2022, 3, 1, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			now:      time.Date(2022, 3, 1, 7, 0, 0, 0, time.UTC),
+			expected: time.Date(2022, 3, 2, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			now:      time.Date(2022, 12, 31, 23, 0, 0, 0, time.UTC),
+			expected: time.Date(2023, 1, 1, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			now:      time.Date(2022, 3, 1, 7, 30, 0, 0, time.FixedZone("CET", 3600)),
+			expected: time.Date(2022, 3, 1, 7, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, testCase := range testCases {
+		if actual := nextRun(testCase.now, at); !actual.Equal(testCase.expected) {
+			t.Errorf("expected next run after %v to be %v, got %v", testCase.now, testCase.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/services"
+)
+
+const scoreBatchSize = 1000
+
+// NewScoreJob returns a Job computing the scores of the movies imported or rated since
+// the previous run
+func NewScoreJob(movies services.MovieService) Job {
+	return func(ctx context.Context, now time.Time) error {
+		for {
+			updated, err := movies.UpdateScores(ctx, scoreBatchSize)
+			if err != nil {
+				return err
+			}
+			if updated < scoreBatchSize {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/services"
+)
+
+const sortTitleBatchSize = 1000
+
+// NewSortTitleJob returns a Job computing the collation sort keys of the titles of
+// the movies imported or renamed since the previous run
+func NewSortTitleJob(movies services.MovieService) Job {
+	return func(ctx context.Context, now time.Time) error {
+		for {
+			updated, err := movies.UpdateSortTitles(ctx, sortTitleBatchSize)
+			if err != nil {
+				return err
+			}
+			if updated < sortTitleBatchSize {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/importer"
+)
+
+// NewTMDBSyncJob returns a Job importing again the movies of the graph edited on TMDB
+// since the previous run
+func NewTMDBSyncJob(tmdb *importer.Importer) Job {
+	return func(ctx context.Context, now time.Time) error {
+		_, err := tmdb.Sync(ctx, now)
+		return err
+	}
+}
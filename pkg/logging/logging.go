@@ -0,0 +1,43 @@
+// Package logging configures the structured logger of the app, which logs the requests
+// it serves and the failures its services recover from.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Formats of the log records
+const (
+	Text = "text"
+	Json = "json"
+)
+
+// Config configures the logger, from the LOG_LEVEL and LOG_FORMAT settings
+type Config struct {
+	// Level is one of debug, info (default), warn or error
+	Level string
+	// Format is either Text (default) or Json
+	Format string
+}
+
+// New returns a logger writing to the writer as configured.
+// Unknown levels and formats are rejected.
+func New(writer io.Writer, config Config) (*slog.Logger, error) {
+	var level slog.Level
+	if config.Level != "" {
+		if err := level.UnmarshalText([]byte(config.Level)); err != nil {
+			return nil, fmt.Errorf("unsupported log level %q", config.Level)
+		}
+	}
+	options := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(config.Format) {
+	case "", Text:
+		return slog.New(slog.NewTextHandler(writer, options)), nil
+	case Json:
+		return slog.New(slog.NewJSONHandler(writer, options)), nil
+	}
+	return nil, fmt.Errorf("unsupported log format %q", config.Format)
+}
@@ -0,0 +1,29 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestNewFiltersLevelsAndFormats(t *testing.T) {
+	var output bytes.Buffer
+	logger, err := New(&output, Config{Level: "warn", Format: "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger.Info("ignored")
+	logger.Warn("kept", "status", 500)
+
+	if lines := strings.Split(strings.TrimSpace(output.String()), "\n"); len(lines) != 1 ||
+		!strings.Contains(lines[0], `"msg":"kept","status":500`) {
+		t.Errorf("expected a single JSON record, got %q", output.String())
+	}
+	if _, err := New(&output, Config{Level: "verbose"}); err == nil {
+		t.Errorf("expected unknown levels to be rejected")
+	}
+	if _, err := New(&output, Config{Format: "xml"}); err == nil {
+		t.Errorf("expected unknown formats to be rejected")
+	}
+}
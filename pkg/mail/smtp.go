@@ -0,0 +1,51 @@
+package mail
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+)
+
+// SmtpConfig configures NewSmtpSender
+type SmtpConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+}
+
+type smtpSender struct {
+	config SmtpConfig
+}
+
+// NewSmtpSender returns a Sender delivering the emails to an SMTP server.
+// PLAIN authentication is used when a username is set.
+func NewSmtpSender(config SmtpConfig) Sender {
+	return &smtpSender{config: config}
+}
+
+func (ss *smtpSender) Send(message Message) error {
+	var auth smtp.Auth
+	if ss.config.Username != "" {
+		auth = smtp.PlainAuth("", ss.config.Username, ss.config.Password, ss.config.Host)
+	}
+	address := fmt.Sprintf("%s:%d", ss.config.Host, ss.config.Port)
+	return smtp.SendMail(address, auth, message.From, []string{message.To}, format(message))
+}
+
+func format(message Message) []byte {
+	var builder strings.Builder
+	builder.WriteString("From: " + header(message.From) + "\r\n")
+	builder.WriteString("To: " + header(message.To) + "\r\n")
+	builder.WriteString("Subject: " + header(message.Subject) + "\r\n")
+	builder.WriteString("MIME-Version: 1.0\r\n")
+	builder.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	builder.WriteString("\r\n")
+	builder.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+	return []byte(builder.String())
+}
+
+// header strips line breaks, which would allow to inject extra headers
+func header(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
@@ -0,0 +1,26 @@
+package mail
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+type writerSender struct {
+	mutex  sync.Mutex
+	writer io.Writer
+}
+
+// NewWriterSender returns a Sender writing the emails to the provided writer
+// instead of delivering them, which is useful during development
+func NewWriterSender(writer io.Writer) Sender {
+	return &writerSender{writer: writer}
+}
+
+func (ws *writerSender) Send(message Message) error {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+	_, err := fmt.Fprintf(ws.writer, "From: %s\nTo: %s\nSubject: %s\n\n%s\n",
+		message.From, message.To, message.Subject, message.Body)
+	return err
+}
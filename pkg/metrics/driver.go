@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// InstrumentDriver decorates the driver so that its sessions are counted while open, and
+// the Neo4j errors their transactions fail with are counted by code.
+// Errors raised while reading the results of explicit transactions, such as the movie
+// streams, are not counted.
+func (m *Metrics) InstrumentDriver(driver neo4j.DriverWithContext) neo4j.DriverWithContext {
+	return &instrumentedDriver{DriverWithContext: driver, metrics: m}
+}
+
+type instrumentedDriver struct {
+	neo4j.DriverWithContext
+	metrics *Metrics
+}
+
+func (id *instrumentedDriver) NewSession(ctx context.Context, config neo4j.SessionConfig) neo4j.SessionWithContext {
+	id.metrics.openSessions.Add(1)
+	return &instrumentedSession{
+		SessionWithContext: id.DriverWithContext.NewSession(ctx, config),
+		metrics:            id.metrics,
+	}
+}
+
+type instrumentedSession struct {
+	neo4j.SessionWithContext
+	metrics *Metrics
+	closing sync.Once
+}
+
+func (is *instrumentedSession) ExecuteRead(ctx context.Context, work neo4j.ManagedTransactionWork, configurers ...func(*neo4j.TransactionConfig)) (interface{}, error) {
+	result, err := is.SessionWithContext.ExecuteRead(ctx, work, configurers...)
+	is.metrics.countError(err)
+	return result, err
+}
+
+func (is *instrumentedSession) ExecuteWrite(ctx context.Context, work neo4j.ManagedTransactionWork, configurers ...func(*neo4j.TransactionConfig)) (interface{}, error) {
+	result, err := is.SessionWithContext.ExecuteWrite(ctx, work, configurers...)
+	is.metrics.countError(err)
+	return result, err
+}
+
+func (is *instrumentedSession) BeginTransaction(ctx context.Context, configurers ...func(*neo4j.TransactionConfig)) (neo4j.ExplicitTransaction, error) {
+	tx, err := is.SessionWithContext.BeginTransaction(ctx, configurers...)
+	is.metrics.countError(err)
+	return tx, err
+}
+
+func (is *instrumentedSession) Run(ctx context.Context, cypher string, params map[string]interface{}, configurers ...func(*neo4j.TransactionConfig)) (neo4j.ResultWithContext, error) {
+	result, err := is.SessionWithContext.Run(ctx, cypher, params, configurers...)
+	is.metrics.countError(err)
+	return result, err
+}
+
+// Close stops counting the session as open, once, however many times it is closed
+func (is *instrumentedSession) Close(ctx context.Context) error {
+	is.closing.Do(func() {
+		is.metrics.openSessions.Add(-1)
+	})
+	return is.SessionWithContext.Close(ctx)
+}
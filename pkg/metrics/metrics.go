@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/services"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Metrics are the metrics of the app, exposed by the handler of their Registry
+type Metrics struct {
+	Registry *Registry
+
+	serviceDuration *HistogramVec
+	neo4jErrors     *CounterVec
+	openSessions    *Gauge
+}
+
+func New() *Metrics {
+	registry := NewRegistry()
+	return &Metrics{
+		Registry: registry,
+		serviceDuration: registry.NewHistogramVec("neoflix_service_duration_seconds",
+			"Duration of the calls to the service methods", DefaultBuckets, "service", "method", "outcome"),
+		neo4jErrors: registry.NewCounterVec("neoflix_neo4j_errors_total",
+			"Errors returned by Neo4j, by code, connectivity errors being counted as ConnectivityError", "code"),
+		openSessions: registry.NewGauge("neoflix_neo4j_open_sessions",
+			"Sessions opened by the services and not closed yet"),
+	}
+}
+
+// WatchCaches exposes the share of the lookups of each of the caches served from the
+// cache since startup
+func (m *Metrics) WatchCaches(caches map[string]services.CachedService) {
+	m.Registry.NewGaugeFunc("neoflix_cache_hit_ratio",
+		"Share of the lookups served from the cache since startup", "cache",
+		func() map[string]float64 {
+			ratios := make(map[string]float64, len(caches))
+			for name, cached := range caches {
+				ratios[name] = cached.CacheStats().HitRate()
+			}
+			return ratios
+		})
+}
+
+// observe records the duration of a call to the method of the service since start.
+// Its outcome is "ok", "client_error" for the DomainErrors below 500, e.g. missing
+// movies, or "error".
+func (m *Metrics) observe(service, method string, start time.Time, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+		var domainError *services.DomainError
+		if errors.As(err, &domainError) && domainError.StatusCode() < 500 {
+			outcome = "client_error"
+		}
+	}
+	m.serviceDuration.Observe(time.Since(start).Seconds(), service, method, outcome)
+}
+
+// countError counts the error if it was returned by Neo4j, or the driver failed to
+// reach it
+func (m *Metrics) countError(err error) {
+	var neo4jError *neo4j.Neo4jError
+	switch {
+	case err == nil:
+	case errors.As(err, &neo4jError):
+		m.neo4jErrors.Inc(neo4jError.Code)
+	case neo4j.IsConnectivityError(err):
+		m.neo4jErrors.Inc("ConnectivityError")
+	}
+}
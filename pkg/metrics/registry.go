@@ -0,0 +1,252 @@
+// Package metrics exposes the latency of the services, the errors and sessions of the
+// Neo4j driver and the hit ratio of the caches to Prometheus, in its text exposition
+// format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the upper bounds of the latency histograms, in seconds
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Registry holds the metrics exposed by its handler, in the order they were registered
+type Registry struct {
+	mutex      sync.Mutex
+	collectors []collector
+}
+
+type collector interface {
+	write(writer io.Writer)
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(collector collector) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.collectors = append(r.collectors, collector)
+}
+
+// Handler serves the metrics in the Prometheus text exposition format
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.Expose(writer)
+	})
+}
+
+// Expose writes the metrics in the Prometheus text exposition format
+func (r *Registry) Expose(writer io.Writer) {
+	r.mutex.Lock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mutex.Unlock()
+	for _, collector := range collectors {
+		collector.write(writer)
+	}
+}
+
+// CounterVec counts events per combination of the values of its labels
+type CounterVec struct {
+	name, help string
+	labels     []string
+
+	mutex  sync.Mutex
+	series map[string]*counterSeries
+}
+
+type counterSeries struct {
+	labelValues []string
+	value       float64
+}
+
+// NewCounterVec registers a counter of the labels
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	counter := &CounterVec{name: name, help: help, labels: labels, series: map[string]*counterSeries{}}
+	r.register(counter)
+	return counter
+}
+
+// Inc adds one to the counter of the label values, given in the order of the labels
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	key := seriesKey(labelValues)
+	series, found := c.series[key]
+	if !found {
+		series = &counterSeries{labelValues: labelValues}
+		c.series[key] = series
+	}
+	series.value++
+}
+
+func (c *CounterVec) write(writer io.Writer) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	writeHeader(writer, c.name, c.help, "counter")
+	for _, key := range sortedKeys(c.series) {
+		series := c.series[key]
+		writeSample(writer, c.name, c.labels, series.labelValues, series.value)
+	}
+}
+
+// HistogramVec counts observations, such as latencies, in buckets per combination of
+// the values of its labels
+type HistogramVec struct {
+	name, help string
+	labels     []string
+	buckets    []float64
+
+	mutex  sync.Mutex
+	series map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labelValues []string
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+// NewHistogramVec registers a histogram of the labels, with the sorted upper bounds of
+// its buckets
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	histogram := &HistogramVec{name: name, help: help, labels: labels, buckets: buckets,
+		series: map[string]*histogramSeries{}}
+	r.register(histogram)
+	return histogram
+}
+
+// Observe records the value for the label values, given in the order of the labels
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	key := seriesKey(labelValues)
+	series, found := h.series[key]
+	if !found {
+		series = &histogramSeries{labelValues: labelValues, counts: make([]uint64, len(h.buckets))}
+		h.series[key] = series
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			series.counts[i]++
+		}
+	}
+	series.count++
+	series.sum += value
+}
+
+func (h *HistogramVec) write(writer io.Writer) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	writeHeader(writer, h.name, h.help, "histogram")
+	labels := append(append([]string(nil), h.labels...), "le")
+	for _, key := range sortedKeys(h.series) {
+		series := h.series[key]
+		for i, bound := range h.buckets {
+			writeSample(writer, h.name+"_bucket", labels,
+				append(append([]string(nil), series.labelValues...), formatFloat(bound)), float64(series.counts[i]))
+		}
+		writeSample(writer, h.name+"_bucket", labels,
+			append(append([]string(nil), series.labelValues...), "+Inf"), float64(series.count))
+		writeSample(writer, h.name+"_sum", h.labels, series.labelValues, series.sum)
+		writeSample(writer, h.name+"_count", h.labels, series.labelValues, float64(series.count))
+	}
+}
+
+// Gauge is a value going up and down, such as a number of open sessions
+type Gauge struct {
+	name, help string
+
+	mutex sync.Mutex
+	value float64
+}
+
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	gauge := &Gauge{name: name, help: help}
+	r.register(gauge)
+	return gauge
+}
+
+func (g *Gauge) Add(delta float64) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.value += delta
+}
+
+func (g *Gauge) write(writer io.Writer) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	writeHeader(writer, g.name, g.help, "gauge")
+	writeSample(writer, g.name, nil, nil, g.value)
+}
+
+// GaugeFunc is a gauge whose values, per value of its label, are collected when the
+// metrics are scraped
+type GaugeFunc struct {
+	name, help string
+	label      string
+	collect    func() map[string]float64
+}
+
+func (r *Registry) NewGaugeFunc(name, help, label string, collect func() map[string]float64) *GaugeFunc {
+	gauge := &GaugeFunc{name: name, help: help, label: label, collect: collect}
+	r.register(gauge)
+	return gauge
+}
+
+func (g *GaugeFunc) write(writer io.Writer) {
+	values := g.collect()
+	writeHeader(writer, g.name, g.help, "gauge")
+	for _, key := range sortedKeys(values) {
+		writeSample(writer, g.name, []string{g.label}, []string{key}, values[key])
+	}
+}
+
+func seriesKey(labelValues []string) string {
+	return strings.Join(labelValues, "\xff")
+}
+
+func sortedKeys[V any](series map[string]V) []string {
+	keys := make([]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func writeHeader(writer io.Writer, name, help, kind string) {
+	_, _ = fmt.Fprintf(writer, "# HELP %s %s\n# TYPE %s %s\n", name, strings.ReplaceAll(help, "\n", " "), name, kind)
+}
+
+func writeSample(writer io.Writer, name string, labels, labelValues []string, value float64) {
+	var pairs []string
+	for i, label := range labels {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, label, labelValueEscaper.Replace(labelValues[i])))
+	}
+	if len(pairs) > 0 {
+		name += "{" + strings.Join(pairs, ",") + "}"
+	}
+	_, _ = fmt.Fprintf(writer, "%s %s\n", name, formatFloat(value))
+}
+
+// labelValueEscaper escapes the backslashes, double quotes and line feeds of label
+// values, the only escapes of the exposition format
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
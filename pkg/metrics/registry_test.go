@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRegistryExposesTextFormat(t *testing.T) {
+	registry := NewRegistry()
+	counter := registry.NewCounterVec("errors_total", "Errors", "code")
+	histogram := registry.NewHistogramVec("duration_seconds", "Duration", []float64{0.1, 1}, "method")
+	registry.NewGaugeFunc("hit_ratio", "Hit ratio", "cache", func() map[string]float64 {
+		return map[string]float64{"movies": 0.5}
+	})
+
+	counter.Inc(`Neo.ClientError."Quoted"`)
+	counter.Inc(`Neo.ClientError."Quoted"`)
+	histogram.Observe(0.05, "FindAll")
+	histogram.Observe(0.5, "FindAll")
+
+	var output bytes.Buffer
+	registry.Expose(&output)
+
+	expected := `# HELP errors_total Errors
+# TYPE errors_total counter
+errors_total{code="Neo.ClientError.\"Quoted\""} 2
+# HELP duration_seconds Duration
+# TYPE duration_seconds histogram
+duration_seconds_bucket{method="FindAll",le="0.1"} 1
+duration_seconds_bucket{method="FindAll",le="1"} 2
+duration_seconds_bucket{method="FindAll",le="+Inf"} 2
+duration_seconds_sum{method="FindAll"} 0.55
+duration_seconds_count{method="FindAll"} 2
+# HELP hit_ratio Hit ratio
+# TYPE hit_ratio gauge
+hit_ratio{cache="movies"} 0.5
+`
+	if output.String() != expected {
+		t.Errorf("unexpected exposition:\n%s", output.String())
+	}
+}
@@ -0,0 +1,290 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/routes/paging"
+	"github.com/neo4j-graphacademy/neoflix/pkg/services"
+)
+
+type instrumentedMovieService struct {
+	services.MovieService
+	metrics *Metrics
+}
+
+// InstrumentMovieService decorates the provided MovieService to record the duration of
+// the calls to each of its methods
+func (m *Metrics) InstrumentMovieService(inner services.MovieService) services.MovieService {
+	return &instrumentedMovieService{MovieService: inner, metrics: m}
+}
+
+func (is *instrumentedMovieService) FindAll(ctx context.Context, userId string, page *paging.Paging) (services.PagedResult, error) {
+	start := time.Now()
+	result, err := is.MovieService.FindAll(ctx, userId, page)
+	is.metrics.observe("movies", "FindAll", start, err)
+	return result, err
+}
+
+func (is *instrumentedMovieService) FindAllAfter(ctx context.Context, cursor paging.Cursor, userId string, page *paging.Paging) (services.CursorPagedResult, error) {
+	start := time.Now()
+	result, err := is.MovieService.FindAllAfter(ctx, cursor, userId, page)
+	is.metrics.observe("movies", "FindAllAfter", start, err)
+	return result, err
+}
+
+func (is *instrumentedMovieService) StreamAll(ctx context.Context, userId string, opts services.MovieStreamOptions) (services.MovieIterator, error) {
+	start := time.Now()
+	result, err := is.MovieService.StreamAll(ctx, userId, opts)
+	is.metrics.observe("movies", "StreamAll", start, err)
+	return result, err
+}
+
+func (is *instrumentedMovieService) Search(ctx context.Context, query, userId string, page *paging.Paging) (services.PagedResult, error) {
+	start := time.Now()
+	result, err := is.MovieService.Search(ctx, query, userId, page)
+	is.metrics.observe("movies", "Search", start, err)
+	return result, err
+}
+
+func (is *instrumentedMovieService) SearchSemantic(ctx context.Context, query, userId string, page *paging.Paging) (services.PagedResult, error) {
+	start := time.Now()
+	result, err := is.MovieService.SearchSemantic(ctx, query, userId, page)
+	is.metrics.observe("movies", "SearchSemantic", start, err)
+	return result, err
+}
+
+func (is *instrumentedMovieService) FindAllByGenre(ctx context.Context, genre, userId string, page *paging.Paging) (services.PagedResult, error) {
+	start := time.Now()
+	result, err := is.MovieService.FindAllByGenre(ctx, genre, userId, page)
+	is.metrics.observe("movies", "FindAllByGenre", start, err)
+	return result, err
+}
+
+func (is *instrumentedMovieService) FindAllByActorId(ctx context.Context, actorId string, userId string, page *paging.Paging) (services.PagedResult, error) {
+	start := time.Now()
+	result, err := is.MovieService.FindAllByActorId(ctx, actorId, userId, page)
+	is.metrics.observe("movies", "FindAllByActorId", start, err)
+	return result, err
+}
+
+func (is *instrumentedMovieService) FindAllByDirectorId(ctx context.Context, actorId string, userId string, page *paging.Paging) (services.PagedResult, error) {
+	start := time.Now()
+	result, err := is.MovieService.FindAllByDirectorId(ctx, actorId, userId, page)
+	is.metrics.observe("movies", "FindAllByDirectorId", start, err)
+	return result, err
+}
+
+func (is *instrumentedMovieService) FindAllByGenreAndPersonId(ctx context.Context, genre, personId, userId string, page *paging.Paging) (services.PagedResult, error) {
+	start := time.Now()
+	result, err := is.MovieService.FindAllByGenreAndPersonId(ctx, genre, personId, userId, page)
+	is.metrics.observe("movies", "FindAllByGenreAndPersonId", start, err)
+	return result, err
+}
+
+func (is *instrumentedMovieService) FindOneById(ctx context.Context, id string, userId string) (services.Movie, error) {
+	start := time.Now()
+	result, err := is.MovieService.FindOneById(ctx, id, userId)
+	is.metrics.observe("movies", "FindOneById", start, err)
+	return result, err
+}
+
+func (is *instrumentedMovieService) FindManyByIds(ctx context.Context, ids []string, userId string) ([]services.Movie, error) {
+	start := time.Now()
+	result, err := is.MovieService.FindManyByIds(ctx, ids, userId)
+	is.metrics.observe("movies", "FindManyByIds", start, err)
+	return result, err
+}
+
+func (is *instrumentedMovieService) FindSummaryById(ctx context.Context, id string) (services.MovieSummary, error) {
+	start := time.Now()
+	result, err := is.MovieService.FindSummaryById(ctx, id)
+	is.metrics.observe("movies", "FindSummaryById", start, err)
+	return result, err
+}
+
+func (is *instrumentedMovieService) FindPrefetchHintsById(ctx context.Context, id string) (services.PrefetchHints, error) {
+	start := time.Now()
+	result, err := is.MovieService.FindPrefetchHintsById(ctx, id)
+	is.metrics.observe("movies", "FindPrefetchHintsById", start, err)
+	return result, err
+}
+
+func (is *instrumentedMovieService) FindAllBySimilarity(ctx context.Context, id string, userId string, page *paging.Paging, opts services.MovieSimilarityOptions) ([]services.Movie, error) {
+	start := time.Now()
+	result, err := is.MovieService.FindAllBySimilarity(ctx, id, userId, page, opts)
+	is.metrics.observe("movies", "FindAllBySimilarity", start, err)
+	return result, err
+}
+
+func (is *instrumentedMovieService) FindAllBySimilarityGDS(ctx context.Context, id string, userId string, page *paging.Paging, opts services.MovieSimilarityOptions) ([]services.Movie, error) {
+	start := time.Now()
+	result, err := is.MovieService.FindAllBySimilarityGDS(ctx, id, userId, page, opts)
+	is.metrics.observe("movies", "FindAllBySimilarityGDS", start, err)
+	return result, err
+}
+
+func (is *instrumentedMovieService) FindAllHiddenGems(ctx context.Context, userId string, page *paging.Paging) (services.PagedResult, error) {
+	start := time.Now()
+	result, err := is.MovieService.FindAllHiddenGems(ctx, userId, page)
+	is.metrics.observe("movies", "FindAllHiddenGems", start, err)
+	return result, err
+}
+
+func (is *instrumentedMovieService) FindAllUpcoming(ctx context.Context, userId string, page *paging.Paging) (services.PagedResult, error) {
+	start := time.Now()
+	result, err := is.MovieService.FindAllUpcoming(ctx, userId, page)
+	is.metrics.observe("movies", "FindAllUpcoming", start, err)
+	return result, err
+}
+
+func (is *instrumentedMovieService) FindAllBoxOffice(ctx context.Context, userId string, page *paging.Paging) (services.PagedResult, error) {
+	start := time.Now()
+	result, err := is.MovieService.FindAllBoxOffice(ctx, userId, page)
+	is.metrics.observe("movies", "FindAllBoxOffice", start, err)
+	return result, err
+}
+
+func (is *instrumentedMovieService) SaveRelease(ctx context.Context, id string, released time.Time) (services.Movie, error) {
+	start := time.Now()
+	result, err := is.MovieService.SaveRelease(ctx, id, released)
+	is.metrics.observe("movies", "SaveRelease", start, err)
+	return result, err
+}
+
+func (is *instrumentedMovieService) UpdateStatuses(ctx context.Context, today time.Time) (int64, error) {
+	start := time.Now()
+	result, err := is.MovieService.UpdateStatuses(ctx, today)
+	is.metrics.observe("movies", "UpdateStatuses", start, err)
+	return result, err
+}
+
+func (is *instrumentedMovieService) UpdateSortTitles(ctx context.Context, limit int) (int, error) {
+	start := time.Now()
+	result, err := is.MovieService.UpdateSortTitles(ctx, limit)
+	is.metrics.observe("movies", "UpdateSortTitles", start, err)
+	return result, err
+}
+
+func (is *instrumentedMovieService) UpdateScores(ctx context.Context, limit int) (int, error) {
+	start := time.Now()
+	result, err := is.MovieService.UpdateScores(ctx, limit)
+	is.metrics.observe("movies", "UpdateScores", start, err)
+	return result, err
+}
+
+func (is *instrumentedMovieService) UpdatePlotEmbeddings(ctx context.Context, limit int) (int, error) {
+	start := time.Now()
+	result, err := is.MovieService.UpdatePlotEmbeddings(ctx, limit)
+	is.metrics.observe("movies", "UpdatePlotEmbeddings", start, err)
+	return result, err
+}
+
+func (is *instrumentedMovieService) RecomputeAggregates(ctx context.Context, ids []string, now time.Time) ([]services.Movie, error) {
+	start := time.Now()
+	result, err := is.MovieService.RecomputeAggregates(ctx, ids, now)
+	is.metrics.observe("movies", "RecomputeAggregates", start, err)
+	return result, err
+}
+
+func (is *instrumentedMovieService) CheckAggregates(ctx context.Context, sample int, now time.Time) (services.AggregateCheck, error) {
+	start := time.Now()
+	result, err := is.MovieService.CheckAggregates(ctx, sample, now)
+	is.metrics.observe("movies", "CheckAggregates", start, err)
+	return result, err
+}
+
+func (is *instrumentedMovieService) SaveBoxOffice(ctx context.Context, id string, budget, revenue *int64) (services.Movie, error) {
+	start := time.Now()
+	result, err := is.MovieService.SaveBoxOffice(ctx, id, budget, revenue)
+	is.metrics.observe("movies", "SaveBoxOffice", start, err)
+	return result, err
+}
+
+func (is *instrumentedMovieService) SaveAliases(ctx context.Context, id string, aliases []string) (services.Movie, error) {
+	start := time.Now()
+	result, err := is.MovieService.SaveAliases(ctx, id, aliases)
+	is.metrics.observe("movies", "SaveAliases", start, err)
+	return result, err
+}
+
+type instrumentedPeopleService struct {
+	services.PeopleService
+	metrics *Metrics
+}
+
+// InstrumentPeopleService decorates the provided PeopleService to record the duration
+// of the calls to each of its methods
+func (m *Metrics) InstrumentPeopleService(inner services.PeopleService) services.PeopleService {
+	return &instrumentedPeopleService{PeopleService: inner, metrics: m}
+}
+
+func (is *instrumentedPeopleService) FindAll(ctx context.Context, page *paging.Paging) (services.PagedResult, error) {
+	start := time.Now()
+	result, err := is.PeopleService.FindAll(ctx, page)
+	is.metrics.observe("people", "FindAll", start, err)
+	return result, err
+}
+
+func (is *instrumentedPeopleService) FindAllFiltered(ctx context.Context, filter services.PersonFilter, page *paging.Paging) (services.PagedResult, error) {
+	start := time.Now()
+	result, err := is.PeopleService.FindAllFiltered(ctx, filter, page)
+	is.metrics.observe("people", "FindAllFiltered", start, err)
+	return result, err
+}
+
+func (is *instrumentedPeopleService) FindAllAfter(ctx context.Context, cursor paging.Cursor, filter services.PersonFilter, page *paging.Paging) (services.CursorPagedResult, error) {
+	start := time.Now()
+	result, err := is.PeopleService.FindAllAfter(ctx, cursor, filter, page)
+	is.metrics.observe("people", "FindAllAfter", start, err)
+	return result, err
+}
+
+func (is *instrumentedPeopleService) CountByInitial(ctx context.Context) ([]services.InitialCount, error) {
+	start := time.Now()
+	result, err := is.PeopleService.CountByInitial(ctx)
+	is.metrics.observe("people", "CountByInitial", start, err)
+	return result, err
+}
+
+func (is *instrumentedPeopleService) FindFilmography(ctx context.Context, id string, page *paging.Paging) (services.PagedResult, error) {
+	start := time.Now()
+	result, err := is.PeopleService.FindFilmography(ctx, id, page)
+	is.metrics.observe("people", "FindFilmography", start, err)
+	return result, err
+}
+
+func (is *instrumentedPeopleService) FindCoActors(ctx context.Context, id string, page *paging.Paging) (services.PagedResult, error) {
+	start := time.Now()
+	result, err := is.PeopleService.FindCoActors(ctx, id, page)
+	is.metrics.observe("people", "FindCoActors", start, err)
+	return result, err
+}
+
+func (is *instrumentedPeopleService) FindOneById(ctx context.Context, id string) (services.Person, error) {
+	start := time.Now()
+	result, err := is.PeopleService.FindOneById(ctx, id)
+	is.metrics.observe("people", "FindOneById", start, err)
+	return result, err
+}
+
+func (is *instrumentedPeopleService) FindAllBySimilarity(ctx context.Context, id string, page *paging.Paging, opts services.PersonSimilarityOptions) ([]services.Person, error) {
+	start := time.Now()
+	result, err := is.PeopleService.FindAllBySimilarity(ctx, id, page, opts)
+	is.metrics.observe("people", "FindAllBySimilarity", start, err)
+	return result, err
+}
+
+func (is *instrumentedPeopleService) FindAllByGenre(ctx context.Context, genre string, page *paging.Paging) (services.PagedResult, error) {
+	start := time.Now()
+	result, err := is.PeopleService.FindAllByGenre(ctx, genre, page)
+	is.metrics.observe("people", "FindAllByGenre", start, err)
+	return result, err
+}
+
+func (is *instrumentedPeopleService) SaveAliases(ctx context.Context, id string, aliases []string) (services.Person, error) {
+	start := time.Now()
+	result, err := is.PeopleService.SaveAliases(ctx, id, aliases)
+	is.metrics.observe("people", "SaveAliases", start, err)
+	return result, err
+}
@@ -0,0 +1,152 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Migration is a schema change the statements of the catalog rely on, and which the
+// app applies by itself on startup.
+// Their statements are idempotent, so that a migration interrupted before the schema
+// version was updated can run again.
+type Migration struct {
+	Version   int
+	Name      string
+	Statement string
+}
+
+// All lists the migrations in the order they are applied, by increasing version.
+// Applied migrations must never change: add new ones instead.
+var All = []Migration{
+	{
+		// searched by the `q` parameter of the movie and people lists
+		Version: 1,
+		Name:    "names",
+		Statement: "CREATE FULLTEXT INDEX names IF NOT EXISTS " +
+			"FOR (n:Movie|Person|Alias) ON EACH [n.title, n.name]",
+	},
+	{
+		// searched by the movie search, ranked by relevance
+		Version: 2,
+		Name:    "movieText",
+		Statement: "CREATE FULLTEXT INDEX movieText IF NOT EXISTS " +
+			"FOR (m:Movie) ON EACH [m.title, m.plot, m.tagline]",
+	},
+	uniqueConstraint(3, "User", "userId"),
+	uniqueConstraint(4, "User", "email"),
+	uniqueConstraint(5, "Movie", "tmdbId"),
+	uniqueConstraint(6, "Person", "tmdbId"),
+	uniqueConstraint(7, "Genre", "name"),
+	// movie lists are sorted by these properties, see paging.MovieSortableAttributes
+	rangeIndex(8, "Movie", "released"),
+	rangeIndex(9, "Movie", "imdbRating"),
+	rangeIndex(10, "Movie", "score"),
+	rangeIndex(11, "Person", "name"),
+}
+
+func uniqueConstraint(version int, label, property string) Migration {
+	name := fmt.Sprintf("%s_%s_unique", label, property)
+	return Migration{
+		Version: version,
+		Name:    name,
+		Statement: fmt.Sprintf("CREATE CONSTRAINT %s IF NOT EXISTS FOR (n:%s) REQUIRE n.%s IS UNIQUE",
+			name, label, property),
+	}
+}
+
+func rangeIndex(version int, label, property string) Migration {
+	name := fmt.Sprintf("%s_%s", label, property)
+	return Migration{
+		Version:   version,
+		Name:      name,
+		Statement: fmt.Sprintf("CREATE INDEX %s IF NOT EXISTS FOR (n:%s) ON (n.%s)", name, label, property),
+	}
+}
+
+// MigrationError reports a migration the target database failed to apply, e.g. a
+// uniqueness constraint on a property with duplicated values
+type MigrationError struct {
+	Migration Migration
+	Err       error
+}
+
+func (me MigrationError) Error() string {
+	return fmt.Sprintf("migration %d (%s) failed: %v", me.Migration.Version, me.Migration.Name, me.Err)
+}
+
+func (me MigrationError) Unwrap() error {
+	return me.Err
+}
+
+// Run applies the migrations more recent than the version of the schema, as recorded by
+// the SchemaVersion node of the graph, in order, and records the version of each of
+// them once applied.
+// It returns the names of the applied migrations, along with a MigrationError for the
+// first one which failed, if any: the following ones are then left for the next run.
+// Indexes are populated in the background once created, so they may not return
+// all their results right away.
+func Run(ctx context.Context, driver neo4j.DriverWithContext) (_ []string, err error) {
+	session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	current, err := schemaVersion(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+	var applied []string
+	for _, migration := range All {
+		if migration.Version <= current {
+			continue
+		}
+		// schema changes cannot run in a transaction which also reads or writes data,
+		// hence the auto-commit transactions
+		result, err := session.Run(ctx, migration.Statement, nil)
+		if err == nil {
+			_, err = result.Consume(ctx)
+		}
+		if err != nil {
+			if _, ok := err.(*neo4j.Neo4jError); !ok {
+				return applied, err
+			}
+			return applied, MigrationError{Migration: migration, Err: err}
+		}
+		if err := saveSchemaVersion(ctx, session, migration.Version); err != nil {
+			return applied, err
+		}
+		applied = append(applied, migration.Name)
+	}
+	return applied, nil
+}
+
+// schemaVersion returns the version of the last applied migration, 0 if none
+func schemaVersion(ctx context.Context, session neo4j.SessionWithContext) (int, error) {
+	result, err := session.Run(ctx, "MATCH (v:SchemaVersion {app: 'neoflix'}) RETURN v.version AS version", nil)
+	if err != nil {
+		return 0, err
+	}
+	records, err := result.Collect(ctx)
+	if err != nil || len(records) == 0 {
+		return 0, err
+	}
+	version, _ := records[0].Get("version")
+	return int(version.(int64)), nil
+}
+
+func saveSchemaVersion(ctx context.Context, session neo4j.SessionWithContext, version int) error {
+	result, err := session.Run(ctx, `
+		MERGE (v:SchemaVersion {app: 'neoflix'})
+		SET v.version = $version, v.migratedAt = datetime()`, map[string]interface{}{
+		"version": version,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = result.Consume(ctx)
+	return err
+}
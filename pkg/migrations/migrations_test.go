@@ -0,0 +1,22 @@
+package migrations
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMigrationsAreOrderedAndIdempotent(t *testing.T) {
+	names := map[string]bool{}
+	for i, migration := range All {
+		if migration.Version != i+1 {
+			t.Errorf("expected version %d for %s, got %d", i+1, migration.Name, migration.Version)
+		}
+		if names[migration.Name] {
+			t.Errorf("duplicated migration name %s", migration.Name)
+		}
+		names[migration.Name] = true
+		if !strings.Contains(migration.Statement, " IF NOT EXISTS ") {
+			t.Errorf("expected %s to be idempotent: %s", migration.Name, migration.Statement)
+		}
+	}
+}
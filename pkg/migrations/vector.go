@@ -0,0 +1,61 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// PlotIndex is the vector index of the embeddings of the plots of the movies, stored
+// as their `plotEmbedding`
+const PlotIndex = "moviePlots"
+
+// EnsurePlotIndex creates the vector index of the plot embeddings, of the dimensions of
+// the embedder, unless it exists.
+// Unlike the migrations of All, it depends on the configured embedder: an existing index
+// of other dimensions, e.g. built for another model, is reported as a MigrationError, and
+// must be dropped for the index to be rebuilt.
+func EnsurePlotIndex(ctx context.Context, driver neo4j.DriverWithContext, dimensions int) (err error) {
+	session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	migration := Migration{
+		Name: PlotIndex,
+		Statement: fmt.Sprintf("CREATE VECTOR INDEX %s IF NOT EXISTS "+
+			"FOR (m:Movie) ON (m.plotEmbedding) "+
+			"OPTIONS {indexConfig: {`vector.dimensions`: %d, `vector.similarity_function`: 'cosine'}}",
+			PlotIndex, dimensions),
+	}
+	result, err := session.Run(ctx, migration.Statement, nil)
+	if err == nil {
+		_, err = result.Consume(ctx)
+	}
+	if err == nil {
+		result, err = session.Run(ctx, `
+			SHOW INDEXES YIELD name, options
+			WHERE name = $name
+			RETURN options.indexConfig['vector.dimensions'] AS dimensions`, map[string]interface{}{
+			"name": PlotIndex,
+		})
+	}
+	var record *neo4j.Record
+	if err == nil {
+		record, err = result.Single(ctx)
+	}
+	if err != nil {
+		if _, ok := err.(*neo4j.Neo4jError); !ok {
+			return err
+		}
+		return MigrationError{Migration: migration, Err: err}
+	}
+	if existing, _ := record.Get("dimensions"); existing != int64(dimensions) {
+		return MigrationError{Migration: migration,
+			Err: fmt.Errorf("the index has %v dimensions, the embedder %d", existing, dimensions)}
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+// Package normalize cleans the records of the movie catalog before they are written,
+// according to declarative rules: trimming whitespace, unifying date formats, mapping
+// genre aliases to the genres of the dataset and coercing numeric types.
+package normalize
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Actions a Rule applies to the values of its property
+const (
+	// Trim removes the leading and trailing whitespace and collapses the inner one
+	Trim = "trim"
+	// Date rewrites the dates parsed with one of the Formats of the rule as DateLayout
+	Date = "date"
+	// Alias replaces the values found in the Aliases of the rule, case-insensitively,
+	// by their canonical value
+	Alias = "alias"
+	// Integer coerces numbers and numeric strings to integers
+	Integer = "integer"
+	// Float coerces numbers and numeric strings to floats
+	Float = "float"
+)
+
+// DateLayout is the layout of the dates of the catalog, e.g. the `released` dates of
+// the movies
+const DateLayout = "2006-01-02"
+
+// Rule normalizes the Property of the records of the Label.
+// Rules apply to each element of list values, e.g. the genres of a movie.
+type Rule struct {
+	Label    string            `json:"label"`
+	Property string            `json:"property"`
+	Action   string            `json:"action"`
+	Formats  []string          `json:"formats,omitempty"`
+	Aliases  map[string]string `json:"aliases,omitempty"`
+}
+
+// Rules are applied in order, so that e.g. values are trimmed before being aliased
+type Rules []Rule
+
+// DefaultRules trims the names, titles and languages, unifies the release dates, maps
+// the TMDB and common genre names to the ones of the dataset, and coerces the ratings,
+// years and box office figures
+func DefaultRules() Rules {
+	return Rules{
+		{Label: "Movie", Property: "title", Action: Trim},
+		{Label: "Movie", Property: "tagline", Action: Trim},
+		{Label: "Movie", Property: "plot", Action: Trim},
+		{Label: "Movie", Property: "languages", Action: Trim},
+		{Label: "Movie", Property: "countries", Action: Trim},
+		{Label: "Movie", Property: "released", Action: Date,
+			Formats: []string{DateLayout, "2006/01/02", "2006.01.02", "January 2, 2006", "2 January 2006", time.RFC3339}},
+		{Label: "Movie", Property: "genres", Action: Trim},
+		{Label: "Movie", Property: "genres", Action: Alias, Aliases: map[string]string{
+			"science fiction": "Sci-Fi",
+			"scifi":           "Sci-Fi",
+			"sci fi":          "Sci-Fi",
+			"family":          "Children",
+			"kids":            "Children",
+			"film noir":       "Film-Noir",
+			"noir":            "Film-Noir",
+			"music":           "Musical",
+			"animated":        "Animation",
+		}},
+		{Label: "Movie", Property: "imdbRating", Action: Float},
+		{Label: "Movie", Property: "year", Action: Integer},
+		{Label: "Movie", Property: "runtime", Action: Integer},
+		{Label: "Movie", Property: "budget", Action: Integer},
+		{Label: "Movie", Property: "revenue", Action: Integer},
+		{Label: "Person", Property: "name", Action: Trim},
+		{Label: "Person", Property: "bornIn", Action: Trim},
+		{Label: "Genre", Property: "name", Action: Trim},
+	}
+}
+
+// ParseRules reads rules declared as a JSON array, e.g.
+// `[{"label": "Movie", "property": "genres", "action": "alias", "aliases": {"family": "Children"}}]`.
+//
+// Rules without label or property, with an unknown action, or dates without formats
+// are rejected.
+func ParseRules(data []byte) (Rules, error) {
+	var rules Rules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+	for i, rule := range rules {
+		if rule.Label == "" || rule.Property == "" {
+			return nil, fmt.Errorf("rule %d: label and property are required", i)
+		}
+		switch rule.Action {
+		case Trim, Alias, Integer, Float:
+		case Date:
+			if len(rule.Formats) == 0 {
+				return nil, fmt.Errorf("rule %d: date rules require formats", i)
+			}
+		default:
+			return nil, fmt.Errorf("rule %d: unknown action %q", i, rule.Action)
+		}
+	}
+	return rules, nil
+}
+
+// Report counts, per property, e.g. `Movie.released`, the values a run normalized,
+// and the ones it could not normalize and left as is, such as unparseable dates
+type Report struct {
+	Normalized map[string]int `json:"normalized"`
+	Invalid    map[string]int `json:"invalid"`
+}
+
+// Normalizer applies rules to the records of a run, and reports the values it changed.
+// It is safe for concurrent use.
+type Normalizer struct {
+	rules map[string]Rules
+
+	mutex  sync.Mutex
+	report Report
+}
+
+func New(rules Rules) *Normalizer {
+	byLabel := map[string]Rules{}
+	for _, rule := range rules {
+		if rule.Action == Alias {
+			rule.Aliases = foldKeys(rule.Aliases)
+		}
+		byLabel[rule.Label] = append(byLabel[rule.Label], rule)
+	}
+	return &Normalizer{
+		rules:  byLabel,
+		report: Report{Normalized: map[string]int{}, Invalid: map[string]int{}},
+	}
+}
+
+// Apply normalizes the properties of the record of the label in place, before it is
+// written, and returns it
+func (n *Normalizer) Apply(label string, record map[string]interface{}) map[string]interface{} {
+	for _, rule := range n.rules[label] {
+		value, found := record[rule.Property]
+		if !found || value == nil {
+			continue
+		}
+		key := label + "." + rule.Property
+		if values, ok := value.([]interface{}); ok {
+			normalized := make([]interface{}, 0, len(values))
+			for _, element := range values {
+				normalized = append(normalized, n.normalize(key, rule, element))
+			}
+			if rule.Action == Alias {
+				normalized = distinct(normalized)
+			}
+			record[rule.Property] = normalized
+			continue
+		}
+		record[rule.Property] = n.normalize(key, rule, value)
+	}
+	return record
+}
+
+// Report returns the values normalized since the normalizer was created
+func (n *Normalizer) Report() Report {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	report := Report{Normalized: map[string]int{}, Invalid: map[string]int{}}
+	for key, count := range n.report.Normalized {
+		report.Normalized[key] = count
+	}
+	for key, count := range n.report.Invalid {
+		report.Invalid[key] = count
+	}
+	return report
+}
+
+func (n *Normalizer) normalize(key string, rule Rule, value interface{}) interface{} {
+	normalized, err := apply(rule, value)
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	switch {
+	case err != nil:
+		n.report.Invalid[key]++
+		return value
+	case normalized != nil:
+		n.report.Normalized[key]++
+		return normalized
+	}
+	return value
+}
+
+// apply returns the value normalized by the rule, nil when it is normalized already,
+// and an error when it cannot be normalized
+func apply(rule Rule, value interface{}) (interface{}, error) {
+	switch rule.Action {
+	case Trim:
+		if text, ok := value.(string); ok {
+			if trimmed := strings.Join(strings.Fields(text), " "); trimmed != text {
+				return trimmed, nil
+			}
+		}
+	case Alias:
+		if text, ok := value.(string); ok {
+			if canonical, found := rule.Aliases[strings.ToLower(text)]; found && canonical != text {
+				return canonical, nil
+			}
+		}
+	case Date:
+		text, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%v is not a date", value)
+		}
+		for _, format := range rule.Formats {
+			if date, err := time.Parse(format, strings.TrimSpace(text)); err == nil {
+				if formatted := date.Format(DateLayout); formatted != text {
+					return formatted, nil
+				}
+				return nil, nil
+			}
+		}
+		return nil, fmt.Errorf("%q matches none of the date formats", text)
+	case Integer:
+		switch number := value.(type) {
+		case float64:
+			if number != math.Trunc(number) {
+				return nil, fmt.Errorf("%v is not an integer", number)
+			}
+			return int64(number), nil
+		case int:
+			return int64(number), nil
+		case string:
+			return strconv.ParseInt(strings.ReplaceAll(strings.TrimSpace(number), ",", ""), 10, 64)
+		}
+	case Float:
+		switch number := value.(type) {
+		case int64:
+			return float64(number), nil
+		case int:
+			return float64(number), nil
+		case string:
+			return strconv.ParseFloat(strings.TrimSpace(number), 64)
+		}
+	}
+	return nil, nil
+}
+
+func foldKeys(aliases map[string]string) map[string]string {
+	folded := make(map[string]string, len(aliases))
+	for alias, canonical := range aliases {
+		folded[strings.ToLower(alias)] = canonical
+	}
+	return folded
+}
+
+// distinct drops the repeated strings of the list, such as two genres aliased to the
+// same genre
+func distinct(values []interface{}) []interface{} {
+	seen := map[string]bool{}
+	unique := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		if text, ok := value.(string); ok {
+			if seen[text] {
+				continue
+			}
+			seen[text] = true
+		}
+		unique = append(unique, value)
+	}
+	return unique
+}
@@ -0,0 +1,81 @@
+package normalize
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyDefaultRules(t *testing.T) {
+	normalizer := New(DefaultRules())
+
+	movie := normalizer.Apply("Movie", map[string]interface{}{
+		"title":      "  The   Matrix ",
+		"released":   "March 31, 1999",
+		"genres":     []interface{}{" Science Fiction", "Action", "sci fi"},
+		"imdbRating": "8.7",
+		"year":       float64(1999),
+		"budget":     "63,000,000",
+	})
+
+	expected := map[string]interface{}{
+		"title":      "The Matrix",
+		"released":   "1999-03-31",
+		"genres":     []interface{}{"Sci-Fi", "Action"},
+		"imdbRating": 8.7,
+		"year":       int64(1999),
+		"budget":     int64(63000000),
+	}
+	if !reflect.DeepEqual(movie, expected) {
+		t.Errorf("expected %v, got %v", expected, movie)
+	}
+	report := normalizer.Report()
+	if report.Normalized["Movie.genres"] != 3 || report.Normalized["Movie.title"] != 1 {
+		t.Errorf("unexpected report %v", report.Normalized)
+	}
+}
+
+func TestApplyLeavesInvalidValues(t *testing.T) {
+	normalizer := New(DefaultRules())
+
+	movie := normalizer.Apply("Movie", map[string]interface{}{
+		"released": "sometime in 1999",
+		"runtime":  "two hours",
+		"year":     float64(1999.5),
+		"title":    "Unchanged",
+	})
+
+	if movie["released"] != "sometime in 1999" || movie["runtime"] != "two hours" || movie["year"] != 1999.5 {
+		t.Errorf("expected invalid values to be left as is, got %v", movie)
+	}
+	report := normalizer.Report()
+	for _, key := range []string{"Movie.released", "Movie.runtime", "Movie.year"} {
+		if report.Invalid[key] != 1 {
+			t.Errorf("expected an invalid %s, got %v", key, report.Invalid)
+		}
+	}
+	if len(report.Normalized) != 0 {
+		t.Errorf("expected no normalized values, got %v", report.Normalized)
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules([]byte(`[{"label": "Movie", "property": "genres", "action": "alias", "aliases": {"Family": "Children"}}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	movie := New(rules).Apply("Movie", map[string]interface{}{"genres": []interface{}{"family"}})
+	if !reflect.DeepEqual(movie["genres"], []interface{}{"Children"}) {
+		t.Errorf("expected the alias to be applied, got %v", movie["genres"])
+	}
+
+	for _, invalid := range []string{
+		`[{"property": "title", "action": "trim"}]`,
+		`[{"label": "Movie", "property": "title", "action": "shout"}]`,
+		`[{"label": "Movie", "property": "released", "action": "date"}]`,
+		`{}`,
+	} {
+		if _, err := ParseRules([]byte(invalid)); err == nil {
+			t.Errorf("expected %s to be rejected", invalid)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package queries
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//go:embed cypher
+var embedded embed.FS
+
+// Statement is a named and versioned Cypher statement of the catalog.
+//
+// Statements may contain `{{fragment}}` placeholders for the parts of the query
+// which cannot be expressed as parameters, such as the sort property or direction.
+// A default value for each fragment is declared in the statement header, e.g.
+//
+//	// version: 2
+//	// default sort: title
+//
+// Statements which only run on some Neo4j versions can be overridden for a given dialect
+// by a sibling file suffixed with the dialect, e.g. `movies/find_one_by_id.v5.cypher`.
+//
+// A read statement being rewritten can be shadowed by a sibling file suffixed with `.shadow`,
+// e.g. `movies/find_all.shadow.cypher`, which is rendered with the same fragments and run
+// with the same parameters in shadow-read mode to verify the rewrite returns the same results.
+//
+// Statements calling the procedures or functions of a plugin declare it in their header,
+// e.g. `// requires: gds`, and are only run when the plugin is installed.
+type Statement struct {
+	Name     string
+	Version  int
+	Dialect  Dialect
+	Text     string
+	Defaults map[string]string
+	Requires string
+}
+
+// Render returns the statement text with its placeholders replaced by the provided fragments,
+// falling back to the declared defaults
+func (s Statement) Render(fragments map[string]string) string {
+	text := s.Text
+	for name, value := range fragments {
+		text = strings.ReplaceAll(text, "{{"+name+"}}", value)
+	}
+	for name, value := range s.Defaults {
+		text = strings.ReplaceAll(text, "{{"+name+"}}", value)
+	}
+	return text
+}
+
+// Catalog holds all the Cypher statements run by the application
+type Catalog struct {
+	statements map[string]Statement
+	variants   map[Dialect]map[string]Statement
+	shadows    map[string]Statement
+}
+
+const shadowSuffix = ".shadow"
+
+var (
+	embeddedCatalog *Catalog
+	embeddedErr     error
+	embeddedOnce    sync.Once
+)
+
+// Embedded returns the catalog of the statements embedded in the binary
+func Embedded() (*Catalog, error) {
+	embeddedOnce.Do(func() {
+		embeddedCatalog, embeddedErr = Load(embedded, "cypher")
+	})
+	return embeddedCatalog, embeddedErr
+}
+
+// MustEmbedded is like Embedded but panics if the embedded catalog is invalid
+func MustEmbedded() *Catalog {
+	catalog, err := Embedded()
+	if err != nil {
+		panic(err)
+	}
+	return catalog
+}
+
+// Load reads all the `.cypher` files under root.
+// Statements are named after their path relative to root, without extension
+// e.g. `movies/find_all`.
+func Load(fsys fs.FS, root string) (*Catalog, error) {
+	catalog := &Catalog{
+		statements: map[string]Statement{},
+		variants:   map[Dialect]map[string]Statement{},
+		shadows:    map[string]Statement{},
+	}
+	err := fs.WalkDir(fsys, root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !strings.HasSuffix(path, ".cypher") {
+			return err
+		}
+		content, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(path, root+"/"), ".cypher")
+		dialect := Dialect("")
+		for _, candidate := range dialects {
+			if strings.HasSuffix(name, "."+string(candidate)) {
+				dialect = candidate
+				name = strings.TrimSuffix(name, "."+string(candidate))
+			}
+		}
+		statement, err := parseStatement(name, string(content))
+		if err != nil {
+			return err
+		}
+		if dialect == "" && strings.HasSuffix(name, shadowSuffix) {
+			catalog.shadows[strings.TrimSuffix(name, shadowSuffix)] = statement
+			return nil
+		}
+		if dialect == "" {
+			catalog.statements[name] = statement
+			return nil
+		}
+		statement.Dialect = dialect
+		if catalog.variants[dialect] == nil {
+			catalog.variants[dialect] = map[string]Statement{}
+		}
+		catalog.variants[dialect][name] = statement
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for dialect, variants := range catalog.variants {
+		for name := range variants {
+			if _, found := catalog.statements[name]; !found {
+				return nil, fmt.Errorf("%s variant of Cypher statement %q has no default statement", dialect, name)
+			}
+		}
+	}
+	for name := range catalog.shadows {
+		if _, found := catalog.statements[name]; !found {
+			return nil, fmt.Errorf("shadow of Cypher statement %q has no primary statement", name)
+		}
+	}
+	return catalog, nil
+}
+
+// ForDialect returns the catalog where statements with a variant for the provided
+// dialect are replaced by that variant
+func (c *Catalog) ForDialect(dialect Dialect) *Catalog {
+	result := &Catalog{
+		statements: make(map[string]Statement, len(c.statements)),
+		variants:   c.variants,
+		shadows:    c.shadows,
+	}
+	for name, statement := range c.statements {
+		result.statements[name] = statement
+	}
+	for name, variant := range c.variants[dialect] {
+		result.statements[name] = variant
+	}
+	return result
+}
+
+// Get returns the named statement.
+// Statement names are static, so a missing statement is a programming error and panics.
+func (c *Catalog) Get(name string) Statement {
+	statement, found := c.statements[name]
+	if !found {
+		panic(fmt.Sprintf("unknown Cypher statement %q", name))
+	}
+	return statement
+}
+
+// Shadow returns the shadow rewrite of the named statement, if any
+func (c *Catalog) Shadow(name string) (Statement, bool) {
+	statement, found := c.shadows[name]
+	return statement, found
+}
+
+// All returns all the statements, shadows included, sorted by name
+func (c *Catalog) All() []Statement {
+	result := make([]Statement, 0, len(c.statements)+len(c.shadows))
+	for _, statement := range c.statements {
+		result = append(result, statement)
+	}
+	for _, statement := range c.shadows {
+		result = append(result, statement)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+func parseStatement(name, content string) (Statement, error) {
+	statement := Statement{Name: name, Version: 1, Defaults: map[string]string{}}
+	var body strings.Builder
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	inHeader := true
+	for scanner.Scan() {
+		line := scanner.Text()
+		if inHeader && strings.HasPrefix(line, "//") {
+			key, value, found := strings.Cut(strings.TrimSpace(strings.TrimPrefix(line, "//")), ":")
+			if !found {
+				continue
+			}
+			key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+			switch {
+			case key == "version":
+				version, err := strconv.Atoi(value)
+				if err != nil {
+					return Statement{}, fmt.Errorf("invalid version of Cypher statement %q: %w", name, err)
+				}
+				statement.Version = version
+			case key == "requires":
+				statement.Requires = value
+			case strings.HasPrefix(key, "default "):
+				statement.Defaults[strings.TrimPrefix(key, "default ")] = value
+			}
+			continue
+		}
+		inHeader = false
+		body.WriteString(line)
+		body.WriteString("\n")
+	}
+	statement.Text = strings.TrimSpace(body.String())
+	if statement.Text == "" {
+		return Statement{}, fmt.Errorf("empty Cypher statement %q", name)
+	}
+	return statement, scanner.Err()
+}
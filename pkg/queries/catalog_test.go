@@ -0,0 +1,129 @@
+package queries_test
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/queries"
+)
+
+func TestEmbeddedStatementsRenderWithDefaults(t *testing.T) {
+	catalog, err := queries.Embedded()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(catalog.All()) == 0 {
+		t.Fatal("expected embedded statements")
+	}
+	for _, statement := range catalog.All() {
+		if rendered := statement.Render(nil); strings.Contains(rendered, "{{") {
+			t.Errorf("statement %s has a fragment without default: %s", statement.Name, rendered)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	catalog, err := queries.Load(fstest.MapFS{
+		"cypher/movies/find_all.cypher": {Data: []byte(
+			"// version: 3\n// default sort: title\n\nMATCH (m:Movie)\nRETURN m ORDER BY m.`{{sort}}`\n")},
+	}, "cypher")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statement := catalog.Get("movies/find_all")
+	if statement.Version != 3 {
+		t.Errorf("expected version 3, got %d", statement.Version)
+	}
+	if rendered := statement.Render(nil); rendered != "MATCH (m:Movie)\nRETURN m ORDER BY m.`title`" {
+		t.Errorf("unexpected default rendering: %q", rendered)
+	}
+	if rendered := statement.Render(map[string]string{"sort": "released"}); !strings.HasSuffix(rendered, "m.`released`") {
+		t.Errorf("unexpected rendering: %q", rendered)
+	}
+}
+
+func TestLoadRejectsInvalidVersion(t *testing.T) {
+	_, err := queries.Load(fstest.MapFS{
+		"cypher/broken.cypher": {Data: []byte("// version: one\nRETURN 1\n")},
+	}, "cypher")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestForDialect(t *testing.T) {
+	catalog, err := queries.Load(fstest.MapFS{
+		"cypher/people/count.cypher":    {Data: []byte("RETURN size((p)-->())\n")},
+		"cypher/people/count.v5.cypher": {Data: []byte("RETURN COUNT { (p)-->() }\n")},
+		"cypher/people/all.cypher":      {Data: []byte("MATCH (p:Person) RETURN p\n")},
+	}, "cypher")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if text := catalog.ForDialect(queries.V4).Get("people/count").Text; text != "RETURN size((p)-->())" {
+		t.Errorf("unexpected v4 statement: %q", text)
+	}
+	v5 := catalog.ForDialect(queries.V5)
+	if text := v5.Get("people/count").Text; text != "RETURN COUNT { (p)-->() }" {
+		t.Errorf("unexpected v5 statement: %q", text)
+	}
+	if text := v5.Get("people/all").Text; text != "MATCH (p:Person) RETURN p" {
+		t.Errorf("unexpected fallback statement: %q", text)
+	}
+}
+
+func TestLoadRejectsOrphanVariant(t *testing.T) {
+	_, err := queries.Load(fstest.MapFS{
+		"cypher/people/count.v5.cypher": {Data: []byte("RETURN COUNT { (p)-->() }\n")},
+	}, "cypher")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestShadow(t *testing.T) {
+	catalog, err := queries.Load(fstest.MapFS{
+		"cypher/people/count.cypher":        {Data: []byte("RETURN size((p)-->())\n")},
+		"cypher/people/count.shadow.cypher": {Data: []byte("RETURN COUNT { (p)-->() }\n")},
+	}, "cypher")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shadow, found := catalog.ForDialect(queries.V4).Shadow("people/count")
+	if !found || shadow.Text != "RETURN COUNT { (p)-->() }" {
+		t.Errorf("unexpected shadow: %v %q", found, shadow.Text)
+	}
+	if text := catalog.Get("people/count").Text; text != "RETURN size((p)-->())" {
+		t.Errorf("unexpected primary statement: %q", text)
+	}
+	if _, found := catalog.Shadow("people/all"); found {
+		t.Error("expected no shadow")
+	}
+	if all := catalog.All(); len(all) != 2 || all[1].Name != "people/count.shadow" {
+		t.Errorf("expected shadows to be listed, got %v", all)
+	}
+
+	_, err = queries.Load(fstest.MapFS{
+		"cypher/people/count.shadow.cypher": {Data: []byte("RETURN 1\n")},
+	}, "cypher")
+	if err == nil {
+		t.Fatal("expected error for orphan shadow")
+	}
+}
+
+func TestLoadRequires(t *testing.T) {
+	catalog, err := queries.Load(fstest.MapFS{
+		"cypher/movies/similar.cypher": {Data: []byte("// version: 1\n// requires: gds\n\nRETURN gds.similarity.jaccard([1], [1])\n")},
+	}, "cypher")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if requires := catalog.Get("movies/similar").Requires; requires != queries.Gds {
+		t.Errorf("expected the statement to require gds, got %q", requires)
+	}
+}
@@ -0,0 +1,3 @@
+// version: 1
+
+MATCH (u:User {email: $email}) RETURN u
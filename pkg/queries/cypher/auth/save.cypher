@@ -0,0 +1,11 @@
+// version: 2
+
+CREATE (u:User {
+	userId: randomUuid(),
+	email: $email,
+	password: $encrypted,
+	name: $name,
+	createdAt: timestamp(),
+	updatedAt: timestamp()
+})
+RETURN u { .userId, .name, .email, .createdAt, .updatedAt } as u
@@ -0,0 +1,5 @@
+// version: 2
+
+MATCH (u:User {userId: $userId})
+SET u.avatarUrl = $avatarUrl, u.updatedAt = timestamp()
+RETURN u { .userId, .name, .email, .avatarUrl, .createdAt, .updatedAt } AS u
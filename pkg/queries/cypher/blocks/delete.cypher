@@ -0,0 +1,7 @@
+// version: 1
+
+MATCH (u:User {userId: $userId})
+MATCH (blocked:User {userId: $blockedId})
+OPTIONAL MATCH (u)-[r:BLOCKS]->(blocked)
+DELETE r
+RETURN blocked { .userId, .name, .avatarUrl } AS user
@@ -0,0 +1,10 @@
+// version: 1
+
+MATCH (u:User {userId: $userId})
+MATCH (blocked:User {userId: $blockedId})
+MERGE (u)-[r:BLOCKS]->(blocked)
+ON CREATE SET r.createdAt = datetime()
+WITH u, blocked
+OPTIONAL MATCH (u)-[f:FOLLOWS]->(blocked)
+DELETE f
+RETURN blocked { .userId, .name, .avatarUrl } AS user
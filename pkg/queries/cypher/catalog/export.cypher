@@ -0,0 +1,16 @@
+// version: 1
+
+MATCH (m:Movie)
+WHERE m.tmdbId > $after
+WITH m
+ORDER BY m.tmdbId
+LIMIT $limit
+RETURN m {
+	.tmdbId,
+	.title,
+	.released,
+	.runtime,
+	.imdbRating,
+	.poster,
+	genres: [(m)-[:IN_GENRE]->(g) | g.name]
+} AS movie
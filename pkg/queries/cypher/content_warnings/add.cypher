@@ -0,0 +1,6 @@
+// version: 1
+
+MATCH (m:Movie {tmdbId: $movieId})
+MERGE (w:ContentWarning {name: $warning})
+MERGE (m)-[:HAS_CONTENT_WARNING]->(w)
+RETURN [ (m)-[:HAS_CONTENT_WARNING]->(warning) | warning.name ] AS warnings
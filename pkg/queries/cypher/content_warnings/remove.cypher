@@ -0,0 +1,7 @@
+// version: 1
+
+MATCH (m:Movie {tmdbId: $movieId})
+OPTIONAL MATCH (m)-[r:HAS_CONTENT_WARNING]->(:ContentWarning {name: $warning})
+DELETE r
+WITH DISTINCT m
+RETURN [ (m)-[:HAS_CONTENT_WARNING]->(warning) | warning.name ] AS warnings
@@ -0,0 +1,5 @@
+// version: 2
+
+MATCH (u:User {userId: $userId})
+SET u.excludedContentWarnings = $warnings, u.updatedAt = timestamp()
+RETURN u.excludedContentWarnings AS warnings
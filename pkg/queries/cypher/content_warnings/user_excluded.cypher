@@ -0,0 +1,4 @@
+// version: 1
+
+MATCH (u:User {userId: $userId})
+RETURN coalesce(u.excludedContentWarnings, []) AS warnings
@@ -0,0 +1,5 @@
+// version: 1
+
+MATCH (u:User {userId: $userId})
+SET u.dataVersion = coalesce(u.dataVersion, 0) + 1
+RETURN u.dataVersion AS version
@@ -0,0 +1,4 @@
+// version: 1
+
+OPTIONAL MATCH (u:User {userId: $userId})
+RETURN coalesce(u.dataVersion, 0) AS version
@@ -0,0 +1,32 @@
+// version: 2
+
+MATCH (u:User)
+WHERE u.dailyDigest = true AND u.userId > $after
+WITH u
+ORDER BY u.userId
+LIMIT $limit
+CALL {
+	WITH u
+	OPTIONAL MATCH (u)-[:FOLLOWS]->(followed:User)-[r:RATED]->(m:Movie)
+	WHERE r.timestamp >= $since AND NOT (u)-[:BLOCKS]->(followed)
+	WITH followed, r, m
+	ORDER BY r.timestamp DESC
+	RETURN collect(r {
+		.rating,
+		.timestamp,
+		user: followed { .userId, .name },
+		movie: m { .tmdbId, .title }
+	})[..$maxItems] AS activity
+}
+CALL {
+	WITH u
+	OPTIONAL MATCH (u)-[r:RATED]->(:Movie)-[:IN_GENRE]->(g:Genre)
+	WHERE r.rating >= 4
+	WITH u, g, count(r) AS weight
+	ORDER BY weight DESC
+	LIMIT $preferredGenres
+	OPTIONAL MATCH (g)<-[:IN_GENRE]-(m:Movie)
+	WHERE $sinceDate <= m.released <= $today AND NOT (u)-[:RATED]->(m)
+	RETURN collect(DISTINCT m { .tmdbId, .title, genre: g.name })[..$maxItems] AS newMovies
+}
+RETURN u { .userId, .email, .name } AS user, activity, newMovies
@@ -0,0 +1,5 @@
+// version: 2
+
+MATCH (u:User {userId: $userId})
+SET u.dailyDigest = $dailyDigest, u.updatedAt = timestamp()
+RETURN u.dailyDigest AS dailyDigest
@@ -0,0 +1,9 @@
+// version: 2
+
+MATCH (u:User)-[:REQUESTED_EMAIL_CHANGE]->(c:EmailChange {tokenHash: $tokenHash})
+WHERE c.expiresAt > timestamp()
+OPTIONAL MATCH (taken:User {email: c.email})
+WITH u, c, count(taken) > 0 AS taken
+FOREACH (_ IN CASE WHEN taken THEN [] ELSE [1] END | SET u.email = c.email, u.updatedAt = timestamp())
+DETACH DELETE c
+RETURN u { .userId, .email, .name, .createdAt, .updatedAt } AS user, taken
@@ -0,0 +1,5 @@
+// version: 1
+
+MATCH (u:User {userId: $userId})
+OPTIONAL MATCH (taken:User {email: $email})
+RETURN u { .email, .name, .password } AS user, count(taken) > 0 AS taken
@@ -0,0 +1,10 @@
+// version: 1
+
+MATCH (u:User {userId: $userId})
+FOREACH (previous IN [(u)-[:REQUESTED_EMAIL_CHANGE]->(c:EmailChange) | c] | DETACH DELETE previous)
+CREATE (u)-[:REQUESTED_EMAIL_CHANGE]->(:EmailChange {
+	tokenHash: $tokenHash,
+	email: $email,
+	requestedAt: timestamp(),
+	expiresAt: $expiresAt
+})
@@ -0,0 +1,9 @@
+// version: 2
+// default sort: title
+// default orderBy: m.`title` ASC
+
+MATCH (u:User {userId: $userId})-[r:HAS_FAVORITE]->(m:Movie)
+RETURN m { .*, favorite: true } AS movie
+ORDER BY {{orderBy}}
+SKIP $skip
+LIMIT $limit
@@ -0,0 +1,19 @@
+// version: 1
+
+MATCH (u:User {userId: $userId})
+MATCH (m:Movie {tmdbId: $movieId})
+SET u.favoritesUpdatedAt = datetime()
+
+WITH u, m
+OPTIONAL MATCH (u)-[r:HAS_FAVORITE]->(m)
+FOREACH (_ IN CASE WHEN r IS NULL THEN [1] ELSE [] END |
+	CREATE (u)-[:HAS_FAVORITE {createdAt: datetime()}]->(m)
+)
+DELETE r
+
+WITH m, r IS NULL AS favorite
+RETURN m {
+	.*,
+	favorite: favorite,
+	favoriteCount: COUNT { (m)<-[:HAS_FAVORITE]-() }
+} AS movie
@@ -0,0 +1,4 @@
+// version: 1
+
+MATCH (u:User {userId: $userId})-[:HAS_FAVORITE]->(m)
+RETURN m.tmdbId AS id
@@ -0,0 +1,7 @@
+// version: 1
+
+MATCH (u:User {userId: $userId})
+MATCH (followed:User {userId: $followedId})
+OPTIONAL MATCH (u)-[r:FOLLOWS]->(followed)
+DELETE r
+RETURN followed { .userId, .name, .avatarUrl } AS user
@@ -0,0 +1,17 @@
+// version: 2
+
+MATCH (u:User {userId: $userId})
+MATCH (followed:User {userId: $followedId})
+OPTIONAL MATCH (u)-[existing:FOLLOWS]->(followed)
+MERGE (u)-[r:FOLLOWS]->(followed)
+ON CREATE SET r.createdAt = datetime()
+FOREACH (_ IN CASE WHEN existing IS NULL THEN [1] ELSE [] END |
+	CREATE (followed)-[:HAS_NOTIFICATION]->(:Notification {
+		id: randomUuid(),
+		type: 'follow',
+		message: coalesce(u.name, 'Someone') + ' started following you',
+		read: false,
+		createdAt: timestamp()
+	})
+)
+RETURN followed { .userId, .name, .avatarUrl } AS user
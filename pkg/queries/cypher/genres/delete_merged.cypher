@@ -0,0 +1,23 @@
+// version: 1
+
+MATCH (from:Genre {name: $from})
+WHERE NOT (from)<-[:IN_GENRE]-()
+DETACH DELETE from
+CREATE (a:AuditEntry {
+	id: randomUuid(),
+	action: 'genres.merge',
+	userId: $userId,
+	from: $from,
+	into: $into,
+	movies: $movies,
+	createdAt: timestamp()
+})
+RETURN a {
+	.id,
+	.action,
+	.userId,
+	.from,
+	.into,
+	.movies,
+	.createdAt
+} AS entry
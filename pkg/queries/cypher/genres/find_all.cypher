@@ -0,0 +1,18 @@
+// version: 2
+
+MATCH (g:Genre)
+WHERE g.name <> '(no genres listed)'
+OPTIONAL MATCH (g)<-[:IN_GENRE]-(m:Movie)
+WHERE m.imdbRating IS NOT NULL
+AND m.poster IS NOT NULL
+WITH g, m
+ORDER BY m.imdbRating DESC
+// genres without any poster are listed without one
+WITH g, head(collect(m)) AS movie
+RETURN g {
+	.name,
+	link: '/genres/'+ g.name,
+	poster: movie.poster,
+	movies: size( (g)<-[:IN_GENRE]-() )
+} as genre
+ORDER BY g.name ASC
@@ -0,0 +1,18 @@
+// version: 2
+
+MATCH (g:Genre {name: $name})
+WHERE g.name <> '(no genres listed)'
+OPTIONAL MATCH (g)<-[:IN_GENRE]-(m:Movie)
+WHERE m.imdbRating IS NOT NULL
+AND m.poster IS NOT NULL
+WITH g, m
+ORDER BY m.imdbRating DESC
+
+WITH g, head(collect(m)) AS movie
+
+RETURN g {
+  link: '/genres/'+ g.name,
+  .name,
+  movies: COUNT { (g)<-[:IN_GENRE]-() },
+  poster: movie.poster
+} AS genre
@@ -0,0 +1,13 @@
+// version: 1
+
+MATCH (from:Genre {name: $from}), (into:Genre {name: $into})
+CALL {
+	WITH from, into
+	MATCH (m:Movie)-[r:IN_GENRE]->(from)
+	WITH m, r, into
+	LIMIT $batchSize
+	MERGE (m)-[:IN_GENRE]->(into)
+	DELETE r
+	RETURN count(r) AS relinked
+}
+RETURN relinked
@@ -0,0 +1,5 @@
+// version: 1
+
+UNWIND $ids AS id
+MATCH (m:Movie {tmdbId: id})
+RETURN collect(m.tmdbId) AS ids
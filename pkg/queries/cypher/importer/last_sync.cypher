@@ -0,0 +1,4 @@
+// version: 1
+
+OPTIONAL MATCH (s:ImportState {source: $source})
+RETURN s.syncedAt AS syncedAt
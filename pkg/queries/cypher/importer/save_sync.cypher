@@ -0,0 +1,5 @@
+// version: 1
+
+MERGE (s:ImportState {source: $source})
+SET s.syncedAt = $syncedAt
+RETURN s.syncedAt AS syncedAt
@@ -0,0 +1,8 @@
+// version: 1
+
+OPTIONAL MATCH (m:Maintenance)
+RETURN {
+	readOnly: coalesce(m.readOnly, false),
+	message: m.message,
+	updatedAt: m.updatedAt
+} AS maintenance
@@ -0,0 +1,5 @@
+// version: 1
+
+MERGE (m:Maintenance)
+SET m.readOnly = $readOnly, m.message = $message, m.updatedAt = timestamp()
+RETURN m { .readOnly, .message, .updatedAt } AS maintenance
@@ -0,0 +1,7 @@
+// version: 1
+// default revenue: revenue
+
+MATCH (m:Movie)
+WHERE m.`{{revenue}}` > 0
+AND none(warning IN [(m)-[:HAS_CONTENT_WARNING]->(w) | w.name] WHERE warning IN $excludedWarnings)
+RETURN count(m) AS total
@@ -0,0 +1,7 @@
+// version: 1
+// default sort: title
+
+MATCH (:Person {tmdbId: $id})-[:ACTED_IN|DIRECTED]->(m:Movie)-[:IN_GENRE]->(:Genre {name: $name})
+WHERE m.`{{sort}}` IS NOT NULL
+AND none(warning IN [(m)-[:HAS_CONTENT_WARNING]->(w) | w.name] WHERE warning IN $excludedWarnings)
+RETURN count(DISTINCT m) AS total
@@ -0,0 +1,8 @@
+// version: 1
+// default rating: imdbRating
+
+MATCH (m:Movie)
+WHERE m.`{{rating}}` >= $minRating
+AND NOT (m)<-[:RATED]-(:User {userId: $userId})
+AND none(warning IN [(m)-[:HAS_CONTENT_WARNING]->(w) | w.name] WHERE warning IN $excludedWarnings)
+RETURN count(m) AS total
@@ -0,0 +1,9 @@
+// version: 1
+// default sort: title
+
+CALL db.index.fulltext.queryNodes('names', $q) YIELD node
+UNWIND [node] + [(node)-[:ALIAS_OF]->(m:Movie) | m] AS m
+WITH DISTINCT m
+WHERE m:Movie AND m.`{{sort}}` IS NOT NULL
+AND none(warning IN [(m)-[:HAS_CONTENT_WARNING]->(w) | w.name] WHERE warning IN $excludedWarnings)
+RETURN count(m) AS total
@@ -0,0 +1,6 @@
+// version: 1
+// requires: vector
+
+CALL db.index.vector.queryNodes('moviePlots', $k, $embedding) YIELD node AS m
+WHERE none(warning IN [(m)-[:HAS_CONTENT_WARNING]->(w) | w.name] WHERE warning IN $excludedWarnings)
+RETURN count(m) AS total
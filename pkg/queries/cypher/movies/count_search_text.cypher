@@ -0,0 +1,5 @@
+// version: 1
+
+CALL db.index.fulltext.queryNodes('movieText', $q) YIELD node AS m
+WHERE none(warning IN [(m)-[:HAS_CONTENT_WARNING]->(w) | w.name] WHERE warning IN $excludedWarnings)
+RETURN count(m) AS total
@@ -0,0 +1,14 @@
+// version: 3
+// default sort: title
+// default orderBy: m.`title` ASC
+
+MATCH (m:Movie)
+WHERE m.`{{sort}}` IS NOT NULL
+AND none(warning IN [(m)-[:HAS_CONTENT_WARNING]->(w) | w.name] WHERE warning IN $excludedWarnings)
+RETURN m {
+	.* ,
+	favorite: m.tmdbId IN $favorites
+} AS movie
+ORDER BY {{orderBy}}
+SKIP $skip
+LIMIT $limit
@@ -0,0 +1,17 @@
+// version: 2
+// default sort: title
+// default orderBy: m.`title` ASC
+// default comparator: >
+
+MATCH (m:Movie)
+WHERE m.`{{sort}}` IS NOT NULL
+AND none(warning IN [(m)-[:HAS_CONTENT_WARNING]->(w) | w.name] WHERE warning IN $excludedWarnings)
+AND ($after IS NULL
+	OR m.`{{sort}}` {{comparator}} $after
+	OR (m.`{{sort}}` = $after AND m.tmdbId > $afterId))
+RETURN m {
+	.* ,
+	favorite: m.tmdbId IN $favorites
+} AS movie, m.`{{sort}}` AS sortValue, m.tmdbId AS id
+ORDER BY {{orderBy}}, m.tmdbId ASC
+LIMIT $limit
@@ -0,0 +1,21 @@
+// version: 1
+
+MATCH (m:Movie)
+WHERE m.aggregatedAt IS NOT NULL
+WITH m ORDER BY rand()
+LIMIT $sample
+OPTIONAL MATCH (m)<-[:FLAGS]-(flag:RatingFlag {status: 'open'})
+WITH m, CASE WHEN $excludeFlagged THEN min(flag.since) END AS excludedSince
+WITH m, excludedSince,
+	[(m)<-[r:RATED]-() | r] AS ratings,
+	size([(m)<-[f:HAS_FAVORITE]-() | f]) AS favoriteCount
+WITH m, favoriteCount, ratings,
+	[r IN ratings WHERE excludedSince IS NULL OR r.timestamp < excludedSince | r.rating] AS averaged
+RETURN m.tmdbId AS id,
+	{ratingCount: m.ratingCount, ratingAvg: m.ratingAvg, favoriteCount: m.favoriteCount} AS stored,
+	{
+		ratingCount: size(ratings),
+		ratingAvg: CASE WHEN size(averaged) = 0 THEN null
+			ELSE reduce(total = 0.0, rating IN averaged | total + rating) / size(averaged) END,
+		favoriteCount: favoriteCount
+	} AS actual
@@ -0,0 +1,15 @@
+// version: 1
+// default revenue: revenue
+// default budget: budget
+
+MATCH (m:Movie)
+WHERE m.`{{revenue}}` > 0
+AND none(warning IN [(m)-[:HAS_CONTENT_WARNING]->(w) | w.name] WHERE warning IN $excludedWarnings)
+RETURN m {
+	.*,
+	profit: CASE WHEN m.`{{budget}}` > 0 THEN m.`{{revenue}}` - m.`{{budget}}` END,
+	favorite: m.tmdbId IN $favorites
+} AS movie
+ORDER BY m.`{{revenue}}` DESC
+SKIP $skip
+LIMIT $limit
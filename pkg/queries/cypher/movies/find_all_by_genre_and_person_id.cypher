@@ -0,0 +1,15 @@
+// version: 2
+// default sort: title
+// default orderBy: m.`title` ASC
+
+MATCH (:Person {tmdbId: $id})-[:ACTED_IN|DIRECTED]->(m:Movie)-[:IN_GENRE]->(:Genre {name: $name})
+WHERE m.`{{sort}}` IS NOT NULL
+AND none(warning IN [(m)-[:HAS_CONTENT_WARNING]->(w) | w.name] WHERE warning IN $excludedWarnings)
+WITH DISTINCT m
+RETURN m {
+	.* ,
+	favorite: m.tmdbId IN $favorites
+} AS movie
+ORDER BY {{orderBy}}
+SKIP $skip
+LIMIT $limit
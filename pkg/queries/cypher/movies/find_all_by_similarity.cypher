@@ -0,0 +1,44 @@
+// version: 5
+
+// the genres, actors and directors of the movies the user liked, none when anonymous
+OPTIONAL MATCH (:User {userId: $userId})-[liked:RATED]->(:Movie)-[:IN_GENRE|ACTED_IN|DIRECTED]-(feature)
+WHERE liked.rating >= $minRating
+WITH collect(DISTINCT feature) AS tastes
+
+// hubs such as popular genres only contribute their best rated movies
+MATCH (source:Movie {tmdbId: $id})-[:IN_GENRE|ACTED_IN|DIRECTED]-(feature)
+CALL {
+	WITH source, feature
+	MATCH (feature)-[r:IN_GENRE|ACTED_IN|DIRECTED]-(m:Movie)
+	WHERE m <> source
+	AND m.imdbRating IS NOT NULL
+	RETURN m, r
+	ORDER BY m.imdbRating DESC
+	LIMIT $maxFanOut
+}
+WITH tastes, m, r
+WHERE none(warning IN [(m)-[:HAS_CONTENT_WARNING]->(w) | w.name] WHERE warning IN $excludedWarnings)
+
+WITH m, tastes, sum(CASE type(r)
+	WHEN 'IN_GENRE' THEN $weights.genre
+	WHEN 'ACTED_IN' THEN $weights.actor
+	ELSE $weights.director
+END) AS inCommon
+WITH m, inCommon, CASE size(tastes) WHEN 0 THEN 0.0 ELSE reduce(total = 0.0, kind IN
+	[(m)-[t:IN_GENRE|ACTED_IN|DIRECTED]-(f) WHERE f IN tastes | type(t)] |
+	total + CASE kind
+		WHEN 'IN_GENRE' THEN $weights.genre
+		WHEN 'ACTED_IN' THEN $weights.actor
+		ELSE $weights.director
+	END) END AS taste
+WITH m, (inCommon + $weights.taste * taste) * m.imdbRating ^ $weights.rating AS score
+ORDER BY score DESC
+
+SKIP $skip
+LIMIT $limit
+
+RETURN m {
+	.*,
+	score: score,
+	favorite: m.tmdbId IN $favorites
+} AS movie
@@ -0,0 +1,35 @@
+// version: 2
+// requires: gds
+
+MATCH (source:Movie {tmdbId: $id})
+WITH source, [(source)-[:IN_GENRE|ACTED_IN|DIRECTED]-(feature) | id(feature)] AS sourceFeatures
+
+// hubs such as popular genres only contribute their best rated movies
+MATCH (source)-[:IN_GENRE|ACTED_IN|DIRECTED]-(feature)
+CALL {
+	WITH source, feature
+	MATCH (feature)-[:IN_GENRE|ACTED_IN|DIRECTED]-(m:Movie)
+	WHERE m <> source
+	AND m.imdbRating IS NOT NULL
+	RETURN m
+	ORDER BY m.imdbRating DESC
+	LIMIT $maxFanOut
+}
+WITH sourceFeatures, m
+WHERE none(warning IN [(m)-[:HAS_CONTENT_WARNING]->(w) | w.name] WHERE warning IN $excludedWarnings)
+
+WITH DISTINCT m, sourceFeatures
+WITH m, gds.similarity.jaccard(sourceFeatures,
+	[(m)-[:IN_GENRE|ACTED_IN|DIRECTED]-(feature) | id(feature)]) AS similarity
+WITH m, similarity, similarity * m.imdbRating ^ $weights.rating AS score
+ORDER BY score DESC
+
+SKIP $skip
+LIMIT $limit
+
+RETURN m {
+	.*,
+	score: score,
+	similarity: similarity,
+	favorite: m.tmdbId IN $favorites
+} AS movie
@@ -0,0 +1,21 @@
+// version: 1
+// default rating: imdbRating
+// default votes: imdbVotes
+
+MATCH (m:Movie)
+WHERE m.`{{rating}}` >= $minRating
+AND NOT (m)<-[:RATED]-(:User {userId: $userId})
+AND none(warning IN [(m)-[:HAS_CONTENT_WARNING]->(w) | w.name] WHERE warning IN $excludedWarnings)
+OPTIONAL MATCH (m)<-[r:RATED]-(:User)
+WITH m, count(r) AS ratingCount
+WITH m, ratingCount,
+	m.`{{rating}}` / log10(10 + ratingCount + coalesce(m.`{{votes}}`, 0)) AS gemScore
+RETURN m {
+	.*,
+	ratingCount: ratingCount,
+	gemScore: gemScore,
+	favorite: m.tmdbId IN $favorites
+} AS movie
+ORDER BY gemScore DESC
+SKIP $skip
+LIMIT $limit
@@ -0,0 +1,8 @@
+// version: 1
+// default plot: plot
+
+MATCH (m:Movie)
+WHERE m.`{{plot}}` IS NOT NULL AND trim(m.`{{plot}}`) <> ''
+AND (m.plotEmbeddingOf IS NULL OR m.plotEmbeddingOf <> m.`{{plot}}`)
+RETURN m.tmdbId AS id, m.`{{plot}}` AS plot
+LIMIT $limit
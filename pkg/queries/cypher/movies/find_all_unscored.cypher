@@ -0,0 +1,9 @@
+// version: 1
+// default rating: imdbRating
+
+MATCH (m:Movie)
+WITH m, [toFloat(coalesce(m.`{{rating}}`, -1)), toFloat(coalesce(m.ratingAvg, -1)), toFloat($scale)] AS scoreOf
+WHERE (m.`{{rating}}` IS NOT NULL OR m.ratingAvg IS NOT NULL OR m.score IS NOT NULL)
+AND (m.scoreOf IS NULL OR m.scoreOf <> scoreOf)
+RETURN m.tmdbId AS id, m.`{{rating}}` AS imdbRating, m.ratingAvg AS ratingAvg, scoreOf
+LIMIT $limit
@@ -0,0 +1,8 @@
+// version: 1
+// default title: title
+
+MATCH (m:Movie)
+WHERE m.`{{title}}` IS NOT NULL
+AND (m.sortTitleOf IS NULL OR m.sortTitleOf <> m.`{{title}}` OR m.sortTitleLanguages <> $languages)
+RETURN m.tmdbId AS id, m.`{{title}}` AS title
+LIMIT $limit
@@ -0,0 +1,13 @@
+// version: 1
+// default released: released
+
+MATCH (m:Movie:Upcoming)
+WHERE none(warning IN [(m)-[:HAS_CONTENT_WARNING]->(w) | w.name] WHERE warning IN $excludedWarnings)
+RETURN m {
+	.*,
+	status: 'upcoming',
+	favorite: m.tmdbId IN $favorites
+} AS movie
+ORDER BY m.`{{released}}` ASC
+SKIP $skip
+LIMIT $limit
@@ -0,0 +1,9 @@
+// version: 1
+
+UNWIND range(0, size($ids) - 1) AS i
+MATCH (m:Movie {tmdbId: $ids[i]})
+RETURN m {
+	.* ,
+	favorite: m.tmdbId IN $favorites
+} AS movie
+ORDER BY i
@@ -0,0 +1,19 @@
+// version: 4
+
+MATCH (m:Movie {tmdbId: $id})
+OPTIONAL MATCH (m)<-[:FLAGS]-(flag:RatingFlag {status: 'open'})
+WITH m, CASE WHEN $excludeFlagged THEN min(flag.since) END AS excludedSince
+WITH m, [(m)<-[r:RATED]-() WHERE excludedSince IS NULL OR r.timestamp < excludedSince | r.rating] AS ratings
+RETURN m {
+  .*,
+	actors: [ (a)-[r:ACTED_IN]->(m) | a { .*, role: r.role } ],
+	directors: [ (d)-[:DIRECTED]->(m) | d { .* } ],
+	genres: [ (m)-[:IN_GENRE]->(g) | g { .name }],
+	contentWarnings: [ (m)-[:HAS_CONTENT_WARNING]->(w) | w.name ],
+	aliases: [ (a:Alias)-[:ALIAS_OF]->(m) | a.name ],
+	ratingCount: size((m)<-[:RATED]-()),
+	averageRating: CASE WHEN size(ratings) = 0 THEN null
+		ELSE reduce(total = 0.0, rating IN ratings | total + rating) / size(ratings) END,
+	favorite: m.tmdbId IN $favorites
+} AS movie
+LIMIT 1
@@ -0,0 +1,18 @@
+// version: 1
+
+MATCH (m:Movie {tmdbId: $id})
+CALL {
+	WITH m
+	MATCH (m)-[s:SIMILAR]-(similar:Movie)
+	WITH similar ORDER BY s.score DESC
+	LIMIT $movies
+	RETURN collect(similar.tmdbId) AS movies
+}
+CALL {
+	WITH m
+	MATCH (a:Person)-[:ACTED_IN]->(m)
+	WITH a ORDER BY size([(a)-[:ACTED_IN]->(credit) | credit]) DESC, a.name
+	LIMIT $people
+	RETURN collect(a.tmdbId) AS people
+}
+RETURN {movies: movies, people: people} AS hints
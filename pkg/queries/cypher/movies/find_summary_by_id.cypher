@@ -0,0 +1,23 @@
+// version: 1
+// default title: title
+// default released: released
+// default plot: plot
+// default rating: imdbRating
+
+MATCH (m:Movie {tmdbId: $id})
+CALL {
+	WITH m
+	MATCH (a:Person)-[:ACTED_IN]->(m)
+	WITH a ORDER BY size([(a)-[:ACTED_IN]->(credit) | credit]) DESC, a.name
+	LIMIT $actors
+	RETURN collect(a.name) AS actors
+}
+RETURN {
+	tmdbId: m.tmdbId,
+	title: m.`{{title}}`,
+	released: m.`{{released}}`,
+	plot: m.`{{plot}}`,
+	rating: m.`{{rating}}`,
+	actors: actors,
+	directors: [(d:Person)-[:DIRECTED]->(m) WHERE d.name IS NOT NULL | d.name]
+} AS summary
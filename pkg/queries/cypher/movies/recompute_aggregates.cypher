@@ -0,0 +1,28 @@
+// version: 1
+
+UNWIND $ids AS id
+MATCH (m:Movie {tmdbId: id})
+OPTIONAL MATCH (m)<-[:FLAGS]-(flag:RatingFlag {status: 'open'})
+WITH m, CASE WHEN $excludeFlagged THEN min(flag.since) END AS excludedSince
+WITH m, excludedSince,
+	[(m)<-[r:RATED]-() | r] AS ratings,
+	size([(m)<-[f:HAS_FAVORITE]-() | f]) AS favoriteCount
+WITH m, favoriteCount,
+	size(ratings) AS ratingCount,
+	size([r IN ratings WHERE r.timestamp >= $recentSince]) AS recentRatingCount,
+	[r IN ratings WHERE excludedSince IS NULL OR r.timestamp < excludedSince | r.rating] AS averaged
+SET m.ratingCount = ratingCount,
+	m.ratingAvg = CASE WHEN size(averaged) = 0 THEN null
+		ELSE reduce(total = 0.0, rating IN averaged | total + rating) / size(averaged) END,
+	m.favoriteCount = favoriteCount,
+	m.popularity = ratingCount + recentRatingCount + $favoriteWeight * favoriteCount,
+	m.aggregatedAt = timestamp()
+RETURN m {
+	.tmdbId,
+	.title,
+	.ratingAvg,
+	.ratingCount,
+	.favoriteCount,
+	.popularity,
+	.aggregatedAt
+} AS movie
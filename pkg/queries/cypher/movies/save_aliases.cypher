@@ -0,0 +1,9 @@
+// version: 2
+
+MATCH (m:Movie {tmdbId: $id})
+OPTIONAL MATCH (m)<-[:ALIAS_OF]-(previous:Alias)
+DETACH DELETE previous
+WITH DISTINCT m
+SET m.updatedAt = timestamp()
+FOREACH (name IN $aliases | CREATE (:Alias {name: name})-[:ALIAS_OF]->(m))
+RETURN m { .*, aliases: $aliases } AS movie
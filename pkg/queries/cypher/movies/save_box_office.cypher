@@ -0,0 +1,9 @@
+// version: 2
+// default revenue: revenue
+// default budget: budget
+
+MATCH (m:Movie {tmdbId: $id})
+SET m.`{{budget}}` = coalesce($budget, m.`{{budget}}`),
+	m.`{{revenue}}` = coalesce($revenue, m.`{{revenue}}`),
+	m.updatedAt = timestamp()
+RETURN m { .* } AS movie
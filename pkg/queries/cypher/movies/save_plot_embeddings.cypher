@@ -0,0 +1,9 @@
+// version: 1
+// default plot: plot
+
+UNWIND $movies AS row
+MATCH (m:Movie {tmdbId: row.id})
+// the plot may have been edited since it was embedded
+WHERE m.`{{plot}}` = row.plot
+SET m.plotEmbedding = row.embedding, m.plotEmbeddingOf = row.plot
+RETURN count(m) AS updated
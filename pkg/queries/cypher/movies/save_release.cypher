@@ -0,0 +1,12 @@
+// version: 2
+// default released: released
+
+MATCH (m:Movie {tmdbId: $id})
+SET m.`{{released}}` = $released, m.updatedAt = timestamp()
+WITH m, $released > $today AS upcoming
+FOREACH (_ IN CASE WHEN upcoming THEN [1] ELSE [] END | SET m:Upcoming REMOVE m:Released)
+FOREACH (_ IN CASE WHEN upcoming THEN [] ELSE [1] END | SET m:Released REMOVE m:Upcoming)
+RETURN m {
+	.*,
+	status: CASE WHEN upcoming THEN 'upcoming' ELSE 'released' END
+} AS movie
@@ -0,0 +1,6 @@
+// version: 1
+
+UNWIND $movies AS row
+MATCH (m:Movie {tmdbId: row.id})
+SET m.score = row.score, m.scoreOf = row.scoreOf
+RETURN count(m) AS updated
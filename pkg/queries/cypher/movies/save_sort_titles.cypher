@@ -0,0 +1,6 @@
+// version: 1
+
+UNWIND $movies AS row
+MATCH (m:Movie {tmdbId: row.id})
+SET m += row.sortTitles, m.sortTitleOf = row.title, m.sortTitleLanguages = $languages
+RETURN count(m) AS updated
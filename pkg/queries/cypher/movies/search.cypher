@@ -0,0 +1,17 @@
+// version: 2
+// default sort: title
+// default orderBy: m.`title` ASC
+
+CALL db.index.fulltext.queryNodes('names', $q) YIELD node
+UNWIND [node] + [(node)-[:ALIAS_OF]->(m:Movie) | m] AS m
+WITH DISTINCT m
+WHERE m:Movie AND m.`{{sort}}` IS NOT NULL
+AND none(warning IN [(m)-[:HAS_CONTENT_WARNING]->(w) | w.name] WHERE warning IN $excludedWarnings)
+RETURN m {
+	.* ,
+	aliases: [ (a:Alias)-[:ALIAS_OF]->(m) | a.name ],
+	favorite: m.tmdbId IN $favorites
+} AS movie
+ORDER BY {{orderBy}}
+SKIP $skip
+LIMIT $limit
@@ -0,0 +1,14 @@
+// version: 1
+// requires: vector
+// default title: title
+
+CALL db.index.vector.queryNodes('moviePlots', $k, $embedding) YIELD node AS m, score
+WHERE none(warning IN [(m)-[:HAS_CONTENT_WARNING]->(w) | w.name] WHERE warning IN $excludedWarnings)
+RETURN m {
+	.* ,
+	relevance: score,
+	favorite: m.tmdbId IN $favorites
+} AS movie
+ORDER BY score DESC, m.`{{title}}` ASC
+SKIP $skip
+LIMIT $limit
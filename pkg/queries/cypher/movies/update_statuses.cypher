@@ -0,0 +1,10 @@
+// version: 1
+// default released: released
+
+MATCH (m:Movie)
+WHERE m.`{{released}}` IS NOT NULL
+AND ((m:Upcoming AND m.`{{released}}` <= $today) OR NOT (m:Upcoming OR m:Released))
+WITH m, m.`{{released}}` > $today AS upcoming
+FOREACH (_ IN CASE WHEN upcoming THEN [1] ELSE [] END | SET m:Upcoming REMOVE m:Released)
+FOREACH (_ IN CASE WHEN upcoming THEN [] ELSE [1] END | SET m:Released REMOVE m:Upcoming)
+RETURN count(m) AS updated
@@ -0,0 +1,4 @@
+// version: 1
+
+MATCH (u:User {userId: $userId})-[:HAS_NOTIFICATION]->(n:Notification)
+RETURN count(n) AS total
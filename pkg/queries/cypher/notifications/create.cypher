@@ -0,0 +1,21 @@
+// version: 1
+
+MATCH (u:User {userId: $userId})
+CREATE (u)-[:HAS_NOTIFICATION]->(n:Notification {
+	id: randomUuid(),
+	type: $type,
+	message: $message,
+	read: false,
+	createdAt: timestamp()
+})
+WITH n
+OPTIONAL MATCH (m:Movie {tmdbId: $movieId})
+FOREACH (_ IN CASE WHEN m IS NULL THEN [] ELSE [1] END | CREATE (n)-[:ABOUT]->(m))
+RETURN n {
+	.id,
+	.type,
+	.message,
+	.read,
+	.createdAt,
+	movie: m { .tmdbId, .title, .poster }
+} AS notification
@@ -0,0 +1,16 @@
+// version: 2
+
+MATCH (u:User {userId: $userId})-[:HAS_NOTIFICATION]->(n:Notification)
+OPTIONAL MATCH (n)-[:ABOUT]->(m:Movie)
+WITH n, m
+ORDER BY n.read, n.createdAt DESC
+SKIP $skip
+LIMIT $limit
+RETURN n {
+	.id,
+	.type,
+	.message,
+	.read,
+	.createdAt,
+	movie: m { .tmdbId, .title, .poster }
+} AS notification
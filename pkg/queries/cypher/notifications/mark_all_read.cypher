@@ -0,0 +1,6 @@
+// version: 1
+
+MATCH (u:User {userId: $userId})-[:HAS_NOTIFICATION]->(n:Notification)
+WHERE NOT n.read
+SET n.read = true
+RETURN count(n) AS updated
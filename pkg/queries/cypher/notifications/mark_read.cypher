@@ -0,0 +1,14 @@
+// version: 1
+
+MATCH (u:User {userId: $userId})-[:HAS_NOTIFICATION]->(n:Notification {id: $id})
+SET n.read = true
+WITH n
+OPTIONAL MATCH (n)-[:ABOUT]->(m:Movie)
+RETURN n {
+	.id,
+	.type,
+	.message,
+	.read,
+	.createdAt,
+	movie: m { .tmdbId, .title, .poster }
+} AS notification
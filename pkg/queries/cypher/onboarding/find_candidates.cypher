@@ -0,0 +1,16 @@
+// version: 1
+// default votes: imdbVotes
+
+// the most voted movies of each genre, taking turns between the genres
+MATCH (g:Genre)<-[:IN_GENRE]-(m:Movie)
+WHERE m.`{{votes}}` IS NOT NULL
+AND NOT (:User {userId: $userId})-[:RATED]->(m)
+AND none(warning IN [(m)-[:HAS_CONTENT_WARNING]->(w) | w.name] WHERE warning IN $excludedWarnings)
+WITH g, m
+ORDER BY m.`{{votes}}` DESC
+WITH g, collect(m)[..$perGenre] AS movies
+UNWIND range(0, size(movies) - 1) AS turn
+WITH movies[turn] AS m, min(turn) AS turn
+RETURN m { .* } AS movie
+ORDER BY turn, m.`{{votes}}` DESC
+LIMIT $limit
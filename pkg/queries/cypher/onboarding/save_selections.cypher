@@ -0,0 +1,14 @@
+// version: 2
+
+MATCH (u:User {userId: $userId})
+CALL {
+	WITH u
+	MATCH (m:Movie)
+	WHERE m.tmdbId IN $movieIds
+	// explicit ratings are left untouched
+	MERGE (u)-[r:RATED]->(m)
+	ON CREATE SET r.rating = $rating, r.originalRating = $rating, r.implicit = true, r.timestamp = timestamp(),
+		r.createdAt = timestamp(), r.updatedAt = timestamp()
+	RETURN count(m) AS selected
+}
+RETURN selected
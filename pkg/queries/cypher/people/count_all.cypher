@@ -0,0 +1,6 @@
+// version: 3
+// default filter: true
+
+MATCH (p:Person)
+WHERE {{filter}}
+RETURN count(p) AS total
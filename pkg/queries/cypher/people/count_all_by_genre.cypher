@@ -0,0 +1,4 @@
+// version: 1
+
+MATCH (:Genre {name: $name})<-[:IN_GENRE]-(:Movie)<-[:ACTED_IN|DIRECTED]-(p:Person)
+RETURN count(DISTINCT p) AS total
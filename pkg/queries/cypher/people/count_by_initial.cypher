@@ -0,0 +1,5 @@
+// version: 1
+
+MATCH (p:Person)
+WHERE p.name IS NOT NULL AND p.name <> ''
+RETURN toUpper(left(p.name, 1)) AS initial, count(p) AS count
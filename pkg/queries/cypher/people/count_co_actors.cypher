@@ -0,0 +1,5 @@
+// version: 1
+
+MATCH (p:Person {tmdbId: $id})-[:ACTED_IN]->(:Movie)<-[:ACTED_IN]-(coActor:Person)
+WHERE coActor <> p
+RETURN count(DISTINCT coActor) AS total
@@ -0,0 +1,6 @@
+// version: 1
+// default sort: released
+
+MATCH (:Person {tmdbId: $id})-[:ACTED_IN|DIRECTED]->(m:Movie)
+WHERE m.`{{sort}}` IS NOT NULL
+RETURN count(m) AS total
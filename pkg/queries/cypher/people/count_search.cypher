@@ -0,0 +1,8 @@
+// version: 2
+// default filter: true
+
+CALL db.index.fulltext.queryNodes('names', $q) YIELD node
+UNWIND [node] + [(node)-[:ALIAS_OF]->(p:Person) | p] AS p
+WITH DISTINCT p
+WHERE p:Person AND {{filter}}
+RETURN count(p) AS total
@@ -0,0 +1,12 @@
+// version: 1
+
+MATCH (:Person {tmdbId: $id})-[r:ACTED_IN|DIRECTED]->(m:Movie)
+WITH r, coalesce(m.year, toInteger(left(m.released, 4))) AS year
+WHERE year IS NOT NULL
+WITH year / 10 * 10 AS decade, type(r) AS type
+RETURN {
+	decade: decade,
+	acted: sum(CASE type WHEN 'ACTED_IN' THEN 1 ELSE 0 END),
+	directed: sum(CASE type WHEN 'DIRECTED' THEN 1 ELSE 0 END)
+} AS credits
+ORDER BY decade ASC
@@ -0,0 +1,4 @@
+// version: 1
+
+OPTIONAL MATCH (p:Person {tmdbId: $id})
+RETURN count(p) > 0 AS found
@@ -0,0 +1,11 @@
+// version: 4
+// default sort: name
+// default orderBy: p.`name` ASC
+// default filter: true
+
+MATCH (p:Person)
+WHERE {{filter}}
+RETURN p { .* } AS person
+ORDER BY {{orderBy}}
+SKIP $skip
+LIMIT $limit
@@ -0,0 +1,15 @@
+// version: 3
+// default sort: name
+// default orderBy: p.`name` ASC
+// default comparator: >
+// default filter: true
+
+MATCH (p:Person)
+WHERE p.`{{sort}}` IS NOT NULL
+AND {{filter}}
+AND ($after IS NULL
+	OR p.`{{sort}}` {{comparator}} $after
+	OR (p.`{{sort}}` = $after AND p.tmdbId > $afterId))
+RETURN p { .* } AS person, p.`{{sort}}` AS sortValue, p.tmdbId AS id
+ORDER BY {{orderBy}}, p.tmdbId ASC
+LIMIT $limit
@@ -0,0 +1,16 @@
+// version: 1
+
+MATCH (:Genre {name: $name})<-[:IN_GENRE]-(m:Movie)<-[r:ACTED_IN|DIRECTED]-(p:Person)
+WITH p,
+	count(DISTINCT m) AS movieCount,
+	count(DISTINCT CASE WHEN type(r) = 'ACTED_IN' THEN m END) AS actedCount,
+	count(DISTINCT CASE WHEN type(r) = 'DIRECTED' THEN m END) AS directedCount
+RETURN p {
+	.*,
+	movieCount: movieCount,
+	actedCount: actedCount,
+	directedCount: directedCount
+} AS person
+ORDER BY movieCount DESC, p.name ASC
+SKIP $skip
+LIMIT $limit
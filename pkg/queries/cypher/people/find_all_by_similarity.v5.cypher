@@ -0,0 +1,17 @@
+// version: 2
+// default orderBy: inCommonCount DESC
+// default relationships: ACTED_IN|DIRECTED
+
+MATCH (:Person {tmdbId: $id})-[:{{relationships}}]->(m)<-[r:{{relationships}}]-(p)
+WITH p, count(*) AS inCommonCount, collect(m {.tmdbId, .title, type: type(r)}) AS inCommon
+WITH p, inCommonCount, inCommon, COUNT { (p)-[:{{relationships}}]->() } AS popularity
+RETURN p {
+	.*,
+	actedCount: COUNT { (p)-[:ACTED_IN]->() },
+	directedCount: COUNT { (p)-[:DIRECTED]->() },
+	inCommonCount: inCommonCount,
+	inCommon: CASE WHEN $maxInCommon > 0 THEN inCommon[0..$maxInCommon] ELSE inCommon END
+} AS person
+ORDER BY {{orderBy}}
+SKIP $skip
+LIMIT $limit
@@ -0,0 +1,19 @@
+// version: 1
+// default order: DESC
+
+MATCH (p:Person {tmdbId: $id})-[:ACTED_IN]->(m:Movie)<-[:ACTED_IN]-(coActor:Person)
+WHERE coActor <> p
+WITH DISTINCT coActor, m
+ORDER BY m.released ASC
+WITH coActor, collect(m.title) AS sharedTitles
+WITH coActor, sharedTitles, size(sharedTitles) AS sharedMovies
+ORDER BY sharedMovies {{order}}, coActor.name ASC
+SKIP $skip
+LIMIT $limit
+RETURN coActor {
+	.tmdbId,
+	.name,
+	.poster,
+	sharedMovies: sharedMovies,
+	sharedTitles: sharedTitles
+} AS coActor
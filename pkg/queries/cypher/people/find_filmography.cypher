@@ -0,0 +1,15 @@
+// version: 2
+// default sort: released
+// default orderBy: m.`released` DESC
+
+MATCH (:Person {tmdbId: $id})-[r:ACTED_IN|DIRECTED]->(m:Movie)
+WHERE m.`{{sort}}` IS NOT NULL
+WITH m, r, CASE type(r) WHEN 'ACTED_IN' THEN 'actor' ELSE 'director' END AS role
+RETURN m {
+	.*,
+	role: role,
+	character: CASE role WHEN 'actor' THEN coalesce(r.role, r.roles[0]) END
+} AS credit
+ORDER BY {{orderBy}}, m.tmdbId ASC, role ASC
+SKIP $skip
+LIMIT $limit
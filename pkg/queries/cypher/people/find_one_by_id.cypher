@@ -0,0 +1,9 @@
+// version: 2
+
+MATCH (p:Person { tmdbId: $id })
+RETURN p {
+	.*,
+	aliases: [ (a:Alias)-[:ALIAS_OF]->(p) | a.name ],
+	actedCount: size((p)-[:ACTED_IN]->()),
+	directedCount: size((p)-[:DIRECTED]->())
+} AS person
@@ -0,0 +1,13 @@
+// version: 1
+
+MATCH (p:Person {tmdbId: $id})-[:ACTED_IN|DIRECTED]->(m:Movie)<-[:ACTED_IN|DIRECTED]-(collaborator:Person)
+WHERE collaborator <> p
+WITH collaborator, count(DISTINCT m) AS sharedMovies
+ORDER BY sharedMovies DESC, collaborator.name ASC
+LIMIT $limit
+RETURN collaborator {
+	.tmdbId,
+	.name,
+	.poster,
+	sharedMovies: sharedMovies
+} AS collaborator
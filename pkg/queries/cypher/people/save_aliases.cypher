@@ -0,0 +1,9 @@
+// version: 2
+
+MATCH (p:Person {tmdbId: $id})
+OPTIONAL MATCH (p)<-[:ALIAS_OF]-(previous:Alias)
+DETACH DELETE previous
+WITH DISTINCT p
+SET p.updatedAt = timestamp()
+FOREACH (name IN $aliases | CREATE (:Alias {name: name})-[:ALIAS_OF]->(p))
+RETURN p { .*, aliases: $aliases } AS person
@@ -0,0 +1,16 @@
+// version: 3
+// default sort: name
+// default orderBy: p.`name` ASC
+// default filter: true
+
+CALL db.index.fulltext.queryNodes('names', $q) YIELD node
+UNWIND [node] + [(node)-[:ALIAS_OF]->(p:Person) | p] AS p
+WITH DISTINCT p
+WHERE p:Person AND {{filter}}
+RETURN p {
+	.*,
+	aliases: [ (a:Alias)-[:ALIAS_OF]->(p) | a.name ]
+} AS person
+ORDER BY {{orderBy}}
+SKIP $skip
+LIMIT $limit
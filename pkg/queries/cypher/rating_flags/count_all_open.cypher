@@ -0,0 +1,4 @@
+// version: 1
+
+MATCH (f:RatingFlag {status: 'open'})-[:FLAGS]->(:Movie)
+RETURN count(f) AS total
@@ -0,0 +1,27 @@
+// version: 1
+
+MATCH (m:Movie)<-[r:RATED]-()
+WHERE r.timestamp >= $since
+AND NOT (m)<-[:FLAGS]-(:RatingFlag {status: 'open'})
+WITH m,
+	count(r) AS recent,
+	sum(CASE WHEN r.rating <= $lowRating OR r.rating >= $highRating THEN 1 ELSE 0 END) AS recentExtreme
+WHERE recent >= $minRatings
+OPTIONAL MATCH (m)<-[old:RATED]-()
+WHERE old.timestamp < $since
+WITH m, recent, toFloat(recentExtreme) / recent AS extremeShare,
+	count(old) AS baseline,
+	sum(CASE WHEN old.rating <= $lowRating OR old.rating >= $highRating THEN 1 ELSE 0 END) AS baselineExtreme
+WITH m, recent, extremeShare,
+	CASE WHEN baseline = 0 THEN 0.0 ELSE toFloat(baselineExtreme) / baseline END AS baselineShare
+WHERE extremeShare - baselineShare >= $minShift
+CREATE (f:RatingFlag {
+	id: randomUuid(),
+	status: 'open',
+	since: $since,
+	detectedAt: timestamp(),
+	recentRatings: recent,
+	extremeShare: extremeShare,
+	baselineShare: baselineShare
+})-[:FLAGS]->(m)
+RETURN count(f) AS flagged
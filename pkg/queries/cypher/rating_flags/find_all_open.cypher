@@ -0,0 +1,16 @@
+// version: 1
+
+MATCH (f:RatingFlag {status: 'open'})-[:FLAGS]->(m:Movie)
+RETURN f {
+	.id,
+	.status,
+	.since,
+	.detectedAt,
+	.recentRatings,
+	.extremeShare,
+	.baselineShare,
+	movie: m { .tmdbId, .title, .poster }
+} AS flag
+ORDER BY f.detectedAt DESC
+SKIP $skip
+LIMIT $limit
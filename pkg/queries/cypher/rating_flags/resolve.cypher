@@ -0,0 +1,15 @@
+// version: 1
+
+MATCH (f:RatingFlag {id: $id})-[:FLAGS]->(m:Movie)
+SET f.status = 'resolved', f.resolvedAt = timestamp(), f.resolvedBy = $userId
+RETURN f {
+	.id,
+	.status,
+	.since,
+	.detectedAt,
+	.resolvedAt,
+	.recentRatings,
+	.extremeShare,
+	.baselineShare,
+	movie: m { .tmdbId, .title, .poster }
+} AS flag
@@ -0,0 +1,5 @@
+// version: 1
+
+MATCH (u:User)-[r:RATED]->(m:Movie {tmdbId: $id})
+WHERE NOT (:User {userId: $userId})-[:BLOCKS]->(u)
+RETURN count(r) AS total
@@ -0,0 +1,8 @@
+// version: 1
+
+MATCH (u:User {userId: $userId})
+WHERE (NOT coalesce(u.reviewsPrivate, false) OR u.userId = $viewerId)
+AND NOT (:User {userId: $viewerId})-[:BLOCKS]->(u)
+OPTIONAL MATCH (u)-[r:RATED]->(:Movie)
+WITH u, count(r) AS total
+RETURN total
@@ -0,0 +1,6 @@
+// version: 1
+
+MATCH (u:User {userId: $userId})-[r:RATED]->(m:Movie {tmdbId: $movieId})
+DELETE r
+
+RETURN m { .* } AS movie
@@ -0,0 +1,17 @@
+// version: 5
+// default sort: rating
+// default orderBy: r.`rating` ASC
+
+MATCH (u:User)-[r:RATED]->(m:Movie {tmdbId: $id})
+WHERE NOT (:User {userId: $userId})-[:BLOCKS]->(u)
+RETURN r {
+	.rating,
+	.timestamp,
+	.createdAt,
+	.updatedAt,
+	text: r.review,
+     user: u { .id, .name, .avatarUrl }
+} AS review
+ORDER BY {{orderBy}}
+SKIP $skip
+LIMIT $limit
@@ -0,0 +1,18 @@
+// version: 6
+// default sort: r.timestamp
+// default order: DESC
+
+MATCH (u:User {userId: $userId})-[r:RATED]->(m:Movie)
+WITH r, m
+ORDER BY {{sort}} {{order}}
+SKIP $skip
+LIMIT $limit
+RETURN r {
+	.rating,
+	.timestamp,
+	.createdAt,
+	.updatedAt,
+	text: r.review,
+	helpfulness: coalesce(r.helpfulCount, 0),
+	movie: m { .tmdbId, .title, .poster }
+} AS review
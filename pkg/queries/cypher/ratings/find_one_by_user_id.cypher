@@ -0,0 +1,14 @@
+// version: 1
+
+MATCH (:User {userId: $userId})-[r:RATED]->(m:Movie {tmdbId: $movieId})
+WITH m, r, coalesce(r.previousRatings, []) AS previousRatings
+RETURN r {
+	.rating,
+	.timestamp,
+	originalRating: coalesce(r.originalRating, r.rating),
+	history: [i IN range(size(previousRatings) - 1, 0, -1) | {
+		rating: previousRatings[i],
+		timestamp: r.previousTimestamps[i]
+	}],
+	movie: m { .tmdbId, .title, .poster }
+} AS rating
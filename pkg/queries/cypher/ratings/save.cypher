@@ -0,0 +1,17 @@
+// version: 3
+
+MATCH (u:User {userId: $userId})
+MATCH (m:Movie {tmdbId: $movieId})
+
+MERGE (u)-[r:RATED]->(m)
+ON CREATE SET r.originalRating = $rating, r.createdAt = timestamp()
+ON MATCH SET
+	r.createdAt = coalesce(r.createdAt, head(r.previousTimestamps), r.timestamp),
+	r.originalRating = coalesce(r.originalRating, r.rating),
+	r.previousRatings = CASE WHEN r.rating = $rating THEN r.previousRatings
+		ELSE (coalesce(r.previousRatings, []) + r.rating)[-$historySize..] END,
+	r.previousTimestamps = CASE WHEN r.rating = $rating THEN r.previousTimestamps
+		ELSE (coalesce(r.previousTimestamps, []) + r.timestamp)[-$historySize..] END
+SET r.rating = $rating, r.timestamp = timestamp(), r.updatedAt = timestamp()
+
+RETURN m { .*, rating: r.rating } AS movie
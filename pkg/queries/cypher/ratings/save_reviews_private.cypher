@@ -0,0 +1,5 @@
+// version: 2
+
+MATCH (u:User {userId: $userId})
+SET u.reviewsPrivate = $private, u.updatedAt = timestamp()
+RETURN u.reviewsPrivate AS private
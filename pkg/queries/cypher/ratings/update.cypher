@@ -0,0 +1,13 @@
+// version: 2
+
+MATCH (u:User {userId: $userId})-[r:RATED]->(m:Movie {tmdbId: $movieId})
+
+SET r.createdAt = coalesce(r.createdAt, head(r.previousTimestamps), r.timestamp),
+	r.originalRating = coalesce(r.originalRating, r.rating),
+	r.previousRatings = CASE WHEN r.rating = $rating THEN r.previousRatings
+		ELSE (coalesce(r.previousRatings, []) + r.rating)[-$historySize..] END,
+	r.previousTimestamps = CASE WHEN r.rating = $rating THEN r.previousTimestamps
+		ELSE (coalesce(r.previousTimestamps, []) + r.timestamp)[-$historySize..] END
+SET r.rating = $rating, r.timestamp = timestamp(), r.updatedAt = timestamp()
+
+RETURN m { .*, rating: r.rating } AS movie
@@ -0,0 +1,20 @@
+// version: 2
+
+MATCH (u:User {userId: $userId})-[r:RATED]->(:Movie)<-[peerRating:RATED]-(peer:User)
+WHERE r.rating >= $minRating AND peerRating.rating >= $minRating
+WITH u, peer, count(*) AS shared
+ORDER BY shared DESC
+LIMIT $peers
+MATCH (peer)-[r:RATED]->(m:Movie)
+WHERE r.rating >= $minRating
+AND NOT (u)-[:RATED]->(m)
+AND NOT m.tmdbId IN $favorites
+AND none(warning IN [(m)-[:HAS_CONTENT_WARNING]->(w) | w.name] WHERE warning IN $excludedWarnings)
+WITH m, sum(shared) AS score
+RETURN m {
+	.*,
+	score: score,
+	favorite: false
+} AS movie
+ORDER BY score DESC
+LIMIT $limit
@@ -0,0 +1,25 @@
+// version: 1
+
+MATCH (u:User)-[e:RECOMMENDED {experiment: $experiment}]->(m:Movie)
+OPTIONAL MATCH (u)-[r:RATED]->(m)
+WHERE r.timestamp >= e.at
+OPTIONAL MATCH (u)-[f:HAS_FAVORITE]->(m)
+WHERE f.createdAt >= datetime({epochMillis: e.at})
+WITH e.strategy AS strategy,
+	count(DISTINCT u) AS users,
+	count(e) AS exposures,
+	count(r) AS ratings,
+	count(f) AS favorites,
+	count(CASE WHEN r IS NOT NULL OR f IS NOT NULL THEN 1 END) AS conversions,
+	avg(r.rating) AS averageRating
+RETURN {
+	strategy: strategy,
+	users: users,
+	exposures: exposures,
+	ratings: ratings,
+	favorites: favorites,
+	conversions: conversions,
+	conversionRate: toFloat(conversions) / exposures,
+	averageRating: averageRating
+} AS comparison
+ORDER BY comparison.strategy
@@ -0,0 +1,15 @@
+// version: 2
+
+MATCH (u:User {userId: $userId})-[r:RATED]->(rated:Movie)
+WHERE r.rating >= $minRating
+MATCH (rated)-[:IN_GENRE|ACTED_IN|DIRECTED]-(feature)-[:IN_GENRE|ACTED_IN|DIRECTED]-(m:Movie)
+WHERE NOT (u)-[:RATED]->(m)
+AND none(warning IN [(m)-[:HAS_CONTENT_WARNING]->(w) | w.name] WHERE warning IN $excludedWarnings)
+WITH m, count(*) AS score
+RETURN m {
+	.*,
+	score: score,
+	favorite: false
+} AS movie
+ORDER BY score DESC
+LIMIT $limit
@@ -0,0 +1,16 @@
+// version: 2
+
+MATCH (u:User {userId: $userId})-[s:SIMILAR]->(peer:User)
+MATCH (peer)-[r:RATED]->(m:Movie)
+WHERE r.rating >= $minRating
+AND NOT (u)-[:RATED]->(m)
+AND NOT m.tmdbId IN $favorites
+AND none(warning IN [(m)-[:HAS_CONTENT_WARNING]->(w) | w.name] WHERE warning IN $excludedWarnings)
+WITH m, sum(s.score * r.rating) AS score
+RETURN m {
+	.*,
+	score: score,
+	favorite: false
+} AS movie
+ORDER BY score DESC
+LIMIT $limit
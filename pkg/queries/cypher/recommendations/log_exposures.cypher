@@ -0,0 +1,8 @@
+// version: 1
+
+MATCH (u:User {userId: $userId})
+UNWIND $movieIds AS movieId
+MATCH (m:Movie {tmdbId: movieId})
+MERGE (u)-[e:RECOMMENDED]->(m)
+SET e.experiment = $experiment, e.strategy = $strategy, e.at = timestamp()
+RETURN count(e) AS logged
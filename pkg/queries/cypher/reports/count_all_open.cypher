@@ -0,0 +1,4 @@
+// version: 1
+
+MATCH (:User)-[:REPORTED]->(r:Report {status: 'open'})-[:REPORTS]->(:Movie)
+RETURN count(r) AS total
@@ -0,0 +1,16 @@
+// version: 1
+
+MATCH (u:User {userId: $userId})
+MATCH (m:Movie {tmdbId: $movieId})
+MERGE (u)-[:REPORTED]->(r:Report {status: 'open', reason: $reason})-[:REPORTS]->(m)
+ON CREATE SET r.id = randomUuid(), r.createdAt = timestamp()
+SET r.comment = $comment, r.duplicateOf = $duplicateOf
+RETURN r {
+	.id,
+	.reason,
+	.comment,
+	.duplicateOf,
+	.status,
+	.createdAt,
+	movie: m { .tmdbId, .title, .poster }
+} AS report
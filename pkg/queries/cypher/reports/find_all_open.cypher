@@ -0,0 +1,16 @@
+// version: 1
+
+MATCH (u:User)-[:REPORTED]->(r:Report {status: 'open'})-[:REPORTS]->(m:Movie)
+RETURN r {
+	.id,
+	.reason,
+	.comment,
+	.duplicateOf,
+	.status,
+	.createdAt,
+	movie: m { .tmdbId, .title, .poster, .released },
+	reporter: u { .userId, .name }
+} AS report
+ORDER BY r.createdAt
+SKIP $skip
+LIMIT $limit
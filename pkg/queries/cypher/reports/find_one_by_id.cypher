@@ -0,0 +1,14 @@
+// version: 1
+
+MATCH (u:User)-[:REPORTED]->(r:Report {id: $id})-[:REPORTS]->(m:Movie)
+RETURN r {
+	.id,
+	.reason,
+	.comment,
+	.duplicateOf,
+	.status,
+	.createdAt,
+	.resolvedAt,
+	movie: m { .tmdbId, .title, .poster, .released },
+	reporter: u { .userId, .name }
+} AS report
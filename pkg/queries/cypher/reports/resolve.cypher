@@ -0,0 +1,25 @@
+// version: 1
+
+MATCH (u:User)-[:REPORTED]->(r:Report {id: $id, status: 'open'})-[:REPORTS]->(m:Movie)
+SET r.status = $status, r.resolvedAt = timestamp(), r.resolvedBy = $userId
+CREATE (u)-[:HAS_NOTIFICATION]->(:Notification {
+	id: randomUuid(),
+	type: 'moderation',
+	message: CASE $status
+		WHEN 'fixed' THEN 'Thanks, your report about ' + m.title + ' was fixed'
+		ELSE 'Your report about ' + m.title + ' was reviewed and dismissed'
+	END,
+	read: false,
+	createdAt: timestamp()
+})-[:ABOUT]->(m)
+RETURN r {
+	.id,
+	.reason,
+	.comment,
+	.duplicateOf,
+	.status,
+	.createdAt,
+	.resolvedAt,
+	movie: m { .tmdbId, .title, .poster, .released },
+	reporter: u { .userId, .name }
+} AS report
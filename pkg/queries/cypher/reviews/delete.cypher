@@ -0,0 +1,9 @@
+// version: 2
+
+MATCH (u:User {userId: $userId})-[r:RATED]->(m:Movie {tmdbId: $movieId})
+WHERE r.review IS NOT NULL
+REMOVE r.review, r.reviewedAt, r.reviewEditedAt
+SET r.createdAt = coalesce(r.createdAt, head(r.previousTimestamps), r.timestamp),
+	r.updatedAt = timestamp()
+
+RETURN m { .tmdbId, .title, .poster } AS movie
@@ -0,0 +1,15 @@
+// version: 1
+
+MATCH (u:User)-[r:RATED]->(m:Movie {tmdbId: $id})
+WHERE r.review IS NOT NULL
+AND NOT (:User {userId: $userId})-[:BLOCKS]->(u)
+RETURN r {
+	.rating,
+	text: r.review,
+	.reviewedAt,
+	editedAt: r.reviewEditedAt,
+	helpfulness: coalesce(r.helpfulCount, 0),
+	user: u { .id, .name, .avatarUrl }
+} AS review
+ORDER BY r.reviewedAt >= $since DESC, coalesce(r.helpfulCount, 0) DESC, r.reviewedAt DESC
+LIMIT 1
@@ -0,0 +1,14 @@
+// version: 1
+
+MATCH (u:User)-[r:RATED]->(m:Movie {tmdbId: $id})
+WHERE r.review IS NOT NULL
+AND NOT (:User {userId: $userId})-[:BLOCKS]->(u)
+RETURN r {
+	.rating,
+	text: r.review,
+	.reviewedAt,
+	editedAt: r.reviewEditedAt,
+	user: u { .id, .name, .avatarUrl }
+} AS review
+ORDER BY r.reviewedAt DESC
+LIMIT $limit
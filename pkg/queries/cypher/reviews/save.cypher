@@ -0,0 +1,18 @@
+// version: 2
+
+MATCH (u:User {userId: $userId})-[r:RATED]->(m:Movie {tmdbId: $movieId})
+SET r.reviewEditedAt = CASE WHEN r.review IS NULL THEN null ELSE timestamp() END,
+	r.reviewedAt = coalesce(r.reviewedAt, timestamp()),
+	r.review = $text,
+	r.createdAt = coalesce(r.createdAt, head(r.previousTimestamps), r.timestamp),
+	r.updatedAt = timestamp()
+
+RETURN r {
+	.rating,
+	text: r.review,
+	.reviewedAt,
+	editedAt: r.reviewEditedAt,
+	.createdAt,
+	.updatedAt,
+	movie: m { .tmdbId, .title, .poster }
+} AS review
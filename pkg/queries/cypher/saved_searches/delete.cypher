@@ -0,0 +1,6 @@
+// version: 2
+
+MATCH (u:User {userId: $userId})-[:SAVED_SEARCH]->(s:SavedSearch {id: $id})
+WITH s, s { .id, .name, .genre, .minRating, .fromYear, .toYear, .createdAt, .updatedAt } AS search
+DETACH DELETE s
+RETURN search
@@ -0,0 +1,5 @@
+// version: 2
+
+MATCH (u:User {userId: $userId})-[:SAVED_SEARCH]->(s:SavedSearch)
+RETURN s { .id, .name, .genre, .minRating, .fromYear, .toYear, .createdAt, .updatedAt } AS search
+ORDER BY s.createdAt DESC
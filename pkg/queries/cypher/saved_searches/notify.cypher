@@ -0,0 +1,28 @@
+// version: 2
+// default released: released
+
+MATCH (u:User)-[:SAVED_SEARCH]->(s:SavedSearch)
+WHERE s.checkedAt < $today
+WITH u, s
+ORDER BY s.id
+LIMIT $limit
+CALL {
+	WITH u, s
+	MATCH (m:Movie)
+	WHERE s.checkedAt < m.`{{released}}` <= $today
+	AND (s.genre IS NULL OR (m)-[:IN_GENRE]->(:Genre {name: s.genre}))
+	AND (s.minRating IS NULL OR m.imdbRating >= s.minRating)
+	AND (s.fromYear IS NULL OR toInteger(left(m.`{{released}}`, 4)) >= s.fromYear)
+	AND (s.toYear IS NULL OR toInteger(left(m.`{{released}}`, 4)) <= s.toYear)
+	CREATE (u)-[:HAS_NOTIFICATION]->(n:Notification {
+		id: randomUuid(),
+		type: 'saved_search',
+		message: 'New match for your saved search ' + s.name + ': ' + m.title,
+		searchId: s.id,
+		read: false,
+		createdAt: timestamp()
+	})-[:ABOUT]->(m)
+	RETURN count(n) AS notified
+}
+SET s.checkedAt = $today
+RETURN count(s) AS searches, sum(notified) AS notifications
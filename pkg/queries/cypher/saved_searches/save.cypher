@@ -0,0 +1,15 @@
+// version: 2
+
+MATCH (u:User {userId: $userId})
+CREATE (u)-[:SAVED_SEARCH]->(s:SavedSearch {
+	id: randomUuid(),
+	name: $name,
+	genre: $genre,
+	minRating: $minRating,
+	fromYear: $fromYear,
+	toYear: $toYear,
+	createdAt: timestamp(),
+	updatedAt: timestamp(),
+	checkedAt: $today
+})
+RETURN s { .id, .name, .genre, .minRating, .fromYear, .toYear, .createdAt, .updatedAt } AS search
@@ -0,0 +1,14 @@
+// version: 1
+
+MATCH (t:SearchTerm)<-[:SEARCHED_FOR]-(s:Search {results: 0})
+WHERE s.createdAt >= $since
+WITH t, count(s) AS searches, count(DISTINCT s.userHash) AS users, max(s.createdAt) AS lastSearchedAt
+ORDER BY searches DESC, t.term
+LIMIT $limit
+RETURN t {
+	.list,
+	.term,
+	searches: searches,
+	users: users,
+	lastSearchedAt: lastSearchedAt
+} AS search
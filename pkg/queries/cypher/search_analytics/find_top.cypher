@@ -0,0 +1,19 @@
+// version: 1
+
+MATCH (t:SearchTerm)<-[:SEARCHED_FOR]-(s:Search)
+WHERE s.createdAt >= $since
+WITH t,
+	count(s) AS searches,
+	count(DISTINCT s.userHash) AS users,
+	avg(s.results) AS averageResults,
+	sum(CASE WHEN s.results = 0 THEN 1 ELSE 0 END) AS zeroResults
+ORDER BY searches DESC, t.term
+LIMIT $limit
+RETURN t {
+	.list,
+	.term,
+	searches: searches,
+	users: users,
+	averageResults: averageResults,
+	zeroResults: zeroResults
+} AS search
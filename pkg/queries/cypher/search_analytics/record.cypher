@@ -0,0 +1,8 @@
+// version: 1
+
+MERGE (t:SearchTerm {list: $list, term: $term})
+CREATE (t)<-[:SEARCHED_FOR]-(:Search {
+	userHash: $userHash,
+	results: $results,
+	createdAt: timestamp()
+})
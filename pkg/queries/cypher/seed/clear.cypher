@@ -0,0 +1,6 @@
+// version: 1
+
+MATCH (n) WHERE NOT n:SchemaVersion
+WITH n LIMIT $batchSize
+DETACH DELETE n
+RETURN count(*) AS deleted
@@ -0,0 +1,6 @@
+// version: 1
+
+UNWIND $rows AS row
+MERGE (g:Genre {name: row.name})
+SET g += row.properties
+RETURN count(g) AS count
@@ -0,0 +1,19 @@
+// version: 2
+
+UNWIND $rows AS row
+MERGE (m:Movie {tmdbId: row.tmdbId})
+SET m += row.properties
+FOREACH (name IN row.genres |
+	MERGE (g:Genre {name: name})
+	MERGE (m)-[:IN_GENRE]->(g)
+)
+FOREACH (actor IN row.actors |
+	MERGE (p:Person {tmdbId: actor.tmdbId})
+	MERGE (p)-[r:ACTED_IN]->(m)
+	SET r.role = actor.role
+)
+FOREACH (director IN row.directors |
+	MERGE (p:Person {tmdbId: director.tmdbId})
+	MERGE (p)-[:DIRECTED]->(m)
+)
+RETURN count(m) AS count
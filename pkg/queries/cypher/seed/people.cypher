@@ -0,0 +1,6 @@
+// version: 2
+
+UNWIND $rows AS row
+MERGE (p:Person {tmdbId: row.tmdbId})
+SET p += row.properties
+RETURN count(p) AS count
@@ -0,0 +1,9 @@
+// version: 1
+
+UNWIND $rows AS row
+MATCH (u:User {userId: row.userId})
+MATCH (m:Movie {tmdbId: row.movieId})
+MERGE (u)-[r:RATED]->(m)
+ON CREATE SET r.createdAt = row.timestamp, r.originalRating = row.rating
+SET r.rating = row.rating, r.timestamp = row.timestamp
+RETURN count(r) AS count
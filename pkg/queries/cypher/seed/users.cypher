@@ -0,0 +1,10 @@
+// version: 1
+
+UNWIND $rows AS row
+MERGE (u:User {userId: row.userId})
+ON CREATE SET u.createdAt = timestamp()
+SET u.email = row.email,
+	u.name = row.name,
+	u.password = row.password,
+	u.updatedAt = timestamp()
+RETURN count(u) AS count
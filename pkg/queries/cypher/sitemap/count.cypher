@@ -0,0 +1,6 @@
+// version: 1
+// default label: Movie
+
+MATCH (n:`{{label}}`)
+WHERE n.tmdbId IS NOT NULL
+RETURN count(n) AS count
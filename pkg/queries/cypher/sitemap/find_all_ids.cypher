@@ -0,0 +1,9 @@
+// version: 1
+// default label: Movie
+
+MATCH (n:`{{label}}`)
+WHERE n.tmdbId IS NOT NULL
+RETURN n.tmdbId AS id
+ORDER BY n.tmdbId ASC
+SKIP $skip
+LIMIT $limit
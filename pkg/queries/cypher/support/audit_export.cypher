@@ -0,0 +1,18 @@
+// version: 1
+
+CREATE (a:AuditEntry {
+	id: randomUuid(),
+	action: 'users.export',
+	userId: $adminId,
+	subject: $userId,
+	redacted: $redacted,
+	createdAt: timestamp()
+})
+RETURN a {
+	.id,
+	.action,
+	.userId,
+	.subject,
+	.redacted,
+	.createdAt
+} AS entry
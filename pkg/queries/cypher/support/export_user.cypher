@@ -0,0 +1,23 @@
+// version: 1
+
+MATCH (u:User {userId: $userId})
+RETURN {
+	user: u { .* },
+	favorites: [ (u)-[f:HAS_FAVORITE]->(m:Movie) | {
+		movie: m { .tmdbId, .title },
+		createdAt: f.createdAt
+	} ],
+	ratings: [ (u)-[r:RATED]->(m:Movie) | r {
+		.*,
+		movie: m { .tmdbId, .title }
+	} ],
+	follows: [ (u)-[f:FOLLOWS]->(followed:User) | {
+		user: followed { .userId, .name, .email },
+		createdAt: f.createdAt
+	} ],
+	followers: [ (follower:User)-[f:FOLLOWS]->(u) | {
+		user: follower { .userId, .name, .email },
+		createdAt: f.createdAt
+	} ],
+	blocks: [ (u)-[:BLOCKS]->(blocked:User) | blocked { .userId, .name, .email } ]
+} AS export
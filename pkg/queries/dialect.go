@@ -0,0 +1,57 @@
+package queries
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Dialect identifies the Cypher flavour understood by a Neo4j server version
+type Dialect string
+
+const (
+	// V4 is the dialect of Neo4j 4.x, where `size()` accepts pattern expressions
+	V4 Dialect = "v4"
+	// V5 is the dialect of Neo4j 5.x, where pattern expressions must be counted with `COUNT {}` subqueries
+	V5 Dialect = "v5"
+)
+
+var dialects = []Dialect{V4, V5}
+
+// DetectDialect queries `dbms.components` to find out the dialect the server speaks
+func DetectDialect(ctx context.Context, driver neo4j.DriverWithContext) (_ Dialect, err error) {
+	session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.Run(ctx, `
+		CALL dbms.components() YIELD name, versions
+		WHERE name = 'Neo4j Kernel'
+		RETURN versions[0] AS version`, nil)
+	if err != nil {
+		return "", err
+	}
+	record, err := result.Single(ctx)
+	if err != nil {
+		return "", err
+	}
+	version, _ := record.Get("version")
+	return dialectOf(version.(string))
+}
+
+func dialectOf(version string) (Dialect, error) {
+	major, err := strconv.Atoi(strings.SplitN(version, ".", 2)[0])
+	if err != nil {
+		return "", fmt.Errorf("unsupported Neo4j version %q", version)
+	}
+	if major < 5 {
+		return V4, nil
+	}
+	return V5, nil
+}
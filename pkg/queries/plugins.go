@@ -0,0 +1,59 @@
+package queries
+
+import (
+	"context"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Gds is the plugin name of the Graph Data Science library, for statements declaring
+// `// requires: gds`
+const Gds = "gds"
+
+// VectorIndexes is the requirement of the statements querying vector indexes, built in
+// from Neo4j 5.11, for statements declaring `// requires: vector`
+const VectorIndexes = "vector"
+
+// DetectGds reports whether the Graph Data Science library is installed on the server,
+// with the `gds.similarity.jaccard` function the GDS statements rely on.
+// Servers which cannot list their functions are considered as lacking it.
+func DetectGds(ctx context.Context, driver neo4j.DriverWithContext) (bool, error) {
+	return detect(ctx, driver, `
+		SHOW FUNCTIONS YIELD name
+		WHERE name = 'gds.similarity.jaccard'
+		RETURN count(*) > 0 AS available`)
+}
+
+// DetectVectorIndexes reports whether the server supports vector indexes, with the
+// `db.index.vector.queryNodes` procedure.
+// Servers which cannot list their procedures are considered as lacking it.
+func DetectVectorIndexes(ctx context.Context, driver neo4j.DriverWithContext) (bool, error) {
+	return detect(ctx, driver, `
+		SHOW PROCEDURES YIELD name
+		WHERE name = 'db.index.vector.queryNodes'
+		RETURN count(*) > 0 AS available`)
+}
+
+// detect runs the query returning whether a feature is `available`, a Neo4jError
+// meaning it is not
+func detect(ctx context.Context, driver neo4j.DriverWithContext, query string) (_ bool, err error) {
+	session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.Run(ctx, query, nil)
+	if err == nil {
+		var record *neo4j.Record
+		if record, err = result.Single(ctx); err == nil {
+			available, _ := record.Get("available")
+			return available == true, nil
+		}
+	}
+	if _, ok := err.(*neo4j.Neo4jError); ok {
+		return false, nil
+	}
+	return false, err
+}
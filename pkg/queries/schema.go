@@ -0,0 +1,84 @@
+package queries
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// SchemaRequirement is an index, or a uniqueness constraint, the statements rely on
+type SchemaRequirement struct {
+	Label    string
+	Property string
+	Unique   bool
+}
+
+func (sr SchemaRequirement) String() string {
+	if sr.Unique {
+		return fmt.Sprintf("uniqueness constraint on :%s(%s)", sr.Label, sr.Property)
+	}
+	return fmt.Sprintf("index on :%s(%s)", sr.Label, sr.Property)
+}
+
+// RequiredSchema lists the indexes and constraints the statements of the catalog rely on
+var RequiredSchema = []SchemaRequirement{
+	{Label: "User", Property: "email", Unique: true},
+	{Label: "User", Property: "userId"},
+	{Label: "Movie", Property: "tmdbId"},
+	{Label: "Person", Property: "tmdbId"},
+	{Label: "Genre", Property: "name"},
+}
+
+// MissingSchema returns the RequiredSchema entries the target database lacks.
+// Uniqueness constraints also satisfy index requirements, as they are backed by an index.
+func MissingSchema(ctx context.Context, driver neo4j.DriverWithContext) (_ []SchemaRequirement, err error) {
+	session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	indexes, err := schemaKeys(ctx, session, "SHOW INDEXES YIELD labelsOrTypes, properties")
+	if err != nil {
+		return nil, err
+	}
+	constraints, err := schemaKeys(ctx, session,
+		"SHOW CONSTRAINTS YIELD labelsOrTypes, properties, type WHERE type IN ['UNIQUENESS', 'NODE_KEY'] RETURN labelsOrTypes, properties")
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []SchemaRequirement
+	for _, requirement := range RequiredSchema {
+		key := schemaKey(requirement.Label, requirement.Property)
+		if requirement.Unique && !constraints[key] || !requirement.Unique && !indexes[key] {
+			missing = append(missing, requirement)
+		}
+	}
+	return missing, nil
+}
+
+// schemaKeys returns the single label and property pairs listed by the SHOW query
+func schemaKeys(ctx context.Context, session neo4j.SessionWithContext, query string) (map[string]bool, error) {
+	result, err := session.Run(ctx, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	keys := map[string]bool{}
+	for result.Next(ctx) {
+		labels, _ := result.Record().Get("labelsOrTypes")
+		properties, _ := result.Record().Get("properties")
+		labelList, _ := labels.([]interface{})
+		propertyList, _ := properties.([]interface{})
+		if len(labelList) == 1 && len(propertyList) == 1 {
+			keys[schemaKey(fmt.Sprint(labelList[0]), fmt.Sprint(propertyList[0]))] = true
+		}
+	}
+	return keys, result.Err()
+}
+
+func schemaKey(label, property string) string {
+	return label + "." + property
+}
@@ -0,0 +1,58 @@
+package queries
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// VerificationError reports a statement the target database failed to plan
+type VerificationError struct {
+	Statement Statement
+	Err       error
+}
+
+func (ve VerificationError) Error() string {
+	return fmt.Sprintf("%s (v%d): %v", ve.Statement.Name, ve.Statement.Version, ve.Err)
+}
+
+// Verify runs EXPLAIN for every statement of the catalog, rendered with its default
+// fragments, against the target database.
+// EXPLAIN only plans the statements, nothing is executed.
+// The statements requiring the GDS plugin or vector indexes are skipped when the server
+// lacks them.
+func (c *Catalog) Verify(ctx context.Context, driver neo4j.DriverWithContext) (_ []VerificationError, err error) {
+	gds, err := DetectGds(ctx, driver)
+	if err != nil {
+		return nil, err
+	}
+	vector, err := DetectVectorIndexes(ctx, driver)
+	if err != nil {
+		return nil, err
+	}
+	session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	var failures []VerificationError
+	for _, statement := range c.All() {
+		if statement.Requires == Gds && !gds || statement.Requires == VectorIndexes && !vector {
+			continue
+		}
+		result, err := session.Run(ctx, "EXPLAIN "+statement.Render(nil), nil)
+		if err == nil {
+			_, err = result.Consume(ctx)
+		}
+		if err != nil {
+			if _, ok := err.(*neo4j.Neo4jError); !ok {
+				return nil, err
+			}
+			failures = append(failures, VerificationError{Statement: statement, Err: err})
+		}
+	}
+	return failures, nil
+}
@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"reflect"
@@ -12,19 +13,63 @@ import (
 	"github.com/neo4j-graphacademy/neoflix/pkg/services"
 )
 
+// maxAvatarUploadSize is the maximum size in bytes of avatar upload requests
+const maxAvatarUploadSize = 5 << 20
+
+const (
+	defaultRecommendations = 6
+	maxRecommendations     = 50
+)
+
+const (
+	defaultOnboardingCandidates = 24
+	maxOnboardingCandidates     = 100
+	maxOnboardingSelections     = 50
+)
+
 type accountRoutes struct {
-	ratings   services.RatingService
-	auth      services.AuthService
-	favorites services.FavoriteService
+	ratings         services.RatingService
+	auth            services.AuthService
+	favorites       services.FavoriteService
+	avatars         services.AvatarService
+	warnings        services.ContentWarningService
+	digests         services.DigestService
+	searches        services.SavedSearchService
+	notifications   services.NotificationService
+	recommendations services.RecommendationService
+	onboarding      services.OnboardingService
+	emailChanges    services.EmailChangeService
+	reviews         services.ReviewService
+	publicUrl       string
 }
 
 func NewAccountRoutes(ratings services.RatingService,
 	auth services.AuthService,
-	favorites services.FavoriteService) Routable {
+	favorites services.FavoriteService,
+	avatars services.AvatarService,
+	warnings services.ContentWarningService,
+	digests services.DigestService,
+	searches services.SavedSearchService,
+	notifications services.NotificationService,
+	recommendations services.RecommendationService,
+	onboarding services.OnboardingService,
+	emailChanges services.EmailChangeService,
+	reviews services.ReviewService,
+	publicUrl string) Routable {
 	return &accountRoutes{
-		ratings:   ratings,
-		auth:      auth,
-		favorites: favorites,
+		ratings:         ratings,
+		auth:            auth,
+		favorites:       favorites,
+		avatars:         avatars,
+		warnings:        warnings,
+		digests:         digests,
+		searches:        searches,
+		notifications:   notifications,
+		recommendations: recommendations,
+		onboarding:      onboarding,
+		emailChanges:    emailChanges,
+		reviews:         reviews,
+		publicUrl:       strings.TrimSuffix(publicUrl, "/"),
 	}
 }
 
@@ -35,7 +80,21 @@ func (a *accountRoutes) Register(server *http.ServeMux) {
 			switch {
 			case strings.HasPrefix(path, "ratings/"):
 				movieId := strings.TrimPrefix(path, "ratings/")
-				a.SaveRating(movieId, request, writer)
+				switch request.Method {
+				case "GET":
+					a.FindRating(movieId, request, writer)
+				case "PUT":
+					a.UpdateRating(movieId, request, writer)
+				case "DELETE":
+					a.DeleteRating(movieId, request, writer)
+				default:
+					a.SaveRating(movieId, request, writer)
+				}
+			case strings.HasPrefix(path, "favorites/") && strings.HasSuffix(path, "/toggle"):
+				movieId := strings.TrimSuffix(strings.TrimPrefix(path, "favorites/"), "/toggle")
+				if request.Method == "PUT" {
+					a.ToggleFavorite(movieId, request, writer)
+				}
 			case strings.HasPrefix(path, "favorites/"):
 				movieId := strings.TrimPrefix(path, "favorites/")
 				switch request.Method {
@@ -46,13 +105,75 @@ func (a *accountRoutes) Register(server *http.ServeMux) {
 				}
 
 			case path == "favorites":
-				page := paging.ParsePaging(request, paging.MovieSortableAttributes())
+				page, err := paging.ParsePaging(request, paging.MovieSortableAttributes())
+				if err != nil {
+					serializeError(writer, err)
+					return
+				}
 				a.FindAllFavorites(page, request, writer)
+			case path == "avatar" && request.Method == "POST":
+				a.SaveAvatar(request, writer)
+			case path == "reviews/privacy" && request.Method == "PUT":
+				a.SaveReviewsPrivacy(request, writer)
+			case strings.HasPrefix(path, "reviews/"):
+				movieId := strings.TrimPrefix(path, "reviews/")
+				switch request.Method {
+				case "PUT":
+					a.SaveReview(movieId, request, writer)
+				case "DELETE":
+					a.DeleteReview(movieId, request, writer)
+				}
+			case path == "settings":
+				if request.Method == "PUT" {
+					a.SaveSettings(request, writer)
+				} else {
+					a.FindSettings(request, writer)
+				}
+			case path == "searches":
+				if request.Method == "POST" {
+					a.SaveSearch(request, writer)
+				} else {
+					a.FindAllSearches(request, writer)
+				}
+			case strings.HasPrefix(path, "searches/") && request.Method == "DELETE":
+				a.DeleteSearch(strings.TrimPrefix(path, "searches/"), request, writer)
+			case path == "notifications":
+				a.FindAllNotifications(request, writer)
+			case path == "notifications/read" && request.Method == "PUT":
+				a.MarkAllNotificationsRead(request, writer)
+			case strings.HasPrefix(path, "notifications/") && strings.HasSuffix(path, "/read") && request.Method == "PUT":
+				id := strings.TrimSuffix(strings.TrimPrefix(path, "notifications/"), "/read")
+				a.MarkNotificationRead(id, request, writer)
+			case path == "recommendations":
+				a.FindAllRecommendations(request, writer)
+			case path == "onboarding":
+				if request.Method == "POST" {
+					a.SaveOnboarding(request, writer)
+				} else {
+					a.FindAllOnboardingCandidates(request, writer)
+				}
+			case path == "email" && request.Method == "POST":
+				a.RequestEmailChange(request, writer)
+			case path == "content-warnings":
+				if request.Method == "PUT" {
+					a.SaveExcludedContentWarnings(request, writer)
+				} else {
+					a.FindExcludedContentWarnings(request, writer)
+				}
 			}
 		})
 }
 
 func (a *accountRoutes) SaveRating(movieId string, request *http.Request, writer http.ResponseWriter) {
+	a.writeRating(a.ratings.Save, movieId, request, writer)
+}
+
+func (a *accountRoutes) UpdateRating(movieId string, request *http.Request, writer http.ResponseWriter) {
+	a.writeRating(a.ratings.Update, movieId, request, writer)
+}
+
+func (a *accountRoutes) writeRating(save func(context.Context, int, string, string) (services.Movie, error),
+	movieId string, request *http.Request, writer http.ResponseWriter) {
 	ratingData, err := ioutils.ReadJson(request.Body)
 	if err != nil {
 		serializeError(writer, err)
@@ -70,17 +191,37 @@ func (a *accountRoutes) SaveRating(movieId string, request *http.Request, writer
 		_, _ = writer.Write([]byte(err.Error()))
 		return
 	}
-	movie, err := a.ratings.Save(rating, movieId, userId)
+	movie, err := save(request.Context(), rating, movieId, userId)
 	serializeJson(writer, movie, err)
 }
 
+func (a *accountRoutes) DeleteRating(movieId string, request *http.Request, writer http.ResponseWriter) {
+	userId, err := extractUserId(request, a.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	movie, err := a.ratings.Delete(request.Context(), movieId, userId)
+	serializeJson(writer, movie, err)
+}
+
+func (a *accountRoutes) FindRating(movieId string, request *http.Request, writer http.ResponseWriter) {
+	userId, err := extractUserId(request, a.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	rating, err := a.ratings.FindOneByUserId(request.Context(), movieId, userId)
+	serializeJson(writer, rating, err)
+}
+
 func (a *accountRoutes) SaveFavorite(movieId string, request *http.Request, writer http.ResponseWriter) {
 	userId, err := extractUserId(request, a.auth)
 	if err != nil {
 		serializeError(writer, err)
 		return
 	}
-	movie, err := a.favorites.Save(userId, movieId)
+	movie, err := a.favorites.Save(request.Context(), userId, movieId)
 	serializeJson(writer, movie, err)
 }
 
@@ -90,8 +231,8 @@ func (a *accountRoutes) FindAllFavorites(page *paging.Paging, request *http.Requ
 		serializeError(writer, err)
 		return
 	}
-	movies, err := a.favorites.FindAllByUserId(userId, page)
-	serializeJson(writer, movies, err)
+	movies, err := a.favorites.FindAllByUserId(request.Context(), userId, page)
+	serializePage(writer, request, page, movies, err)
 }
 
 func (a *accountRoutes) DeleteFavorite(movieId string, request *http.Request, writer http.ResponseWriter) {
@@ -100,17 +241,334 @@ func (a *accountRoutes) DeleteFavorite(movieId string, request *http.Request, wr
 		serializeError(writer, err)
 		return
 	}
-	movie, err := a.favorites.Delete(userId, movieId)
+	movie, err := a.favorites.Delete(request.Context(), userId, movieId)
+	serializeJson(writer, movie, err)
+}
+
+func (a *accountRoutes) ToggleFavorite(movieId string, request *http.Request, writer http.ResponseWriter) {
+	userId, err := extractUserId(request, a.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	movie, err := a.favorites.Toggle(request.Context(), userId, movieId)
+	serializeJson(writer, movie, err)
+}
+
+func (a *accountRoutes) SaveAvatar(request *http.Request, writer http.ResponseWriter) {
+	userId, err := extractUserId(request, a.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	if userId == "" {
+		serializeError(writer, services.NewDomainError(401, "Authentication required", nil))
+		return
+	}
+	request.Body = http.MaxBytesReader(writer, request.Body, maxAvatarUploadSize)
+	file, _, err := request.FormFile("avatar")
+	if err != nil {
+		serializeError(writer, services.NewDomainError(400, "Missing or too large avatar file", nil))
+		return
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	user, err := a.avatars.Save(request.Context(), userId, file)
+	serializeJson(writer, user, err)
+}
+
+func (a *accountRoutes) FindExcludedContentWarnings(request *http.Request, writer http.ResponseWriter) {
+	userId, err := extractUserId(request, a.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	warnings, err := a.warnings.FindAllExcludedByUserId(request.Context(), userId)
+	serializeJson(writer, warnings, err)
+}
+
+func (a *accountRoutes) SaveExcludedContentWarnings(request *http.Request, writer http.ResponseWriter) {
+	userId, err := extractUserId(request, a.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	payload, err := ioutils.ReadJson(request.Body)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	var excluded []string
+	values, _ := payload["excluded"].([]interface{})
+	for _, value := range values {
+		if warning, ok := value.(string); ok {
+			excluded = append(excluded, warning)
+		}
+	}
+	warnings, err := a.warnings.SaveExcluded(request.Context(), userId, excluded)
+	serializeJson(writer, warnings, err)
+}
+
+func (a *accountRoutes) SaveReviewsPrivacy(request *http.Request, writer http.ResponseWriter) {
+	userId, err := extractUserId(request, a.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	if userId == "" {
+		serializeError(writer, services.NewDomainError(401, "Authentication required", nil))
+		return
+	}
+	payload, err := ioutils.ReadJson(request.Body)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	private, _ := payload["private"].(bool)
+	saved, err := a.ratings.SaveReviewsPrivate(request.Context(), userId, private)
+	serializeJson(writer, map[string]interface{}{"private": saved}, err)
+}
+
+// SaveReview posts or edits the `text` of the review of a movie the user rated
+func (a *accountRoutes) SaveReview(movieId string, request *http.Request, writer http.ResponseWriter) {
+	userId, err := extractUserId(request, a.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	if userId == "" {
+		serializeError(writer, services.NewDomainError(401, "Authentication required", nil))
+		return
+	}
+	payload, err := ioutils.ReadJson(request.Body)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	text, _ := payload["text"].(string)
+	review, err := a.reviews.Save(request.Context(), movieId, userId, text)
+	serializeJson(writer, review, err)
+}
+
+func (a *accountRoutes) DeleteReview(movieId string, request *http.Request, writer http.ResponseWriter) {
+	userId, err := extractUserId(request, a.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	if userId == "" {
+		serializeError(writer, services.NewDomainError(401, "Authentication required", nil))
+		return
+	}
+	movie, err := a.reviews.Delete(request.Context(), movieId, userId)
 	serializeJson(writer, movie, err)
 }
 
-func extractUserId(request *http.Request, auth services.AuthService) (string, error) {
-	bearer := strings.TrimPrefix(request.Header.Get("Authorization"), "Bearer ")
-	// FIXME remove once frontend bug fixed
-	if bearer == "undefined" {
-		bearer = ""
+func (a *accountRoutes) FindSettings(request *http.Request, writer http.ResponseWriter) {
+	userId, err := extractUserId(request, a.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
 	}
-	return auth.ExtractUserId(bearer)
+	dailyDigest, err := a.digests.FindOptIn(request.Context(), userId)
+	serializeJson(writer, map[string]interface{}{"dailyDigest": dailyDigest}, err)
+}
+
+func (a *accountRoutes) SaveSettings(request *http.Request, writer http.ResponseWriter) {
+	userId, err := extractUserId(request, a.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	payload, err := ioutils.ReadJson(request.Body)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	optIn, _ := payload["dailyDigest"].(bool)
+	dailyDigest, err := a.digests.SaveOptIn(request.Context(), userId, optIn)
+	serializeJson(writer, map[string]interface{}{"dailyDigest": dailyDigest}, err)
+}
+
+func (a *accountRoutes) FindAllSearches(request *http.Request, writer http.ResponseWriter) {
+	userId, err := extractUserId(request, a.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	searches, err := a.searches.FindAllByUserId(request.Context(), userId)
+	serializeJson(writer, searches, err)
+}
+
+func (a *accountRoutes) SaveSearch(request *http.Request, writer http.ResponseWriter) {
+	userId, err := extractUserId(request, a.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	if userId == "" {
+		serializeError(writer, services.NewDomainError(401, "Authentication required", nil))
+		return
+	}
+	payload, err := ioutils.ReadJson(request.Body)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	filter := services.SearchFilter{}
+	filter.Name, _ = payload["name"].(string)
+	filter.Genre, _ = payload["genre"].(string)
+	filter.MinRating, _ = payload["minRating"].(float64)
+	if fromYear, ok := payload["fromYear"].(float64); ok {
+		filter.FromYear = int(fromYear)
+	}
+	if toYear, ok := payload["toYear"].(float64); ok {
+		filter.ToYear = int(toYear)
+	}
+	search, err := a.searches.Save(request.Context(), userId, filter)
+	serializeJson(writer, search, err)
+}
+
+func (a *accountRoutes) DeleteSearch(id string, request *http.Request, writer http.ResponseWriter) {
+	userId, err := extractUserId(request, a.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	search, err := a.searches.Delete(request.Context(), userId, id)
+	serializeJson(writer, search, err)
+}
+
+func (a *accountRoutes) FindAllNotifications(request *http.Request, writer http.ResponseWriter) {
+	page, err := paging.ParsePaging(request, paging.NotificationSortableAttributes())
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	userId, err := extractUserId(request, a.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	notifications, err := a.notifications.FindAllByUserId(request.Context(), userId, page)
+	serializePage(writer, request, page, notifications, err)
+}
+
+func (a *accountRoutes) MarkNotificationRead(id string, request *http.Request, writer http.ResponseWriter) {
+	userId, err := extractUserId(request, a.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	notification, err := a.notifications.MarkRead(request.Context(), userId, id)
+	serializeJson(writer, notification, err)
+}
+
+func (a *accountRoutes) MarkAllNotificationsRead(request *http.Request, writer http.ResponseWriter) {
+	userId, err := extractUserId(request, a.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	updated, err := a.notifications.MarkAllRead(request.Context(), userId)
+	serializeJson(writer, map[string]interface{}{"updated": updated}, err)
+}
+
+// checkPagingQuota applies the anonymous paging quota unless the request is authenticated
+func checkPagingQuota(page *paging.Paging, request *http.Request, auth services.AuthService) error {
+	userId, err := extractUserId(request, auth)
+	if err != nil {
+		return err
+	}
+	return page.CheckQuota(userId != "")
+}
+
+// FindAllRecommendations returns up to `limit` movies recommended to the current user,
+// 6 by default
+func (a *accountRoutes) FindAllRecommendations(request *http.Request, writer http.ResponseWriter) {
+	userId, err := extractUserId(request, a.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	if userId == "" {
+		serializeError(writer, services.NewDomainError(401, "Authentication required", nil))
+		return
+	}
+	limit, _ := strconv.Atoi(request.URL.Query().Get("limit"))
+	if limit <= 0 {
+		limit = defaultRecommendations
+	}
+	if limit > maxRecommendations {
+		limit = maxRecommendations
+	}
+	movies, err := a.recommendations.FindAllByUserId(request.Context(), userId, limit)
+	serializeJson(writer, movies, err)
+}
+
+// FindAllOnboardingCandidates returns up to `limit` well-known movies, 24 by default,
+// for the current user to pick the ones they like from
+func (a *accountRoutes) FindAllOnboardingCandidates(request *http.Request, writer http.ResponseWriter) {
+	userId, err := extractUserId(request, a.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	if userId == "" {
+		serializeError(writer, services.NewDomainError(401, "Authentication required", nil))
+		return
+	}
+	limit, _ := strconv.Atoi(request.URL.Query().Get("limit"))
+	if limit <= 0 {
+		limit = defaultOnboardingCandidates
+	}
+	if limit > maxOnboardingCandidates {
+		limit = maxOnboardingCandidates
+	}
+	movies, err := a.onboarding.FindAllCandidates(request.Context(), userId, limit)
+	serializeJson(writer, movies, err)
+}
+
+// SaveOnboarding records the movies listed in the `movieIds` field of the body as liked
+// by the current user, and returns up to `limit` movies recommended from then on,
+// 6 by default
+func (a *accountRoutes) SaveOnboarding(request *http.Request, writer http.ResponseWriter) {
+	payload, err := ioutils.ReadJson(request.Body)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	userId, err := extractUserId(request, a.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	if userId == "" {
+		serializeError(writer, services.NewDomainError(401, "Authentication required", nil))
+		return
+	}
+	rawIds, _ := payload["movieIds"].([]interface{})
+	if len(rawIds) == 0 || len(rawIds) > maxOnboardingSelections {
+		serializeError(writer, services.NewDomainError(400,
+			fmt.Sprintf("movieIds must list between 1 and %d movie IDs", maxOnboardingSelections), nil))
+		return
+	}
+	movieIds := make([]string, 0, len(rawIds))
+	for _, rawId := range rawIds {
+		if id, ok := rawId.(string); ok {
+			movieIds = append(movieIds, id)
+		}
+	}
+	limit, _ := strconv.Atoi(request.URL.Query().Get("limit"))
+	if limit <= 0 {
+		limit = defaultRecommendations
+	}
+	if limit > maxRecommendations {
+		limit = maxRecommendations
+	}
+	movies, err := a.onboarding.Save(request.Context(), userId, movieIds, limit)
+	serializeJson(writer, movies, err)
 }
 
 // FIXME remove once frontend bug fixed - rating should always be a number
@@ -126,3 +584,32 @@ func parseIntRating(rating interface{}) (int, error) {
 		reflect.TypeOf(rating),
 	)
 }
+
+// RequestEmailChange records the change of the email address of the User, to be
+// confirmed with the link sent to the new address
+func (a *accountRoutes) RequestEmailChange(request *http.Request, writer http.ResponseWriter) {
+	payload, err := ioutils.ReadJson(request.Body)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	userId, err := extractUserId(request, a.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	if userId == "" {
+		serializeError(writer, services.NewDomainError(401, "Authentication required", nil))
+		return
+	}
+	email, _ := payload["email"].(string)
+	password, _ := payload["password"].(string)
+	email = strings.TrimSpace(email)
+	if !strings.Contains(email, "@") {
+		serializeError(writer, services.NewDomainError(400, "A valid email address is required", nil))
+		return
+	}
+	change, err := a.emailChanges.Request(request.Context(), userId, password, email,
+		a.publicUrl+"/api/auth/email/confirm")
+	serializeJson(writer, change, err)
+}
@@ -0,0 +1,619 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/config"
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j-graphacademy/neoflix/pkg/routes/paging"
+	"github.com/neo4j-graphacademy/neoflix/pkg/services"
+)
+
+// maxRecomputedMovies bounds the number of movies whose aggregates are recomputed in a
+// single transaction
+const maxRecomputedMovies = 1000
+
+// exportFlushInterval is the number of movies written between flushes of an export
+const exportFlushInterval = 500
+
+type adminRoutes struct {
+	auth            services.AuthService
+	genres          services.GenreService
+	contentWarnings services.ContentWarningService
+	movies          services.MovieService
+	people          services.PeopleService
+	maintenance     services.MaintenanceService
+	ratingFlags     services.RatingFlagService
+	reports         services.ReportService
+	recommendations services.RecommendationService
+	dryRuns         services.DryRunService
+	searches        services.SearchAnalyticsService
+	caches          map[string]services.CachedService
+	retries         *services.RetryMetrics
+	support         services.SupportService
+	aggregateChecks *services.AggregateCheckMetrics
+	logger          *slog.Logger
+	runtime         *config.Runtime
+}
+
+func NewAdminRoutes(auth services.AuthService,
+	genres services.GenreService,
+	contentWarnings services.ContentWarningService,
+	movies services.MovieService,
+	people services.PeopleService,
+	maintenance services.MaintenanceService,
+	ratingFlags services.RatingFlagService,
+	reports services.ReportService,
+	recommendations services.RecommendationService,
+	dryRuns services.DryRunService,
+	searches services.SearchAnalyticsService,
+	caches map[string]services.CachedService,
+	retries *services.RetryMetrics,
+	support services.SupportService,
+	aggregateChecks *services.AggregateCheckMetrics,
+	logger *slog.Logger,
+	runtime *config.Runtime) Routable {
+	return &adminRoutes{
+		auth:            auth,
+		genres:          genres,
+		contentWarnings: contentWarnings,
+		movies:          movies,
+		people:          people,
+		maintenance:     maintenance,
+		ratingFlags:     ratingFlags,
+		reports:         reports,
+		recommendations: recommendations,
+		dryRuns:         dryRuns,
+		searches:        searches,
+		caches:          caches,
+		retries:         retries,
+		support:         support,
+		aggregateChecks: aggregateChecks,
+		logger:          logger,
+		runtime:         runtime,
+	}
+}
+
+func (a *adminRoutes) Register(server *http.ServeMux) {
+	server.HandleFunc("/api/admin/",
+		func(writer http.ResponseWriter, request *http.Request) {
+			userId, err := requireAdmin(request, a.auth)
+			if err != nil {
+				serializeError(writer, err)
+				return
+			}
+			path := strings.TrimPrefix(request.URL.Path, "/api/admin/")
+			switch {
+			case strings.HasPrefix(path, "movies/") && strings.Contains(path, "/content-warnings/"):
+				movieId, warning := splitPair(strings.TrimPrefix(path, "movies/"), "/content-warnings/")
+				switch request.Method {
+				case "PUT":
+					a.AddContentWarning(movieId, warning, request, writer)
+				case "DELETE":
+					a.RemoveContentWarning(movieId, warning, request, writer)
+				}
+			case path == "movies/export" && request.Method == "GET":
+				a.ExportMovies(request, writer)
+			case path == "movies/recompute" && request.Method == "POST":
+				a.RecomputeAllAggregates(request, writer)
+			case strings.HasPrefix(path, "movies/") && strings.HasSuffix(path, "/recompute") && request.Method == "POST":
+				movieId := strings.TrimSuffix(strings.TrimPrefix(path, "movies/"), "/recompute")
+				a.RecomputeAggregates(movieId, request, writer)
+			case strings.HasPrefix(path, "movies/") && strings.HasSuffix(path, "/release") && request.Method == "PUT":
+				movieId := strings.TrimSuffix(strings.TrimPrefix(path, "movies/"), "/release")
+				a.SaveRelease(movieId, request, writer)
+			case path == "genres/merge" && request.Method == "POST":
+				a.MergeGenres(userId, request, writer)
+			case strings.HasPrefix(path, "movies/") && strings.HasSuffix(path, "/box-office") && request.Method == "PUT":
+				movieId := strings.TrimSuffix(strings.TrimPrefix(path, "movies/"), "/box-office")
+				a.SaveBoxOffice(movieId, request, writer)
+			case strings.HasPrefix(path, "movies/") && strings.HasSuffix(path, "/aliases") && request.Method == "PUT":
+				movieId := strings.TrimSuffix(strings.TrimPrefix(path, "movies/"), "/aliases")
+				a.SaveMovieAliases(movieId, request, writer)
+			case strings.HasPrefix(path, "people/") && strings.HasSuffix(path, "/aliases") && request.Method == "PUT":
+				personId := strings.TrimSuffix(strings.TrimPrefix(path, "people/"), "/aliases")
+				a.SavePersonAliases(personId, request, writer)
+			case path == "rating-flags":
+				a.FindAllRatingFlags(request, writer)
+			case strings.HasPrefix(path, "rating-flags/") && strings.HasSuffix(path, "/resolve") && request.Method == "PUT":
+				id := strings.TrimSuffix(strings.TrimPrefix(path, "rating-flags/"), "/resolve")
+				a.ResolveRatingFlag(id, userId, request, writer)
+			case path == "reports":
+				a.FindAllReports(request, writer)
+			case strings.HasPrefix(path, "reports/") && strings.HasSuffix(path, "/resolve") && request.Method == "PUT":
+				id := strings.TrimSuffix(strings.TrimPrefix(path, "reports/"), "/resolve")
+				a.ResolveReport(id, userId, request, writer)
+			case path == "recommendations":
+				a.CompareRecommendationStrategies(request, writer)
+			case path == "searches":
+				a.FindSearchStatistics(request, writer)
+			case path == "caches":
+				if request.Method == "DELETE" {
+					a.BustCaches(writer)
+				} else {
+					a.FindCacheStats(writer)
+				}
+			case strings.HasPrefix(path, "users/") && strings.HasSuffix(path, "/export"):
+				id := strings.TrimSuffix(strings.TrimPrefix(path, "users/"), "/export")
+				a.ExportUser(id, userId, request, writer)
+			case path == "retries":
+				a.FindRetries(writer)
+			case path == "aggregates/checks":
+				a.FindAggregateChecks(writer)
+			case path == "config":
+				a.FindRuntimeConfig(writer)
+			case path == "maintenance":
+				if request.Method == "PUT" {
+					a.SaveMaintenance(request, writer)
+				} else {
+					a.FindMaintenance(request, writer)
+				}
+			}
+		})
+}
+
+func (a *adminRoutes) AddContentWarning(movieId, warning string, request *http.Request, writer http.ResponseWriter) {
+	warnings, err := a.contentWarnings.Add(request.Context(), movieId, warning)
+	serializeJson(writer, warnings, err)
+}
+
+func (a *adminRoutes) RemoveContentWarning(movieId, warning string, request *http.Request, writer http.ResponseWriter) {
+	warnings, err := a.contentWarnings.Remove(request.Context(), movieId, warning)
+	serializeJson(writer, warnings, err)
+}
+
+// SaveRelease sets the release date of a movie from the `released` field of the
+// body, formatted as YYYY-MM-DD, which also updates its Upcoming or Released status
+func (a *adminRoutes) SaveRelease(movieId string, request *http.Request, writer http.ResponseWriter) {
+	payload, err := ioutils.ReadJson(request.Body)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	rawReleased, _ := payload["released"].(string)
+	released, err := time.Parse("2006-01-02", rawReleased)
+	if err != nil {
+		serializeError(writer, services.NewDomainError(400, "released must be a YYYY-MM-DD date", map[string]interface{}{
+			"released": rawReleased,
+		}))
+		return
+	}
+	movie, err := a.movies.SaveRelease(request.Context(), movieId, released)
+	serializeJson(writer, movie, err)
+}
+
+// SaveBoxOffice sets the `budget` and `revenue` of a movie from the body, in US dollars.
+// Omitted fields are left unchanged.
+func (a *adminRoutes) SaveBoxOffice(movieId string, request *http.Request, writer http.ResponseWriter) {
+	payload, err := ioutils.ReadJson(request.Body)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	amounts := map[string]*int64{}
+	for _, field := range []string{"budget", "revenue"} {
+		value, found := payload[field]
+		if !found {
+			continue
+		}
+		amount, ok := value.(float64)
+		if !ok || amount < 0 || amount != float64(int64(amount)) {
+			serializeError(writer, services.NewDomainError(400, field+" must be a non-negative whole amount", map[string]interface{}{
+				field: value,
+			}))
+			return
+		}
+		dollars := int64(amount)
+		amounts[field] = &dollars
+	}
+	movie, err := a.movies.SaveBoxOffice(request.Context(), movieId, amounts["budget"], amounts["revenue"])
+	if err == nil {
+		// the cached movie lists sorted by revenue are outdated
+		a.bust("movies")
+	}
+	serializeJson(writer, movie, err)
+}
+
+// SaveMovieAliases replaces the alternate titles of a movie with the `aliases` listed
+// in the body, e.g. "Se7en" for "Seven"
+func (a *adminRoutes) SaveMovieAliases(movieId string, request *http.Request, writer http.ResponseWriter) {
+	aliases, err := readAliases(request)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	movie, err := a.movies.SaveAliases(request.Context(), movieId, aliases)
+	if err == nil {
+		// the cached searches may now match the movie
+		a.bust("movies")
+	}
+	serializeJson(writer, movie, err)
+}
+
+// SavePersonAliases replaces the alternate names of a person, such as stage names,
+// with the `aliases` listed in the body
+func (a *adminRoutes) SavePersonAliases(personId string, request *http.Request, writer http.ResponseWriter) {
+	aliases, err := readAliases(request)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	person, err := a.people.SaveAliases(request.Context(), personId, aliases)
+	serializeJson(writer, person, err)
+}
+
+func readAliases(request *http.Request) ([]string, error) {
+	payload, err := ioutils.ReadJson(request.Body)
+	if err != nil {
+		return nil, err
+	}
+	rawAliases, ok := payload["aliases"].([]interface{})
+	if !ok {
+		return nil, services.NewDomainError(400, "aliases must be a list of names", nil)
+	}
+	aliases := make([]string, 0, len(rawAliases))
+	for _, rawAlias := range rawAliases {
+		alias, ok := rawAlias.(string)
+		if !ok {
+			return nil, services.NewDomainError(400, "aliases must be a list of names", map[string]interface{}{
+				"alias": rawAlias,
+			})
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases, nil
+}
+
+// RecomputeAggregates recalculates the rating, favorite and popularity aggregates of a movie
+func (a *adminRoutes) RecomputeAggregates(movieId string, request *http.Request, writer http.ResponseWriter) {
+	a.runOperation(request, writer, func(ctx context.Context) (interface{}, error) {
+		movies, err := a.movies.RecomputeAggregates(ctx, []string{movieId}, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if len(movies) == 0 {
+			return nil, services.NewDomainError(404, "Movie not found", map[string]interface{}{"id": movieId})
+		}
+		return movies[0], nil
+	})
+}
+
+// RecomputeAllAggregates recalculates the aggregates of the movies listed in the `ids`
+// field of the body, up to maxRecomputedMovies at once
+func (a *adminRoutes) RecomputeAllAggregates(request *http.Request, writer http.ResponseWriter) {
+	payload, err := ioutils.ReadJson(request.Body)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	rawIds, _ := payload["ids"].([]interface{})
+	if len(rawIds) == 0 || len(rawIds) > maxRecomputedMovies {
+		serializeError(writer, services.NewDomainError(400,
+			fmt.Sprintf("ids must list between 1 and %d movie IDs", maxRecomputedMovies), nil))
+		return
+	}
+	ids := make([]string, 0, len(rawIds))
+	for _, rawId := range rawIds {
+		if id, ok := rawId.(string); ok {
+			ids = append(ids, id)
+		}
+	}
+	a.runOperation(request, writer, func(ctx context.Context) (interface{}, error) {
+		return a.movies.RecomputeAggregates(ctx, ids, time.Now())
+	})
+}
+
+// MergeGenres moves all the movies of the `from` genre of the body to the `into` genre,
+// and deletes the `from` genre, e.g. to merge "Sci-Fi" into "Science Fiction"
+func (a *adminRoutes) MergeGenres(userId string, request *http.Request, writer http.ResponseWriter) {
+	payload, err := ioutils.ReadJson(request.Body)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	from, _ := payload["from"].(string)
+	into, _ := payload["into"].(string)
+	if from == "" || into == "" {
+		serializeError(writer, services.NewDomainError(400, "from and into genres are required", nil))
+		return
+	}
+	// the cached movie lists by genre are outdated once merged
+	a.runOperation(request, writer, func(ctx context.Context) (interface{}, error) {
+		return a.genres.Merge(ctx, from, into, userId)
+	}, "movies")
+}
+
+// runOperation serializes the result of the mutating operation, and busts the caches
+// it outdates.
+// With ?dryRun=true, the operation is rolled back instead, and the response previews
+// its `result` and the `changes` it would have made.
+func (a *adminRoutes) runOperation(request *http.Request, writer http.ResponseWriter,
+	operation func(ctx context.Context) (interface{}, error), outdatedCaches ...string) {
+	preview, err := isDryRun(request)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	if preview {
+		result, err := a.dryRuns.Run(request.Context(), operation)
+		serializeJson(writer, result, err)
+		return
+	}
+	result, err := operation(request.Context())
+	if err == nil {
+		a.bust(outdatedCaches...)
+	}
+	serializeJson(writer, result, err)
+}
+
+func isDryRun(request *http.Request) (bool, error) {
+	value := request.URL.Query().Get("dryRun")
+	if value == "" {
+		return false, nil
+	}
+	dryRun, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, services.NewDomainError(400, "dryRun must be true or false", map[string]interface{}{
+			"dryRun": value,
+		})
+	}
+	return dryRun, nil
+}
+
+// FindSearchStatistics returns the `top` searches and the `failing` ones, which returned
+// no results, over the last `days` (30 by default), up to `limit` of each (20 by default)
+func (a *adminRoutes) FindSearchStatistics(request *http.Request, writer http.ResponseWriter) {
+	days := 30
+	if value, err := strconv.Atoi(request.URL.Query().Get("days")); err == nil && value > 0 {
+		days = value
+	}
+	limit := 20
+	if value, err := strconv.Atoi(request.URL.Query().Get("limit")); err == nil && value > 0 && value <= 100 {
+		limit = value
+	}
+	since := time.Now().AddDate(0, 0, -days)
+	top, err := a.searches.FindTop(request.Context(), since, limit)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	failing, err := a.searches.FindFailing(request.Context(), since, limit)
+	serializeJson(writer, map[string]interface{}{
+		"top":     top,
+		"failing": failing,
+	}, err)
+}
+
+// FindCacheStats returns the hits, misses and hit rate of each in-process cache
+// ExportUser returns the neighborhood of a user for support investigations, with the
+// comma-separated personal fields of the `redact` query parameter hidden, both email
+// and name by default, or none with `redact=none`
+func (a *adminRoutes) ExportUser(id, adminId string, request *http.Request, writer http.ResponseWriter) {
+	redacted := services.RedactableFields
+	if request.URL.Query().Has("redact") {
+		redacted = []string{}
+		if value := request.URL.Query().Get("redact"); value != "" && value != "none" {
+			redacted = strings.Split(value, ",")
+		}
+	}
+	export, err := a.support.ExportUser(request.Context(), id, adminId, redacted)
+	serializeJson(writer, export, err)
+}
+
+// ExportMovies streams all the movies as NDJSON, one movie per line, in the `sort` and
+// `order` of the query parameters, without loading them in memory.
+// Failures happening after the export started truncate the stream: they are logged, and
+// the stream then ends with an `{"error": ..., "exported": ...}` record, so that
+// consumers can tell a truncated export from a complete one.
+func (a *adminRoutes) ExportMovies(request *http.Request, writer http.ResponseWriter) {
+	page, err := paging.ParsePaging(request, paging.MovieSortableAttributes())
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	movies, err := a.movies.StreamAll(request.Context(), "", services.MovieStreamOptions{
+		Sort:  page.Sort(),
+		Order: page.Order(),
+	})
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	defer func() {
+		_ = movies.Close(request.Context())
+	}()
+
+	writer.Header().Set("Content-Type", "application/x-ndjson")
+	writer.WriteHeader(200)
+	encoder := json.NewEncoder(writer)
+	flusher, _ := writer.(http.Flusher)
+	count := 0
+	for movies.Next(request.Context()) {
+		if err := encoder.Encode(movies.Movie()); err != nil {
+			return
+		}
+		count++
+		if flusher != nil && count%exportFlushInterval == 0 {
+			flusher.Flush()
+		}
+	}
+	if err := movies.Err(); err != nil {
+		a.logger.Error("movie export truncated", "exported", count, "error", err)
+		_ = encoder.Encode(map[string]interface{}{
+			"error":    "export truncated",
+			"exported": count,
+		})
+	}
+}
+
+// FindRetries lists, per route, how many transactions the driver retried after
+// transient failures and the time it spent doing so, the most retried routes first
+func (a *adminRoutes) FindRetries(writer http.ResponseWriter) {
+	serializeJson(writer, a.retries.FindAll(), nil)
+}
+
+// FindAggregateChecks reports how many movies the aggregate check job found drifting
+// and repaired, along with the drifts of its last run which found some
+func (a *adminRoutes) FindAggregateChecks(writer http.ResponseWriter) {
+	serializeJson(writer, a.aggregateChecks.Stats(), nil)
+}
+
+// FindRuntimeConfig returns the configuration the instance runs with, secrets redacted:
+// its settings, along with the effective settings of the driver and the database name
+func (a *adminRoutes) FindRuntimeConfig(writer http.ResponseWriter) {
+	serializeJson(writer, a.runtime, nil)
+}
+
+func (a *adminRoutes) FindCacheStats(writer http.ResponseWriter) {
+	stats := map[string]interface{}{}
+	for name, cached := range a.caches {
+		cacheStats := cached.CacheStats()
+		stats[name] = map[string]interface{}{
+			"hits":      cacheStats.Hits,
+			"misses":    cacheStats.Misses,
+			"hitRate":   cacheStats.HitRate(),
+			"evictions": cacheStats.Evictions,
+		}
+	}
+	serializeJson(writer, stats, nil)
+}
+
+// BustCaches empties all the in-process caches, e.g. after editing the database directly
+func (a *adminRoutes) BustCaches(writer http.ResponseWriter) {
+	names := make([]string, 0, len(a.caches))
+	for name := range a.caches {
+		names = append(names, name)
+	}
+	a.bust(names...)
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func (a *adminRoutes) bust(names ...string) {
+	for _, name := range names {
+		if cached, found := a.caches[name]; found {
+			cached.Bust()
+		}
+	}
+}
+
+func (a *adminRoutes) FindMaintenance(request *http.Request, writer http.ResponseWriter) {
+	maintenance, err := a.maintenance.Find(request.Context())
+	serializeJson(writer, maintenance, err)
+}
+
+// SaveMaintenance toggles the read-only mode from the `readOnly` field of the body,
+// rejected writes being answered with its optional `message`
+func (a *adminRoutes) SaveMaintenance(request *http.Request, writer http.ResponseWriter) {
+	payload, err := ioutils.ReadJson(request.Body)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	readOnly, _ := payload["readOnly"].(bool)
+	message, _ := payload["message"].(string)
+	maintenance, err := a.maintenance.Save(request.Context(), readOnly, message)
+	serializeJson(writer, maintenance, err)
+}
+
+func (a *adminRoutes) FindAllRatingFlags(request *http.Request, writer http.ResponseWriter) {
+	page, err := paging.ParsePaging(request, paging.RatingFlagSortableAttributes())
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	flags, err := a.ratingFlags.FindAllOpen(request.Context(), page)
+	serializePage(writer, request, page, flags, err)
+}
+
+func (a *adminRoutes) ResolveRatingFlag(id, userId string, request *http.Request, writer http.ResponseWriter) {
+	flag, err := a.ratingFlags.Resolve(request.Context(), id, userId)
+	serializeJson(writer, flag, err)
+}
+
+// requireAdmin returns the ID of the authenticated user if they hold the admin role
+func (a *adminRoutes) FindAllReports(request *http.Request, writer http.ResponseWriter) {
+	page, err := paging.ParsePaging(request, paging.ReportSortableAttributes())
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	reports, err := a.reports.FindAllOpen(request.Context(), page)
+	serializePage(writer, request, page, reports, err)
+}
+
+// ResolveReport closes a report with the `status` field of the body, either fixed or
+// dismissed. Fixing a wrong_year report with a `released` date, formatted as YYYY-MM-DD,
+// first saves the release date of the reported movie, as SaveRelease does.
+func (a *adminRoutes) ResolveReport(id, userId string, request *http.Request, writer http.ResponseWriter) {
+	payload, err := ioutils.ReadJson(request.Body)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	status, _ := payload["status"].(string)
+	if rawReleased, found := payload["released"].(string); found && status == services.ReportFixed {
+		released, err := time.Parse("2006-01-02", rawReleased)
+		if err != nil {
+			serializeError(writer, services.NewDomainError(400, "released must be a YYYY-MM-DD date", map[string]interface{}{
+				"released": rawReleased,
+			}))
+			return
+		}
+		report, err := a.reports.FindOneById(request.Context(), id)
+		if err != nil {
+			serializeError(writer, err)
+			return
+		}
+		if report["reason"] != services.ReportWrongYear {
+			serializeError(writer, services.NewDomainError(400, "released only fixes wrong_year reports", map[string]interface{}{
+				"reason": report["reason"],
+			}))
+			return
+		}
+		movie, _ := report["movie"].(map[string]interface{})
+		movieId, _ := movie["tmdbId"].(string)
+		if _, err := a.movies.SaveRelease(request.Context(), movieId, released); err != nil {
+			serializeError(writer, err)
+			return
+		}
+	}
+	report, err := a.reports.Resolve(request.Context(), id, userId, status)
+	serializeJson(writer, report, err)
+}
+
+func (a *adminRoutes) CompareRecommendationStrategies(request *http.Request, writer http.ResponseWriter) {
+	comparisons, err := a.recommendations.CompareStrategies(request.Context())
+	serializeJson(writer, comparisons, err)
+}
+
+func requireAdmin(request *http.Request, auth services.AuthService) (string, error) {
+	userId, err := extractUserId(request, auth)
+	if err != nil {
+		return "", err
+	}
+	if userId == "" {
+		return "", services.NewDomainError(401, "Authentication required", nil)
+	}
+	admin, err := auth.IsAdmin(request.Context(), userId)
+	if err != nil {
+		return "", err
+	}
+	if !admin {
+		return "", services.NewDomainError(403, "Admin role required", nil)
+	}
+	return userId, nil
+}
+
+// splitPair splits the path around the separator, e.g. "1234/content-warnings/violence"
+// around "/content-warnings/" returns "1234" and "violence"
+func splitPair(path, separator string) (string, string) {
+	index := strings.Index(path, separator)
+	return path[:index], path[index+len(separator):]
+}
@@ -8,11 +8,12 @@ import (
 )
 
 type authRoutes struct {
-	auth services.AuthService
+	auth         services.AuthService
+	emailChanges services.EmailChangeService
 }
 
-func NewAuthRoutes(auth services.AuthService) Routable {
-	return &authRoutes{auth: auth}
+func NewAuthRoutes(auth services.AuthService, emailChanges services.EmailChangeService) Routable {
+	return &authRoutes{auth: auth, emailChanges: emailChanges}
 }
 
 func (a *authRoutes) Register(server *http.ServeMux) {
@@ -24,6 +25,8 @@ func (a *authRoutes) Register(server *http.ServeMux) {
 				a.Save(request, writer)
 			case strings.HasSuffix(path, "/login"):
 				a.Login(request, writer)
+			case strings.HasSuffix(path, "/email/confirm"):
+				a.ConfirmEmailChange(request, writer)
 			}
 		})
 }
@@ -34,7 +37,7 @@ func (a *authRoutes) Save(request *http.Request, writer http.ResponseWriter) {
 		serializeError(writer, err)
 		return
 	}
-	user, err := a.auth.Save(
+	user, err := a.auth.Save(request.Context(),
 		userData["email"].(string),
 		userData["password"].(string),
 		userData["name"].(string),
@@ -48,9 +51,16 @@ func (a *authRoutes) Login(request *http.Request, writer http.ResponseWriter) {
 		serializeError(writer, err)
 		return
 	}
-	user, err := a.auth.FindOneByEmailAndPassword(
+	user, err := a.auth.FindOneByEmailAndPassword(request.Context(),
 		userData["email"].(string),
 		userData["password"].(string),
 	)
 	serializeJson(writer, user, err)
 }
+
+// ConfirmEmailChange applies the email change matching the `token` parameter, as sent
+// to the new address of the User
+func (a *authRoutes) ConfirmEmailChange(request *http.Request, writer http.ResponseWriter) {
+	user, err := a.emailChanges.Confirm(request.Context(), request.URL.Query().Get("token"))
+	serializeJson(writer, user, err)
+}
@@ -0,0 +1,47 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/services"
+)
+
+// authentication is the outcome of the verification of the bearer token of a request
+type authentication struct {
+	userId string
+	err    error
+}
+
+type authenticationKey struct{}
+
+// WithAuthentication verifies the bearer token of every request once, before any route
+// runs, so that the routes and the other middlewares read the ID of the authenticated
+// user from the request context rather than verifying the token again.
+// Invalid tokens are reported by the routes requiring a user.
+func WithAuthentication(handler http.Handler, auth services.AuthService) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		userId, err := auth.ExtractUserId(bearerToken(request))
+		ctx := context.WithValue(request.Context(), authenticationKey{}, authentication{userId: userId, err: err})
+		handler.ServeHTTP(writer, request.WithContext(ctx))
+	})
+}
+
+// extractUserId returns the ID of the user authenticated by the bearer token of the
+// request, or an empty string for anonymous requests
+func extractUserId(request *http.Request, auth services.AuthService) (string, error) {
+	if result, found := request.Context().Value(authenticationKey{}).(authentication); found {
+		return result.userId, result.err
+	}
+	return auth.ExtractUserId(bearerToken(request))
+}
+
+func bearerToken(request *http.Request) string {
+	bearer := strings.TrimPrefix(request.Header.Get("Authorization"), "Bearer ")
+	// FIXME remove once frontend bug fixed
+	if bearer == "undefined" {
+		bearer = ""
+	}
+	return bearer
+}
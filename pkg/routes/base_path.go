@@ -0,0 +1,45 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+type basePathKey struct{}
+
+// WithBasePath serves the handler under the base path, e.g. "/neoflix", so that the app
+// can be deployed behind a reverse proxy shared with other apps without rewriting.
+// The routes see the paths without the base path, while the URLs they generate, such as
+// the links to the other pages of a list or the share URLs, include it.
+// Requests outside of the base path get a 404 error.
+func WithBasePath(handler http.Handler, basePath string) http.Handler {
+	basePath = strings.TrimSuffix(basePath, "/")
+	if basePath == "" {
+		return handler
+	}
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		path := strings.TrimPrefix(request.URL.Path, basePath)
+		if path == request.URL.Path || path != "" && path[0] != '/' {
+			http.NotFound(writer, request)
+			return
+		}
+		if path == "" {
+			http.Redirect(writer, request, basePath+"/", http.StatusMovedPermanently)
+			return
+		}
+		stripped := request.WithContext(context.WithValue(request.Context(), basePathKey{}, basePath))
+		url := *request.URL
+		url.Path = path
+		url.RawPath = strings.TrimPrefix(request.URL.RawPath, basePath)
+		stripped.URL = &url
+		handler.ServeHTTP(writer, stripped)
+	})
+}
+
+// basePathOf returns the base path the request was sent to, empty when the app is
+// served from the root
+func basePathOf(request *http.Request) string {
+	basePath, _ := request.Context().Value(basePathKey{}).(string)
+	return basePath
+}
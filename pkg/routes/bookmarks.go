@@ -0,0 +1,104 @@
+package routes
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/bookmarks"
+	"github.com/neo4j-graphacademy/neoflix/pkg/services"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+const bookmarksHeader = "X-Bookmarks"
+
+// WithBookmarks makes the transactions of every request wait for the bookmarks of the
+// X-Bookmarks header of the request, comma-separated, and returns the bookmarks of the
+// writes of the request, or the ones it waited for, in the X-Bookmarks header of the
+// response.
+// Clients passing the bookmarks of their last response to their next request read their
+// own writes, even when the request is routed to a server lagging behind the leader.
+//
+// When a store is provided, the bookmarks of the writes of the authenticated users are
+// kept in it as well, so that they read their own writes whether or not their client
+// passes the bookmarks back.
+// Failing to read or write the store is logged and does not fail the request.
+// It must be wrapped by WithRequestMetadata, which identifies the user of the request.
+func WithBookmarks(handler http.Handler, store bookmarks.Store, logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		config := neo4j.BookmarkManagerConfig{
+			InitialBookmarks: parseBookmarks(request.Header.Get(bookmarksHeader)),
+		}
+		if metadata, found := services.RequestMetadataFromContext(request.Context()); found && metadata.UserId != "" && store != nil {
+			userId := metadata.UserId
+			stored, err := store.Get(request.Context(), userId)
+			if err != nil {
+				logger.Warn("failed to read the bookmarks of the user", "userId", userId, "error", err)
+			}
+			config.InitialBookmarks = append(config.InitialBookmarks, stored...)
+			config.BookmarkConsumer = func(ctx context.Context, written neo4j.Bookmarks) error {
+				if err := store.Set(ctx, userId, written); err != nil {
+					logger.Warn("failed to save the bookmarks of the user", "userId", userId, "error", err)
+				}
+				return nil
+			}
+		}
+		manager := neo4j.NewBookmarkManager(config)
+		ctx := services.ContextWithBookmarkManager(request.Context(), manager)
+		handler.ServeHTTP(&bookmarkReportingWriter{ResponseWriter: writer, ctx: ctx, manager: manager}, request.WithContext(ctx))
+	})
+}
+
+func parseBookmarks(header string) neo4j.Bookmarks {
+	var bookmarks neo4j.Bookmarks
+	for _, bookmark := range strings.Split(header, ",") {
+		if bookmark = strings.TrimSpace(bookmark); bookmark != "" {
+			bookmarks = append(bookmarks, bookmark)
+		}
+	}
+	return bookmarks
+}
+
+// bookmarkReportingWriter sets the bookmarks header right before the response is
+// written, once the transactions of the request are over
+type bookmarkReportingWriter struct {
+	http.ResponseWriter
+	ctx         context.Context
+	manager     neo4j.BookmarkManager
+	wroteHeader bool
+}
+
+func (bw *bookmarkReportingWriter) WriteHeader(statusCode int) {
+	if !bw.wroteHeader {
+		bw.wroteHeader = true
+		bookmarks, err := bw.manager.GetBookmarks(bw.ctx)
+		if err == nil && len(bookmarks) > 0 {
+			bw.Header().Set(bookmarksHeader, strings.Join(bookmarks, ","))
+		}
+	}
+	bw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (bw *bookmarkReportingWriter) Write(body []byte) (int, error) {
+	if !bw.wroteHeader {
+		bw.WriteHeader(http.StatusOK)
+	}
+	return bw.ResponseWriter.Write(body)
+}
+
+// Flush keeps the streamed responses, such as exports, flushable, the bookmarks header
+// being set before the first flush
+func (bw *bookmarkReportingWriter) Flush() {
+	if !bw.wroteHeader {
+		bw.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := bw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (bw *bookmarkReportingWriter) Unwrap() http.ResponseWriter {
+	return bw.ResponseWriter
+}
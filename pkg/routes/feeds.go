@@ -0,0 +1,176 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/cache"
+	"github.com/neo4j-graphacademy/neoflix/pkg/routes/paging"
+	"github.com/neo4j-graphacademy/neoflix/pkg/services"
+)
+
+const (
+	atomXmlns = "http://www.w3.org/2005/Atom"
+	// feedSectionSize is the number of new and of top rated movies listed by each feed
+	feedSectionSize = 10
+	// feeds are regenerated in the background once older than feedTtl
+	feedTtl      = time.Hour
+	feedStaleTtl = 24 * time.Hour
+	// feedCacheMaxEntries bounds the feeds cached, one per genre
+	feedCacheMaxEntries = 1000
+)
+
+type feedRoutes struct {
+	movies    services.MovieService
+	publicUrl string
+	cache     *cache.Cache
+}
+
+// NewFeedRoutes serves the feeds of the genres, linking to the pages under the public URL
+// of the app
+func NewFeedRoutes(movies services.MovieService, publicUrl string) Routable {
+	return &feedRoutes{
+		movies:    movies,
+		publicUrl: strings.TrimSuffix(publicUrl, "/"),
+		cache:     cache.New(cache.Options{TTL: feedTtl, StaleTTL: feedStaleTtl, MaxEntries: feedCacheMaxEntries}),
+	}
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	Id      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	Id       string         `xml:"id"`
+	Title    string         `xml:"title"`
+	Updated  string         `xml:"updated"`
+	Link     atomLink       `xml:"link"`
+	Summary  string         `xml:"summary,omitempty"`
+	Category []atomCategory `xml:"category"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+func (f *feedRoutes) Register(server *http.ServeMux) {
+	server.HandleFunc("/feeds/genres/",
+		func(writer http.ResponseWriter, request *http.Request) {
+			path := strings.TrimPrefix(request.URL.Path, "/feeds/genres/")
+			if !strings.HasSuffix(path, ".atom") {
+				serializeError(writer, services.NewDomainError(404, "feed not found", nil))
+				return
+			}
+			f.GenreFeed(strings.TrimSuffix(path, ".atom"), request, writer)
+		})
+}
+
+// GenreFeed serves the Atom feed of the recently released and top rated movies of the genre
+func (f *feedRoutes) GenreFeed(genre string, request *http.Request, writer http.ResponseWriter) {
+	// the feed may be regenerated in the background, after the request completed,
+	// so only its metadata is kept
+	metadata, _ := services.RequestMetadataFromContext(request.Context())
+	ctx := services.ContextWithRequestMetadata(context.Background(), metadata)
+	// unknown genres fail with a 404 error, which is not cached
+	feed, err := f.cache.Get(genre, func() (interface{}, error) {
+		return f.generate(ctx, f.publicUrl, genre)
+	})
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	writer.Header().Add("Content-Type", "application/atom+xml; charset=utf-8")
+	writer.WriteHeader(200)
+	_, _ = writer.Write(feed.([]byte))
+}
+
+func (f *feedRoutes) generate(ctx context.Context, base, genre string) ([]byte, error) {
+	latest, err := f.movies.FindAllByGenre(ctx, genre, "",
+		paging.NewPaging("", "released", "DESC", 0, feedSectionSize))
+	if err != nil {
+		return nil, err
+	}
+	top, err := f.movies.FindAllByGenre(ctx, genre, "",
+		paging.NewPaging("", "imdbRating", "DESC", 0, feedSectionSize))
+	if err != nil {
+		return nil, err
+	}
+	if len(latest.Items) == 0 && len(top.Items) == 0 {
+		return nil, services.NewDomainError(404, "feed not found", map[string]interface{}{"genre": genre})
+	}
+
+	now := time.Now().UTC()
+	selfUrl := fmt.Sprintf("%s/feeds/genres/%s.atom", base, url.PathEscape(genre))
+	feed := atomFeed{
+		Xmlns:   atomXmlns,
+		Id:      selfUrl,
+		Title:   fmt.Sprintf("Neoflix - %s movies", genre),
+		Updated: now.Format(time.RFC3339),
+		Author:  atomAuthor{Name: "Neoflix"},
+		Links: []atomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: selfUrl},
+			{Rel: "alternate", Type: "text/html", Href: fmt.Sprintf("%s/genres/%s", base, url.PathEscape(genre))},
+		},
+	}
+	seen := map[string]bool{}
+	today := now.Format("2006-01-02")
+	for _, movie := range append(latest.Items, top.Items...) {
+		id := fmt.Sprint(movie["tmdbId"])
+		released, _ := movie["released"].(string)
+		if seen[id] || released > today {
+			continue
+		}
+		seen[id] = true
+		feed.Entries = append(feed.Entries, feedEntry(base, genre, movie, now))
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteString(xml.Header)
+	if err := xml.NewEncoder(&buffer).Encode(feed); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// feedEntry describes the movie, dated by its release as movies carry no modification time
+func feedEntry(base, genre string, movie services.Movie, now time.Time) atomEntry {
+	link := fmt.Sprintf("%s/movies/%v", base, movie["tmdbId"])
+	updated := now
+	if released, ok := movie["released"].(string); ok {
+		if date, err := time.Parse("2006-01-02", released); err == nil {
+			updated = date
+		}
+	}
+	summary, _ := movie["plot"].(string)
+	return atomEntry{
+		Id:       link,
+		Title:    fmt.Sprint(movie["title"]),
+		Updated:  updated.Format(time.RFC3339),
+		Link:     atomLink{Rel: "alternate", Type: "text/html", Href: link},
+		Summary:  summary,
+		Category: []atomCategory{{Term: genre}},
+	}
+}
@@ -0,0 +1,82 @@
+package routes
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/services"
+	"github.com/neo4j-graphacademy/neoflix/pkg/services/flags"
+)
+
+// anonymousIdCookie identifies anonymous clients, for them to keep their flags and
+// experiment buckets across requests
+const anonymousIdCookie = "neoflix_anonymous_id"
+
+const anonymousIdTtl = 365 * 24 * time.Hour
+
+type flagRoutes struct {
+	auth      services.AuthService
+	evaluator *flags.Evaluator
+}
+
+// NewFlagRoutes exposes the feature flags and experiment buckets evaluated by the
+// server, for front-ends to render the experimental UI consistently
+func NewFlagRoutes(auth services.AuthService, evaluator *flags.Evaluator) Routable {
+	return &flagRoutes{
+		auth:      auth,
+		evaluator: evaluator,
+	}
+}
+
+func (f *flagRoutes) Register(server *http.ServeMux) {
+	server.HandleFunc("/api/flags",
+		func(writer http.ResponseWriter, request *http.Request) {
+			f.FindFlags(request, writer)
+		})
+}
+
+// FindFlags returns the flags and experiment buckets of the current user, or of the
+// anonymous ID of the client, along with a signed `token` holding them
+func (f *flagRoutes) FindFlags(request *http.Request, writer http.ResponseWriter) {
+	userId, err := extractUserId(request, f.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	subject := userId
+	if subject == "" {
+		subject = "anonymous:" + anonymousId(request, writer)
+	}
+	evaluation := f.evaluator.Evaluate(subject, time.Now().UTC().Truncate(time.Second))
+	token, err := f.evaluator.Sign(evaluation)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	writer.Header().Set("Cache-Control", "private, no-store")
+	serializeJson(writer, map[string]interface{}{
+		"subject":     evaluation.Subject,
+		"flags":       evaluation.Flags,
+		"experiments": evaluation.Experiments,
+		"issuedAt":    evaluation.IssuedAt,
+		"token":       token,
+	}, nil)
+}
+
+// anonymousId returns the anonymous ID of the client, which is assigned one on
+// its first request
+func anonymousId(request *http.Request, writer http.ResponseWriter) string {
+	if cookie, err := request.Cookie(anonymousIdCookie); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+	id := newRequestId()
+	http.SetCookie(writer, &http.Cookie{
+		Name:     anonymousIdCookie,
+		Value:    id,
+		Path:     "/",
+		Expires:  time.Now().Add(anonymousIdTtl),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return id
+}
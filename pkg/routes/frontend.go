@@ -0,0 +1,52 @@
+package routes
+
+import (
+	"bytes"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/services"
+)
+
+// NewFrontendHandler serves the files of the front-end, and its index.html for the
+// paths of the client-side routes, such as /movies/{id}, so that they can be reloaded
+// and bookmarked.
+// Missing files, such as /js/missing.js, and unknown API endpoints are not routed to
+// the front-end and get a 404 error.
+func NewFrontendHandler(files fs.FS) http.Handler {
+	fileServer := http.FileServer(http.FS(files))
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if strings.HasPrefix(request.URL.Path, "/api/") {
+			serializeError(writer, services.NewDomainError(404, "Not found", map[string]interface{}{
+				"path": request.URL.Path,
+			}))
+			return
+		}
+		name := strings.TrimPrefix(path.Clean(request.URL.Path), "/")
+		if name != "" && path.Ext(name) == "" && isPageRequest(request) {
+			if _, err := fs.Stat(files, name); err != nil {
+				serveIndex(files, writer, request)
+				return
+			}
+		}
+		fileServer.ServeHTTP(writer, request)
+	})
+}
+
+func isPageRequest(request *http.Request) bool {
+	return request.Method == "GET" || request.Method == "HEAD"
+}
+
+func serveIndex(files fs.FS, writer http.ResponseWriter, request *http.Request) {
+	index, err := fs.ReadFile(files, "index.html")
+	if err != nil {
+		http.NotFound(writer, request)
+		return
+	}
+	// the index references the current assets, it must not be cached
+	writer.Header().Set("Cache-Control", "no-cache")
+	http.ServeContent(writer, request, "index.html", time.Time{}, bytes.NewReader(index))
+}
@@ -10,16 +10,19 @@ import (
 type genreRoutes struct {
 	genres services.GenreService
 	movies services.MovieService
+	people services.PeopleService
 	auth   services.AuthService
 }
 
 func NewGenreRoutes(genres services.GenreService,
 	movies services.MovieService,
+	people services.PeopleService,
 	auth services.AuthService) Routable {
 
 	return &genreRoutes{
 		genres: genres,
 		movies: movies,
+		people: people,
 		auth:   auth,
 	}
 }
@@ -30,19 +33,29 @@ func (g *genreRoutes) Register(server *http.ServeMux) {
 			path := strings.TrimPrefix(request.URL.Path, "/api/genres/")
 			switch {
 			case path == "":
-				g.FindAllGenres(writer)
+				g.FindAllGenres(request, writer)
+			case strings.Contains(path, "/people/") && strings.HasSuffix(path, "/movies"):
+				genre, personId := splitPair(strings.TrimSuffix(path, "/movies"), "/people/")
+				g.FindAllMoviesByGenreAndPersonId(genre, personId, request, writer)
 			case strings.HasSuffix(path, "/movies"):
 				genre := strings.TrimSuffix(path, "/movies")
-				pagingParams := paging.ParsePaging(request, paging.MovieSortableAttributes())
+				pagingParams, err := paging.ParsePaging(request, paging.GenreSortableAttributes())
+				if err != nil {
+					serializeError(writer, err)
+					return
+				}
 				g.FindAllMoviesByGenre(genre, pagingParams, request, writer)
+			case strings.HasSuffix(path, "/people"):
+				genre := strings.TrimSuffix(path, "/people")
+				g.FindAllPeopleByGenre(genre, request, writer)
 			default:
-				g.FindOneGenreByName(path, writer)
+				g.FindOneGenreByName(path, request, writer)
 			}
 		})
 }
 
-func (g *genreRoutes) FindAllGenres(writer http.ResponseWriter) {
-	genres, err := g.genres.FindAll()
+func (g *genreRoutes) FindAllGenres(request *http.Request, writer http.ResponseWriter) {
+	genres, err := g.genres.FindAll(request.Context())
 	serializeJson(writer, genres, err)
 }
 
@@ -56,11 +69,48 @@ func (g *genreRoutes) FindAllMoviesByGenre(genre string,
 		serializeError(writer, err)
 		return
 	}
-	movies, err := g.movies.FindAllByGenre(genre, userId, page)
-	serializeJson(writer, movies, err)
+	if err := page.CheckQuota(userId != ""); err != nil {
+		serializeError(writer, err)
+		return
+	}
+	movies, err := g.movies.FindAllByGenre(request.Context(), genre, userId, page)
+	serializePagedResult(writer, request, page, movies, err)
 }
 
-func (g *genreRoutes) FindOneGenreByName(name string, writer http.ResponseWriter) {
-	genre, err := g.genres.FindOneByName(name)
+func (g *genreRoutes) FindOneGenreByName(name string, request *http.Request, writer http.ResponseWriter) {
+	genre, err := g.genres.FindOneByName(request.Context(), name)
 	serializeJson(writer, genre, err)
 }
+
+func (g *genreRoutes) FindAllPeopleByGenre(genre string, request *http.Request, writer http.ResponseWriter) {
+	page, err := paging.ParsePaging(request, paging.PersonSortableAttributes())
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	if err := checkPagingQuota(page, request, g.auth); err != nil {
+		serializeError(writer, err)
+		return
+	}
+	people, err := g.people.FindAllByGenre(request.Context(), genre, page)
+	serializePagedResult(writer, request, page, people, err)
+}
+
+func (g *genreRoutes) FindAllMoviesByGenreAndPersonId(genre, personId string, request *http.Request, writer http.ResponseWriter) {
+	page, err := paging.ParsePaging(request, paging.GenreSortableAttributes())
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	userId, err := extractUserId(request, g.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	if err := page.CheckQuota(userId != ""); err != nil {
+		serializeError(writer, err)
+		return
+	}
+	movies, err := g.movies.FindAllByGenreAndPersonId(request.Context(), genre, personId, userId, page)
+	serializePagedResult(writer, request, page, movies, err)
+}
@@ -3,6 +3,11 @@ package routes
 import (
 	"encoding/json"
 	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/routes/paging"
+	"github.com/neo4j-graphacademy/neoflix/pkg/services"
 )
 
 type withStatusCode interface {
@@ -24,6 +29,46 @@ func serializeJson(writer http.ResponseWriter, result interface{}, err error) {
 	_, _ = writer.Write(jsonPayload)
 }
 
+// serializePage serializes a page of a list, linking to the other pages of the list
+// with a Link header, along with its total number of results when known
+func serializePage(writer http.ResponseWriter, request *http.Request, page *paging.Paging, results interface{}, err error) {
+	if err == nil {
+		count := 0
+		if value := reflect.ValueOf(results); value.Kind() == reflect.Slice {
+			count = value.Len()
+		}
+		current := *request.URL
+		current.Path = basePathOf(request) + current.Path
+		if links := page.Links(&current, count); links != "" {
+			writer.Header().Set("Link", links)
+		}
+		if total, found := page.Total(); found {
+			writer.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+		}
+	}
+	serializeJson(writer, results, err)
+}
+
+// serializeCursorPage serializes the items of a page of a list paginated by keyset,
+// linking to the next page with a Link header
+func serializeCursorPage(writer http.ResponseWriter, request *http.Request, page *paging.Paging, result services.CursorPagedResult, err error) {
+	if err == nil {
+		current := *request.URL
+		current.Path = basePathOf(request) + current.Path
+		writer.Header().Set("Link", page.CursorLinks(&current, result.Next))
+	}
+	serializeJson(writer, result.Items, err)
+}
+
+// serializePagedResult serializes the items of a PagedResult as serializePage does,
+// with the total counted along with them
+func serializePagedResult(writer http.ResponseWriter, request *http.Request, page *paging.Paging, result services.PagedResult, err error) {
+	if err == nil {
+		page.SetTotal(result.Total)
+	}
+	serializePage(writer, request, page, result.Items, err)
+}
+
 func serializeError(writer http.ResponseWriter, err error) {
 	writer.Header().Add("Content-Type", "text/plain")
 	writeStatusCode(writer, err)
@@ -33,6 +78,8 @@ func serializeError(writer http.ResponseWriter, err error) {
 func writeStatusCode(writer http.ResponseWriter, err error) {
 	if errWithCode, ok := err.(withStatusCode); ok {
 		writer.WriteHeader(errWithCode.StatusCode())
+	} else if services.IsDeadlineExceeded(err) {
+		writer.WriteHeader(504)
 	} else {
 		writer.WriteHeader(500)
 	}
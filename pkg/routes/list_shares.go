@@ -0,0 +1,151 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j-graphacademy/neoflix/pkg/routes/paging"
+	"github.com/neo4j-graphacademy/neoflix/pkg/services"
+	"github.com/neo4j-graphacademy/neoflix/pkg/services/sharetokens"
+)
+
+// Lists which can be shared
+const (
+	sharedFavorites = "favorites"
+	sharedReviews   = "reviews"
+)
+
+const (
+	defaultShareTtl = 7 * 24 * time.Hour
+	maxShareTtl     = 30 * 24 * time.Hour
+)
+
+type listShareRoutes struct {
+	favorites services.FavoriteService
+	ratings   services.RatingService
+	auth      services.AuthService
+	tokens    *sharetokens.Signer
+	publicUrl string
+}
+
+// NewListShareRoutes lets users share their private lists through expiring links,
+// which grant read-only access without logging in, under the public URL of the app
+func NewListShareRoutes(favorites services.FavoriteService,
+	ratings services.RatingService,
+	auth services.AuthService,
+	tokens *sharetokens.Signer,
+	publicUrl string) Routable {
+	return &listShareRoutes{
+		favorites: favorites,
+		ratings:   ratings,
+		auth:      auth,
+		tokens:    tokens,
+		publicUrl: strings.TrimSuffix(publicUrl, "/"),
+	}
+}
+
+func (l *listShareRoutes) Register(server *http.ServeMux) {
+	server.HandleFunc("/api/account/shares",
+		func(writer http.ResponseWriter, request *http.Request) {
+			if request.Method == "POST" {
+				l.SaveShare(request, writer)
+			}
+		})
+	server.Handle("/share/lists/", WithShareToken(http.HandlerFunc(
+		func(writer http.ResponseWriter, request *http.Request) {
+			l.FindSharedList(request, writer)
+		}), l.tokens))
+}
+
+// SaveShare creates a link to the `list` of the body, either favorites or reviews,
+// valid for `ttlHours`, 7 days by default and 30 days at most
+func (l *listShareRoutes) SaveShare(request *http.Request, writer http.ResponseWriter) {
+	userId, err := extractUserId(request, l.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	if userId == "" {
+		serializeError(writer, services.NewDomainError(401, "Authentication required", nil))
+		return
+	}
+	payload, err := ioutils.ReadJson(request.Body)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	list, _ := payload["list"].(string)
+	if list != sharedFavorites && list != sharedReviews {
+		serializeError(writer, services.NewDomainError(400, "Unsupported list", map[string]interface{}{
+			"list":  list,
+			"lists": []string{sharedFavorites, sharedReviews},
+		}))
+		return
+	}
+	ttl := defaultShareTtl
+	if ttlHours, _ := payload["ttlHours"].(float64); ttlHours > 0 {
+		ttl = time.Duration(ttlHours * float64(time.Hour))
+	}
+	if ttl > maxShareTtl {
+		ttl = maxShareTtl
+	}
+	expiresAt := time.Now().Add(ttl).Truncate(time.Second)
+	token, err := l.tokens.Sign(sharetokens.Claims{UserId: userId, List: list, ExpiresAt: expiresAt})
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	serializeJson(writer, map[string]interface{}{
+		"list":      list,
+		"token":     token,
+		"url":       fmt.Sprintf("%s/share/lists/%s", l.publicUrl, token),
+		"expiresAt": expiresAt,
+	}, nil)
+}
+
+// FindSharedList returns a page of the list granted by the share token
+func (l *listShareRoutes) FindSharedList(request *http.Request, writer http.ResponseWriter) {
+	claims, _ := sharetokens.ClaimsFromContext(request.Context())
+	switch claims.List {
+	case sharedFavorites:
+		page, err := paging.ParsePaging(request, paging.MovieSortableAttributes())
+		if err != nil {
+			serializeError(writer, err)
+			return
+		}
+		movies, err := l.favorites.FindAllByUserId(request.Context(), claims.UserId, page)
+		serializePage(writer, request, page, movies, err)
+	case sharedReviews:
+		page, err := paging.ParsePaging(request, paging.ReviewSortableAttributes())
+		if err != nil {
+			serializeError(writer, err)
+			return
+		}
+		// the owner granted access, private reviews included
+		reviews, err := l.ratings.FindAllReviewsByUserId(request.Context(), claims.UserId, claims.UserId, page)
+		serializePage(writer, request, page, reviews, err)
+	default:
+		serializeError(writer, services.NewDomainError(404, "List not found", nil))
+	}
+}
+
+// WithShareToken serves the requests to /share/lists/{token} carrying a valid token,
+// with the claims of the token held by the request context.
+// Invalid tokens are rejected with a 401 error, and expired ones with a 410 error.
+func WithShareToken(handler http.Handler, tokens *sharetokens.Signer) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		token := strings.TrimPrefix(request.URL.Path, "/share/lists/")
+		claims, err := tokens.Verify(token, time.Now())
+		switch err {
+		case nil:
+			handler.ServeHTTP(writer, request.WithContext(sharetokens.ContextWithClaims(request.Context(), claims)))
+		case sharetokens.ErrExpired:
+			serializeError(writer, services.NewDomainError(410, "This share link has expired", nil))
+		default:
+			serializeError(writer, services.NewDomainError(401, "Invalid share link", nil))
+		}
+	})
+}
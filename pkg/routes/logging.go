@@ -0,0 +1,73 @@
+package routes
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/services"
+)
+
+// WithRequestLogging logs every request once served: its method, path, route, status,
+// duration, request ID and authenticated user, along with the names of the Cypher
+// statements it ran.
+// It must be wrapped by WithRequestMetadata, which identifies the request and its user.
+// Server errors are logged at the error level, other requests at the info level.
+func WithRequestLogging(handler http.Handler, logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		start := time.Now()
+		tracker := &services.QueryTracker{}
+		ctx := services.ContextWithQueryTracker(request.Context(), tracker)
+		recorder := &statusRecordingWriter{ResponseWriter: writer, status: http.StatusOK}
+		handler.ServeHTTP(recorder, request.WithContext(ctx))
+
+		level := slog.LevelInfo
+		if recorder.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		metadata, _ := services.RequestMetadataFromContext(ctx)
+		logger.LogAttrs(ctx, level, "request",
+			slog.String("method", request.Method),
+			slog.String("path", request.URL.Path),
+			slog.String("route", metadata.Route),
+			slog.Int("status", recorder.status),
+			slog.Int64("durationMs", time.Since(start).Milliseconds()),
+			slog.String("requestId", metadata.RequestId),
+			slog.String("userId", metadata.UserId),
+			slog.Any("queries", tracker.Names()))
+	})
+}
+
+// statusRecordingWriter records the status code of the response
+type statusRecordingWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (sw *statusRecordingWriter) WriteHeader(statusCode int) {
+	if !sw.wroteHeader {
+		sw.wroteHeader = true
+		sw.status = statusCode
+	}
+	sw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (sw *statusRecordingWriter) Write(body []byte) (int, error) {
+	if !sw.wroteHeader {
+		sw.WriteHeader(http.StatusOK)
+	}
+	return sw.ResponseWriter.Write(body)
+}
+
+// Flush keeps the streamed responses, such as exports, flushable
+func (sw *statusRecordingWriter) Flush() {
+	if flusher, ok := sw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (sw *statusRecordingWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
@@ -0,0 +1,67 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/cache"
+	"github.com/neo4j-graphacademy/neoflix/pkg/services"
+)
+
+// maintenanceCacheOptions bounds how long instances take to observe a maintenance toggle,
+// while keeping the status off the path of most requests
+var maintenanceCacheOptions = cache.Options{
+	TTL:      5 * time.Second,
+	StaleTTL: time.Minute,
+}
+
+// readOnlyAllowed lists the write requests still served in read-only mode:
+// logging in and sharing lists do not write, and admins must be able to leave
+// the read-only mode
+var readOnlyAllowed = map[string]bool{
+	"POST /api/auth/login":       true,
+	"POST /api/account/shares":   true,
+	"PUT /api/admin/maintenance": true,
+}
+
+// WithMaintenanceMode rejects the write requests with a 503 error while the API is
+// in read-only mode, reads being served as usual.
+// The status is shared through the database: it is served from memory and refreshed
+// every few seconds, and the API stays writable when it cannot be read.
+func WithMaintenanceMode(handler http.Handler, maintenance services.MaintenanceService) http.Handler {
+	status := cache.New(maintenanceCacheOptions)
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if !isWrite(request) || readOnlyAllowed[request.Method+" "+request.URL.Path] {
+			handler.ServeHTTP(writer, request)
+			return
+		}
+		current, err := status.Get("maintenance", func() (interface{}, error) {
+			// refreshes may outlive the request triggering them
+			return maintenance.Find(context.Background())
+		})
+		if err != nil {
+			fmt.Printf("Maintenance status unavailable: %v\n", err)
+			handler.ServeHTTP(writer, request)
+			return
+		}
+		if readOnly, _ := current.(services.Maintenance)["readOnly"].(bool); readOnly {
+			message, _ := current.(services.Maintenance)["message"].(string)
+			if message == "" {
+				message = services.DefaultMaintenanceMessage
+			}
+			serializeError(writer, services.NewDomainError(503, message, nil))
+			return
+		}
+		handler.ServeHTTP(writer, request)
+	})
+}
+
+func isWrite(request *http.Request) bool {
+	switch request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
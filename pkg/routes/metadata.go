@@ -0,0 +1,70 @@
+package routes
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/services"
+)
+
+const requestIdHeader = "X-Request-Id"
+
+// WithRequestMetadata attaches the services.RequestMetadata of every request to its
+// context, so the transactions it runs can be attributed to it in the Neo4j query log.
+// The request ID is read from the X-Request-Id header when set by a proxy, generated
+// otherwise, and echoed back in the response.
+func WithRequestMetadata(handler http.Handler, auth services.AuthService) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requestId := request.Header.Get(requestIdHeader)
+		if requestId == "" {
+			requestId = newRequestId()
+		}
+		writer.Header().Set(requestIdHeader, requestId)
+		// invalid tokens are reported by the routes themselves
+		userId, _ := extractUserId(request, auth)
+		ctx := services.ContextWithRequestMetadata(request.Context(), services.RequestMetadata{
+			Route:     routeOf(request),
+			RequestId: requestId,
+			UserId:    userId,
+		})
+		handler.ServeHTTP(writer, request.WithContext(ctx))
+	})
+}
+
+// routeOf returns the method and path of the request, where numeric segments such as
+// movie and people IDs are replaced by "{id}", e.g. "GET /api/movies/{id}/similar",
+// and share tokens by "{token}"
+func routeOf(request *http.Request) string {
+	if strings.HasPrefix(request.URL.Path, "/share/lists/") {
+		return request.Method + " /share/lists/{token}"
+	}
+	segments := strings.Split(request.URL.Path, "/")
+	for i, segment := range segments {
+		if isNumeric(segment) {
+			segments[i] = "{id}"
+		}
+	}
+	return request.Method + " " + strings.Join(segments, "/")
+}
+
+func isNumeric(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	for _, char := range segment {
+		if char < '0' || char > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestId() string {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(id)
+}
@@ -2,8 +2,10 @@ package routes
 
 import (
 	"net/http"
+	"strconv"
 	"strings"
 
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
 	"github.com/neo4j-graphacademy/neoflix/pkg/routes/paging"
 	"github.com/neo4j-graphacademy/neoflix/pkg/services"
 )
@@ -11,15 +13,18 @@ import (
 type movieRoutes struct {
 	movies  services.MovieService
 	ratings services.RatingService
+	reports services.ReportService
 	auth    services.AuthService
 }
 
 func NewMovieRoutes(movies services.MovieService,
 	ratings services.RatingService,
+	reports services.ReportService,
 	auth services.AuthService) Routable {
 	return &movieRoutes{
 		movies:  movies,
 		ratings: ratings,
+		reports: reports,
 		auth:    auth,
 	}
 }
@@ -31,12 +36,28 @@ func (m *movieRoutes) Register(server *http.ServeMux) {
 			switch {
 			case path == "":
 				m.FindAllMovies(request, writer)
+			case path == "search":
+				m.SearchMovies(request, writer)
+			case path == "batch":
+				m.FindManyMoviesByIds(request, writer)
+			case path == "hidden-gems":
+				m.FindAllHiddenGems(request, writer)
+			case path == "box-office":
+				m.FindAllBoxOffice(request, writer)
+			case path == "upcoming":
+				m.FindAllUpcoming(request, writer)
+			case strings.HasSuffix(path, "/summary"):
+				id := strings.TrimSuffix(path, "/summary")
+				m.FindMovieSummary(id, request, writer)
 			case strings.HasSuffix(path, "/similar"):
 				id := strings.TrimSuffix(path, "/similar")
 				m.FindAllMoviesBySimilarity(id, request, writer)
 			case strings.HasSuffix(path, "/ratings"):
 				id := strings.TrimSuffix(path, "/ratings")
 				m.FindAllRatingsByMovieId(id, request, writer)
+			case strings.HasSuffix(path, "/reports") && request.Method == "POST":
+				id := strings.TrimSuffix(path, "/reports")
+				m.SaveReport(id, request, writer)
 			default:
 				m.FindOneMovieById(path, request, writer)
 			}
@@ -46,7 +67,11 @@ func (m *movieRoutes) Register(server *http.ServeMux) {
 // tag::list[]
 func (m *movieRoutes) FindAllMovies(request *http.Request, writer http.ResponseWriter) {
 	// <1> Extract pagination values from request
-	page := paging.ParsePaging(request, paging.MovieSortableAttributes())
+	page, err := paging.ParsePaging(request, paging.MovieSortableAttributes())
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
 
 	// <2> Extract User ID from request
 	userId, err := extractUserId(request, m.auth)
@@ -54,37 +79,280 @@ func (m *movieRoutes) FindAllMovies(request *http.Request, writer http.ResponseW
 		serializeError(writer, err)
 		return
 	}
+	if err := page.CheckQuota(userId != ""); err != nil {
+		serializeError(writer, err)
+		return
+	}
+
+	if cursor, found := page.Cursor(); found {
+		if page.Query() != "" {
+			serializeError(writer, services.NewDomainError(400, "q cannot be combined with a cursor", nil))
+			return
+		}
+		movies, err := m.movies.FindAllAfter(request.Context(), cursor, userId, page)
+		serializeCursorPage(writer, request, page, movies, err)
+		return
+	}
 
 	// <3> Get the results
-	movies, err := m.movies.FindAll(userId, page)
-	serializeJson(writer, movies, err)
+	movies, err := m.movies.FindAll(request.Context(), userId, page)
+	serializePagedResult(writer, request, page, movies, err)
 }
 
 // end::list[]
 
-func (m *movieRoutes) FindOneMovieById(id string, request *http.Request, writer http.ResponseWriter) {
+// SearchMovies searches the titles, plots and taglines of the movies for the `q`
+// parameter, the most relevant first.
+// With `mode=semantic`, the movies are ranked by the similarity of the meaning of their
+// plot to the query instead.
+func (m *movieRoutes) SearchMovies(request *http.Request, writer http.ResponseWriter) {
+	page, err := paging.ParsePaging(request, paging.MovieSearchSortableAttributes())
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	query := strings.TrimSpace(page.Query())
+	if query == "" {
+		serializeError(writer, services.NewDomainError(400, "q is required", nil))
+		return
+	}
+
+	userId, err := extractUserId(request, m.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	if err := page.CheckQuota(userId != ""); err != nil {
+		serializeError(writer, err)
+		return
+	}
+
+	search := m.movies.Search
+	switch mode := request.URL.Query().Get("mode"); mode {
+	case "", "text":
+	case "semantic":
+		search = m.movies.SearchSemantic
+	default:
+		serializeError(writer, services.NewDomainError(400, "Unsupported search mode", map[string]interface{}{
+			"mode":  mode,
+			"modes": []string{"text", "semantic"},
+		}))
+		return
+	}
+	movies, err := search(request.Context(), query, userId, page)
+	serializePagedResult(writer, request, page, movies, err)
+}
+
+// FindManyMoviesByIds returns the movies of the comma-separated `ids` parameter, in
+// the same order
+func (m *movieRoutes) FindManyMoviesByIds(request *http.Request, writer http.ResponseWriter) {
 	userId, err := extractUserId(request, m.auth)
 	if err != nil {
 		serializeError(writer, err)
 		return
 	}
-	movies, err := m.movies.FindOneById(id, userId)
+	var ids []string
+	if raw := request.URL.Query().Get("ids"); raw != "" {
+		for _, id := range strings.Split(raw, ",") {
+			ids = append(ids, strings.TrimSpace(id))
+		}
+	}
+	movies, err := m.movies.FindManyByIds(request.Context(), ids, userId)
 	serializeJson(writer, movies, err)
 }
 
+func (m *movieRoutes) FindOneMovieById(id string, request *http.Request, writer http.ResponseWriter) {
+	userId, err := extractUserId(request, m.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	movie, err := m.movies.FindOneById(request.Context(), id, userId)
+	if err == nil && request.URL.Query().Get("prefetch") == "true" {
+		var hints services.PrefetchHints
+		hints, err = m.movies.FindPrefetchHintsById(request.Context(), id)
+		movie["prefetch"] = hints
+	}
+	serializeJson(writer, movie, err)
+}
+
 func (m *movieRoutes) FindAllMoviesBySimilarity(id string, request *http.Request, writer http.ResponseWriter) {
-	page := paging.ParsePaging(request, paging.MovieSortableAttributes())
+	page, err := paging.ParsePaging(request, paging.MovieSortableAttributes())
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
 	userId, err := extractUserId(request, m.auth)
 	if err != nil {
 		serializeError(writer, err)
 		return
 	}
-	movies, err := m.movies.FindAllBySimilarity(id, userId, page)
-	serializeJson(writer, movies, err)
+	if err := page.CheckQuota(userId != ""); err != nil {
+		serializeError(writer, err)
+		return
+	}
+	opts, err := parseSimilarityOptions(request)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	if !opts.IsZero() {
+		// tuning the weights is reserved to admins
+		if _, err := requireAdmin(request, m.auth); err != nil {
+			serializeError(writer, err)
+			return
+		}
+	}
+	find := m.movies.FindAllBySimilarity
+	switch algorithm := request.URL.Query().Get("algorithm"); algorithm {
+	case "", "cypher":
+	case "gds":
+		find = m.movies.FindAllBySimilarityGDS
+	default:
+		serializeError(writer, services.NewDomainError(400, "Unsupported similarity algorithm", map[string]interface{}{
+			"algorithm":  algorithm,
+			"algorithms": []string{"cypher", "gds"},
+		}))
+		return
+	}
+	movies, err := find(request.Context(), id, userId, page, opts)
+	serializePage(writer, request, page, movies, err)
 }
 
 func (m *movieRoutes) FindAllRatingsByMovieId(id string, request *http.Request, writer http.ResponseWriter) {
-	page := paging.ParsePaging(request, paging.RatingSortableAttributes())
-	movies, err := m.ratings.FindAllByMovieId(id, page)
-	serializeJson(writer, movies, err)
+	page, err := paging.ParsePaging(request, paging.RatingSortableAttributes())
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	userId, err := extractUserId(request, m.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	if err := page.CheckQuota(userId != ""); err != nil {
+		serializeError(writer, err)
+		return
+	}
+	movies, err := m.ratings.FindAllByMovieId(request.Context(), id, userId, page)
+	serializePage(writer, request, page, movies, err)
+}
+
+func (m *movieRoutes) FindAllHiddenGems(request *http.Request, writer http.ResponseWriter) {
+	page, err := paging.ParsePaging(request, paging.MovieSortableAttributes())
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	userId, err := extractUserId(request, m.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	if err := page.CheckQuota(userId != ""); err != nil {
+		serializeError(writer, err)
+		return
+	}
+	movies, err := m.movies.FindAllHiddenGems(request.Context(), userId, page)
+	serializePagedResult(writer, request, page, movies, err)
+}
+
+// FindAllBoxOffice returns the highest grossing movies first
+func (m *movieRoutes) FindAllBoxOffice(request *http.Request, writer http.ResponseWriter) {
+	page, err := paging.ParsePaging(request, paging.MovieSortableAttributes())
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	userId, err := extractUserId(request, m.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	if err := page.CheckQuota(userId != ""); err != nil {
+		serializeError(writer, err)
+		return
+	}
+	movies, err := m.movies.FindAllBoxOffice(request.Context(), userId, page)
+	serializePagedResult(writer, request, page, movies, err)
+}
+
+func (m *movieRoutes) FindAllUpcoming(request *http.Request, writer http.ResponseWriter) {
+	page, err := paging.ParsePaging(request, paging.MovieSortableAttributes())
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	userId, err := extractUserId(request, m.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	if err := page.CheckQuota(userId != ""); err != nil {
+		serializeError(writer, err)
+		return
+	}
+	movies, err := m.movies.FindAllUpcoming(request.Context(), userId, page)
+	serializePagedResult(writer, request, page, movies, err)
+}
+
+// parseSimilarityOptions reads the similarity weights overridden with the `genreWeight`,
+// `actorWeight`, `directorWeight`, `ratingWeight` and `tasteWeight` query parameters
+func parseSimilarityOptions(request *http.Request) (services.MovieSimilarityOptions, error) {
+	query := request.URL.Query()
+	var opts services.MovieSimilarityOptions
+	for _, param := range []struct {
+		name   string
+		target **float64
+	}{
+		{"genreWeight", &opts.Genre},
+		{"actorWeight", &opts.Actor},
+		{"directorWeight", &opts.Director},
+		{"ratingWeight", &opts.Rating},
+		{"tasteWeight", &opts.Taste},
+	} {
+		raw := query.Get(param.name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return opts, services.NewDomainError(400, "Invalid similarity weight", map[string]interface{}{
+				param.name: raw,
+			})
+		}
+		*param.target = &value
+	}
+	return opts, nil
+}
+
+// SaveReport reports incorrect data of a movie from the `reason` field of the body,
+// one of wrong_year, duplicate or broken_poster, along with an optional `comment`
+// and, for duplicates, the `duplicateOf` ID of the duplicated movie
+func (m *movieRoutes) SaveReport(id string, request *http.Request, writer http.ResponseWriter) {
+	userId, err := extractUserId(request, m.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	if userId == "" {
+		serializeError(writer, services.NewDomainError(401, "Authentication required", nil))
+		return
+	}
+	payload, err := ioutils.ReadJson(request.Body)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	reason, _ := payload["reason"].(string)
+	comment, _ := payload["comment"].(string)
+	duplicateOf, _ := payload["duplicateOf"].(string)
+	report, err := m.reports.Create(request.Context(), userId, id, reason, comment, duplicateOf)
+	serializeJson(writer, report, err)
+}
+
+// FindMovieSummary returns a compact summary of a movie for voice and chat assistants
+func (m *movieRoutes) FindMovieSummary(id string, request *http.Request, writer http.ResponseWriter) {
+	summary, err := m.movies.FindSummaryById(request.Context(), id)
+	serializeJson(writer, summary, err)
 }
@@ -0,0 +1,113 @@
+package paging
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/signing"
+)
+
+// DateCursorValue is the Kind of the cursors whose sort value is a date, encoded as
+// "2006-01-02"
+const DateCursorValue = "date"
+
+// Cursor points to the last result of a page of a list paginated by keyset: the next
+// page starts right after its sort Value, ties being broken by ID.
+// Cursors are only valid for the sort and order of the list they were issued for.
+type Cursor struct {
+	Sort  string      `json:"s"`
+	Order Order       `json:"o"`
+	Value interface{} `json:"v"`
+	Kind  string      `json:"k,omitempty"`
+	Id    string      `json:"i"`
+	// Position is the number of results listed before the next page, which the
+	// AnonymousQuota of the Options is enforced on
+	Position int `json:"p"`
+}
+
+// IsZero reports whether the cursor points to the start of the list
+func (c Cursor) IsZero() bool {
+	return c.Id == ""
+}
+
+// Encode returns the opaque value of the cursor passed as the `cursor` parameter,
+// signed by the signer
+func (c Cursor) Encode(signer *signing.Signer) string {
+	value, _ := signer.Sign(c)
+	return value
+}
+
+// ParseCursor decodes the cursor of a list sorted by the sort attribute in the order,
+// verifying it was signed by the signer.
+// An empty value is the start of the list.
+//
+// Cursors which cannot be decoded, whose signature does not match, or which were issued
+// for another sort or order, are rejected with an InvalidCursorError.
+func ParseCursor(signer *signing.Signer, value, sort string, order Order) (Cursor, error) {
+	if value == "" {
+		return Cursor{Sort: sort, Order: order}, nil
+	}
+	var cursor Cursor
+	if err := signer.Verify(value, &cursor); err != nil || cursor.Id == "" || cursor.Position < 0 {
+		return Cursor{}, &InvalidCursorError{value: value}
+	}
+	if cursor.Sort != sort || cursor.Order != order {
+		return Cursor{}, &InvalidCursorError{value: value, reason: "the sort or order of the list changed"}
+	}
+	if cursor.Kind == DateCursorValue {
+		date, ok := cursor.Value.(string)
+		if _, err := time.Parse("2006-01-02", date); !ok || err != nil {
+			return Cursor{}, &InvalidCursorError{value: value}
+		}
+	}
+	return cursor, nil
+}
+
+// InvalidCursorError is returned when the cursor of a list is malformed, tampered with or
+// was issued for another sort or order
+type InvalidCursorError struct {
+	value  string
+	reason string
+}
+
+func (i *InvalidCursorError) Error() string {
+	message := "Invalid cursor"
+	if i.reason != "" {
+		message = fmt.Sprintf("Invalid cursor, %s", i.reason)
+	}
+	errorJson, _ := json.Marshal(map[string]interface{}{
+		"status":  "error",
+		"code":    i.StatusCode(),
+		"message": message,
+		"details": map[string]interface{}{
+			"cursor": i.value,
+		},
+	})
+	return string(errorJson)
+}
+
+func (i *InvalidCursorError) StatusCode() int {
+	return 400
+}
+
+// CursorLinks returns the value of the RFC 5988 Link header of a page paginated by
+// keyset, pointing to the first page and, unless the current page is the last one, to
+// the page after the next cursor
+func (p Paging) CursorLinks(current *url.URL, next *Cursor) string {
+	link := func(cursor, rel string) string {
+		query := current.Query()
+		query.Set("cursor", cursor)
+		query.Set("limit", strconv.Itoa(p.limit))
+		query.Del("skip")
+		target := url.URL{Path: current.Path, RawQuery: query.Encode()}
+		return fmt.Sprintf("<%s>; rel=\"%s\"", target.String(), rel)
+	}
+	links := link("", "first")
+	if next != nil {
+		links += ", " + link(next.Encode(p.cursorSigner()), "next")
+	}
+	return links
+}
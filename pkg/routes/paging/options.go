@@ -0,0 +1,49 @@
+package paging
+
+import (
+	"context"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/signing"
+)
+
+// DefaultAnonymousQuota is the AnonymousQuota of the lists, unless configured otherwise
+const DefaultAnonymousQuota = 100
+
+// CursorPurpose is the purpose the signers of the cursors derive their key for
+const CursorPurpose = "cursors"
+
+// unconfiguredCursors signs the cursors of the requests without options, with an empty
+// secret which only fits tests
+var unconfiguredCursors = signing.New("", CursorPurpose)
+
+// Options configures the pagination of the lists of a request
+type Options struct {
+	// AnonymousQuota is the maximum number of results anonymous clients can page through
+	// in a single list, authenticated users are not limited.
+	// Zero or negative values disable the quota.
+	AnonymousQuota int
+	// Cursors signs the cursors handed out to the clients, so that they cannot tamper with
+	// the position the AnonymousQuota is enforced on
+	Cursors *signing.Signer
+}
+
+// DefaultOptions are the options of the requests whose context holds none
+func DefaultOptions() Options {
+	return Options{AnonymousQuota: DefaultAnonymousQuota, Cursors: unconfiguredCursors}
+}
+
+type optionsKey struct{}
+
+// ContextWithOptions returns a copy of the context holding the options, which
+// ParsePaging applies to the pages of the request
+func ContextWithOptions(ctx context.Context, options Options) context.Context {
+	return context.WithValue(ctx, optionsKey{}, options)
+}
+
+// OptionsFromContext returns the options held by the context, the DefaultOptions if none
+func OptionsFromContext(ctx context.Context) Options {
+	if options, ok := ctx.Value(optionsKey{}).(Options); ok {
+		return options
+	}
+	return DefaultOptions()
+}
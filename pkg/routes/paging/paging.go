@@ -1,15 +1,58 @@
 package paging
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/url"
 	"sort"
 	"strconv"
+	"strings"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/collation"
+	"github.com/neo4j-graphacademy/neoflix/pkg/signing"
 )
 
 func MovieSortableAttributes() *SortableAttributes {
 	return newSortableAttributes([]string{
-		"title", "released", "imdbRating", "score",
+		"title", "released", "imdbRating", "score", "revenue",
+	})
+}
+
+// MovieSearchSortableAttributes only allows the searched movies to be listed most
+// relevant first
+func MovieSearchSortableAttributes() *SortableAttributes {
+	attributes := newSortableAttributes([]string{
+		"relevance",
+	})
+	attributes.defaultOrder = Desc
+	return attributes
+}
+
+// CoActorSortableAttributes only allows the co-actors of a person to be listed by their
+// number of movies in common, the most first by default
+func CoActorSortableAttributes() *SortableAttributes {
+	attributes := newSortableAttributes([]string{
+		"sharedMovies",
+	})
+	attributes.defaultOrder = Desc
+	return attributes
+}
+
+// FilmographySortableAttributes lists the credits of a person most recent first by
+// default
+func FilmographySortableAttributes() *SortableAttributes {
+	attributes := newSortableAttributes([]string{
+		"released", "title", "imdbRating",
+	})
+	attributes.defaultOrder = Desc
+	return attributes
+}
+
+// GenreSortableAttributes lists the movies of a genre by title by default
+func GenreSortableAttributes() *SortableAttributes {
+	return newSortableAttributes([]string{
+		"title", "released", "imdbRating", "score", "revenue",
 	})
 }
 
@@ -21,32 +64,163 @@ func PersonSortableAttributes() *SortableAttributes {
 
 func RatingSortableAttributes() *SortableAttributes {
 	return newSortableAttributes([]string{
-		"rating", "timestamp",
+		"rating", "timestamp", "createdAt", "updatedAt",
+	})
+}
+
+// ReviewSortableAttributes lists the reviews most recent first by default
+func ReviewSortableAttributes() *SortableAttributes {
+	attributes := newSortableAttributes([]string{
+		"timestamp", "helpfulness", "createdAt", "updatedAt",
+	})
+	attributes.defaultOrder = Desc
+	return attributes
+}
+
+// NotificationSortableAttributes only allows the notifications to be listed unread first,
+// then most recent first
+func NotificationSortableAttributes() *SortableAttributes {
+	return newSortableAttributes([]string{
+		"createdAt",
+	})
+}
+
+// RatingFlagSortableAttributes only allows the rating flags to be listed most recent first
+func RatingFlagSortableAttributes() *SortableAttributes {
+	return newSortableAttributes([]string{
+		"detectedAt",
+	})
+}
+
+// ReportSortableAttributes only allows the reports to be listed oldest first
+func ReportSortableAttributes() *SortableAttributes {
+	return newSortableAttributes([]string{
+		"createdAt",
 	})
 }
 
 type SortableAttributes struct {
 	defaultValue string
+	defaultOrder Order
 	values       []string
 }
 
 func newSortableAttributes(values []string) *SortableAttributes {
 	defaultValue := values[0]
 	sort.Strings(values)
-	return &SortableAttributes{defaultValue: defaultValue, values: values}
+	return &SortableAttributes{defaultValue: defaultValue, defaultOrder: Asc, values: values}
 }
 
-func (sa *SortableAttributes) contains(s string) bool {
+// Contains reports whether the attribute is sortable
+func (sa *SortableAttributes) Contains(s string) bool {
 	i := sort.SearchStrings(sa.values, s)
 	return i < len(sa.values) && sa.values[i] == s
 }
 
+// Order is the direction results are sorted in
+type Order string
+
+const (
+	Asc  Order = "ASC"
+	Desc Order = "DESC"
+)
+
+// ParseOrder parses the provided order case-insensitively, an empty value
+// being parsed as defaultOrder.
+//
+// Values other than "asc" and "desc" are rejected with an InvalidOrderError.
+func ParseOrder(value string, defaultOrder Order) (Order, error) {
+	value = strings.TrimSpace(value)
+	switch {
+	case value == "":
+		return defaultOrder, nil
+	case strings.EqualFold(value, string(Asc)):
+		return Asc, nil
+	case strings.EqualFold(value, string(Desc)):
+		return Desc, nil
+	}
+	return "", &InvalidOrderError{value: value}
+}
+
+// InvalidOrderError is returned when the order of a list is neither ascending nor descending
+type InvalidOrderError struct {
+	value string
+}
+
+func (i *InvalidOrderError) Error() string {
+	errorJson, _ := json.Marshal(map[string]interface{}{
+		"status":  "error",
+		"code":    i.StatusCode(),
+		"message": fmt.Sprintf("Unsupported order %q, expected %q or %q", i.value, Asc, Desc),
+		"details": map[string]interface{}{
+			"order": i.value,
+		},
+	})
+	return string(errorJson)
+}
+
+func (i *InvalidOrderError) StatusCode() int {
+	return 400
+}
+
+// InvalidSortError is returned when a list is sorted by an attribute it cannot be sorted by
+type InvalidSortError struct {
+	value    string
+	sortable []string
+}
+
+func (i *InvalidSortError) Error() string {
+	errorJson, _ := json.Marshal(map[string]interface{}{
+		"status":  "error",
+		"code":    i.StatusCode(),
+		"message": fmt.Sprintf("Unsupported sort %q, expected one of %s", i.value, strings.Join(i.sortable, ", ")),
+		"details": map[string]interface{}{
+			"sort":     i.value,
+			"sortable": i.sortable,
+		},
+	})
+	return string(errorJson)
+}
+
+func (i *InvalidSortError) StatusCode() int {
+	return 400
+}
+
+// QuotaExceededError is returned when anonymous clients page past the AnonymousQuota of
+// the Options of the request
+type QuotaExceededError struct {
+	quota int
+}
+
+func (q *QuotaExceededError) Error() string {
+	errorJson, _ := json.Marshal(map[string]interface{}{
+		"status":  "error",
+		"code":    q.StatusCode(),
+		"message": fmt.Sprintf("Sign in to browse past the first %d results", q.quota),
+		"details": map[string]interface{}{
+			"quota": q.quota,
+		},
+	})
+	return string(errorJson)
+}
+
+func (q *QuotaExceededError) StatusCode() int {
+	return 401
+}
+
 type Paging struct {
-	query string
-	sort  string
-	order string
-	skip  int
-	limit int
+	query     string
+	sort      string
+	order     Order
+	skip      int
+	limit     int
+	collation string
+	total     int64
+	hasTotal  bool
+	cursor    *Cursor
+	// quota and cursors are the options of the request the page was parsed from
+	quota   int
+	cursors *signing.Signer
 }
 
 func (p Paging) Query() string {
@@ -57,7 +231,7 @@ func (p Paging) Sort() string {
 	return p.sort
 }
 
-func (p Paging) Order() string {
+func (p Paging) Order() Order {
 	return p.order
 }
 
@@ -69,34 +243,153 @@ func (p Paging) Limit() int {
 	return p.limit
 }
 
-func ParsePaging(req *http.Request, sortableAttributes *SortableAttributes) *Paging {
+// Collation returns the language titles are sorted for, or "" to sort them in byte order
+func (p Paging) Collation() string {
+	return p.collation
+}
+
+// Cursor returns the cursor the page starts after, when the list is paginated by keyset
+// rather than skipped through, as requested with the `cursor` parameter
+func (p Paging) Cursor() (Cursor, bool) {
+	if p.cursor == nil {
+		return Cursor{}, false
+	}
+	return *p.cursor, true
+}
+
+// SetTotal records the total number of results of the list across all pages,
+// from which the Links to the last page are computed
+func (p *Paging) SetTotal(total int64) {
+	p.total = total
+	p.hasTotal = true
+}
+
+// Total returns the total number of results recorded by SetTotal, if any
+func (p Paging) Total() (int64, bool) {
+	return p.total, p.hasTotal
+}
+
+// Links returns the value of the RFC 5988 Link header pointing to the first, previous,
+// next and last pages of the list, relative to the URL of the current page, which held
+// `count` results.
+// Without total, the last page is unknown and the next page is only linked when the
+// current page is full.
+func (p Paging) Links(current *url.URL, count int) string {
+	if p.limit <= 0 {
+		return ""
+	}
+	link := func(skip int, rel string) string {
+		query := current.Query()
+		query.Set("skip", strconv.Itoa(skip))
+		query.Set("limit", strconv.Itoa(p.limit))
+		target := url.URL{Path: current.Path, RawQuery: query.Encode()}
+		return fmt.Sprintf("<%s>; rel=\"%s\"", target.String(), rel)
+	}
+	links := []string{link(0, "first")}
+	if p.skip > 0 {
+		previous := p.skip - p.limit
+		if previous < 0 {
+			previous = 0
+		}
+		links = append(links, link(previous, "prev"))
+	}
+	next := p.skip + p.limit
+	if (p.hasTotal && int64(next) < p.total) || (!p.hasTotal && count >= p.limit) {
+		links = append(links, link(next, "next"))
+	}
+	if p.hasTotal {
+		last := 0
+		if p.total > 0 {
+			last = int((p.total - 1) / int64(p.limit) * int64(p.limit))
+		}
+		links = append(links, link(last, "last"))
+	}
+	return strings.Join(links, ", ")
+}
+
+// CheckQuota enforces the AnonymousQuota of the request the page was parsed from on
+// unauthenticated requests: the limit is reduced to stay within the quota and pages
+// starting past it are rejected.
+// Pages created with NewPaging have no quota.
+func (p *Paging) CheckQuota(authenticated bool) error {
+	if authenticated || p.quota <= 0 {
+		return nil
+	}
+	if p.skip >= p.quota {
+		return &QuotaExceededError{quota: p.quota}
+	}
+	if p.skip+p.limit > p.quota {
+		p.limit = p.quota - p.skip
+	}
+	return nil
+}
+
+// ParsePaging parses the paging parameters of the request, falling back to the defaults
+// of the sortable attributes for missing values, under the Options of the request.
+//
+// Sort attributes other than the sortable ones are rejected with an InvalidSortError,
+// and unsupported orders with an InvalidOrderError.
+//
+// With a `cursor` parameter, even empty, the list is paginated by keyset from the
+// cursor, the results listed before it counting as skipped.
+func ParsePaging(req *http.Request, sortableAttributes *SortableAttributes) (*Paging, error) {
 	query := req.URL.Query()
-	sortParameter := query.Get("sort")
-	if !sortableAttributes.contains(sortParameter) {
-		sortParameter = sortableAttributes.defaultValue
+	order, err := ParseOrder(query.Get("order"), sortableAttributes.defaultOrder)
+	if err != nil {
+		return nil, err
 	}
-	return &Paging{
+	spec, err := sortableAttributes.SortSpec(strings.TrimSpace(query.Get("sort")), order)
+	if err != nil {
+		return nil, err
+	}
+	sortParameter := spec.Attribute()
+	options := OptionsFromContext(req.Context())
+	page := &Paging{
 		query: query.Get("q"),
 		sort:  sortParameter,
-		order: query.Get("order"),
+		order: order,
 		skip:  getIntOrDefault(query, "skip", 0),
 		limit: getIntOrDefault(query, "limit", 6),
+		// titles are collated for the language preferred by the client
+		collation: collation.FromAcceptLanguage(req.Header.Get("Accept-Language")),
+		quota:     options.AnonymousQuota,
+		cursors:   options.Cursors,
 	}
+	if _, found := query["cursor"]; found {
+		cursor, err := ParseCursor(page.cursorSigner(), query.Get("cursor"), sortParameter, order)
+		if err != nil {
+			return nil, err
+		}
+		page.cursor = &cursor
+		page.skip = cursor.Position
+	}
+	return page, nil
 }
 
+// cursorSigner returns the signer of the cursors of the page, the one of the
+// DefaultOptions for pages created with NewPaging
+func (p Paging) cursorSigner() *signing.Signer {
+	if p.cursors == nil {
+		return unconfiguredCursors
+	}
+	return p.cursors
+}
+
+// getIntOrDefault returns the value of the query parameter, or the default value
+// when it is missing, not a number or negative
 func getIntOrDefault(query url.Values, key string, defaultValue int) int {
 	rawSkip := query.Get(key)
 	if rawSkip == "" {
 		return defaultValue
 	}
 	result, err := strconv.Atoi(rawSkip)
-	if err != nil {
+	if err != nil || result < 0 {
 		return defaultValue
 	}
 	return result
 }
 
-func NewPaging(query string, sort string, order string, skip int, limit int) *Paging {
+func NewPaging(query string, sort string, order Order, skip int, limit int) *Paging {
 	return &Paging{
 		query: query,
 		sort:  sort,
@@ -0,0 +1,286 @@
+package paging_test
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/routes/paging"
+	"github.com/neo4j-graphacademy/neoflix/pkg/signing"
+)
+
+// parseWithQuota parses the paging parameters of the query under the quota
+func parseWithQuota(t *testing.T, query string, quota int) *paging.Paging {
+	request := httptest.NewRequest("GET", "/api/movies?"+query, nil)
+	request = request.WithContext(paging.ContextWithOptions(request.Context(), paging.Options{AnonymousQuota: quota}))
+	page, err := paging.ParsePaging(request, paging.MovieSortableAttributes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return page
+}
+
+func TestCheckQuota(outer *testing.T) {
+	outer.Run("authenticated users are not limited", func(t *testing.T) {
+		page := parseWithQuota(t, "skip=500&limit=50", 100)
+		if err := page.CheckQuota(true); err != nil {
+			t.Fatal(err)
+		}
+		if page.Limit() != 50 {
+			t.Fatalf("expected limit 50, got %d", page.Limit())
+		}
+	})
+
+	outer.Run("anonymous limit is reduced to the quota", func(t *testing.T) {
+		page := parseWithQuota(t, "skip=90&limit=20", 100)
+		if err := page.CheckQuota(false); err != nil {
+			t.Fatal(err)
+		}
+		if page.Limit() != 10 {
+			t.Fatalf("expected limit 10, got %d", page.Limit())
+		}
+	})
+
+	outer.Run("anonymous pages past the quota are rejected", func(t *testing.T) {
+		page := parseWithQuota(t, "skip=100&limit=6", 100)
+		err := page.CheckQuota(false)
+		quotaErr, ok := err.(*paging.QuotaExceededError)
+		if !ok {
+			t.Fatalf("expected quota error, got %v", err)
+		}
+		if quotaErr.StatusCode() != 401 {
+			t.Fatalf("expected status 401, got %d", quotaErr.StatusCode())
+		}
+	})
+
+	outer.Run("disabled quotas do not limit anonymous users", func(t *testing.T) {
+		page := parseWithQuota(t, "skip=500&limit=50", -1)
+		if err := page.CheckQuota(false); err != nil || page.Limit() != 50 {
+			t.Fatalf("expected limit 50, got %d (%v)", page.Limit(), err)
+		}
+	})
+}
+
+func TestLinks(outer *testing.T) {
+	current, _ := url.Parse("/api/movies?sort=title&skip=12&limit=6")
+
+	outer.Run("links all pages when the total is known", func(t *testing.T) {
+		page := paging.NewPaging("", "title", "ASC", 12, 6)
+		page.SetTotal(40)
+		expected := `</api/movies?limit=6&skip=0&sort=title>; rel="first", ` +
+			`</api/movies?limit=6&skip=6&sort=title>; rel="prev", ` +
+			`</api/movies?limit=6&skip=18&sort=title>; rel="next", ` +
+			`</api/movies?limit=6&skip=36&sort=title>; rel="last"`
+		if links := page.Links(current, 6); links != expected {
+			t.Fatalf("expected %s, got %s", expected, links)
+		}
+	})
+
+	outer.Run("does not link past the last page", func(t *testing.T) {
+		page := paging.NewPaging("", "title", "ASC", 12, 6)
+		page.SetTotal(18)
+		expected := `</api/movies?limit=6&skip=0&sort=title>; rel="first", ` +
+			`</api/movies?limit=6&skip=6&sort=title>; rel="prev", ` +
+			`</api/movies?limit=6&skip=12&sort=title>; rel="last"`
+		if links := page.Links(current, 6); links != expected {
+			t.Fatalf("expected %s, got %s", expected, links)
+		}
+	})
+
+	outer.Run("links the next page of full pages when the total is unknown", func(t *testing.T) {
+		page := paging.NewPaging("", "title", "ASC", 0, 6)
+		expected := `</api/movies?limit=6&skip=0&sort=title>; rel="first", ` +
+			`</api/movies?limit=6&skip=6&sort=title>; rel="next"`
+		if links := page.Links(current, 6); links != expected {
+			t.Fatalf("expected %s, got %s", expected, links)
+		}
+	})
+}
+
+func TestParseOrder(outer *testing.T) {
+	outer.Run("orders are parsed case-insensitively", func(t *testing.T) {
+		for value, expected := range map[string]paging.Order{"asc": paging.Asc, "Desc": paging.Desc, "DESC": paging.Desc} {
+			order, err := paging.ParseOrder(value, paging.Asc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if order != expected {
+				t.Fatalf("expected %s for %q, got %s", expected, value, order)
+			}
+		}
+	})
+
+	outer.Run("missing orders default to the order of the sortable attributes", func(t *testing.T) {
+		page, err := paging.ParsePaging(httptest.NewRequest("GET", "/api/users/1/reviews", nil), paging.ReviewSortableAttributes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Order() != paging.Desc {
+			t.Fatalf("expected %s, got %s", paging.Desc, page.Order())
+		}
+	})
+
+	outer.Run("unsupported orders are rejected", func(t *testing.T) {
+		_, err := paging.ParsePaging(httptest.NewRequest("GET", "/api/movies?order=ASC,title", nil), paging.MovieSortableAttributes())
+		orderErr, ok := err.(*paging.InvalidOrderError)
+		if !ok {
+			t.Fatalf("expected order error, got %v", err)
+		}
+		if orderErr.StatusCode() != 400 {
+			t.Fatalf("expected status 400, got %d", orderErr.StatusCode())
+		}
+	})
+}
+
+func TestSortSpec(outer *testing.T) {
+	outer.Run("renders the order by item of the property backing the attribute", func(t *testing.T) {
+		spec, err := parseWithQuota(t, "sort=released&order=desc", 0).SortSpec(paging.MovieSortableAttributes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if orderBy := spec.CypherOrderBy("m", map[string]string{"released": "year"}); orderBy != "m.`year` DESC" {
+			t.Fatalf("expected m.`year` DESC, got %s", orderBy)
+		}
+		if orderBy := spec.CypherOrderBy("m", nil); orderBy != "m.`released` DESC" {
+			t.Fatalf("expected m.`released` DESC, got %s", orderBy)
+		}
+	})
+
+	outer.Run("rejects the attributes of pages created outside of ParsePaging", func(t *testing.T) {
+		page := paging.NewPaging("", "title` DESC //", paging.Asc, 0, 0)
+		_, err := page.SortSpec(paging.MovieSortableAttributes())
+		if _, ok := err.(*paging.InvalidSortError); !ok {
+			t.Fatalf("expected sort error, got %v", err)
+		}
+	})
+
+	outer.Run("rejects the unknown orders", func(t *testing.T) {
+		page := paging.NewPaging("", "title", paging.Order("DESC, m.title"), 0, 0)
+		_, err := page.SortSpec(paging.MovieSortableAttributes())
+		if _, ok := err.(*paging.InvalidOrderError); !ok {
+			t.Fatalf("expected order error, got %v", err)
+		}
+	})
+
+	outer.Run("defaults to the sort of the sortable attributes", func(t *testing.T) {
+		spec, err := paging.NewPaging("", "", "", 0, 0).SortSpec(paging.ReviewSortableAttributes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if spec.Attribute() != "timestamp" || spec.CypherOrder() != "DESC" {
+			t.Fatalf("expected timestamp DESC, got %s %s", spec.Attribute(), spec.CypherOrder())
+		}
+	})
+}
+
+func TestCursors(outer *testing.T) {
+	signer := paging.DefaultOptions().Cursors
+
+	outer.Run("cursors resume the list they were issued for", func(t *testing.T) {
+		cursor := paging.Cursor{Sort: "released", Order: paging.Desc, Value: "1999-03-31", Id: "603", Position: 12}
+		request := httptest.NewRequest("GET", "/api/movies?sort=released&order=desc&cursor="+cursor.Encode(signer), nil)
+		page, err := paging.ParsePaging(request, paging.MovieSortableAttributes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, found := page.Cursor()
+		if !found || parsed != cursor {
+			t.Fatalf("expected %+v, got %+v", cursor, parsed)
+		}
+		if page.Skip() != 12 {
+			t.Fatalf("expected the position to count as skipped, got %d", page.Skip())
+		}
+	})
+
+	outer.Run("empty cursors start the list", func(t *testing.T) {
+		page, err := paging.ParsePaging(httptest.NewRequest("GET", "/api/movies?cursor=", nil), paging.MovieSortableAttributes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cursor, found := page.Cursor(); !found || !cursor.IsZero() {
+			t.Fatalf("expected an empty cursor, got %+v", cursor)
+		}
+	})
+
+	outer.Run("cursors of another sort or malformed are rejected", func(t *testing.T) {
+		other := paging.Cursor{Sort: "title", Order: paging.Asc, Value: "Matrix", Id: "603"}
+		for _, value := range []string{other.Encode(signer), "not a cursor"} {
+			request := httptest.NewRequest("GET", "/api/movies?sort=released&cursor="+url.QueryEscape(value), nil)
+			_, err := paging.ParsePaging(request, paging.MovieSortableAttributes())
+			if cursorErr, ok := err.(*paging.InvalidCursorError); !ok || cursorErr.StatusCode() != 400 {
+				t.Fatalf("expected cursor error for %q, got %v", value, err)
+			}
+		}
+	})
+
+	outer.Run("tampered cursors are rejected", func(t *testing.T) {
+		// a cursor rewound to the start of the list, to page past the anonymous quota
+		forged := paging.Cursor{Sort: "title", Order: paging.Asc, Value: "Matrix", Id: "603", Position: 0}
+		for _, signer := range []*signing.Signer{signing.New("another secret", paging.CursorPurpose), signing.New("", "flags")} {
+			request := httptest.NewRequest("GET", "/api/movies?cursor="+forged.Encode(signer), nil)
+			_, err := paging.ParsePaging(request, paging.MovieSortableAttributes())
+			if _, ok := err.(*paging.InvalidCursorError); !ok {
+				t.Fatalf("expected cursor error, got %v", err)
+			}
+		}
+	})
+
+	outer.Run("links the next cursor", func(t *testing.T) {
+		current, _ := url.Parse("/api/movies?cursor=&limit=6&skip=6")
+		next := paging.Cursor{Sort: "title", Order: paging.Asc, Value: "Matrix", Id: "603", Position: 6}
+		expected := `</api/movies?cursor=&limit=6>; rel="first", ` +
+			`</api/movies?cursor=` + next.Encode(signer) + `&limit=6>; rel="next"`
+		if links := paging.NewPaging("", "title", "ASC", 0, 6).CursorLinks(current, &next); links != expected {
+			t.Fatalf("expected %s, got %s", expected, links)
+		}
+	})
+}
+
+func FuzzParsePaging(f *testing.F) {
+	f.Add("title", "asc", "6", "0", "matrix", "en-US")
+	f.Add("title` DETACH DELETE m //", "ASC; MATCH (n) DETACH DELETE n", "-1", "-6", "' OR 1=1 //", "fr;q=abc")
+	f.Add("released", " desc ", "9223372036854775808", "1e3", "{{sort}}", "*")
+	f.Add("", "DESC\nRETURN 1", "", "", "`", ",;q=0.5,de")
+
+	sortable := map[string]bool{"title": true, "released": true, "imdbRating": true, "score": true, "revenue": true}
+	f.Fuzz(func(t *testing.T, sort, order, limit, skip, q, acceptLanguage string) {
+		query := url.Values{"sort": {sort}, "order": {order}, "limit": {limit}, "skip": {skip}, "q": {q}}
+		request := httptest.NewRequest("GET", "/api/movies?"+query.Encode(), nil)
+		request.Header.Set("Accept-Language", acceptLanguage)
+
+		page, err := paging.ParsePaging(request, paging.MovieSortableAttributes())
+		if err != nil {
+			switch err.(type) {
+			case *paging.InvalidSortError:
+				if trimmed := strings.TrimSpace(sort); sortable[trimmed] || trimmed == "" {
+					t.Fatalf("sort %q rejected", sort)
+				}
+			case *paging.InvalidOrderError:
+				if trimmed := strings.TrimSpace(order); strings.EqualFold(trimmed, "asc") || strings.EqualFold(trimmed, "desc") || trimmed == "" {
+					t.Fatalf("order %q rejected", order)
+				}
+			default:
+				t.Fatalf("unexpected error %v", err)
+			}
+			return
+		}
+		if !sortable[page.Sort()] {
+			t.Fatalf("sort %q is not sortable", page.Sort())
+		}
+		if page.Order() != paging.Asc && page.Order() != paging.Desc {
+			t.Fatalf("unexpected order %q", page.Order())
+		}
+		if page.Skip() < 0 || page.Limit() < 0 {
+			t.Fatalf("negative skip %d or limit %d", page.Skip(), page.Limit())
+		}
+		if page.Query() != q {
+			t.Fatalf("expected query %q, got %q", q, page.Query())
+		}
+		switch page.Collation() {
+		case "", "de", "en", "es", "fr", "it":
+		default:
+			t.Fatalf("unsupported collation %q", page.Collation())
+		}
+	})
+}
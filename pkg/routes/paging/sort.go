@@ -0,0 +1,67 @@
+package paging
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SortSpec is the sort of a list by one of its sortable attributes, in one of the
+// paging orders.
+// It is only built from the SortableAttributes of the list, and renders the Cypher
+// sorting the results, so that neither the attribute nor the order requested by clients
+// are ever interpolated as is in the statements.
+type SortSpec struct {
+	attribute string
+	order     Order
+}
+
+// SortSpec returns the sort by the attribute in the order, empty values falling back to
+// the default attribute and order.
+//
+// Attributes other than the sortable ones are rejected with an InvalidSortError, and
+// orders other than Asc and Desc with an InvalidOrderError.
+func (sa *SortableAttributes) SortSpec(attribute string, order Order) (SortSpec, error) {
+	if attribute == "" {
+		attribute = sa.defaultValue
+	}
+	if !sa.Contains(attribute) {
+		return SortSpec{}, &InvalidSortError{value: attribute, sortable: sa.values}
+	}
+	if order == "" {
+		order = sa.defaultOrder
+	}
+	if order != Asc && order != Desc {
+		return SortSpec{}, &InvalidOrderError{value: string(order)}
+	}
+	return SortSpec{attribute: attribute, order: order}, nil
+}
+
+// SortSpec returns the sort of the page, checked against the sortable attributes of the
+// list, as pages created with NewPaging are not
+func (p Paging) SortSpec(sortable *SortableAttributes) (SortSpec, error) {
+	return sortable.SortSpec(p.sort, p.order)
+}
+
+// Attribute returns the sortable attribute the results are sorted by
+func (s SortSpec) Attribute() string {
+	return s.attribute
+}
+
+// CypherOrderBy renders the `ORDER BY` item sorting the results bound to the variable by
+// the property backing the attribute, e.g. "m.`title` ASC".
+// The properties map the attributes to the properties backing them, such as the
+// properties of the dataset, the attributes missing from them being sorted by the
+// property of the same name. The property is escaped as a quoted identifier.
+func (s SortSpec) CypherOrderBy(variable string, properties map[string]string) string {
+	property := s.attribute
+	if mapped, found := properties[s.attribute]; found {
+		property = mapped
+	}
+	return fmt.Sprintf("%s.`%s` %s", variable, strings.ReplaceAll(property, "`", "``"), s.order)
+}
+
+// CypherOrder renders the direction of the sort, for the lists sorted by computed values
+// rather than by a property
+func (s SortSpec) CypherOrder() string {
+	return string(s.order)
+}
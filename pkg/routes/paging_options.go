@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/routes/paging"
+)
+
+// WithPagingOptions applies the options to the lists served by the handler, such as the
+// quota of the anonymous clients
+func WithPagingOptions(handler http.Handler, options paging.Options) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		ctx := paging.ContextWithOptions(request.Context(), options)
+		handler.ServeHTTP(writer, request.WithContext(ctx))
+	})
+}
@@ -0,0 +1,94 @@
+package routes
+
+import (
+	"compress/gzip"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/services"
+)
+
+// catalogBatchSize is the number of movies fetched per query while streaming the catalog
+const catalogBatchSize = 1000
+
+type partnerRoutes struct {
+	catalog services.CatalogService
+	apiKeys []string
+}
+
+// NewPartnerRoutes serves the endpoints reserved to partners, who authenticate
+// with one of the provided API keys in the X-Api-Key header
+func NewPartnerRoutes(catalog services.CatalogService, apiKeys []string) Routable {
+	return &partnerRoutes{
+		catalog: catalog,
+		apiKeys: apiKeys,
+	}
+}
+
+func (p *partnerRoutes) Register(server *http.ServeMux) {
+	server.HandleFunc("/api/partners/catalog.ndjson.gz",
+		func(writer http.ResponseWriter, request *http.Request) {
+			if !p.authenticated(request) {
+				serializeError(writer, services.NewDomainError(401, "Invalid or missing API key", nil))
+				return
+			}
+			p.ExportCatalog(request, writer)
+		})
+}
+
+// ExportCatalog streams the whole movie catalog as gzip-compressed NDJSON, one movie
+// per line, ordered by `tmdbId`.
+// An interrupted download resumes by passing the last received `tmdbId` as the `after`
+// query parameter. Failures happening after the download started truncate the stream,
+// which gzip readers then report as unexpected EOF.
+func (p *partnerRoutes) ExportCatalog(request *http.Request, writer http.ResponseWriter) {
+	after := request.URL.Query().Get("after")
+	movies, err := p.catalog.FindAllAfter(request.Context(), after, catalogBatchSize)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/gzip")
+	writer.Header().Set("Content-Disposition", `attachment; filename="catalog.ndjson.gz"`)
+	writer.WriteHeader(200)
+	compressor := gzip.NewWriter(writer)
+	encoder := json.NewEncoder(compressor)
+	flusher, _ := writer.(http.Flusher)
+	for len(movies) > 0 {
+		for _, movie := range movies {
+			if err := encoder.Encode(movie); err != nil {
+				return
+			}
+		}
+		if err := compressor.Flush(); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if len(movies) < catalogBatchSize {
+			break
+		}
+		after = fmt.Sprint(movies[len(movies)-1]["tmdbId"])
+		if movies, err = p.catalog.FindAllAfter(request.Context(), after, catalogBatchSize); err != nil {
+			return
+		}
+	}
+	_ = compressor.Close()
+}
+
+func (p *partnerRoutes) authenticated(request *http.Request) bool {
+	key := request.Header.Get("X-Api-Key")
+	if key == "" {
+		return false
+	}
+	for _, apiKey := range p.apiKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) == 1 {
+			return true
+		}
+	}
+	return false
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/neo4j-graphacademy/neoflix/pkg/routes/paging"
 	"github.com/neo4j-graphacademy/neoflix/pkg/services"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
@@ -30,9 +31,17 @@ func (p *peopleRoutes) Register(server *http.ServeMux) {
 			switch {
 			case path == "":
 				p.FindAllPeople(request, writer)
+			case path == "initials":
+				p.CountPeopleByInitial(request, writer)
 			case strings.HasSuffix(path, "/similar"):
 				id := strings.TrimSuffix(path, "/similar")
 				p.FindAllPeopleBySimilarity(id, request, writer)
+			case strings.HasSuffix(path, "/co-actors"):
+				id := strings.TrimSuffix(path, "/co-actors")
+				p.FindCoActors(id, request, writer)
+			case strings.HasSuffix(path, "/filmography"):
+				id := strings.TrimSuffix(path, "/filmography")
+				p.FindFilmography(id, request, writer)
 			case strings.HasSuffix(path, "/acted"):
 				id := strings.TrimSuffix(path, "/acted")
 				p.FindAllActedInMovies(id, request, writer)
@@ -40,46 +49,139 @@ func (p *peopleRoutes) Register(server *http.ServeMux) {
 				id := strings.TrimSuffix(path, "/directed")
 				p.FindAllDirectedMovies(id, request, writer)
 			default:
-				p.FindOnePersonById(path, writer)
+				p.FindOnePersonById(path, request, writer)
 			}
 		})
 }
 
+// FindAllPeople lists the people, only those whose name starts with the `startsWith`
+// query parameter when set, e.g. a letter of the initials, and only the actors or the
+// directors with the `role` query parameter
 func (p *peopleRoutes) FindAllPeople(request *http.Request, writer http.ResponseWriter) {
-	page := paging.ParsePaging(request, paging.PersonSortableAttributes())
-	people, err := p.people.FindAll(page)
-	serializeJson(writer, people, err)
+	page, err := paging.ParsePaging(request, paging.PersonSortableAttributes())
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	if err := checkPagingQuota(page, request, p.auth); err != nil {
+		serializeError(writer, err)
+		return
+	}
+	filter := services.PersonFilter{
+		StartsWith: strings.TrimSpace(request.URL.Query().Get("startsWith")),
+		Role:       services.PersonRole(request.URL.Query().Get("role")),
+	}
+	if cursor, found := page.Cursor(); found {
+		if page.Query() != "" {
+			serializeError(writer, services.NewDomainError(400, "q cannot be combined with a cursor", nil))
+			return
+		}
+		people, err := p.people.FindAllAfter(request.Context(), cursor, filter, page)
+		serializeCursorPage(writer, request, page, people, err)
+		return
+	}
+	people, err := p.people.FindAllFiltered(request.Context(), filter, page)
+	serializePagedResult(writer, request, page, people, err)
 }
 
-func (p *peopleRoutes) FindOnePersonById(personId string, writer http.ResponseWriter) {
-	person, err := p.people.FindOneById(personId)
+// CountPeopleByInitial returns the number of people per initial of their name, A to Z
+// then "#" for the other initials
+func (p *peopleRoutes) CountPeopleByInitial(request *http.Request, writer http.ResponseWriter) {
+	initials, err := p.people.CountByInitial(request.Context())
+	serializeJson(writer, initials, err)
+}
+
+func (p *peopleRoutes) FindOnePersonById(personId string, request *http.Request, writer http.ResponseWriter) {
+	person, err := p.people.FindOneById(request.Context(), personId)
 	serializeJson(writer, person, err)
 }
 
 func (p *peopleRoutes) FindAllPeopleBySimilarity(id string, request *http.Request, writer http.ResponseWriter) {
-	page := paging.ParsePaging(request, paging.PersonSortableAttributes())
-	people, err := p.people.FindAllBySimilarity(id, page)
-	serializeJson(writer, people, err)
+	page, err := paging.ParsePaging(request, paging.PersonSortableAttributes())
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	if err := checkPagingQuota(page, request, p.auth); err != nil {
+		serializeError(writer, err)
+		return
+	}
+	query := request.URL.Query()
+	maxInCommon, _ := strconv.Atoi(query.Get("maxInCommon"))
+	people, err := p.people.FindAllBySimilarity(request.Context(), id, page, services.PersonSimilarityOptions{
+		ByPopularity: query.Get("secondarySort") == "popularity",
+		MaxInCommon:  maxInCommon,
+		Scope:        services.PersonSimilarityScope(query.Get("scope")),
+	})
+	serializePage(writer, request, page, people, err)
+}
+
+// FindFilmography lists the movies a person acted in or directed, along with their role
+// in each, most recently released first by default
+func (p *peopleRoutes) FindFilmography(id string, request *http.Request, writer http.ResponseWriter) {
+	page, err := paging.ParsePaging(request, paging.FilmographySortableAttributes())
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	if err := checkPagingQuota(page, request, p.auth); err != nil {
+		serializeError(writer, err)
+		return
+	}
+	credits, err := p.people.FindFilmography(request.Context(), id, page)
+	serializePagedResult(writer, request, page, credits, err)
+}
+
+// FindCoActors lists the people who acted in the same movies as a person, those who
+// shared the most movies with them first
+func (p *peopleRoutes) FindCoActors(id string, request *http.Request, writer http.ResponseWriter) {
+	page, err := paging.ParsePaging(request, paging.CoActorSortableAttributes())
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	if err := checkPagingQuota(page, request, p.auth); err != nil {
+		serializeError(writer, err)
+		return
+	}
+	coActors, err := p.people.FindCoActors(request.Context(), id, page)
+	serializePagedResult(writer, request, page, coActors, err)
 }
 
 func (p *peopleRoutes) FindAllActedInMovies(id string, request *http.Request, writer http.ResponseWriter) {
-	page := paging.ParsePaging(request, paging.MovieSortableAttributes())
+	page, err := paging.ParsePaging(request, paging.MovieSortableAttributes())
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
 	userId, err := extractUserId(request, p.auth)
 	if err != nil {
 		serializeError(writer, err)
 		return
 	}
-	movies, err := p.movies.FindAllByActorId(id, userId, page)
-	serializeJson(writer, movies, err)
+	if err := page.CheckQuota(userId != ""); err != nil {
+		serializeError(writer, err)
+		return
+	}
+	movies, err := p.movies.FindAllByActorId(request.Context(), id, userId, page)
+	serializePagedResult(writer, request, page, movies, err)
 }
 
 func (p *peopleRoutes) FindAllDirectedMovies(id string, request *http.Request, writer http.ResponseWriter) {
-	page := paging.ParsePaging(request, paging.MovieSortableAttributes())
+	page, err := paging.ParsePaging(request, paging.MovieSortableAttributes())
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
 	userId, err := extractUserId(request, p.auth)
 	if err != nil {
 		serializeError(writer, err)
 		return
 	}
-	movies, err := p.movies.FindAllByDirectorId(id, userId, page)
-	serializeJson(writer, movies, err)
+	if err := page.CheckQuota(userId != ""); err != nil {
+		serializeError(writer, err)
+		return
+	}
+	movies, err := p.movies.FindAllByDirectorId(request.Context(), id, userId, page)
+	serializePagedResult(writer, request, page, movies, err)
 }
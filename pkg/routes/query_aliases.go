@@ -0,0 +1,52 @@
+package routes
+
+import (
+	"net/http"
+)
+
+// QueryParameterAliases maps legacy names of query parameters to the names the routes
+// read, e.g. `orderBy` to `sort`
+type QueryParameterAliases map[string]string
+
+// DefaultQueryParameterAliases returns the names of the paging parameters used by the
+// clients built against the Node.js and Java versions of the app
+func DefaultQueryParameterAliases() QueryParameterAliases {
+	return QueryParameterAliases{
+		"orderBy":   "sort",
+		"direction": "order",
+		"pageSize":  "limit",
+		"offset":    "skip",
+	}
+}
+
+// WithQueryParameterAliases renames the aliased query parameters of the requests before
+// they reach the routes, so that the routes, and the URLs they generate, only ever deal
+// with the current names.
+// When a request sets both an alias and the parameter it stands for, the parameter wins.
+func WithQueryParameterAliases(handler http.Handler, aliases QueryParameterAliases) http.Handler {
+	if len(aliases) == 0 {
+		return handler
+	}
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		query := request.URL.Query()
+		renamed := false
+		for alias, name := range aliases {
+			values, found := query[alias]
+			if !found {
+				continue
+			}
+			if _, set := query[name]; !set {
+				query[name] = values
+			}
+			delete(query, alias)
+			renamed = true
+		}
+		if !renamed {
+			handler.ServeHTTP(writer, request)
+			return
+		}
+		rewritten := request.Clone(request.Context())
+		rewritten.URL.RawQuery = query.Encode()
+		handler.ServeHTTP(writer, rewritten)
+	})
+}
@@ -0,0 +1,67 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/services"
+)
+
+const (
+	transactionAttemptsHeader  = "X-Transaction-Attempts"
+	transactionRetryTimeHeader = "X-Transaction-Retry-Ms"
+)
+
+// WithRetryReporting sums the retries of the transactions run by every request and,
+// when the driver retried some of them after transient failures, reports the number of
+// attempts of all its transactions and the time spent retrying them in the
+// X-Transaction-Attempts and X-Transaction-Retry-Ms headers of the response
+func WithRetryReporting(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		tracker := &services.RetryTracker{}
+		ctx := services.ContextWithRetryTracker(request.Context(), tracker)
+		handler.ServeHTTP(&retryReportingWriter{ResponseWriter: writer, tracker: tracker}, request.WithContext(ctx))
+	})
+}
+
+// retryReportingWriter sets the retry headers right before the response is written,
+// once the transactions of the request are over
+type retryReportingWriter struct {
+	http.ResponseWriter
+	tracker     *services.RetryTracker
+	wroteHeader bool
+}
+
+func (rw *retryReportingWriter) WriteHeader(statusCode int) {
+	if !rw.wroteHeader {
+		rw.wroteHeader = true
+		if retries := rw.tracker.Retries(); retries.Retried > 0 {
+			rw.Header().Set(transactionAttemptsHeader, strconv.FormatInt(retries.Attempts, 10))
+			rw.Header().Set(transactionRetryTimeHeader, strconv.FormatInt(retries.RetryTimeMs, 10))
+		}
+	}
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rw *retryReportingWriter) Write(body []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	return rw.ResponseWriter.Write(body)
+}
+
+// Flush keeps the streamed responses, such as exports, flushable, the retry headers
+// being set before the first flush
+func (rw *retryReportingWriter) Flush() {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (rw *retryReportingWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,83 @@
+package routes
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/services"
+)
+
+// sharePage renders the Open Graph and Twitter meta tags of a movie,
+// then redirects browsers to the front-end movie page
+var sharePage = template.Must(template.New("share").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8">
+	<title>{{.Title}} | Neoflix</title>
+	<meta name="description" content="{{.Description}}">
+	<meta property="og:type" content="video.movie">
+	<meta property="og:site_name" content="Neoflix">
+	<meta property="og:title" content="{{.Title}}">
+	<meta property="og:description" content="{{.Description}}">
+	<meta property="og:url" content="{{.Url}}">
+	{{- if .Image}}
+	<meta property="og:image" content="{{.Image}}">
+	{{- end}}
+	<meta name="twitter:card" content="{{if .Image}}summary_large_image{{else}}summary{{end}}">
+	<meta name="twitter:title" content="{{.Title}}">
+	<meta name="twitter:description" content="{{.Description}}">
+	{{- if .Image}}
+	<meta name="twitter:image" content="{{.Image}}">
+	{{- end}}
+	<meta http-equiv="refresh" content="0; url={{.Url}}">
+</head>
+<body>
+	<a href="{{.Url}}">{{.Title}}</a>
+</body>
+</html>
+`))
+
+type shareRoutes struct {
+	movies    services.MovieService
+	publicUrl string
+}
+
+// NewShareRoutes serves the share pages of the movies, linking to the movie pages under
+// the public URL of the app
+func NewShareRoutes(movies services.MovieService, publicUrl string) Routable {
+	return &shareRoutes{movies: movies, publicUrl: strings.TrimSuffix(publicUrl, "/")}
+}
+
+func (s *shareRoutes) Register(server *http.ServeMux) {
+	server.HandleFunc("/share/movies/",
+		func(writer http.ResponseWriter, request *http.Request) {
+			id := strings.TrimPrefix(request.URL.Path, "/share/movies/")
+			s.ShareMovie(id, request, writer)
+		})
+}
+
+func (s *shareRoutes) ShareMovie(id string, request *http.Request, writer http.ResponseWriter) {
+	movie, err := s.movies.FindOneById(request.Context(), id, "")
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	var page bytes.Buffer
+	err = sharePage.Execute(&page, map[string]interface{}{
+		"Title":       movie["title"],
+		"Description": movie["plot"],
+		"Image":       movie["poster"],
+		"Url":         fmt.Sprintf("%s/movies/%s", s.publicUrl, url.PathEscape(id)),
+	})
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	writer.Header().Add("Content-Type", "text/html; charset=utf-8")
+	writer.WriteHeader(200)
+	_, _ = writer.Write(page.Bytes())
+}
@@ -0,0 +1,178 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/cache"
+	"github.com/neo4j-graphacademy/neoflix/pkg/services"
+)
+
+const (
+	sitemapXmlns = "http://www.sitemaps.org/schemas/sitemap/0.9"
+	// sitemapChunkSize is the number of URLs per sitemap (the protocol allows up to 50,000)
+	sitemapChunkSize = 10000
+	// sitemapBatchSize is the number of ids fetched per query while generating a sitemap
+	sitemapBatchSize = 1000
+	sitemapCacheTtl  = 24 * time.Hour
+	// sitemapCacheMaxEntries bounds the sitemaps cached, the index included
+	sitemapCacheMaxEntries = 1000
+)
+
+// sitemapSections maps the sitemap names to the label they list and
+// the front-end path of the matching pages
+var sitemapSections = map[string]struct {
+	label services.SitemapLabel
+	path  string
+}{
+	"movies": {label: services.SitemapMovies, path: "/movies/"},
+	"people": {label: services.SitemapPeople, path: "/people/"},
+}
+
+type sitemapRoutes struct {
+	sitemaps  services.SitemapService
+	publicUrl string
+	cache     *cache.Cache
+}
+
+// NewSitemapRoutes serves the sitemaps of the catalog, linking to the pages under the
+// public URL of the app
+func NewSitemapRoutes(sitemaps services.SitemapService, publicUrl string) Routable {
+	return &sitemapRoutes{
+		sitemaps:  sitemaps,
+		publicUrl: strings.TrimSuffix(publicUrl, "/"),
+		cache:     cache.New(cache.Options{TTL: sitemapCacheTtl, MaxEntries: sitemapCacheMaxEntries}),
+	}
+}
+
+func (s *sitemapRoutes) Register(server *http.ServeMux) {
+	server.HandleFunc("/robots.txt",
+		func(writer http.ResponseWriter, request *http.Request) {
+			s.Robots(request, writer)
+		})
+	server.HandleFunc("/sitemap.xml",
+		func(writer http.ResponseWriter, request *http.Request) {
+			s.serveCached("index", request, writer, s.writeIndex)
+		})
+	server.HandleFunc("/sitemaps/",
+		func(writer http.ResponseWriter, request *http.Request) {
+			name := strings.TrimSuffix(strings.TrimPrefix(request.URL.Path, "/sitemaps/"), ".xml")
+			s.serveCached(name, request, writer, func(ctx context.Context, buffer *bytes.Buffer) error {
+				return s.writeSitemap(ctx, name, buffer)
+			})
+		})
+}
+
+func (s *sitemapRoutes) Robots(request *http.Request, writer http.ResponseWriter) {
+	writer.Header().Add("Content-Type", "text/plain")
+	writer.WriteHeader(200)
+	_, _ = fmt.Fprintf(writer, "User-agent: *\nDisallow: /api/\nAllow: /\n\nSitemap: %s/sitemap.xml\n", s.publicUrl)
+}
+
+// serveCached serves the named document, generating it at most once a day.
+// Unknown documents are not cached, so that the cache only ever holds the index and
+// the existing sitemaps.
+func (s *sitemapRoutes) serveCached(name string,
+	request *http.Request,
+	writer http.ResponseWriter,
+	generate func(context.Context, *bytes.Buffer) error) {
+
+	// the document may be generated for other requests as well, so only the metadata of
+	// the request is kept
+	metadata, _ := services.RequestMetadataFromContext(request.Context())
+	ctx := services.ContextWithRequestMetadata(context.Background(), metadata)
+	body, err := s.cache.Get(name, func() (interface{}, error) {
+		var buffer bytes.Buffer
+		buffer.WriteString(xml.Header)
+		if err := generate(ctx, &buffer); err != nil {
+			return nil, err
+		}
+		return buffer.Bytes(), nil
+	})
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	writeXml(writer, body.([]byte))
+}
+
+func (s *sitemapRoutes) writeIndex(ctx context.Context, buffer *bytes.Buffer) error {
+	buffer.WriteString(`<sitemapindex xmlns="` + sitemapXmlns + `">`)
+	for _, name := range []string{"movies", "people"} {
+		chunks, err := s.chunks(ctx, sitemapSections[name].label)
+		if err != nil {
+			return err
+		}
+		for chunk := 1; chunk <= chunks; chunk++ {
+			buffer.WriteString("<sitemap><loc>")
+			_ = xml.EscapeText(buffer, []byte(fmt.Sprintf("%s/sitemaps/%s-%d.xml", s.publicUrl, name, chunk)))
+			buffer.WriteString("</loc></sitemap>")
+		}
+	}
+	buffer.WriteString("</sitemapindex>")
+	return nil
+}
+
+// chunks returns the number of sitemaps listing the entities of the label
+func (s *sitemapRoutes) chunks(ctx context.Context, label services.SitemapLabel) (int, error) {
+	count, err := s.sitemaps.Count(ctx, label)
+	if err != nil {
+		return 0, err
+	}
+	return int(math.Ceil(float64(count) / sitemapChunkSize)), nil
+}
+
+// writeSitemap generates a sitemap chunk named after its section and 1-based number,
+// e.g. movies-2, by streaming ids in batches.
+//
+// If the section is unknown or the chunk is past the last one, a 404 error is returned.
+func (s *sitemapRoutes) writeSitemap(ctx context.Context, name string, buffer *bytes.Buffer) error {
+	dash := strings.LastIndex(name, "-")
+	if dash < 0 {
+		return services.NewDomainError(404, "sitemap not found", nil)
+	}
+	section, found := sitemapSections[name[:dash]]
+	chunk, err := strconv.Atoi(name[dash+1:])
+	if !found || err != nil || chunk < 1 {
+		return services.NewDomainError(404, "sitemap not found", nil)
+	}
+	chunks, err := s.chunks(ctx, section.label)
+	if err != nil {
+		return err
+	}
+	if chunk > chunks {
+		return services.NewDomainError(404, "sitemap not found", nil)
+	}
+
+	buffer.WriteString(`<urlset xmlns="` + sitemapXmlns + `">`)
+	start := (chunk - 1) * sitemapChunkSize
+	for skip := start; skip < start+sitemapChunkSize; skip += sitemapBatchSize {
+		ids, err := s.sitemaps.FindAllIds(ctx, section.label, skip, sitemapBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			buffer.WriteString("<url><loc>")
+			_ = xml.EscapeText(buffer, []byte(s.publicUrl+section.path+id))
+			buffer.WriteString("</loc></url>")
+		}
+		if len(ids) < sitemapBatchSize {
+			break
+		}
+	}
+	buffer.WriteString("</urlset>")
+	return nil
+}
+
+func writeXml(writer http.ResponseWriter, body []byte) {
+	writer.Header().Add("Content-Type", "application/xml")
+	writer.WriteHeader(200)
+	_, _ = writer.Write(body)
+}
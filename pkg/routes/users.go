@@ -0,0 +1,115 @@
+package routes
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/routes/paging"
+	"github.com/neo4j-graphacademy/neoflix/pkg/services"
+)
+
+type userRoutes struct {
+	ratings services.RatingService
+	follows services.FollowService
+	blocks  services.BlockService
+	auth    services.AuthService
+}
+
+func NewUserRoutes(ratings services.RatingService,
+	follows services.FollowService,
+	blocks services.BlockService,
+	auth services.AuthService) Routable {
+	return &userRoutes{
+		ratings: ratings,
+		follows: follows,
+		blocks:  blocks,
+		auth:    auth,
+	}
+}
+
+func (u *userRoutes) Register(server *http.ServeMux) {
+	server.HandleFunc("/api/users/",
+		func(writer http.ResponseWriter, request *http.Request) {
+			path := strings.TrimPrefix(request.URL.Path, "/api/users/")
+			switch {
+			case strings.HasSuffix(path, "/reviews") && request.Method == "GET":
+				id := strings.TrimSuffix(path, "/reviews")
+				u.FindAllReviewsByUserId(id, request, writer)
+			case strings.HasSuffix(path, "/follow"):
+				id := strings.TrimSuffix(path, "/follow")
+				switch request.Method {
+				case "PUT":
+					u.Follow(id, request, writer)
+				case "DELETE":
+					u.Unfollow(id, request, writer)
+				}
+			case strings.HasSuffix(path, "/block"):
+				id := strings.TrimSuffix(path, "/block")
+				switch request.Method {
+				case "PUT":
+					u.Block(id, request, writer)
+				case "DELETE":
+					u.Unblock(id, request, writer)
+				}
+			}
+		})
+}
+
+func (u *userRoutes) FindAllReviewsByUserId(id string, request *http.Request, writer http.ResponseWriter) {
+	page, err := paging.ParsePaging(request, paging.ReviewSortableAttributes())
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	viewerId, err := extractUserId(request, u.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	if err := page.CheckQuota(viewerId != ""); err != nil {
+		serializeError(writer, err)
+		return
+	}
+	reviews, err := u.ratings.FindAllReviewsByUserId(request.Context(), id, viewerId, page)
+	serializePage(writer, request, page, reviews, err)
+}
+
+func (u *userRoutes) Follow(id string, request *http.Request, writer http.ResponseWriter) {
+	userId, err := extractUserId(request, u.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	user, err := u.follows.Save(request.Context(), userId, id)
+	serializeJson(writer, user, err)
+}
+
+func (u *userRoutes) Unfollow(id string, request *http.Request, writer http.ResponseWriter) {
+	userId, err := extractUserId(request, u.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	user, err := u.follows.Delete(request.Context(), userId, id)
+	serializeJson(writer, user, err)
+}
+
+func (u *userRoutes) Block(id string, request *http.Request, writer http.ResponseWriter) {
+	userId, err := extractUserId(request, u.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	user, err := u.blocks.Save(request.Context(), userId, id)
+	serializeJson(writer, user, err)
+}
+
+func (u *userRoutes) Unblock(id string, request *http.Request, writer http.ResponseWriter) {
+	userId, err := extractUserId(request, u.auth)
+	if err != nil {
+		serializeError(writer, err)
+		return
+	}
+	user, err := u.blocks.Delete(request.Context(), userId, id)
+	serializeJson(writer, user, err)
+}
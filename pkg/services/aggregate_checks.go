@@ -0,0 +1,112 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// AggregateDrift is a stored aggregate of a Movie which differs from the value computed
+// from its relationships
+type AggregateDrift struct {
+	Id     string      `json:"id"`
+	Field  string      `json:"field"`
+	Stored interface{} `json:"stored"`
+	Actual interface{} `json:"actual"`
+}
+
+// checkedAggregates are the aggregates of movies compared by FindAllAggregateDrifts
+var checkedAggregates = []string{"ratingCount", "ratingAvg", "favoriteCount"}
+
+// aggregateDrifts compares the stored aggregates of the Movie to the actual ones.
+// Averages differing by rounding errors only do not drift.
+func aggregateDrifts(id string, stored, actual map[string]interface{}) []AggregateDrift {
+	var drifts []AggregateDrift
+	for _, field := range checkedAggregates {
+		storedValue, actualValue := stored[field], actual[field]
+		storedNumber, storedOk := toFloat(storedValue)
+		actualNumber, actualOk := toFloat(actualValue)
+		if storedOk && actualOk && math.Abs(storedNumber-actualNumber) < 1e-9 ||
+			storedValue == nil && actualValue == nil {
+			continue
+		}
+		drifts = append(drifts, AggregateDrift{Id: id, Field: field, Stored: storedValue, Actual: actualValue})
+	}
+	return drifts
+}
+
+// AggregateCheck is the result of a check of the aggregates of a sample of movies
+type AggregateCheck struct {
+	Checked  int
+	Drifts   []AggregateDrift
+	Repaired int
+}
+
+// AggregateCheckStats sums the results of the checks of the aggregates of movies
+type AggregateCheckStats struct {
+	Runs      int64            `json:"runs"`
+	Checked   int64            `json:"checked"`
+	Drifted   int64            `json:"drifted"`
+	Repaired  int64            `json:"repaired"`
+	ByField   map[string]int64 `json:"byField"`
+	LastRunAt *time.Time       `json:"lastRunAt"`
+	// LastDrifts are the drifts found by the last run which found some
+	LastDrifts []AggregateDrift `json:"lastDrifts"`
+}
+
+// AggregateCheckMetrics sums the results of the checks of the aggregates of movies
+type AggregateCheckMetrics struct {
+	mutex sync.Mutex
+	stats AggregateCheckStats
+}
+
+func NewAggregateCheckMetrics() *AggregateCheckMetrics {
+	return &AggregateCheckMetrics{stats: AggregateCheckStats{
+		ByField:    map[string]int64{},
+		LastDrifts: []AggregateDrift{},
+	}}
+}
+
+// Record adds the results of a check run at the given time
+func (am *AggregateCheckMetrics) Record(now time.Time, check AggregateCheck) {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+	am.stats.Runs++
+	am.stats.Checked += int64(check.Checked)
+	am.stats.Drifted += int64(len(driftedIds(check.Drifts)))
+	am.stats.Repaired += int64(check.Repaired)
+	am.stats.LastRunAt = &now
+	for _, drift := range check.Drifts {
+		am.stats.ByField[drift.Field]++
+	}
+	if len(check.Drifts) > 0 {
+		am.stats.LastDrifts = check.Drifts
+	}
+}
+
+// Stats returns the results of the checks so far
+func (am *AggregateCheckMetrics) Stats() AggregateCheckStats {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+	stats := am.stats
+	stats.ByField = map[string]int64{}
+	for field, count := range am.stats.ByField {
+		stats.ByField[field] = count
+	}
+	return stats
+}
+
+// driftedIds returns the sorted IDs of the drifting movies
+func driftedIds(drifts []AggregateDrift) []string {
+	var ids []string
+	seen := map[string]bool{}
+	for _, drift := range drifts {
+		if !seen[drift.Id] {
+			seen[drift.Id] = true
+			ids = append(ids, drift.Id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
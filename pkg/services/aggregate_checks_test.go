@@ -0,0 +1,43 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAggregateDrifts(t *testing.T) {
+	stored := map[string]interface{}{"ratingCount": int64(3), "ratingAvg": 4.0, "favoriteCount": nil}
+	actual := map[string]interface{}{"ratingCount": int64(4), "ratingAvg": 4.0 + 1e-12, "favoriteCount": int64(0)}
+
+	drifts := aggregateDrifts("603", stored, actual)
+
+	expected := []AggregateDrift{
+		{Id: "603", Field: "ratingCount", Stored: int64(3), Actual: int64(4)},
+		{Id: "603", Field: "favoriteCount", Stored: nil, Actual: int64(0)},
+	}
+	if !reflect.DeepEqual(drifts, expected) {
+		t.Fatalf("expected %v, got %v", expected, drifts)
+	}
+}
+
+func TestAggregateCheckMetrics(t *testing.T) {
+	metrics := NewAggregateCheckMetrics()
+	metrics.Record(time.Now(), AggregateCheck{Checked: 10, Drifts: []AggregateDrift{
+		{Id: "603", Field: "ratingCount"},
+		{Id: "603", Field: "ratingAvg"},
+		{Id: "604", Field: "ratingCount"},
+	}, Repaired: 2})
+	metrics.Record(time.Now(), AggregateCheck{Checked: 10})
+
+	stats := metrics.Stats()
+	if stats.Runs != 2 || stats.Checked != 20 || stats.Drifted != 2 || stats.Repaired != 2 {
+		t.Fatalf("unexpected totals %+v", stats)
+	}
+	if stats.ByField["ratingCount"] != 2 || stats.ByField["ratingAvg"] != 1 {
+		t.Fatalf("unexpected counts per field %v", stats.ByField)
+	}
+	if len(stats.LastDrifts) != 3 {
+		t.Fatalf("expected the drifts of the first run, got %v", stats.LastDrifts)
+	}
+}
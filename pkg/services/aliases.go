@@ -0,0 +1,47 @@
+package services
+
+import (
+	"strings"
+)
+
+// fulltextSpecialCharacters are the characters with a meaning in the Lucene query
+// syntax of the full-text index
+const fulltextSpecialCharacters = `+-&|!(){}[]^"~*?:\/`
+
+// fulltextQuery turns the terms searched by users into a query of the full-text
+// indexes matching all of them, so that the Lucene syntax in the terms is
+// searched as is, operators included.
+// The last term is also matched as a prefix, to match names while they are typed.
+func fulltextQuery(q string) string {
+	terms := strings.Fields(q)
+	for i, term := range terms {
+		var escaped strings.Builder
+		for _, char := range strings.ToLower(term) {
+			if strings.ContainsRune(fulltextSpecialCharacters, char) {
+				escaped.WriteRune('\\')
+			}
+			escaped.WriteRune(char)
+		}
+		terms[i] = escaped.String()
+	}
+	if len(terms) > 0 {
+		last := terms[len(terms)-1]
+		terms[len(terms)-1] = "(" + last + " OR " + last + "*)"
+	}
+	return strings.Join(terms, " AND ")
+}
+
+// normalizeAliases trims the aliases and drops the blank and duplicated ones
+func normalizeAliases(aliases []string) []string {
+	result := []string{}
+	seen := map[string]bool{}
+	for _, alias := range aliases {
+		alias = strings.TrimSpace(alias)
+		if alias == "" || seen[strings.ToLower(alias)] {
+			continue
+		}
+		seen[strings.ToLower(alias)] = true
+		result = append(result, alias)
+	}
+	return result
+}
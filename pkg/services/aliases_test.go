@@ -0,0 +1,18 @@
+package services
+
+import "testing"
+
+func TestFulltextQuery(t *testing.T) {
+	for q, expected := range map[string]string{
+		"Se7en":            `(se7en OR se7en*)`,
+		"  the  Matrix ":   `the AND (matrix OR matrix*)`,
+		"AND OR":           `and AND (or OR or*)`,
+		`title:"x" (y)`:    `title\:\"x\" AND (\(y\) OR \(y\)*)`,
+		"spider-man 2/3 !": `spider\-man AND 2\/3 AND (\! OR \!*)`,
+		"":                 "",
+	} {
+		if query := fulltextQuery(q); query != expected {
+			t.Errorf("expected %q for %q, got %q", expected, q, query)
+		}
+	}
+}
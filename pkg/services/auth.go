@@ -1,39 +1,44 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
 	"github.com/neo4j-graphacademy/neoflix/pkg/services/jwtutils"
-	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type User map[string]interface{}
 
 type AuthService interface {
-	Save(email, plainPassword, name string) (User, error)
+	Save(ctx context.Context, email, plainPassword, name string) (User, error)
 
-	FindOneByEmailAndPassword(email string, password string) (User, error)
+	FindOneByEmailAndPassword(ctx context.Context, email string, password string) (User, error)
 
 	ExtractUserId(bearer string) (string, error)
+
+	IsAdmin(ctx context.Context, userId string) (bool, error)
 }
 
 type neo4jAuthService struct {
 	loader     *fixtures.FixtureLoader
-	driver     neo4j.Driver
+	driver     SessionFactory
 	jwtSecret  string
 	saltRounds int
+	options    serviceOptions
 }
 
-func NewAuthService(loader *fixtures.FixtureLoader, driver neo4j.Driver, jwtSecret string, saltRounds int) AuthService {
+func NewAuthService(loader *fixtures.FixtureLoader, driver SessionFactory, jwtSecret string, saltRounds int, opts ...Option) AuthService {
 	return &neo4jAuthService{
 		loader:     loader,
 		driver:     driver,
 		jwtSecret:  jwtSecret,
 		saltRounds: saltRounds,
+		options:    newServiceOptions(opts),
 	}
 }
 
@@ -44,14 +49,16 @@ func NewAuthService(loader *fixtures.FixtureLoader, driver neo4j.Driver, jwtSecr
 // The properties also be used to generate a JWT `token` which should be included
 // with the returned user.
 // tag::register[]
-func (as *neo4jAuthService) Save(email, plainPassword, name string) (_ User, err error) {
-	session := as.driver.NewSession(neo4j.SessionConfig{})
+func (as *neo4jAuthService) Save(ctx context.Context, email, plainPassword, name string) (_ User, err error) {
+	ctx, cancel := as.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := as.driver.NewSession(ctx, as.options.sessionConfig(ctx, neo4j.AccessModeWrite))
 
 	defer func() {
-		err = ioutils.DeferredClose(session, err)
+		err = ioutils.DeferredContextClose(ctx, session, err)
 	}()
 
-	result, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
 		encryptedPassword, err := encryptPassword(plainPassword, as.saltRounds)
 		if err != nil {
 			return nil, err
@@ -62,14 +69,7 @@ func (as *neo4jAuthService) Save(email, plainPassword, name string) (_ User, err
 		// IF NOT EXISTS
 		// FOR (user:User)
 		// REQUIRE user.email IS UNIQUE;
-		result, err := tx.Run(`
-			CREATE (u:User {
-				userId: randomUuid(),
-				email: $email,
-				password: $encrypted,
-				name: $name
-			})
-			RETURN u { .userId, .name, .email } as u`,
+		result, err := as.options.run(ctx, tx, "auth/save", nil,
 			map[string]interface{}{
 				"email":     email,
 				"encrypted": encryptedPassword,
@@ -86,14 +86,14 @@ func (as *neo4jAuthService) Save(email, plainPassword, name string) (_ User, err
 			)
 		}
 
-		record, err := result.Single()
+		record, err := result.Single(ctx)
 		if err != nil {
 			return nil, err
 		}
 
 		user, _ := record.Get("u")
 		return user, nil
-	})
+	}, as.options.txConfig(ctx, FastLookup))
 
 	if err != nil {
 		return nil, err
@@ -113,28 +113,29 @@ func (as *neo4jAuthService) Save(email, plainPassword, name string) (_ User, err
 // end::register[]
 
 // tag::authenticate[]
-func (as *neo4jAuthService) FindOneByEmailAndPassword(email string, password string) (_ User, err error) {
-	session := as.driver.NewSession(neo4j.SessionConfig{})
+func (as *neo4jAuthService) FindOneByEmailAndPassword(ctx context.Context, email string, password string) (_ User, err error) {
+	ctx, cancel := as.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := as.driver.NewSession(ctx, as.options.sessionConfig(ctx, neo4j.AccessModeRead))
 
 	defer func() {
-		err = ioutils.DeferredClose(session, err)
+		err = ioutils.DeferredContextClose(ctx, session, err)
 	}()
 
-	result, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		result, err := tx.Run(`
-			MATCH (u:User {email: $email}) RETURN u`,
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := as.options.run(ctx, tx, "auth/find_one_by_email_and_password", nil,
 			map[string]interface{}{
 				"email": email,
 			})
 
-		record, err := result.Single()
+		record, err := result.Single(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("account not found or multiple ones")
 		}
 
 		user, _ := record.Get("u")
 		return user, nil
-	})
+	}, as.options.txConfig(ctx, FastLookup))
 	if err != nil {
 		return nil, err
 	}
@@ -170,6 +171,40 @@ func (as *neo4jAuthService) ExtractUserId(bearer string) (string, error) {
 	return userId.(string), nil
 }
 
+// IsAdmin returns true when the User holds the `admin` role
+func (as *neo4jAuthService) IsAdmin(ctx context.Context, userId string) (_ bool, err error) {
+	if userId == "" {
+		return false, nil
+	}
+
+	ctx, cancel := as.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := as.driver.NewSession(ctx, as.options.sessionConfig(ctx, neo4j.AccessModeRead))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := as.options.run(ctx, tx, "auth/is_admin", nil,
+			map[string]interface{}{
+				"userId": userId,
+			})
+		if err != nil {
+			return nil, err
+		}
+		if !result.Next(ctx) {
+			return false, result.Err()
+		}
+		admin, _ := result.Record().Get("admin")
+		return admin, nil
+	}, as.options.txConfig(ctx, FastLookup))
+	if err != nil {
+		return false, err
+	}
+	return result.(bool), nil
+}
+
 func encryptPassword(password string, cost int) (string, error) {
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
 	if err != nil {
@@ -196,9 +231,10 @@ func userToClaims(user User) map[string]interface{} {
 
 func userWithToken(user User, token string) User {
 	return map[string]interface{}{
-		"token":  token,
-		"userId": user["userId"],
-		"email":  user["email"],
-		"name":   user["name"],
+		"token":     token,
+		"userId":    user["userId"],
+		"email":     user["email"],
+		"name":      user["name"],
+		"avatarUrl": user["avatarUrl"],
 	}
 }
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j-graphacademy/neoflix/pkg/storage"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"golang.org/x/image/draw"
+)
+
+const (
+	// AvatarSize is the maximum width and height of stored avatars
+	AvatarSize = 256
+	// maxAvatarSourceSize is the maximum width and height of uploaded images,
+	// checked before decoding to guard against decompression bombs
+	maxAvatarSourceSize = 4096
+)
+
+type AvatarService interface {
+	Save(ctx context.Context, userId string, image io.Reader) (User, error)
+}
+
+type neo4jAvatarService struct {
+	loader  *fixtures.FixtureLoader
+	driver  SessionFactory
+	storage storage.Storage
+	options serviceOptions
+}
+
+func NewAvatarService(loader *fixtures.FixtureLoader, driver SessionFactory, storage storage.Storage, opts ...Option) AvatarService {
+	return &neo4jAvatarService{
+		loader:  loader,
+		driver:  driver,
+		storage: storage,
+		options: newServiceOptions(opts),
+	}
+}
+
+// Save validates the uploaded image, resizes it to fit within AvatarSize,
+// stores it and sets the resulting URL as the `avatarUrl` property of the User.
+//
+// If the image is not a valid JPEG, PNG or GIF file, a 422 error is returned.
+func (as *neo4jAvatarService) Save(ctx context.Context, userId string, upload io.Reader) (_ User, err error) {
+	avatar, err := resizeAvatar(upload)
+	if err != nil {
+		return nil, err
+	}
+	avatarUrl, err := as.storage.Put(fmt.Sprintf("%s-%d.png", userId, time.Now().Unix()), "image/png", avatar)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := as.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := as.driver.NewSession(ctx, as.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := as.options.run(ctx, tx, "avatars/save", nil,
+			map[string]interface{}{
+				"userId":    userId,
+				"avatarUrl": avatarUrl,
+			})
+		if err != nil {
+			return nil, err
+		}
+
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		user, _ := record.Get("u")
+		return user, nil
+	}, as.options.txConfig(ctx, FastLookup))
+
+	if err != nil {
+		return nil, err
+	}
+	return result.(map[string]interface{}), nil
+}
+
+func resizeAvatar(upload io.Reader) (io.Reader, error) {
+	content, err := ioutil.ReadAll(upload)
+	if err != nil {
+		return nil, err
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, invalidAvatarError("Unsupported image format, expected JPEG, PNG or GIF")
+	}
+	if config.Width > maxAvatarSourceSize || config.Height > maxAvatarSourceSize {
+		return nil, invalidAvatarError(fmt.Sprintf("Image must be at most %dx%d pixels", maxAvatarSourceSize, maxAvatarSourceSize))
+	}
+	source, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, invalidAvatarError("Corrupted image")
+	}
+
+	width, height := fitWithin(source.Bounds().Dx(), source.Bounds().Dy(), AvatarSize)
+	target := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(target, target.Bounds(), source, source.Bounds(), draw.Over, nil)
+
+	var result bytes.Buffer
+	if err := png.Encode(&result, target); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// fitWithin scales the dimensions down, preserving the aspect ratio,
+// so that neither exceeds maxSize
+func fitWithin(width, height, maxSize int) (int, int) {
+	if width <= maxSize && height <= maxSize {
+		return width, height
+	}
+	if width >= height {
+		return maxSize, maxInt(1, height*maxSize/width)
+	}
+	return maxInt(1, width*maxSize/height), maxSize
+}
+
+func maxInt(a, b int) int {
+	if a >= b {
+		return a
+	}
+	return b
+}
+
+func invalidAvatarError(reason string) error {
+	return NewDomainError(422, "Invalid avatar", map[string]interface{}{
+		"avatar": reason,
+	})
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+type BlockService interface {
+	Save(ctx context.Context, userId, blockedId string) (User, error)
+
+	Delete(ctx context.Context, userId, blockedId string) (User, error)
+}
+
+type neo4jBlockService struct {
+	loader  *fixtures.FixtureLoader
+	driver  SessionFactory
+	options serviceOptions
+}
+
+func NewBlockService(loader *fixtures.FixtureLoader, driver SessionFactory, opts ...Option) BlockService {
+	return &neo4jBlockService{
+		loader:  loader,
+		driver:  driver,
+		options: newServiceOptions(opts),
+	}
+}
+
+// Save creates a `:BLOCKS` relationship between the User and the blocked User, whose
+// reviews and activity are then hidden from the User, and returns the blocked User.
+// The User stops following the blocked User.
+//
+// If either User cannot be found, a 404 error is returned.
+func (bs *neo4jBlockService) Save(ctx context.Context, userId, blockedId string) (User, error) {
+	if userId == blockedId {
+		return nil, NewDomainError(400, "Users cannot block themselves", nil)
+	}
+	return bs.write(ctx, "blocks/save", userId, blockedId)
+}
+
+// Delete removes the `:BLOCKS` relationship between the User and the blocked User
+func (bs *neo4jBlockService) Delete(ctx context.Context, userId, blockedId string) (User, error) {
+	return bs.write(ctx, "blocks/delete", userId, blockedId)
+}
+
+func (bs *neo4jBlockService) write(ctx context.Context, statement, userId, blockedId string) (_ User, err error) {
+	ctx, cancel := bs.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := bs.driver.NewSession(ctx, bs.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := bs.options.run(ctx, tx, statement, nil, map[string]interface{}{
+			"userId":    userId,
+			"blockedId": blockedId,
+		})
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, NewDomainError(404, "User not found", map[string]interface{}{"userId": blockedId})
+		}
+		user, _ := record.Get("user")
+		return User(user.(map[string]interface{})), nil
+	}, bs.options.txConfig(ctx, FastLookup))
+	if err != nil {
+		return nil, err
+	}
+	return result.(User), nil
+}
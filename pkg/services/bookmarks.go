@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+type bookmarkManagerKey struct{}
+
+// ContextWithBookmarkManager returns a copy of the context in which the sessions of the
+// services wait for the bookmarks of the manager before running their transactions, and
+// the write sessions hand it the bookmarks of the transactions they ran.
+// Passing the bookmarks of the requests of a user to the next ones guarantees that
+// they read their own writes, even when routed to a server lagging behind the leader.
+func ContextWithBookmarkManager(ctx context.Context, manager neo4j.BookmarkManager) context.Context {
+	return context.WithValue(ctx, bookmarkManagerKey{}, manager)
+}
+
+// readOnlyBookmarkManager hands the bookmarks of the manager to read sessions, without
+// replacing them by the bookmarks of their transactions, which only reflect the writes
+// the manager knows of already
+type readOnlyBookmarkManager struct {
+	neo4j.BookmarkManager
+}
+
+func (readOnlyBookmarkManager) UpdateBookmarks(context.Context, neo4j.Bookmarks, neo4j.Bookmarks) error {
+	return nil
+}
+
+// BookmarkManagerFromContext returns the BookmarkManager of the context, if any
+func BookmarkManagerFromContext(ctx context.Context) (neo4j.BookmarkManager, bool) {
+	manager, found := ctx.Value(bookmarkManagerKey{}).(neo4j.BookmarkManager)
+	return manager, found
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+)
+
+type cacheInvalidatingFavoriteService struct {
+	FavoriteService
+	caches []UserCache
+}
+
+// NewCacheInvalidatingFavoriteService decorates the provided FavoriteService to drop
+// the cached results of the users whose favorites change
+func NewCacheInvalidatingFavoriteService(inner FavoriteService, caches ...UserCache) FavoriteService {
+	return &cacheInvalidatingFavoriteService{FavoriteService: inner, caches: caches}
+}
+
+func (cs *cacheInvalidatingFavoriteService) Save(ctx context.Context, userId, movieId string) (Movie, error) {
+	defer invalidateUser(cs.caches, userId)
+	return cs.FavoriteService.Save(ctx, userId, movieId)
+}
+
+func (cs *cacheInvalidatingFavoriteService) Delete(ctx context.Context, userId, movieId string) (Movie, error) {
+	defer invalidateUser(cs.caches, userId)
+	return cs.FavoriteService.Delete(ctx, userId, movieId)
+}
+
+func (cs *cacheInvalidatingFavoriteService) Toggle(ctx context.Context, userId, movieId string) (Movie, error) {
+	defer invalidateUser(cs.caches, userId)
+	return cs.FavoriteService.Toggle(ctx, userId, movieId)
+}
+
+type cacheInvalidatingRatingService struct {
+	RatingService
+	caches []UserCache
+}
+
+// NewCacheInvalidatingRatingService decorates the provided RatingService to drop the
+// cached results of the users whose ratings change
+func NewCacheInvalidatingRatingService(inner RatingService, caches ...UserCache) RatingService {
+	return &cacheInvalidatingRatingService{RatingService: inner, caches: caches}
+}
+
+func (cs *cacheInvalidatingRatingService) Save(ctx context.Context, rating int, movieId string, userId string) (Movie, error) {
+	defer invalidateUser(cs.caches, userId)
+	return cs.RatingService.Save(ctx, rating, movieId, userId)
+}
+
+func (cs *cacheInvalidatingRatingService) Update(ctx context.Context, rating int, movieId string, userId string) (Movie, error) {
+	defer invalidateUser(cs.caches, userId)
+	return cs.RatingService.Update(ctx, rating, movieId, userId)
+}
+
+func (cs *cacheInvalidatingRatingService) Delete(ctx context.Context, movieId string, userId string) (Movie, error) {
+	defer invalidateUser(cs.caches, userId)
+	return cs.RatingService.Delete(ctx, movieId, userId)
+}
+
+type cacheInvalidatingContentWarningService struct {
+	ContentWarningService
+	caches []UserCache
+}
+
+// NewCacheInvalidatingContentWarningService decorates the provided ContentWarningService
+// to drop the cached results of the users whose excluded content warnings change
+func NewCacheInvalidatingContentWarningService(inner ContentWarningService, caches ...UserCache) ContentWarningService {
+	return &cacheInvalidatingContentWarningService{ContentWarningService: inner, caches: caches}
+}
+
+func (cs *cacheInvalidatingContentWarningService) SaveExcluded(ctx context.Context, userId string, warnings []string) ([]string, error) {
+	defer invalidateUser(cs.caches, userId)
+	return cs.ContentWarningService.SaveExcluded(ctx, userId, warnings)
+}
+
+// invalidateUser drops the results of the user from the caches once the write is over,
+// whether it succeeded or not, as a failed write may still have been committed
+func invalidateUser(caches []UserCache, userId string) {
+	for _, cache := range caches {
+		cache.InvalidateUser(userId)
+	}
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/cache"
+)
+
+// CachedService is implemented by the services decorated with an in-process cache,
+// to monitor their hit rate and to bust them once the cached data is edited
+type CachedService interface {
+	CacheStats() cache.Stats
+
+	Bust()
+}
+
+type cachedPeopleService struct {
+	PeopleService
+	cache       *cache.Cache
+	loadTimeout time.Duration
+}
+
+// NewCachedPeopleService decorates the provided PeopleService with a short-lived
+// memoization of the person details returned by FindOneById.
+// Saving the aliases of a person busts their memoized details.
+func NewCachedPeopleService(inner PeopleService, opts CacheOptions) PeopleService {
+	return &cachedPeopleService{
+		PeopleService: inner,
+		cache:         newCache(opts),
+		loadTimeout:   opts.LoadTimeout,
+	}
+}
+
+func (cs *cachedPeopleService) FindOneById(ctx context.Context, id string) (Person, error) {
+	result, err := cs.cache.Get(id, func() (interface{}, error) {
+		ctx, cancel := loadContext(ctx, cs.loadTimeout)
+		defer cancel()
+		return cs.PeopleService.FindOneById(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(Person), nil
+}
+
+func (cs *cachedPeopleService) SaveAliases(ctx context.Context, id string, aliases []string) (Person, error) {
+	person, err := cs.PeopleService.SaveAliases(ctx, id, aliases)
+	if err == nil {
+		cs.cache.Invalidate(id)
+	}
+	return person, err
+}
+
+func (cs *cachedPeopleService) CacheStats() cache.Stats {
+	return cs.cache.Stats()
+}
+
+func (cs *cachedPeopleService) Bust() {
+	cs.cache.Clear()
+}
+
+type cachedGenreService struct {
+	GenreService
+	cache       *cache.Cache
+	loadTimeout time.Duration
+}
+
+// NewCachedGenreService decorates the provided GenreService with a short-lived
+// memoization of the genre details returned by FindOneByName.
+// Merging genres busts the memoized details of both genres.
+func NewCachedGenreService(inner GenreService, opts CacheOptions) GenreService {
+	return &cachedGenreService{
+		GenreService: inner,
+		cache:        newCache(opts),
+		loadTimeout:  opts.LoadTimeout,
+	}
+}
+
+func (cs *cachedGenreService) FindOneByName(ctx context.Context, name string) (Genre, error) {
+	result, err := cs.cache.Get(name, func() (interface{}, error) {
+		ctx, cancel := loadContext(ctx, cs.loadTimeout)
+		defer cancel()
+		return cs.GenreService.FindOneByName(ctx, name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(Genre), nil
+}
+
+func (cs *cachedGenreService) Merge(ctx context.Context, from, into, userId string) (AuditEntry, error) {
+	entry, err := cs.GenreService.Merge(ctx, from, into, userId)
+	if err == nil && !IsDryRun(ctx) {
+		cs.cache.Invalidate(from)
+		cs.cache.Invalidate(into)
+	}
+	return entry, err
+}
+
+func (cs *cachedGenreService) CacheStats() cache.Stats {
+	return cs.cache.Stats()
+}
+
+func (cs *cachedGenreService) Bust() {
+	cs.cache.Clear()
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/cache"
+	"github.com/neo4j-graphacademy/neoflix/pkg/routes/paging"
+)
+
+// CacheOptions configures NewCachedMovieService
+type CacheOptions struct {
+	// TTL is the duration during which cached lists are served as is
+	TTL time.Duration
+	// StaleTTL is the additional duration during which expired lists are served
+	// while being refreshed in the background
+	StaleTTL time.Duration
+	// MaxEntries bounds the number of cached entries, the least recently used ones being
+	// evicted first, DefaultCacheMaxEntries when zero
+	MaxEntries int
+	// LoadTimeout bounds the loads of the cache, which outlive the requests starting them
+	// when shared with other requests or refreshing stale results,
+	// DefaultCacheLoadTimeout when zero
+	LoadTimeout time.Duration
+	// Logger reports the failures to read or bump the data versions of users, the
+	// standard logger when nil
+	Logger *log.Logger
+}
+
+// DefaultCacheMaxEntries is the number of entries the caches hold at most, unless
+// configured otherwise
+const DefaultCacheMaxEntries = 10000
+
+// DefaultCacheLoadTimeout bounds the loads of the caches, unless configured otherwise
+const DefaultCacheLoadTimeout = 30 * time.Second
+
+// loadContext returns the context of a load of a cache started by the request of ctx.
+// The load runs in the background and its result may be shared with other requests, so
+// that it keeps the values of the request, e.g. its trace, but not its cancellation,
+// and is bounded by the timeout instead.
+func loadContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout == 0 {
+		timeout = DefaultCacheLoadTimeout
+	}
+	return context.WithTimeout(context.WithoutCancel(ctx), timeout)
+}
+
+// newCache creates the cache configured by the options
+func newCache(opts CacheOptions) *cache.Cache {
+	maxEntries := opts.MaxEntries
+	if maxEntries == 0 {
+		maxEntries = DefaultCacheMaxEntries
+	}
+	return cache.New(cache.Options{TTL: opts.TTL, StaleTTL: opts.StaleTTL, MaxEntries: maxEntries})
+}
+
+// UserCache is implemented by the caches holding personalized results, to drop the
+// results of a user once the favorites, ratings or settings they depend on change
+type UserCache interface {
+	InvalidateUser(userId string)
+}
+
+type cachedMovieService struct {
+	MovieService
+	cache       *cache.Cache
+	versions    DataVersionService
+	logger      *log.Logger
+	loadTimeout time.Duration
+}
+
+// NewCachedMovieService decorates the provided MovieService with an in-process cache
+// of the movie lists, such as the top rated movies.
+// Personalized results, which include the `favorite` flag, are cached per user, and
+// dropped by InvalidateUser when the favorites, ratings or settings of the user change.
+func NewCachedMovieService(inner MovieService, opts CacheOptions) MovieService {
+	logger := opts.Logger
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &cachedMovieService{
+		MovieService: inner,
+		cache:        newCache(opts),
+		logger:       logger,
+		loadTimeout:  opts.LoadTimeout,
+	}
+}
+
+// NewVersionedCachedMovieService decorates the provided MovieService with a cache like
+// NewCachedMovieService, except that personalized results are keyed by the version of
+// the data of the user as well.
+// InvalidateUser bumps the version, so that the results cached by every instance of
+// the API for the previous version are no longer served, while the results of the
+// other users remain cached.
+func NewVersionedCachedMovieService(inner MovieService, versions DataVersionService, opts CacheOptions) MovieService {
+	cached := NewCachedMovieService(inner, opts).(*cachedMovieService)
+	cached.versions = versions
+	return cached
+}
+
+func (cs *cachedMovieService) FindAll(ctx context.Context, userId string, page *paging.Paging) (PagedResult, error) {
+	key, ok := cs.cacheKey(ctx, userId, pageKey("FindAll", page))
+	if !ok {
+		return cs.MovieService.FindAll(ctx, userId, page)
+	}
+	result, err := cs.cache.Get(key, func() (interface{}, error) {
+		ctx, cancel := loadContext(ctx, cs.loadTimeout)
+		defer cancel()
+		return cs.MovieService.FindAll(ctx, userId, page)
+	})
+	if err != nil {
+		return PagedResult{}, err
+	}
+	return result.(PagedResult), nil
+}
+
+func (cs *cachedMovieService) FindAllByGenre(ctx context.Context, genre, userId string, page *paging.Paging) (PagedResult, error) {
+	key, ok := cs.cacheKey(ctx, userId, pageKey("FindAllByGenre", page, genre))
+	if !ok {
+		return cs.MovieService.FindAllByGenre(ctx, genre, userId, page)
+	}
+	result, err := cs.cache.Get(key, func() (interface{}, error) {
+		ctx, cancel := loadContext(ctx, cs.loadTimeout)
+		defer cancel()
+		return cs.MovieService.FindAllByGenre(ctx, genre, userId, page)
+	})
+	if err != nil {
+		return PagedResult{}, err
+	}
+	return result.(PagedResult), nil
+}
+
+func (cs *cachedMovieService) InvalidateUser(userId string) {
+	if cs.versions != nil && userId != "" {
+		if _, err := cs.versions.Bump(context.Background(), userId); err != nil {
+			cs.logger.Printf("could not bump the data version of user %s: %v", userId, err)
+		}
+	}
+	cs.cache.InvalidatePrefix(userKey(userId, ""))
+}
+
+// cacheKey returns the key of the results personalized for the user, prefixed by the
+// version of their data with versioned caches.
+// When the version cannot be found, the results must not be cached and false is returned.
+func (cs *cachedMovieService) cacheKey(ctx context.Context, userId, key string) (string, bool) {
+	if cs.versions == nil || userId == "" {
+		return userKey(userId, key), true
+	}
+	version, err := cs.versions.Find(ctx, userId)
+	if err != nil {
+		cs.logger.Printf("could not find the data version of user %s, bypassing the cache: %v", userId, err)
+		return "", false
+	}
+	return userKey(userId, fmt.Sprintf("v%d|%s", version, key)), true
+}
+
+func (cs *cachedMovieService) CacheStats() cache.Stats {
+	return cs.cache.Stats()
+}
+
+func (cs *cachedMovieService) Bust() {
+	cs.cache.Clear()
+}
+
+func pageKey(method string, page *paging.Paging, args ...string) string {
+	return fmt.Sprintf("%s|%q|%s|%s|%s|%d|%d|%s",
+		method, args, page.Query(), page.Sort(), page.Order(), page.Skip(), page.Limit(), page.Collation())
+}
+
+// userKey prefixes the key with the user it is personalized for, none for anonymous
+// results, so that the keys of a user can be invalidated at once
+func userKey(userId, key string) string {
+	return fmt.Sprintf("%q|%s", userId, key)
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/routes/paging"
+)
+
+type countingMovieService struct {
+	MovieService
+	loads map[string]int
+}
+
+func (cs *countingMovieService) FindAll(_ context.Context, userId string, _ *paging.Paging) (PagedResult, error) {
+	cs.loads[userId]++
+	return PagedResult{Total: int64(cs.loads[userId])}, nil
+}
+
+type memoryDataVersions struct {
+	versions map[string]int64
+	failing  bool
+}
+
+func (ms *memoryDataVersions) Find(_ context.Context, userId string) (int64, error) {
+	if ms.failing {
+		return 0, fmt.Errorf("unavailable")
+	}
+	return ms.versions[userId], nil
+}
+
+func (ms *memoryDataVersions) Bump(_ context.Context, userId string) (int64, error) {
+	ms.versions[userId]++
+	return ms.versions[userId], nil
+}
+
+func TestVersionedCacheServesNewVersionsOnly(t *testing.T) {
+	inner := &countingMovieService{loads: map[string]int{}}
+	versions := &memoryDataVersions{versions: map[string]int64{}}
+	movies := NewVersionedCachedMovieService(inner, versions, CacheOptions{TTL: time.Minute})
+	page := paging.NewPaging("", "title", "ASC", 0, 6)
+
+	for _, userId := range []string{"alice", "bob", "alice", "bob"} {
+		_, _ = movies.FindAll(context.Background(), userId, page)
+	}
+	// another instance of the API writing on behalf of alice
+	_, _ = versions.Bump(context.Background(), "alice")
+	alice, _ := movies.FindAll(context.Background(), "alice", page)
+	bob, _ := movies.FindAll(context.Background(), "bob", page)
+
+	if alice.Total != 2 || bob.Total != 1 {
+		t.Fatalf("expected alice to be reloaded once and bob to stay cached, got %d and %d loads",
+			alice.Total, bob.Total)
+	}
+}
+
+func TestVersionedCacheIsBypassedWithoutVersion(t *testing.T) {
+	inner := &countingMovieService{loads: map[string]int{}}
+	versions := &memoryDataVersions{versions: map[string]int64{}, failing: true}
+	movies := NewVersionedCachedMovieService(inner, versions, CacheOptions{TTL: time.Minute})
+	page := paging.NewPaging("", "title", "ASC", 0, 6)
+
+	_, _ = movies.FindAll(context.Background(), "alice", page)
+	alice, _ := movies.FindAll(context.Background(), "alice", page)
+	_, _ = movies.FindAll(context.Background(), "", page)
+	anonymous, _ := movies.FindAll(context.Background(), "", page)
+
+	if alice.Total != 2 || anonymous.Total != 1 {
+		t.Fatalf("expected alice to bypass the cache and anonymous users to use it, got %d and %d loads",
+			alice.Total, anonymous.Total)
+	}
+}
+
+type contextCheckingMovieService struct {
+	MovieService
+}
+
+func (cs *contextCheckingMovieService) FindAll(ctx context.Context, _ string, _ *paging.Paging) (PagedResult, error) {
+	if _, found := ctx.Deadline(); !found {
+		return PagedResult{}, fmt.Errorf("expected the load to be bounded")
+	}
+	return PagedResult{Total: 1}, ctx.Err()
+}
+
+func TestCacheLoadsOutliveTheRequest(t *testing.T) {
+	movies := NewCachedMovieService(&contextCheckingMovieService{}, CacheOptions{TTL: time.Minute})
+	ctx, cancel := context.WithCancel(context.Background())
+	// the request which started a load shared with others returned
+	cancel()
+
+	result, err := movies.FindAll(ctx, "", paging.NewPaging("", "title", "ASC", 0, 6))
+
+	if err != nil || result.Total != 1 {
+		t.Fatalf("expected the load to ignore the cancellation of the request, got %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+type CatalogService interface {
+	FindAllAfter(ctx context.Context, after string, limit int) ([]Movie, error)
+}
+
+type neo4jCatalogService struct {
+	loader  *fixtures.FixtureLoader
+	driver  SessionFactory
+	options serviceOptions
+}
+
+func NewCatalogService(loader *fixtures.FixtureLoader, driver SessionFactory, opts ...Option) CatalogService {
+	return &neo4jCatalogService{
+		loader:  loader,
+		driver:  driver,
+		options: newServiceOptions(opts),
+	}
+}
+
+// FindAllAfter returns up to `limit` movies of the catalog, ordered by `tmdbId` and
+// starting after the `after` ID, in a light projection meant for partners mirroring it.
+// Pages are delimited by IDs rather than offsets, so an export can resume where it stopped.
+func (cs *neo4jCatalogService) FindAllAfter(ctx context.Context, after string, limit int) (_ []Movie, err error) {
+	ctx, cancel := cs.options.withDeadline(ctx, Export)
+	defer cancel()
+	session := cs.driver.NewSession(ctx, cs.options.sessionConfig(ctx, neo4j.AccessModeRead))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	results, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := cs.options.run(ctx, tx, "catalog/export", nil, map[string]interface{}{
+			"after": after,
+			"limit": limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		results := make([]Movie, 0, len(records))
+		for _, record := range records {
+			movie, _ := record.Get("movie")
+			results = append(results, cs.options.properties.project("Movie", movie.(map[string]interface{})))
+		}
+		return results, nil
+	}, cs.options.txConfig(ctx, Export))
+
+	if err != nil {
+		return nil, err
+	}
+	return results.([]Movie), nil
+}
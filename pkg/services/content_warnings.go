@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j-graphacademy/neoflix/pkg/queries"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// ContentWarnings lists the supported content warnings
+var ContentWarnings = []string{
+	"violence", "language", "sexual-content", "nudity", "drug-use", "self-harm", "flashing-lights",
+}
+
+type ContentWarningService interface {
+	Add(ctx context.Context, movieId, warning string) ([]string, error)
+
+	Remove(ctx context.Context, movieId, warning string) ([]string, error)
+
+	FindAllExcludedByUserId(ctx context.Context, userId string) ([]string, error)
+
+	SaveExcluded(ctx context.Context, userId string, warnings []string) ([]string, error)
+}
+
+type neo4jContentWarningService struct {
+	loader  *fixtures.FixtureLoader
+	driver  SessionFactory
+	options serviceOptions
+}
+
+func NewContentWarningService(loader *fixtures.FixtureLoader, driver SessionFactory, opts ...Option) ContentWarningService {
+	return &neo4jContentWarningService{
+		loader:  loader,
+		driver:  driver,
+		options: newServiceOptions(opts),
+	}
+}
+
+// Add attaches the content warning to the Movie and returns all its warnings
+func (cs *neo4jContentWarningService) Add(ctx context.Context, movieId, warning string) ([]string, error) {
+	if err := validateContentWarnings(warning); err != nil {
+		return nil, err
+	}
+	return cs.writeMovieWarnings(ctx, "content_warnings/add", movieId, warning)
+}
+
+// Remove detaches the content warning from the Movie and returns its remaining warnings
+func (cs *neo4jContentWarningService) Remove(ctx context.Context, movieId, warning string) ([]string, error) {
+	return cs.writeMovieWarnings(ctx, "content_warnings/remove", movieId, warning)
+}
+
+func (cs *neo4jContentWarningService) writeMovieWarnings(ctx context.Context, statement, movieId, warning string) (_ []string, err error) {
+	ctx, cancel := cs.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := cs.driver.NewSession(ctx, cs.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := cs.options.run(ctx, tx, statement, nil, map[string]interface{}{
+			"movieId": movieId,
+			"warning": warning,
+		})
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, NewDomainError(404, "Movie not found", map[string]interface{}{"movieId": movieId})
+		}
+		warnings, _ := record.Get("warnings")
+		return toStrings(warnings), nil
+	}, cs.options.txConfig(ctx, FastLookup))
+
+	if err != nil {
+		return nil, err
+	}
+	return result.([]string), nil
+}
+
+// FindAllExcludedByUserId returns the content warnings the User does not want to see in lists
+func (cs *neo4jContentWarningService) FindAllExcludedByUserId(ctx context.Context, userId string) (_ []string, err error) {
+	ctx, cancel := cs.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := cs.driver.NewSession(ctx, cs.options.sessionConfig(ctx, neo4j.AccessModeRead))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		return getUserExcludedContentWarnings(ctx, tx, cs.options.catalog, userId)
+	}, cs.options.txConfig(ctx, FastLookup))
+
+	if err != nil {
+		return nil, err
+	}
+	return result.([]string), nil
+}
+
+// SaveExcluded replaces the content warnings the User does not want to see in lists
+func (cs *neo4jContentWarningService) SaveExcluded(ctx context.Context, userId string, warnings []string) (_ []string, err error) {
+	if err := validateContentWarnings(warnings...); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := cs.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := cs.driver.NewSession(ctx, cs.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := cs.options.run(ctx, tx, "content_warnings/save_user_excluded", nil, map[string]interface{}{
+			"userId":   userId,
+			"warnings": warnings,
+		})
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		saved, _ := record.Get("warnings")
+		return toStrings(saved), nil
+	}, cs.options.txConfig(ctx, FastLookup))
+
+	if err != nil {
+		return nil, err
+	}
+	return result.([]string), nil
+}
+
+// getUserExcludedContentWarnings returns the content warnings the user has chosen to exclude
+// from lists.
+// The result is never nil, so that it can safely be used in `IN` predicates.
+func getUserExcludedContentWarnings(ctx context.Context, tx neo4j.ManagedTransaction, catalog *queries.Catalog, userId string) ([]string, error) {
+	if userId == "" {
+		return []string{}, nil
+	}
+
+	result, err := tx.Run(trackQuery(ctx, "content_warnings/user_excluded"), catalog.Get("content_warnings/user_excluded").Render(nil), map[string]interface{}{"userId": userId})
+	if err != nil {
+		return nil, err
+	}
+	if !result.Next(ctx) {
+		return []string{}, result.Err()
+	}
+	warnings, _ := result.Record().Get("warnings")
+	return toStrings(warnings), nil
+}
+
+func validateContentWarnings(warnings ...string) error {
+	for _, warning := range warnings {
+		if !containsString(ContentWarnings, warning) {
+			return NewDomainError(422, fmt.Sprintf("Unknown content warning %q", warning), map[string]interface{}{
+				"supported": strings.Join(ContentWarnings, ", "),
+			})
+		}
+	}
+	return nil
+}
+
+func toStrings(values interface{}) []string {
+	result := []string{}
+	list, _ := values.([]interface{})
+	for _, value := range list {
+		result = append(result, value.(string))
+	}
+	return result
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/routes/paging"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// CursorPagedResult is a page of a list paginated by keyset, along with the cursor the
+// next page starts after, nil on the last page
+type CursorPagedResult struct {
+	Items []map[string]interface{}
+	Next  *paging.Cursor
+}
+
+// keysetFragments returns the fragments of the statements listing entities of the label
+// by keyset: those of listFragments, along with the `comparator` selecting the results
+// after the cursor in the order of the page
+func (o serviceOptions) keysetFragments(label string, sortable *paging.SortableAttributes, page *paging.Paging) (map[string]string, error) {
+	fragments, err := o.listFragments(label, sortable, page)
+	if err != nil {
+		return nil, err
+	}
+	fragments["comparator"] = ">"
+	if page.Order() == paging.Desc {
+		fragments["comparator"] = "<"
+	}
+	return fragments, nil
+}
+
+// keysetParams returns the parameters of the statements listing the results after the
+// cursor: its sort value as `after`, null at the start of the list, and its ID as
+// `afterId`.
+// One more result than the limit is fetched, to tell whether a next page follows.
+func keysetParams(cursor paging.Cursor, page *paging.Paging) map[string]interface{} {
+	params := map[string]interface{}{
+		"after":   nil,
+		"afterId": "",
+		"limit":   page.Limit() + 1,
+	}
+	if !cursor.IsZero() {
+		params["after"] = cursor.Value
+		if cursor.Kind == paging.DateCursorValue {
+			date, _ := time.Parse("2006-01-02", cursor.Value.(string))
+			params["after"] = neo4j.DateOf(date)
+		}
+		params["afterId"] = cursor.Id
+	}
+	return params
+}
+
+// collectKeysetPage reads the page of the `key` entities of the label from the result,
+// each record holding its `sortValue` and `id` as well, and returns it along with the
+// cursor of the next page
+func (o serviceOptions) collectKeysetPage(ctx context.Context,
+	result neo4j.ResultWithContext,
+	key, label string,
+	cursor paging.Cursor,
+	page *paging.Paging) (CursorPagedResult, error) {
+
+	records, err := result.Collect(ctx)
+	if err != nil {
+		return CursorPagedResult{}, err
+	}
+	items := make([]map[string]interface{}, 0, len(records))
+	if page.Limit() == 0 {
+		return CursorPagedResult{Items: items}, nil
+	}
+	for i, record := range records {
+		if i == page.Limit() {
+			last := records[i-1]
+			sortValue, _ := last.Get("sortValue")
+			id, _ := last.Get("id")
+			next, err := nextCursor(cursor, page, sortValue, id.(string), len(items))
+			if err != nil {
+				return CursorPagedResult{}, err
+			}
+			return CursorPagedResult{Items: items, Next: &next}, nil
+		}
+		entity, _ := record.Get(key)
+		items = append(items, o.properties.project(label, entity.(map[string]interface{})))
+	}
+	return CursorPagedResult{Items: items}, nil
+}
+
+// nextCursor returns the cursor pointing to the last of the count results listed
+// after the cursor
+func nextCursor(cursor paging.Cursor, page *paging.Paging, sortValue interface{}, id string, count int) (paging.Cursor, error) {
+	next := paging.Cursor{
+		Sort:     page.Sort(),
+		Order:    page.Order(),
+		Value:    sortValue,
+		Id:       id,
+		Position: cursor.Position + count,
+	}
+	switch value := sortValue.(type) {
+	case string, int64, float64, bool:
+	case neo4j.Date:
+		next.Value = value.Time().Format("2006-01-02")
+		next.Kind = paging.DateCursorValue
+	default:
+		return paging.Cursor{}, fmt.Errorf("cannot paginate %s by keyset: unsupported value %v", page.Sort(), sortValue)
+	}
+	return next, nil
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// DataVersionService tracks a version of the personal data of each User, bumped on
+// their writes, so that the results cached for a version of the data are never served
+// once it changed, whichever instance of the API cached them
+type DataVersionService interface {
+	Find(ctx context.Context, userId string) (int64, error)
+
+	Bump(ctx context.Context, userId string) (int64, error)
+}
+
+type neo4jDataVersionService struct {
+	loader  *fixtures.FixtureLoader
+	driver  SessionFactory
+	options serviceOptions
+}
+
+func NewDataVersionService(loader *fixtures.FixtureLoader, driver SessionFactory, opts ...Option) DataVersionService {
+	return &neo4jDataVersionService{
+		loader:  loader,
+		driver:  driver,
+		options: newServiceOptions(opts),
+	}
+}
+
+// Find returns the current version of the data of the User, 0 until their first write
+// or when the User cannot be found
+func (ds *neo4jDataVersionService) Find(ctx context.Context, userId string) (_ int64, err error) {
+	ctx, cancel := ds.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := ds.driver.NewSession(ctx, ds.options.sessionConfig(ctx, neo4j.AccessModeRead))
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteRead(ctx, ds.version(ctx, "data_versions/find", userId),
+		ds.options.txConfig(ctx, FastLookup))
+	if err != nil {
+		return 0, err
+	}
+	return result.(int64), nil
+}
+
+// Bump increments the version of the data of the User, once their write is committed.
+//
+// If the User cannot be found, a 404 error is returned.
+func (ds *neo4jDataVersionService) Bump(ctx context.Context, userId string) (_ int64, err error) {
+	ctx, cancel := ds.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := ds.driver.NewSession(ctx, ds.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, ds.version(ctx, "data_versions/bump", userId),
+		ds.options.txConfig(ctx, FastLookup))
+	if err != nil {
+		return 0, err
+	}
+	return result.(int64), nil
+}
+
+func (ds *neo4jDataVersionService) version(ctx context.Context, statement, userId string) neo4j.ManagedTransactionWork {
+	return func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := ds.options.run(ctx, tx, statement, nil, map[string]interface{}{
+			"userId": userId,
+		})
+		if err != nil {
+			return nil, err
+		}
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, NewDomainError(404, "User not found", map[string]interface{}{
+				"userId": userId,
+			})
+		}
+		version, _ := records[0].Get("version")
+		return version.(int64), nil
+	}
+}
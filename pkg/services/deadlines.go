@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// EndpointClass groups service methods sharing the same latency budget
+type EndpointClass int
+
+const (
+	// FastLookup covers single entity reads and writes (movie, person, genre details...)
+	FastLookup EndpointClass = iota
+	// List covers paginated listings
+	List
+	// Similarity covers similarity and recommendation queries
+	Similarity
+	// Export covers admin exports, which are unlimited by default
+	Export
+)
+
+// Deadlines defines the maximum duration of the service methods of each EndpointClass,
+// bounding both their context and the timeout of their transactions.
+// A zero duration means no deadline is enforced by the application.
+type Deadlines struct {
+	FastLookup time.Duration
+	List       time.Duration
+	Similarity time.Duration
+	Export     time.Duration
+}
+
+func DefaultDeadlines() Deadlines {
+	return Deadlines{
+		FastLookup: 500 * time.Millisecond,
+		List:       2 * time.Second,
+		Similarity: 5 * time.Second,
+		Export:     0,
+	}
+}
+
+func (d Deadlines) For(class EndpointClass) time.Duration {
+	switch class {
+	case FastLookup:
+		return d.FastLookup
+	case List:
+		return d.List
+	case Similarity:
+		return d.Similarity
+	default:
+		return d.Export
+	}
+}
+
+// withTimeout configures the transaction timeout matching the deadline of the provided class.
+// The server aborts any transaction running past that deadline.
+func (d Deadlines) withTimeout(class EndpointClass) func(*neo4j.TransactionConfig) {
+	return neo4j.WithTxTimeout(d.For(class))
+}
+
+// IsDeadlineExceeded returns true when the error is caused by a transaction
+// running past its deadline
+func IsDeadlineExceeded(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var neo4jError *neo4j.Neo4jError
+	return errors.As(err, &neo4jError) && strings.HasPrefix(neo4jError.Title(), "TransactionTimedOut")
+}
+
+// withDeadline bounds the context of a service method by the deadline of its class, so
+// that the waits of the driver, e.g. for a connection of the pool, are bounded as well
+// as the transactions aborted by the server
+func (o serviceOptions) withDeadline(ctx context.Context, class EndpointClass) (context.Context, context.CancelFunc) {
+	deadline := o.deadlines.For(class)
+	if deadline == 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, deadline)
+}
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWithDeadlineBoundsTheContextOfTheClass(t *testing.T) {
+	options := newServiceOptions([]Option{WithDeadlines(Deadlines{List: time.Second})})
+
+	list, cancel := options.withDeadline(context.Background(), List)
+	defer cancel()
+	export, cancelExport := options.withDeadline(context.Background(), Export)
+	defer cancelExport()
+
+	if deadline, found := list.Deadline(); !found || time.Until(deadline) > time.Second {
+		t.Errorf("expected the list deadline to bound the context, got %v", deadline)
+	}
+	if _, found := export.Deadline(); found {
+		t.Errorf("expected exports to be left unbounded")
+	}
+}
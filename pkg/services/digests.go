@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Digest gathers what happened since the previous digest for a User who opted in
+type Digest struct {
+	User User
+	// Activity lists the latest ratings of the users they follow
+	Activity []map[string]interface{}
+	// NewMovies lists the movies recently released in their preferred genres,
+	// i.e. the genres of the movies they rated the best
+	NewMovies []Movie
+}
+
+// IsEmpty returns true when there is nothing worth sending
+func (d Digest) IsEmpty() bool {
+	return len(d.Activity) == 0 && len(d.NewMovies) == 0
+}
+
+type DigestService interface {
+	FindAll(ctx context.Context, since time.Time, after string, limit int) ([]Digest, error)
+
+	FindOptIn(ctx context.Context, userId string) (bool, error)
+
+	SaveOptIn(ctx context.Context, userId string, dailyDigest bool) (bool, error)
+}
+
+const (
+	// DigestMaxItems caps the number of ratings and movies of each digest section
+	DigestMaxItems = 10
+	// DigestPreferredGenres is the number of preferred genres new movies are picked from
+	DigestPreferredGenres = 3
+)
+
+type neo4jDigestService struct {
+	loader  *fixtures.FixtureLoader
+	driver  SessionFactory
+	options serviceOptions
+}
+
+func NewDigestService(loader *fixtures.FixtureLoader, driver SessionFactory, opts ...Option) DigestService {
+	return &neo4jDigestService{
+		loader:  loader,
+		driver:  driver,
+		options: newServiceOptions(opts),
+	}
+}
+
+// FindAll builds the digests of up to `limit` Users who opted in, ordered by their ID
+// and starting after the `after` ID, covering the activity since the provided time.
+// Digests may be empty.
+func (ds *neo4jDigestService) FindAll(ctx context.Context, since time.Time, after string, limit int) (_ []Digest, err error) {
+	ctx, cancel := ds.options.withDeadline(ctx, Export)
+	defer cancel()
+	session := ds.driver.NewSession(ctx, ds.options.sessionConfig(ctx, neo4j.AccessModeRead))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	results, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := ds.options.run(ctx, tx, "digests/find_all", nil, map[string]interface{}{
+			"after":           after,
+			"limit":           limit,
+			"since":           since.UnixMilli(),
+			"sinceDate":       since.UTC().Format("2006-01-02"),
+			"today":           time.Now().UTC().Format("2006-01-02"),
+			"maxItems":        DigestMaxItems,
+			"preferredGenres": DigestPreferredGenres,
+		})
+		if err != nil {
+			return nil, err
+		}
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var digests []Digest
+		for _, record := range records {
+			user, _ := record.Get("user")
+			activity, _ := record.Get("activity")
+			newMovies, _ := record.Get("newMovies")
+			digest := Digest{User: user.(map[string]interface{})}
+			for _, item := range activity.([]interface{}) {
+				digest.Activity = append(digest.Activity, item.(map[string]interface{}))
+			}
+			for _, movie := range newMovies.([]interface{}) {
+				digest.NewMovies = append(digest.NewMovies,
+					ds.options.properties.project("Movie", movie.(map[string]interface{})))
+			}
+			digests = append(digests, digest)
+		}
+		return digests, nil
+	}, ds.options.txConfig(ctx, Export))
+
+	if err != nil {
+		return nil, err
+	}
+	return results.([]Digest), nil
+}
+
+// FindOptIn returns whether the User opted in the daily digest
+func (ds *neo4jDigestService) FindOptIn(ctx context.Context, userId string) (_ bool, err error) {
+	return ds.runOptIn(ctx, "digests/find_opt_in", map[string]interface{}{"userId": userId})
+}
+
+// SaveOptIn sets whether the User receives the daily digest
+func (ds *neo4jDigestService) SaveOptIn(ctx context.Context, userId string, dailyDigest bool) (_ bool, err error) {
+	return ds.runOptIn(ctx, "digests/save_opt_in", map[string]interface{}{
+		"userId":      userId,
+		"dailyDigest": dailyDigest,
+	})
+}
+
+func (ds *neo4jDigestService) runOptIn(ctx context.Context, statement string, params map[string]interface{}) (_ bool, err error) {
+	ctx, cancel := ds.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := ds.driver.NewSession(ctx, ds.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := ds.options.run(ctx, tx, statement, nil, params)
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, NewDomainError(404, "User not found", map[string]interface{}{"userId": params["userId"]})
+		}
+		dailyDigest, _ := record.Get("dailyDigest")
+		return dailyDigest, nil
+	}, ds.options.txConfig(ctx, FastLookup))
+	if err != nil {
+		return false, err
+	}
+	return result.(bool), nil
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Changes sums the update counters of the statements run by an operation
+type Changes struct {
+	NodesCreated         int `json:"nodesCreated"`
+	NodesDeleted         int `json:"nodesDeleted"`
+	RelationshipsCreated int `json:"relationshipsCreated"`
+	RelationshipsDeleted int `json:"relationshipsDeleted"`
+	PropertiesSet        int `json:"propertiesSet"`
+	LabelsAdded          int `json:"labelsAdded"`
+	LabelsRemoved        int `json:"labelsRemoved"`
+}
+
+func (c *Changes) add(counters neo4j.Counters) {
+	c.NodesCreated += counters.NodesCreated()
+	c.NodesDeleted += counters.NodesDeleted()
+	c.RelationshipsCreated += counters.RelationshipsCreated()
+	c.RelationshipsDeleted += counters.RelationshipsDeleted()
+	c.PropertiesSet += counters.PropertiesSet()
+	c.LabelsAdded += counters.LabelsAdded()
+	c.LabelsRemoved += counters.LabelsRemoved()
+}
+
+// DryRunPreview holds what a mutating operation returned and the changes it made
+// before being rolled back
+type DryRunPreview struct {
+	Result  interface{} `json:"result"`
+	Changes Changes     `json:"changes"`
+}
+
+// DryRunService previews mutating admin operations.
+// The operations must write through serviceOptions.writeTransaction for their
+// writes to be previewed.
+type DryRunService interface {
+	Run(ctx context.Context, operation func(ctx context.Context) (interface{}, error)) (DryRunPreview, error)
+}
+
+type neo4jDryRunService struct {
+	loader  *fixtures.FixtureLoader
+	driver  SessionFactory
+	options serviceOptions
+}
+
+func NewDryRunService(loader *fixtures.FixtureLoader, driver SessionFactory, opts ...Option) DryRunService {
+	return &neo4jDryRunService{
+		loader:  loader,
+		driver:  driver,
+		options: newServiceOptions(opts),
+	}
+}
+
+// Run runs all the writes of the operation in a single transaction, and rolls it back
+// once the operation returns, along with the changes the operation would have made.
+// Operations writing in batches therefore see their previous batches, as they would
+// once committed.
+func (ds *neo4jDryRunService) Run(ctx context.Context, operation func(ctx context.Context) (interface{}, error)) (_ DryRunPreview, err error) {
+	ctx, cancel := ds.options.withDeadline(ctx, Export)
+	defer cancel()
+	session := ds.driver.NewSession(ctx, ds.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	tx, err := session.BeginTransaction(ctx, ds.options.txConfig(ctx, Export))
+	if err != nil {
+		return DryRunPreview{}, err
+	}
+	// closing the transaction rolls it back
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, tx, err)
+	}()
+
+	recording := &recordingTransaction{ExplicitTransaction: tx}
+	result, err := operation(context.WithValue(ctx, dryRunKey{}, recording))
+	if err != nil {
+		return DryRunPreview{}, err
+	}
+	changes, err := recording.changes(ctx)
+	if err != nil {
+		return DryRunPreview{}, err
+	}
+	return DryRunPreview{Result: result, Changes: changes}, nil
+}
+
+type dryRunKey struct{}
+
+// IsDryRun returns true when the context previews an operation run by a DryRunService
+func IsDryRun(ctx context.Context) bool {
+	_, found := ctx.Value(dryRunKey{}).(*recordingTransaction)
+	return found
+}
+
+// writeTransaction runs the work in a write transaction of the session, or in the
+// transaction of the dry run previewed by the context, if any
+func (o serviceOptions) writeTransaction(ctx context.Context, session neo4j.SessionWithContext, class EndpointClass, work neo4j.ManagedTransactionWork) (interface{}, error) {
+	if tx, found := ctx.Value(dryRunKey{}).(*recordingTransaction); found {
+		return work(tx)
+	}
+	return session.ExecuteWrite(ctx, work, o.txConfig(ctx, class))
+}
+
+// recordingTransaction keeps the results of the statements it runs, to sum their
+// update counters
+type recordingTransaction struct {
+	neo4j.ExplicitTransaction
+	results []neo4j.ResultWithContext
+}
+
+func (rt *recordingTransaction) Run(ctx context.Context, cypher string, params map[string]interface{}) (neo4j.ResultWithContext, error) {
+	result, err := rt.ExplicitTransaction.Run(ctx, cypher, params)
+	if err == nil {
+		rt.results = append(rt.results, result)
+	}
+	return result, err
+}
+
+func (rt *recordingTransaction) changes(ctx context.Context) (Changes, error) {
+	var changes Changes
+	for _, result := range rt.results {
+		summary, err := result.Consume(ctx)
+		if err != nil {
+			return Changes{}, err
+		}
+		changes.add(summary.Counters())
+	}
+	return changes, nil
+}
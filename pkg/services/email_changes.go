@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j-graphacademy/neoflix/pkg/mail"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// EmailChangeTTL is the time users have to confirm their new email address
+const EmailChangeTTL = 24 * time.Hour
+
+// EmailChange is a change of the email address of a User, pending until the new
+// address is confirmed
+type EmailChange struct {
+	Email     string    `json:"email"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// EmailChangeService lets users change the email address of their account, once they
+// proved they own the new one
+type EmailChangeService interface {
+	Request(ctx context.Context, userId, password, email, confirmUrl string) (EmailChange, error)
+
+	Confirm(ctx context.Context, token string) (User, error)
+}
+
+type neo4jEmailChangeService struct {
+	loader  *fixtures.FixtureLoader
+	driver  SessionFactory
+	sender  mail.Sender
+	from    string
+	options serviceOptions
+}
+
+func NewEmailChangeService(loader *fixtures.FixtureLoader, driver SessionFactory, sender mail.Sender, from string, opts ...Option) EmailChangeService {
+	return &neo4jEmailChangeService{
+		loader:  loader,
+		driver:  driver,
+		sender:  sender,
+		from:    from,
+		options: newServiceOptions(opts),
+	}
+}
+
+// Request records a pending change of the email address of the User, replacing the
+// previous one if any, once their password is verified.
+// The new address is sent a link to confirm the change, made of the confirmUrl and the
+// `token` parameter, valid for EmailChangeTTL, while the current address is notified of
+// the request.
+// The current address remains the one of the account until the change is confirmed.
+//
+// If the password is incorrect, a 403 error is returned, and if the new address is
+// already taken, a 422 error.
+func (es *neo4jEmailChangeService) Request(ctx context.Context, userId, password, email, confirmUrl string) (_ EmailChange, err error) {
+	token, err := newEmailChangeToken()
+	if err != nil {
+		return EmailChange{}, err
+	}
+	change := EmailChange{Email: email, ExpiresAt: time.Now().Add(EmailChangeTTL)}
+
+	ctx, cancel := es.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := es.driver.NewSession(ctx, es.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := es.options.run(ctx, tx, "email_changes/find_user", nil, map[string]interface{}{
+			"userId": userId,
+			"email":  email,
+		})
+		if err != nil {
+			return nil, err
+		}
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, NewDomainError(404, "User not found", map[string]interface{}{"userId": userId})
+		}
+		rawUser, _ := records[0].Get("user")
+		user := rawUser.(map[string]interface{})
+		if hash, _ := user["password"].(string); !verifyPassword(password, hash) {
+			return nil, NewDomainError(403, "Incorrect password", nil)
+		}
+		if taken, _ := records[0].Get("taken"); taken.(bool) {
+			return nil, NewDomainError(422, fmt.Sprintf("An account already exists with the email address %s", email),
+				map[string]interface{}{
+					"email": "Email address taken",
+				})
+		}
+
+		if _, err := es.options.run(ctx, tx, "email_changes/save", nil, map[string]interface{}{
+			"userId":    userId,
+			"email":     email,
+			"tokenHash": hashEmailChangeToken(token),
+			"expiresAt": change.ExpiresAt.UnixMilli(),
+		}); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}, es.options.txConfig(ctx, FastLookup))
+	if err != nil {
+		return EmailChange{}, err
+	}
+
+	// the emails are sent once the change is recorded, a failed delivery is fixed by
+	// requesting the change again
+	user := result.(map[string]interface{})
+	if err := es.sender.Send(mail.Message{
+		From:    es.from,
+		To:      email,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf("Hi %v,\n\nConfirm %s as the email address of your Neoflix account by opening\n%s\n\n"+
+			"This link expires in %d hours.\n", user["name"], email, withToken(confirmUrl, token), int(EmailChangeTTL.Hours())),
+	}); err != nil {
+		return EmailChange{}, err
+	}
+	if err := es.sender.Send(mail.Message{
+		From:    es.from,
+		To:      fmt.Sprint(user["email"]),
+		Subject: "Change of your email address",
+		Body: fmt.Sprintf("Hi %v,\n\nA change of the email address of your Neoflix account to %s was requested.\n"+
+			"It only takes effect once confirmed from the new address.\n\n"+
+			"If you did not request it, change your password.\n", user["name"], email),
+	}); err != nil {
+		return EmailChange{}, err
+	}
+	return change, nil
+}
+
+// Confirm makes the new email address of the pending change matching the token the
+// address of its User, and returns the User.
+//
+// If the token does not match any pending change, or has expired, a 404 error is
+// returned, and if the new address was taken in the meantime, a 422 error.
+func (es *neo4jEmailChangeService) Confirm(ctx context.Context, token string) (_ User, err error) {
+	ctx, cancel := es.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := es.driver.NewSession(ctx, es.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := es.options.run(ctx, tx, "email_changes/confirm", nil, map[string]interface{}{
+			"tokenHash": hashEmailChangeToken(token),
+		})
+		if err != nil {
+			return nil, err
+		}
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, NewDomainError(404, "Email change not found or expired", nil)
+		}
+		user, _ := records[0].Get("user")
+		if taken, _ := records[0].Get("taken"); taken.(bool) {
+			return nil, NewDomainError(422, "The email address was taken in the meantime", map[string]interface{}{
+				"email": "Email address taken",
+			})
+		}
+		return user, nil
+	}, es.options.txConfig(ctx, FastLookup))
+	if err != nil {
+		return nil, err
+	}
+	return result.(map[string]interface{}), nil
+}
+
+// newEmailChangeToken returns a random token, only ever stored hashed
+func newEmailChangeToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+func hashEmailChangeToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// withToken appends the token to the query of the URL
+func withToken(rawUrl, token string) string {
+	parsed, err := url.Parse(rawUrl)
+	if err != nil {
+		return rawUrl
+	}
+	query := parsed.Query()
+	query.Set("token", token)
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
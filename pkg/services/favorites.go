@@ -1,53 +1,55 @@
 package services
 
 import (
-	"fmt"
+	"context"
+
 	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
 	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
 
 	"github.com/neo4j-graphacademy/neoflix/pkg/routes/paging"
-	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
 type FavoriteService interface {
-	Save(userId, movieId string) (Movie, error)
+	Save(ctx context.Context, userId, movieId string) (Movie, error)
+
+	FindAllByUserId(ctx context.Context, userId string, page *paging.Paging) ([]Movie, error)
 
-	FindAllByUserId(userId string, page *paging.Paging) ([]Movie, error)
+	Delete(ctx context.Context, userId, movieId string) (Movie, error)
 
-	Delete(userId, movieId string) (Movie, error)
+	Toggle(ctx context.Context, userId, movieId string) (Movie, error)
 }
 
 type neo4jFavoriteService struct {
-	loader *fixtures.FixtureLoader
-	driver neo4j.Driver
+	loader  *fixtures.FixtureLoader
+	driver  SessionFactory
+	options serviceOptions
 }
 
-func NewFavoriteService(loader *fixtures.FixtureLoader, driver neo4j.Driver) FavoriteService {
-	return &neo4jFavoriteService{loader: loader, driver: driver}
+func NewFavoriteService(loader *fixtures.FixtureLoader, driver SessionFactory, opts ...Option) FavoriteService {
+	return &neo4jFavoriteService{
+		loader:  loader,
+		driver:  driver,
+		options: newServiceOptions(opts),
+	}
 }
 
 // Save should create a `:HAS_FAVORITE` relationship between
 // the User and Movie ID nodes provided.
 //
-// If either the user or movie cannot be found, a `NotFoundError` should be thrown.
+// If either the user or movie cannot be found, a 404 error is returned.
 // tag::add[]
-func (fs *neo4jFavoriteService) Save(userId, movieId string) (_ Movie, err error) {
-	session := fs.driver.NewSession(neo4j.SessionConfig{})
+func (fs *neo4jFavoriteService) Save(ctx context.Context, userId, movieId string) (_ Movie, err error) {
+	ctx, cancel := fs.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := fs.driver.NewSession(ctx, fs.options.sessionConfig(ctx, neo4j.AccessModeWrite))
 
 	defer func() {
-		err = ioutils.DeferredClose(session, err)
+		err = ioutils.DeferredContextClose(ctx, session, err)
 	}()
 
-	result, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		result, err := tx.Run(`
-			MATCH (u:User {userId: $userId})
-			MATCH (m:Movie {tmdbId: $movieId})
-			
-			MERGE (u)-[r:HAS_FAVORITE]->(m)
-			ON CREATE SET r.createdAt = datetime()
-			
-			RETURN m { .*, favorite: true } AS movie
-		`, map[string]interface{}{
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := fs.options.run(ctx, tx, "favorites/save", nil, map[string]interface{}{
 			"userId":  userId,
 			"movieId": movieId,
 		})
@@ -55,13 +57,18 @@ func (fs *neo4jFavoriteService) Save(userId, movieId string) (_ Movie, err error
 			return nil, err
 		}
 
-		record, err := result.Single()
-		if err != nil {
-			return nil, err
+		if !result.Next(ctx) {
+			if err := result.Err(); err != nil {
+				return nil, err
+			}
+			return nil, NewDomainError(404, "User or movie not found", map[string]interface{}{
+				"movieId": movieId,
+			})
 		}
+		record := result.Record()
 		movie, _ := record.Get("movie")
-		return movie.(map[string]interface{}), nil
-	})
+		return fs.options.properties.project("Movie", movie.(map[string]interface{})), nil
+	}, fs.options.txConfig(ctx, FastLookup))
 	if err != nil {
 		return nil, err
 	}
@@ -79,30 +86,34 @@ func (fs *neo4jFavoriteService) Save(userId, movieId string) (_ Movie, err error
 // Results should be limited to the number passed as `limit`.
 // The `skip` variable should be used to skip a certain number of rows.
 // tag::all[]
-func (fs *neo4jFavoriteService) FindAllByUserId(userId string, page *paging.Paging) (_ []Movie, err error) {
-	session := fs.driver.NewSession(neo4j.SessionConfig{})
+func (fs *neo4jFavoriteService) FindAllByUserId(ctx context.Context, userId string, page *paging.Paging) (_ []Movie, err error) {
+	ctx, cancel := fs.options.withDeadline(ctx, List)
+	defer cancel()
+	session := fs.driver.NewSession(ctx, fs.options.sessionConfig(ctx, neo4j.AccessModeRead))
 
 	defer func() {
-		err = ioutils.DeferredClose(session, err)
+		err = ioutils.DeferredContextClose(ctx, session, err)
 	}()
 
-	result, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		result, err := tx.Run(fmt.Sprintf(`
-			MATCH (u:User {userId: $userId})-[r:HAS_FAVORITE]->(m:Movie)
-			RETURN m { .*, favorite: true } AS movie
-			ORDER BY m.`+"`%s`"+` %s
-			SKIP $skip
-			LIMIT $limit`, page.Sort(), page.Order()),
-			map[string]interface{}{
-				"userId": userId,
-				"skip":   page.Skip(),
-				"limit":  page.Limit(),
-			})
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		fragments, err := fs.options.listFragments("Movie", paging.MovieSortableAttributes(), page)
+		if err != nil {
+			return nil, err
+		}
+		params := map[string]interface{}{
+			"userId": userId,
+			"skip":   page.Skip(),
+			"limit":  page.Limit(),
+		}
+		if err := fs.options.countAll(ctx, tx, page, "favorites/count_all_by_user_id", fragments, params); err != nil {
+			return nil, err
+		}
+		result, err := fs.options.run(ctx, tx, "favorites/find_all_by_user_id", fragments, params)
 		if err != nil {
 			return nil, err
 		}
 
-		records, err := result.Collect()
+		records, err := result.Collect(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -110,11 +121,11 @@ func (fs *neo4jFavoriteService) FindAllByUserId(userId string, page *paging.Pagi
 		var movies []map[string]interface{}
 		for _, record := range records {
 			movie, _ := record.Get("movie")
-			movies = append(movies, movie.(map[string]interface{}))
+			movies = append(movies, fs.options.properties.project("Movie", movie.(map[string]interface{})))
 		}
 
 		return movies, nil
-	})
+	}, fs.options.txConfig(ctx, List))
 	if err != nil {
 		return nil, err
 	}
@@ -127,22 +138,19 @@ func (fs *neo4jFavoriteService) FindAllByUserId(userId string, page *paging.Pagi
 // Delete should remove the `:HAS_FAVORITE` relationship between
 // the User and Movie ID nodes provided.
 // If either the user, movie or the relationship between them cannot be found,
-// a `NotFoundError` should be thrown.
+// a 404 error is returned.
 // tag::remove[]
-func (fs *neo4jFavoriteService) Delete(userId, movieId string) (_ Movie, err error) {
-	session := fs.driver.NewSession(neo4j.SessionConfig{})
+func (fs *neo4jFavoriteService) Delete(ctx context.Context, userId, movieId string) (_ Movie, err error) {
+	ctx, cancel := fs.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := fs.driver.NewSession(ctx, fs.options.sessionConfig(ctx, neo4j.AccessModeWrite))
 
 	defer func() {
-		err = ioutils.DeferredClose(session, err)
+		err = ioutils.DeferredContextClose(ctx, session, err)
 	}()
 
-	result, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		result, err := tx.Run(`
-			MATCH (u:User {userId: $userId})-[r:HAS_FAVORITE]->(m:Movie {tmdbId: $movieId})
-			DELETE r
-			
-			RETURN m { .*, favorite: false } AS movie
-		`, map[string]interface{}{
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := fs.options.run(ctx, tx, "favorites/delete", nil, map[string]interface{}{
 			"userId":  userId,
 			"movieId": movieId,
 		})
@@ -150,14 +158,19 @@ func (fs *neo4jFavoriteService) Delete(userId, movieId string) (_ Movie, err err
 			return nil, err
 		}
 
-		record, err := result.Single()
-		if err != nil {
-			return nil, err
+		if !result.Next(ctx) {
+			if err := result.Err(); err != nil {
+				return nil, err
+			}
+			return nil, NewDomainError(404, "Favorite not found", map[string]interface{}{
+				"movieId": movieId,
+			})
 		}
+		record := result.Record()
 
 		movie, _ := record.Get("movie")
-		return movie.(map[string]interface{}), nil
-	})
+		return fs.options.properties.project("Movie", movie.(map[string]interface{})), nil
+	}, fs.options.txConfig(ctx, FastLookup))
 
 	if err != nil {
 		return nil, err
@@ -167,3 +180,51 @@ func (fs *neo4jFavoriteService) Delete(userId, movieId string) (_ Movie, err err
 }
 
 // end::remove[]
+
+// Toggle flips the `:HAS_FAVORITE` relationship between the User and Movie ID
+// nodes provided within a single write transaction.
+// The returned movie holds the new `favorite` state as well as the updated
+// `favoriteCount`.
+// If either the user or the movie cannot be found, a 404 error is returned.
+// tag::toggle[]
+func (fs *neo4jFavoriteService) Toggle(ctx context.Context, userId, movieId string) (_ Movie, err error) {
+	ctx, cancel := fs.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := fs.driver.NewSession(ctx, fs.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		// Updating the user first takes a write lock on the node, so that
+		// concurrent toggles from the same user are serialized
+		result, err := fs.options.run(ctx, tx, "favorites/toggle", nil, map[string]interface{}{
+			"userId":  userId,
+			"movieId": movieId,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if !result.Next(ctx) {
+			if err := result.Err(); err != nil {
+				return nil, err
+			}
+			return nil, NewDomainError(404, "User or movie not found", map[string]interface{}{
+				"movieId": movieId,
+			})
+		}
+
+		movie, _ := result.Record().Get("movie")
+		return fs.options.properties.project("Movie", movie.(map[string]interface{})), nil
+	}, fs.options.txConfig(ctx, FastLookup))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result.(Movie), nil
+}
+
+// end::toggle[]
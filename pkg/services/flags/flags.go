@@ -0,0 +1,89 @@
+package flags
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/signing"
+)
+
+// ErrInvalid is returned for malformed tokens and tokens whose signature does not match
+var ErrInvalid = errors.New("invalid flags token")
+
+// Bucketer returns the bucket of an experiment a subject is assigned to,
+// an empty bucket meaning the subject takes no part in the experiment
+type Bucketer func(subject string) string
+
+// Evaluation holds the feature flags and experiment buckets of a subject,
+// either a user ID or an anonymous ID
+type Evaluation struct {
+	Subject     string            `json:"subject"`
+	Flags       map[string]bool   `json:"flags"`
+	Experiments map[string]string `json:"experiments"`
+	IssuedAt    time.Time         `json:"issuedAt"`
+}
+
+// Evaluator evaluates the feature flags, each rolled out to a percentage of the
+// subjects, and the experiments, and signs the evaluations with HMAC-SHA256 so
+// that clients cannot tamper with them.
+// Evaluations are deterministic: a subject always gets the same flags and buckets
+// as long as the rollouts and the experiments are unchanged.
+type Evaluator struct {
+	signer      *signing.Signer
+	rollouts    map[string]int
+	experiments map[string]Bucketer
+}
+
+// New returns an Evaluator of the flags rolled out to the percentage of subjects
+// of the rollouts, between 0 and 100, and of the experiments
+func New(secret string, rollouts map[string]int, experiments map[string]Bucketer) *Evaluator {
+	return &Evaluator{
+		signer:      signing.New(secret, "flags"),
+		rollouts:    rollouts,
+		experiments: experiments,
+	}
+}
+
+// Enabled returns true if the flag is rolled out to the subject
+func (e *Evaluator) Enabled(flag, subject string) bool {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(flag + "|" + subject))
+	return int(hash.Sum32()%100) < e.rollouts[flag]
+}
+
+// Evaluate returns all the flags and the experiment buckets of the subject
+func (e *Evaluator) Evaluate(subject string, now time.Time) Evaluation {
+	evaluation := Evaluation{
+		Subject:     subject,
+		Flags:       make(map[string]bool, len(e.rollouts)),
+		Experiments: make(map[string]string, len(e.experiments)),
+		IssuedAt:    now,
+	}
+	for flag := range e.rollouts {
+		evaluation.Flags[flag] = e.Enabled(flag, subject)
+	}
+	for name, bucket := range e.experiments {
+		evaluation.Experiments[name] = bucket(subject)
+	}
+	return evaluation
+}
+
+// Sign returns a token holding the evaluation
+func (e *Evaluator) Sign(evaluation Evaluation) (string, error) {
+	token, err := e.signer.Sign(evaluation)
+	if err != nil {
+		return "", fmt.Errorf("could not encode flags: %w", err)
+	}
+	return token, nil
+}
+
+// Verify returns the evaluation held by the token if it was signed by this Evaluator
+func (e *Evaluator) Verify(token string) (Evaluation, error) {
+	var evaluation Evaluation
+	if err := e.signer.Verify(token, &evaluation); err != nil {
+		return Evaluation{}, ErrInvalid
+	}
+	return evaluation, nil
+}
@@ -0,0 +1,66 @@
+package flags_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/services/flags"
+)
+
+func TestEvaluate(outer *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	evaluator := flags.New("secret",
+		map[string]int{"everyone": 100, "nobody": 0, "half": 50},
+		map[string]flags.Bucketer{"layout": func(subject string) string { return "grid" }})
+
+	outer.Run("flags are rolled out to their percentage of subjects", func(t *testing.T) {
+		enabled := 0
+		for i := 0; i < 1000; i++ {
+			subject := fmt.Sprintf("user-%d", i)
+			evaluation := evaluator.Evaluate(subject, now)
+			if !evaluation.Flags["everyone"] || evaluation.Flags["nobody"] {
+				t.Fatalf("unexpected flags for %s: %v", subject, evaluation.Flags)
+			}
+			if evaluation.Flags["half"] != evaluator.Evaluate(subject, now).Flags["half"] {
+				t.Fatalf("expected %s to be evaluated consistently", subject)
+			}
+			if evaluation.Flags["half"] {
+				enabled++
+			}
+			if evaluation.Experiments["layout"] != "grid" {
+				t.Fatalf("unexpected experiments for %s: %v", subject, evaluation.Experiments)
+			}
+		}
+		if enabled < 400 || enabled > 600 {
+			t.Errorf("expected about half of the subjects, got %d", enabled)
+		}
+	})
+
+	outer.Run("signed evaluations cannot be tampered with", func(t *testing.T) {
+		evaluation := evaluator.Evaluate("user-1", now)
+		token, err := evaluator.Sign(evaluation)
+		if err != nil {
+			t.Fatal(err)
+		}
+		verified, err := evaluator.Verify(token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if verified.Subject != "user-1" || !verified.Flags["everyone"] || !verified.IssuedAt.Equal(now) {
+			t.Fatalf("expected %v, got %v", evaluation, verified)
+		}
+
+		evaluation.Flags["nobody"] = true
+		tampered, _ := evaluator.Sign(evaluation)
+		tamperedPayload, _, _ := strings.Cut(tampered, ".")
+		_, signature, _ := strings.Cut(token, ".")
+		forged, _ := flags.New("other", nil, nil).Sign(evaluation)
+		for _, invalid := range []string{forged, token + "x", tamperedPayload + "." + signature, ""} {
+			if _, err := evaluator.Verify(invalid); err != flags.ErrInvalid {
+				t.Fatalf("expected invalid token for %q, got %v", invalid, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+type FollowService interface {
+	Save(ctx context.Context, userId, followedId string) (User, error)
+
+	Delete(ctx context.Context, userId, followedId string) (User, error)
+}
+
+type neo4jFollowService struct {
+	loader  *fixtures.FixtureLoader
+	driver  SessionFactory
+	options serviceOptions
+}
+
+func NewFollowService(loader *fixtures.FixtureLoader, driver SessionFactory, opts ...Option) FollowService {
+	return &neo4jFollowService{
+		loader:  loader,
+		driver:  driver,
+		options: newServiceOptions(opts),
+	}
+}
+
+// Save creates a `:FOLLOWS` relationship between the User and the followed User,
+// whose ratings then show up in the daily digest, and returns the followed User.
+// The followed User is notified the first time.
+//
+// If either User cannot be found, a 404 error is returned.
+func (fs *neo4jFollowService) Save(ctx context.Context, userId, followedId string) (User, error) {
+	if userId == followedId {
+		return nil, NewDomainError(400, "Users cannot follow themselves", nil)
+	}
+	return fs.write(ctx, "follows/save", userId, followedId)
+}
+
+// Delete removes the `:FOLLOWS` relationship between the User and the followed User
+func (fs *neo4jFollowService) Delete(ctx context.Context, userId, followedId string) (User, error) {
+	return fs.write(ctx, "follows/delete", userId, followedId)
+}
+
+func (fs *neo4jFollowService) write(ctx context.Context, statement, userId, followedId string) (_ User, err error) {
+	ctx, cancel := fs.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := fs.driver.NewSession(ctx, fs.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := fs.options.run(ctx, tx, statement, nil, map[string]interface{}{
+			"userId":     userId,
+			"followedId": followedId,
+		})
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, NewDomainError(404, "User not found", map[string]interface{}{"userId": followedId})
+		}
+		user, _ := record.Get("user")
+		return User(user.(map[string]interface{})), nil
+	}, fs.options.txConfig(ctx, FastLookup))
+	if err != nil {
+		return nil, err
+	}
+	return result.(User), nil
+}
@@ -1,32 +1,50 @@
 package services
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
 
 	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
-	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
 type Genre = map[string]interface{}
 
+// AuditEntry records an administrative operation, who performed it and when
+type AuditEntry = map[string]interface{}
+
+// genreMergeBatchSize is the number of IN_GENRE relationships relinked per transaction
+// when merging genres
+const genreMergeBatchSize = 500
+
 type GenreService interface {
-	FindAll() ([]Genre, error)
+	FindAll(ctx context.Context) ([]Genre, error)
 
-	FindOneByName(name string) (Genre, error)
+	FindOneByName(ctx context.Context, name string) (Genre, error)
+
+	Merge(ctx context.Context, from, into, userId string) (AuditEntry, error)
 }
 
 type neo4jGenreService struct {
-	loader *fixtures.FixtureLoader
-	driver neo4j.Driver
+	loader  *fixtures.FixtureLoader
+	driver  SessionFactory
+	options serviceOptions
 }
 
-func NewGenreService(loader *fixtures.FixtureLoader, driver neo4j.Driver) GenreService {
-	return &neo4jGenreService{loader: loader, driver: driver}
+func NewGenreService(loader *fixtures.FixtureLoader, driver SessionFactory, opts ...Option) GenreService {
+	return &neo4jGenreService{
+		loader:  loader,
+		driver:  driver,
+		options: newServiceOptions(opts),
+	}
 }
 
 // FindAll should return a list of genres from the database with a
 // `name` property, `movies` which is the count of the incoming `IN_GENRE`
 // relationships and a `poster` property to be used as a background.
+// Genres none of whose movies have a poster are listed with a null `poster`.
 //
 // [
 //
@@ -39,38 +57,22 @@ func NewGenreService(loader *fixtures.FixtureLoader, driver neo4j.Driver) GenreS
 // ]
 //
 // tag::all[]
-func (gs *neo4jGenreService) FindAll() (_ []Genre, err error) {
-	session := gs.driver.NewSession(neo4j.SessionConfig{})
+func (gs *neo4jGenreService) FindAll(ctx context.Context) (_ []Genre, err error) {
+	ctx, cancel := gs.options.withDeadline(ctx, List)
+	defer cancel()
+	session := gs.driver.NewSession(ctx, gs.options.sessionConfig(ctx, neo4j.AccessModeRead))
 
 	defer func() {
-		err = ioutils.DeferredClose(session, err)
+		err = ioutils.DeferredContextClose(ctx, session, err)
 	}()
 
-	result, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		// Doesn't work in v5
-		result, err := tx.Run(`
-			MATCH (g:Genre)
-			WHERE g.name <> '(no genres listed)'
-			CALL {
-				WITH g
-				MATCH (g)<-[:IN_GENRE]-(m:Movie)
-				WHERE m.imdbRating IS NOT NULL
-				AND m.poster IS NOT NULL
-				RETURN m.poster AS poster
-				ORDER BY m.imdbRating DESC LIMIT 1
-			}
-			RETURN g {
-				.name,
-				link: '/genres/'+ g.name,
-				poster: poster,
-				movies: size( (g)<-[:IN_GENRE]-() )
-			} as genre
-			ORDER BY g.name ASC`, nil)
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := gs.options.run(ctx, tx, "genres/find_all", nil, nil)
 		if err != nil {
 			return nil, err
 		}
 
-		records, err := result.Collect()
+		records, err := result.Collect(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -81,7 +83,7 @@ func (gs *neo4jGenreService) FindAll() (_ []Genre, err error) {
 			results = append(results, genre.(map[string]interface{}))
 		}
 		return results, nil
-	})
+	}, gs.options.txConfig(ctx, List))
 	if err != nil {
 		return nil, err
 	}
@@ -92,35 +94,21 @@ func (gs *neo4jGenreService) FindAll() (_ []Genre, err error) {
 
 // FindOneByName should find a Genre node by its name and return a set of properties
 // along with a `poster` image and `movies` count.
+// The poster is the one of the best rated movie of the genre, if any has one.
 //
-// If the genre is not found, an error should be thrown.
+// If the genre is not found, a 404 error is returned.
 // tag::find[]
-func (gs *neo4jGenreService) FindOneByName(name string) (_ Genre, err error) {
-	session := gs.driver.NewSession(neo4j.SessionConfig{})
+func (gs *neo4jGenreService) FindOneByName(ctx context.Context, name string) (_ Genre, err error) {
+	ctx, cancel := gs.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := gs.driver.NewSession(ctx, gs.options.sessionConfig(ctx, neo4j.AccessModeRead))
 
 	defer func() {
-		err = ioutils.DeferredClose(session, err)
+		err = ioutils.DeferredContextClose(ctx, session, err)
 	}()
 
-	result, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		// Doesn't work in v5
-		result, err := tx.Run(`
-			MATCH (g:Genre {name: $name})<-[:IN_GENRE]-(m:Movie)
-			WHERE m.imdbRating IS NOT NULL
-			AND m.poster IS NOT NULL
-			AND g.name <> '(no genres listed)'
-			WITH g, m
-			ORDER BY m.imdbRating DESC
-	
-			WITH g, head(collect(m)) AS movie
-	
-			RETURN g {
-			  link: '/genres/'+ g.name,
-			  .name,
-			  movies: size((g)<-[:IN_GENRE]-()),
-			  poster: movie.poster
-			} AS genre
-		`, map[string]interface{}{
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := gs.options.run(ctx, tx, "genres/find_one_by_name", nil, map[string]interface{}{
 			"name": name,
 		})
 		if err != nil {
@@ -128,15 +116,19 @@ func (gs *neo4jGenreService) FindOneByName(name string) (_ Genre, err error) {
 		}
 
 		// Attempt to get the first and only record
-		records, err := result.Single()
-		if err != nil {
-			return nil, err
+		if !result.Next(ctx) {
+			if err := result.Err(); err != nil {
+				return nil, err
+			}
+			return nil, NewDomainError(404, "Genre not found", map[string]interface{}{
+				"name": name,
+			})
 		}
 
 		// Get genre information from the first record
-		record, _ := records.Get("genre")
+		record, _ := result.Record().Get("genre")
 		return record, nil
-	})
+	}, gs.options.txConfig(ctx, FastLookup))
 	if err != nil {
 		return nil, err
 	}
@@ -145,3 +137,80 @@ func (gs *neo4jGenreService) FindOneByName(name string) (_ Genre, err error) {
 }
 
 // end::find[]
+
+// Merge moves all the movies of the `from` genre to the `into` genre, in batches of
+// genreMergeBatchSize relationships, then deletes the `from` genre and records the
+// operation in the audit log.
+//
+// If either genre is not found, a 404 error is returned.
+func (gs *neo4jGenreService) Merge(ctx context.Context, from, into, userId string) (_ AuditEntry, err error) {
+	if from == into {
+		return nil, NewDomainError(400, "A genre cannot be merged into itself", map[string]interface{}{
+			"from": from,
+			"into": into,
+		})
+	}
+
+	ctx, cancel := gs.options.withDeadline(ctx, Export)
+	defer cancel()
+	session := gs.driver.NewSession(ctx, gs.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	var movies int64
+	for {
+		result, err := gs.options.writeTransaction(ctx, session, Export, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+			result, err := gs.options.run(ctx, tx, "genres/relink_movies", nil, map[string]interface{}{
+				"from":      from,
+				"into":      into,
+				"batchSize": genreMergeBatchSize,
+			})
+			if err != nil {
+				return nil, err
+			}
+			records, err := result.Collect(ctx)
+			if err != nil || len(records) == 0 {
+				return nil, err
+			}
+			relinked, _ := records[0].Get("relinked")
+			return relinked, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if result == nil {
+			return nil, NewDomainError(404, fmt.Sprintf("Genre %s or %s not found", from, into), map[string]interface{}{
+				"from": from,
+				"into": into,
+			})
+		}
+		relinked := result.(int64)
+		movies += relinked
+		if relinked < genreMergeBatchSize {
+			break
+		}
+	}
+
+	result, err := gs.options.writeTransaction(ctx, session, FastLookup, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := gs.options.run(ctx, tx, "genres/delete_merged", nil, map[string]interface{}{
+			"from":   from,
+			"into":   into,
+			"userId": userId,
+			"movies": movies,
+		})
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		entry, _ := record.Get("entry")
+		return entry, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(AuditEntry), nil
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Maintenance holds the `readOnly` flag of the API, the `message` returned to the
+// rejected writes, and the time it was last `updatedAt`
+type Maintenance = map[string]interface{}
+
+// DefaultMaintenanceMessage is returned to the writes rejected in read-only mode
+// when no message is set
+const DefaultMaintenanceMessage = "Neoflix is under maintenance, please try again later"
+
+type MaintenanceService interface {
+	Find(ctx context.Context) (Maintenance, error)
+
+	Save(ctx context.Context, readOnly bool, message string) (Maintenance, error)
+}
+
+type neo4jMaintenanceService struct {
+	loader  *fixtures.FixtureLoader
+	driver  SessionFactory
+	options serviceOptions
+}
+
+func NewMaintenanceService(loader *fixtures.FixtureLoader, driver SessionFactory, opts ...Option) MaintenanceService {
+	return &neo4jMaintenanceService{
+		loader:  loader,
+		driver:  driver,
+		options: newServiceOptions(opts),
+	}
+}
+
+// Find returns the maintenance status shared by all the instances of the API
+func (ms *neo4jMaintenanceService) Find(ctx context.Context) (_ Maintenance, err error) {
+	ctx, cancel := ms.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := ms.driver.NewSession(ctx, ms.options.sessionConfig(ctx, neo4j.AccessModeRead))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteRead(ctx, ms.single(ctx, "maintenance/find", nil),
+		ms.options.txConfig(ctx, FastLookup))
+	if err != nil {
+		return nil, err
+	}
+	return result.(Maintenance), nil
+}
+
+// Save toggles the read-only mode of all the instances of the API.
+// An empty message falls back to the DefaultMaintenanceMessage.
+func (ms *neo4jMaintenanceService) Save(ctx context.Context, readOnly bool, message string) (_ Maintenance, err error) {
+	if message == "" {
+		message = DefaultMaintenanceMessage
+	}
+	ctx, cancel := ms.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := ms.driver.NewSession(ctx, ms.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, ms.single(ctx, "maintenance/save", map[string]interface{}{
+		"readOnly": readOnly,
+		"message":  message,
+	}), ms.options.txConfig(ctx, FastLookup))
+	if err != nil {
+		return nil, err
+	}
+	return result.(Maintenance), nil
+}
+
+func (ms *neo4jMaintenanceService) single(ctx context.Context, statement string, params map[string]interface{}) neo4j.ManagedTransactionWork {
+	return func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := ms.options.run(ctx, tx, statement, nil, params)
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		maintenance, _ := record.Get("maintenance")
+		return maintenance.(map[string]interface{}), nil
+	}
+}
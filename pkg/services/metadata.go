@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// AppName identifies the application in the metadata of its transactions
+const AppName = "neoflix"
+
+// RequestMetadata describes the HTTP request a transaction runs on behalf of.
+// It is attached to the transaction so slow queries listed in the Neo4j query log
+// can be attributed to a specific endpoint and request.
+type RequestMetadata struct {
+	// Route is the endpoint, e.g. "GET /api/movies/{id}"
+	Route string
+	// RequestId correlates the transaction with the request logs
+	RequestId string
+	// UserId is the ID of the authenticated user, if any. It is hashed before
+	// being attached to the transaction.
+	UserId string
+}
+
+type requestMetadataKey struct{}
+
+// ContextWithRequestMetadata returns a copy of the context holding the request metadata
+func ContextWithRequestMetadata(ctx context.Context, metadata RequestMetadata) context.Context {
+	return context.WithValue(ctx, requestMetadataKey{}, metadata)
+}
+
+// RequestMetadataFromContext returns the request metadata held by the context, if any
+func RequestMetadataFromContext(ctx context.Context) (RequestMetadata, bool) {
+	metadata, ok := ctx.Value(requestMetadataKey{}).(RequestMetadata)
+	return metadata, ok
+}
+
+// txConfig configures the timeout matching the deadline of the provided class and
+// attaches the metadata of the request held by the context to the transaction
+func (o serviceOptions) txConfig(ctx context.Context, class EndpointClass) func(*neo4j.TransactionConfig) {
+	return func(config *neo4j.TransactionConfig) {
+		o.deadlines.withTimeout(class)(config)
+		neo4j.WithTxMetadata(txMetadata(ctx))(config)
+	}
+}
+
+func txMetadata(ctx context.Context) map[string]interface{} {
+	result := map[string]interface{}{"app": AppName}
+	metadata, ok := RequestMetadataFromContext(ctx)
+	if !ok {
+		return result
+	}
+	if metadata.Route != "" {
+		result["route"] = metadata.Route
+	}
+	if metadata.RequestId != "" {
+		result["requestId"] = metadata.RequestId
+	}
+	if metadata.UserId != "" {
+		result["userIdHash"] = hashUserId(metadata.UserId)
+	}
+	return result
+}
+
+// hashUserId keeps user IDs out of the query log while still allowing to group
+// the queries of a same user
+func hashUserId(userId string) string {
+	sum := sha256.Sum256([]byte(userId))
+	return hex.EncodeToString(sum[:8])
+}
@@ -1,79 +1,156 @@
 package services
 
 import (
-	"fmt"
+	"context"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/collation"
 	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
 	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j-graphacademy/neoflix/pkg/queries"
 
 	"github.com/neo4j-graphacademy/neoflix/pkg/routes/paging"
-	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
 type Movie = map[string]interface{}
 
 type MovieService interface {
-	FindAll(userId string, page *paging.Paging) ([]Movie, error)
+	FindAll(ctx context.Context, userId string, page *paging.Paging) (PagedResult, error)
+
+	FindAllAfter(ctx context.Context, cursor paging.Cursor, userId string, page *paging.Paging) (CursorPagedResult, error)
+
+	StreamAll(ctx context.Context, userId string, opts MovieStreamOptions) (MovieIterator, error)
+
+	Search(ctx context.Context, query, userId string, page *paging.Paging) (PagedResult, error)
+
+	SearchSemantic(ctx context.Context, query, userId string, page *paging.Paging) (PagedResult, error)
+
+	FindAllByGenre(ctx context.Context, genre, userId string, page *paging.Paging) (PagedResult, error)
+
+	FindAllByActorId(ctx context.Context, actorId string, userId string, page *paging.Paging) (PagedResult, error)
+
+	FindAllByDirectorId(ctx context.Context, actorId string, userId string, page *paging.Paging) (PagedResult, error)
+
+	FindAllByGenreAndPersonId(ctx context.Context, genre, personId, userId string, page *paging.Paging) (PagedResult, error)
+
+	FindOneById(ctx context.Context, id string, userId string) (Movie, error)
+
+	FindManyByIds(ctx context.Context, ids []string, userId string) ([]Movie, error)
+
+	FindSummaryById(ctx context.Context, id string) (MovieSummary, error)
+
+	FindPrefetchHintsById(ctx context.Context, id string) (PrefetchHints, error)
+
+	FindAllBySimilarity(ctx context.Context, id string, userId string, page *paging.Paging, opts MovieSimilarityOptions) ([]Movie, error)
+
+	FindAllBySimilarityGDS(ctx context.Context, id string, userId string, page *paging.Paging, opts MovieSimilarityOptions) ([]Movie, error)
+
+	FindAllHiddenGems(ctx context.Context, userId string, page *paging.Paging) (PagedResult, error)
 
-	FindAllByGenre(genre, userId string, page *paging.Paging) ([]Movie, error)
+	FindAllUpcoming(ctx context.Context, userId string, page *paging.Paging) (PagedResult, error)
 
-	FindAllByActorId(actorId string, userId string, page *paging.Paging) ([]Movie, error)
+	FindAllBoxOffice(ctx context.Context, userId string, page *paging.Paging) (PagedResult, error)
 
-	FindAllByDirectorId(actorId string, userId string, page *paging.Paging) ([]Movie, error)
+	SaveRelease(ctx context.Context, id string, released time.Time) (Movie, error)
 
-	FindOneById(id string, userId string) (Movie, error)
+	UpdateStatuses(ctx context.Context, today time.Time) (int64, error)
 
-	FindAllBySimilarity(id string, userId string, page *paging.Paging) ([]Movie, error)
+	UpdateSortTitles(ctx context.Context, limit int) (int, error)
+
+	UpdateScores(ctx context.Context, limit int) (int, error)
+
+	UpdatePlotEmbeddings(ctx context.Context, limit int) (int, error)
+
+	RecomputeAggregates(ctx context.Context, ids []string, now time.Time) ([]Movie, error)
+
+	CheckAggregates(ctx context.Context, sample int, now time.Time) (AggregateCheck, error)
+
+	SaveBoxOffice(ctx context.Context, id string, budget, revenue *int64) (Movie, error)
+
+	SaveAliases(ctx context.Context, id string, aliases []string) (Movie, error)
 }
 
+// releaseDateLayout is the layout of the `released` property of movies
+const releaseDateLayout = "2006-01-02"
+
+// Popularity weights the favorites of a movie and the ratings of the recentPopularityWindow
+// on top of its ratings
+const (
+	popularityFavoriteWeight = 2
+	recentPopularityWindow   = 30 * 24 * time.Hour
+)
+
+// HiddenGemMinRating is the minimum IMDB rating of the movies considered as hidden gems
+const HiddenGemMinRating = 7.0
+
 type neo4jMovieService struct {
-	loader *fixtures.FixtureLoader
-	driver neo4j.Driver
+	loader  *fixtures.FixtureLoader
+	driver  SessionFactory
+	options serviceOptions
 }
 
-func NewMovieService(loader *fixtures.FixtureLoader, driver neo4j.Driver) MovieService {
-	return &neo4jMovieService{loader: loader, driver: driver}
+func NewMovieService(loader *fixtures.FixtureLoader, driver SessionFactory, opts ...Option) MovieService {
+	return &neo4jMovieService{
+		loader:  loader,
+		driver:  driver,
+		options: newServiceOptions(opts),
+	}
 }
 
 // FindAll should return a paginated list of movies ordered by the `sort`
 // parameter and limited to the number passed as `limit`.  The `skip` variable should be
 // used to skip a certain number of rows.
 //
+// When the `q` parameter is set, only the movies whose title or aliases match it
+// in the `names` full-text index are returned.
+//
 // If a userId value is supplied, a `favorite` boolean property should be returned to
 // signify whether the user has added the movie to their "My Favorites" list.
 // tag::all[]
-func (ms *neo4jMovieService) FindAll(userId string, page *paging.Paging) (_ []Movie, err error) {
-	session := ms.driver.NewSession(neo4j.SessionConfig{})
+func (ms *neo4jMovieService) FindAll(ctx context.Context, userId string, page *paging.Paging) (_ PagedResult, err error) {
+	ctx, cancel := ms.options.withDeadline(ctx, List)
+	defer cancel()
+	session := ms.driver.NewSession(ctx, ms.options.sessionConfig(ctx, neo4j.AccessModeRead))
 
 	defer func() {
-		err = ioutils.DeferredClose(session, err)
+		err = ioutils.DeferredContextClose(ctx, session, err)
 	}()
 
-	results, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		favorites, err := getUserFavorites(tx, userId)
+	results, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		favorites, err := ms.userFavorites(ctx, tx, userId)
+		if err != nil {
+			return nil, err
+		}
+		excludedWarnings, err := getUserExcludedContentWarnings(ctx, tx, ms.options.catalog, userId)
 		if err != nil {
 			return nil, err
 		}
 
-		result, err := tx.Run(fmt.Sprintf(`
-			MATCH (m:Movie)
-			WHERE m.`+"`%[1]s`"+` IS NOT NULL
-			RETURN m { 
-				.* ,
-				favorite: m.tmdbId IN $favorites
-			} AS movie
-			ORDER BY m.`+"`%[1]s`"+` %s
-			SKIP $skip
-			LIMIT $limit
-		`, page.Sort(), page.Order()), map[string]interface{}{
-			"skip":      page.Skip(),
-			"limit":     page.Limit(),
-			"favorites": favorites,
-		})
+		fragments, err := ms.options.listFragments("Movie", paging.MovieSortableAttributes(), page)
+		if err != nil {
+			return nil, err
+		}
+		params := map[string]interface{}{
+			"skip":             page.Skip(),
+			"limit":            page.Limit(),
+			"favorites":        favorites,
+			"excludedWarnings": excludedWarnings,
+		}
+		find, count := "movies/find_all", "movies/count_all"
+		if page.Query() != "" {
+			find, count = "movies/search", "movies/count_search"
+			params["q"] = fulltextQuery(page.Query())
+		}
+		if err := ms.options.countAll(ctx, tx, page, count, fragments, params); err != nil {
+			return nil, err
+		}
+		result, err := ms.options.run(ctx, tx, find, fragments, params)
 		if err != nil {
 			return nil, err
 		}
 
-		records, err := result.Collect()
+		records, err := result.Collect(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -81,21 +158,131 @@ func (ms *neo4jMovieService) FindAll(userId string, page *paging.Paging) (_ []Mo
 		var results []map[string]interface{}
 		for _, record := range records {
 			movie, _ := record.Get("movie")
-			results = append(results, movie.(map[string]interface{}))
+			results = append(results, ms.options.properties.project("Movie", movie.(map[string]interface{})))
 		}
 
 		return results, nil
-	})
+	}, ms.options.txConfig(ctx, List))
 
 	if err != nil {
-		return nil, err
+		return PagedResult{}, err
 	}
 
-	return fixtures.Slice(results.([]Movie), page.Skip(), page.Limit()), nil
+	return newPagedResult(page, results.([]Movie)), nil
 }
 
 // end::all[]
 
+// FindAllAfter returns the page of movies following the cursor, in the order of the
+// `sort` and `order` parameters, by keyset rather than by skipping the previous pages,
+// so that deep pages are as fast to list as the first ones.
+// Movies sharing a sort value are ordered by ID.
+//
+// If a userId value is supplied, a `favorite` boolean property is returned to signify
+// whether the user has added the movie to their "My Favorites" list.
+func (ms *neo4jMovieService) FindAllAfter(ctx context.Context, cursor paging.Cursor, userId string, page *paging.Paging) (_ CursorPagedResult, err error) {
+	ctx, cancel := ms.options.withDeadline(ctx, List)
+	defer cancel()
+	session := ms.driver.NewSession(ctx, ms.options.sessionConfig(ctx, neo4j.AccessModeRead))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		favorites, err := ms.userFavorites(ctx, tx, userId)
+		if err != nil {
+			return nil, err
+		}
+		excludedWarnings, err := getUserExcludedContentWarnings(ctx, tx, ms.options.catalog, userId)
+		if err != nil {
+			return nil, err
+		}
+
+		params := keysetParams(cursor, page)
+		params["favorites"] = favorites
+		params["excludedWarnings"] = excludedWarnings
+		fragments, err := ms.options.keysetFragments("Movie", paging.MovieSortableAttributes(), page)
+		if err != nil {
+			return nil, err
+		}
+		result, err := ms.options.run(ctx, tx, "movies/find_all_after", fragments, params)
+		if err != nil {
+			return nil, err
+		}
+		return ms.options.collectKeysetPage(ctx, result, "movie", "Movie", cursor, page)
+	}, ms.options.txConfig(ctx, List))
+
+	if err != nil {
+		return CursorPagedResult{}, err
+	}
+	return result.(CursorPagedResult), nil
+}
+
+// Search returns a paginated list of the movies whose title, plot or tagline match the
+// query in the `movieText` full-text index, the most relevant first, along with their
+// `relevance` score.
+//
+// If a userId value is supplied, a `favorite` boolean property should be returned to
+// signify whether the user has added the movie to their "My Favorites" list.
+func (ms *neo4jMovieService) Search(ctx context.Context, query, userId string, page *paging.Paging) (_ PagedResult, err error) {
+	ctx, cancel := ms.options.withDeadline(ctx, List)
+	defer cancel()
+	session := ms.driver.NewSession(ctx, ms.options.sessionConfig(ctx, neo4j.AccessModeRead))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	results, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		favorites, err := ms.userFavorites(ctx, tx, userId)
+		if err != nil {
+			return nil, err
+		}
+		excludedWarnings, err := getUserExcludedContentWarnings(ctx, tx, ms.options.catalog, userId)
+		if err != nil {
+			return nil, err
+		}
+
+		fragments := map[string]string{
+			"title": ms.options.properties.datasetProperty("Movie", "title"),
+		}
+		params := map[string]interface{}{
+			"q":                fulltextQuery(query),
+			"skip":             page.Skip(),
+			"limit":            page.Limit(),
+			"favorites":        favorites,
+			"excludedWarnings": excludedWarnings,
+		}
+		if err := ms.options.countAll(ctx, tx, page, "movies/count_search_text", fragments, params); err != nil {
+			return nil, err
+		}
+		result, err := ms.options.run(ctx, tx, "movies/search_text", fragments, params)
+		if err != nil {
+			return nil, err
+		}
+
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		results := make([]Movie, 0, len(records))
+		for _, record := range records {
+			movie, _ := record.Get("movie")
+			results = append(results, ms.options.properties.project("Movie", movie.(map[string]interface{})))
+		}
+
+		return results, nil
+	}, ms.options.txConfig(ctx, List))
+
+	if err != nil {
+		return PagedResult{}, err
+	}
+
+	return newPagedResult(page, results.([]Movie)), nil
+}
+
 // FindAllByGenre should return a paginated list of movies that have a relationship to the
 // supplied Genre.
 //
@@ -108,40 +295,45 @@ func (ms *neo4jMovieService) FindAll(userId string, page *paging.Paging) (_ []Mo
 // signify whether the user has added the movie to their "My Favorites" list.
 //
 // tag::getByGenre[]
-func (ms *neo4jMovieService) FindAllByGenre(genre string, userId string, page *paging.Paging) (_ []Movie, err error) {
-	session := ms.driver.NewSession(neo4j.SessionConfig{})
+func (ms *neo4jMovieService) FindAllByGenre(ctx context.Context, genre string, userId string, page *paging.Paging) (_ PagedResult, err error) {
+	ctx, cancel := ms.options.withDeadline(ctx, List)
+	defer cancel()
+	session := ms.driver.NewSession(ctx, ms.options.sessionConfig(ctx, neo4j.AccessModeRead))
 
 	defer func() {
-		err = ioutils.DeferredClose(session, err)
+		err = ioutils.DeferredContextClose(ctx, session, err)
 	}()
 
-	results, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		favorites, err := getUserFavorites(tx, userId)
+	results, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		favorites, err := ms.userFavorites(ctx, tx, userId)
+		if err != nil {
+			return nil, err
+		}
+		excludedWarnings, err := getUserExcludedContentWarnings(ctx, tx, ms.options.catalog, userId)
 		if err != nil {
 			return nil, err
 		}
 
-		result, err := tx.Run(fmt.Sprintf(`
-			MATCH (m:Movie)-[:IN_GENRE]->(:Genre {name: $name})
-			WHERE m.`+"`%[1]s`"+` IS NOT NULL
-			RETURN m { 
-				.* ,
-				favorite: m.tmdbId IN $favorites
-			} AS movie
-			ORDER BY m.`+"`%[1]s`"+` %s
-			SKIP $skip
-			LIMIT $limit
-		`, page.Sort(), page.Order()), map[string]interface{}{
-			"skip":      page.Skip(),
-			"limit":     page.Limit(),
-			"favorites": favorites,
-			"name":      genre,
-		})
+		fragments, err := ms.options.listFragments("Movie", paging.GenreSortableAttributes(), page)
+		if err != nil {
+			return nil, err
+		}
+		params := map[string]interface{}{
+			"skip":             page.Skip(),
+			"limit":            page.Limit(),
+			"favorites":        favorites,
+			"excludedWarnings": excludedWarnings,
+			"name":             genre,
+		}
+		if err := ms.options.countAll(ctx, tx, page, "movies/count_all_by_genre", fragments, params); err != nil {
+			return nil, err
+		}
+		result, err := ms.options.run(ctx, tx, "movies/find_all_by_genre", fragments, params)
 		if err != nil {
 			return nil, err
 		}
 
-		records, err := result.Collect()
+		records, err := result.Collect(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -149,17 +341,17 @@ func (ms *neo4jMovieService) FindAllByGenre(genre string, userId string, page *p
 		var results []map[string]interface{}
 		for _, record := range records {
 			movie, _ := record.Get("movie")
-			results = append(results, movie.(map[string]interface{}))
+			results = append(results, ms.options.properties.project("Movie", movie.(map[string]interface{})))
 		}
 
 		return results, nil
-	})
+	}, ms.options.txConfig(ctx, List))
 
 	if err != nil {
-		return nil, err
+		return PagedResult{}, err
 	}
 
-	return fixtures.Slice(results.([]Movie), page.Skip(), page.Limit()), nil
+	return newPagedResult(page, results.([]Movie)), nil
 }
 
 // end::getByGenre[]
@@ -174,59 +366,72 @@ func (ms *neo4jMovieService) FindAllByGenre(genre string, userId string, page *p
 //
 // If a userId value is supplied, a `favorite` boolean property should be returned to
 // signify whether the user has added the movie to their "My Favorites" list.
+//
+// If the Person cannot be found, a 404 error is returned, while a Person without
+// credits gets an empty page.
 // tag::getForActor[]
-func (ms *neo4jMovieService) FindAllByActorId(actorId string, userId string, page *paging.Paging) (_ []Movie, err error) {
-	session := ms.driver.NewSession(neo4j.SessionConfig{})
+func (ms *neo4jMovieService) FindAllByActorId(ctx context.Context, actorId string, userId string, page *paging.Paging) (_ PagedResult, err error) {
+	ctx, cancel := ms.options.withDeadline(ctx, List)
+	defer cancel()
+	session := ms.driver.NewSession(ctx, ms.options.sessionConfig(ctx, neo4j.AccessModeRead))
 
 	defer func() {
-		err = ioutils.DeferredClose(session, err)
+		err = ioutils.DeferredContextClose(ctx, session, err)
 	}()
 
-	results, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		favorites, err := getUserFavorites(tx, userId)
+	results, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		favorites, err := ms.userFavorites(ctx, tx, userId)
+		if err != nil {
+			return nil, err
+		}
+		excludedWarnings, err := getUserExcludedContentWarnings(ctx, tx, ms.options.catalog, userId)
 		if err != nil {
 			return nil, err
 		}
 
-		result, err := tx.Run(fmt.Sprintf(`
-			MATCH (:Person {tmdbId: $id})-[:ACTED_IN]->(m:Movie)
-			WHERE m.`+"`%[1]s`"+` IS NOT NULL
-			RETURN m { 
-				.* ,
-				favorite: m.tmdbId IN $favorites
-			} AS movie
-			ORDER BY m.`+"`%[1]s`"+` %s
-			SKIP $skip
-			LIMIT $limit
-		`, page.Sort(), page.Order()), map[string]interface{}{
-			"skip":      page.Skip(),
-			"limit":     page.Limit(),
-			"favorites": favorites,
-			"id":        actorId,
-		})
+		fragments, err := ms.options.listFragments("Movie", paging.MovieSortableAttributes(), page)
+		if err != nil {
+			return nil, err
+		}
+		params := map[string]interface{}{
+			"skip":             page.Skip(),
+			"limit":            page.Limit(),
+			"favorites":        favorites,
+			"excludedWarnings": excludedWarnings,
+			"id":               actorId,
+		}
+		if err := ms.options.countAll(ctx, tx, page, "movies/count_all_by_actor_id", fragments, params); err != nil {
+			return nil, err
+		}
+		result, err := ms.options.run(ctx, tx, "movies/find_all_by_actor_id", fragments, params)
 		if err != nil {
 			return nil, err
 		}
 
-		records, err := result.Collect()
+		records, err := result.Collect(ctx)
 		if err != nil {
 			return nil, err
 		}
+		if len(records) == 0 {
+			if err := checkPersonExists(ctx, tx, ms.options, actorId); err != nil {
+				return nil, err
+			}
+		}
 
-		var results []map[string]interface{}
+		results := make([]Movie, 0, len(records))
 		for _, record := range records {
 			movie, _ := record.Get("movie")
-			results = append(results, movie.(map[string]interface{}))
+			results = append(results, ms.options.properties.project("Movie", movie.(map[string]interface{})))
 		}
 
 		return results, nil
-	})
+	}, ms.options.txConfig(ctx, List))
 
 	if err != nil {
-		return nil, err
+		return PagedResult{}, err
 	}
 
-	return fixtures.Slice(results.([]Movie), page.Skip(), page.Limit()), nil
+	return newPagedResult(page, results.([]Movie)), nil
 }
 
 // end::getForActor[]
@@ -241,110 +446,226 @@ func (ms *neo4jMovieService) FindAllByActorId(actorId string, userId string, pag
 //
 // If a userId value is supplied, a `favorite` boolean property should be returned to
 // signify whether the user has added the movie to their "My Favorites" list.
+//
+// If the Person cannot be found, a 404 error is returned, while a Person without
+// credits gets an empty page.
 // tag::getForDirector[]
-func (ms *neo4jMovieService) FindAllByDirectorId(actorId string, userId string, page *paging.Paging) (_ []Movie, err error) {
-	session := ms.driver.NewSession(neo4j.SessionConfig{})
+func (ms *neo4jMovieService) FindAllByDirectorId(ctx context.Context, actorId string, userId string, page *paging.Paging) (_ PagedResult, err error) {
+	ctx, cancel := ms.options.withDeadline(ctx, List)
+	defer cancel()
+	session := ms.driver.NewSession(ctx, ms.options.sessionConfig(ctx, neo4j.AccessModeRead))
 
 	defer func() {
-		err = ioutils.DeferredClose(session, err)
+		err = ioutils.DeferredContextClose(ctx, session, err)
 	}()
 
-	results, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		favorites, err := getUserFavorites(tx, userId)
+	results, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		favorites, err := ms.userFavorites(ctx, tx, userId)
+		if err != nil {
+			return nil, err
+		}
+		excludedWarnings, err := getUserExcludedContentWarnings(ctx, tx, ms.options.catalog, userId)
 		if err != nil {
 			return nil, err
 		}
 
-		result, err := tx.Run(fmt.Sprintf(`
-			MATCH (:Person {tmdbId: $id})-[:DIRECTED]->(m:Movie)
-			WHERE m.`+"`%[1]s`"+` IS NOT NULL
-			RETURN m { 
-				.* ,
-				favorite: m.tmdbId IN $favorites
-			} AS movie
-			ORDER BY m.`+"`%[1]s`"+` %s
-			SKIP $skip
-			LIMIT $limit
-		`, page.Sort(), page.Order()), map[string]interface{}{
-			"skip":      page.Skip(),
-			"limit":     page.Limit(),
-			"favorites": favorites,
-			"id":        actorId,
-		})
+		fragments, err := ms.options.listFragments("Movie", paging.MovieSortableAttributes(), page)
+		if err != nil {
+			return nil, err
+		}
+		params := map[string]interface{}{
+			"skip":             page.Skip(),
+			"limit":            page.Limit(),
+			"favorites":        favorites,
+			"excludedWarnings": excludedWarnings,
+			"id":               actorId,
+		}
+		if err := ms.options.countAll(ctx, tx, page, "movies/count_all_by_director_id", fragments, params); err != nil {
+			return nil, err
+		}
+		result, err := ms.options.run(ctx, tx, "movies/find_all_by_director_id", fragments, params)
 		if err != nil {
 			return nil, err
 		}
 
-		records, err := result.Collect()
+		records, err := result.Collect(ctx)
 		if err != nil {
 			return nil, err
 		}
+		if len(records) == 0 {
+			if err := checkPersonExists(ctx, tx, ms.options, actorId); err != nil {
+				return nil, err
+			}
+		}
 
-		var results []map[string]interface{}
+		results := make([]Movie, 0, len(records))
 		for _, record := range records {
 			movie, _ := record.Get("movie")
-			results = append(results, movie.(map[string]interface{}))
+			results = append(results, ms.options.properties.project("Movie", movie.(map[string]interface{})))
 		}
 
 		return results, nil
-	})
+	}, ms.options.txConfig(ctx, List))
 
 	if err != nil {
-		return nil, err
+		return PagedResult{}, err
 	}
 
-	return fixtures.Slice(results.([]Movie), page.Skip(), page.Limit()), nil
+	return newPagedResult(page, results.([]Movie)), nil
 }
 
 // end::getForDirector[]
 
+// FindAllByGenreAndPersonId returns a paginated list of the movies of the Genre which the
+// Person with the id supplied acted in or directed.
+//
+// Results are ordered by the `sort` parameter, in the direction specified in the `order`
+// parameter, and flagged as `favorite` for the user with the userId supplied, if any.
+func (ms *neo4jMovieService) FindAllByGenreAndPersonId(ctx context.Context, genre, personId, userId string, page *paging.Paging) (_ PagedResult, err error) {
+	ctx, cancel := ms.options.withDeadline(ctx, List)
+	defer cancel()
+	session := ms.driver.NewSession(ctx, ms.options.sessionConfig(ctx, neo4j.AccessModeRead))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	results, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		favorites, err := ms.userFavorites(ctx, tx, userId)
+		if err != nil {
+			return nil, err
+		}
+		excludedWarnings, err := getUserExcludedContentWarnings(ctx, tx, ms.options.catalog, userId)
+		if err != nil {
+			return nil, err
+		}
+
+		fragments, err := ms.options.listFragments("Movie", paging.GenreSortableAttributes(), page)
+		if err != nil {
+			return nil, err
+		}
+		params := map[string]interface{}{
+			"skip":             page.Skip(),
+			"limit":            page.Limit(),
+			"favorites":        favorites,
+			"excludedWarnings": excludedWarnings,
+			"name":             genre,
+			"id":               personId,
+		}
+		if err := ms.options.countAll(ctx, tx, page, "movies/count_all_by_genre_and_person_id", fragments, params); err != nil {
+			return nil, err
+		}
+		result, err := ms.options.run(ctx, tx, "movies/find_all_by_genre_and_person_id", fragments, params)
+		if err != nil {
+			return nil, err
+		}
+
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		results := make([]Movie, 0, len(records))
+		for _, record := range records {
+			movie, _ := record.Get("movie")
+			results = append(results, ms.options.properties.project("Movie", movie.(map[string]interface{})))
+		}
+
+		return results, nil
+	}, ms.options.txConfig(ctx, List))
+
+	if err != nil {
+		return PagedResult{}, err
+	}
+	return newPagedResult(page, results.([]Movie)), nil
+}
+
+// recentReviews is the number of reviews listed with the details of movies
+const recentReviews = 5
+
+// featuredReviewWindow is the age of the reviews preferred for the featured review of
+// movies
+const featuredReviewWindow = 90 * 24 * time.Hour
+
 // FindOneById finds a Movie node with the ID passed as the `id` parameter.
 // Along with the returned payload, a list of actors, directors, and genres should
 // be included.
-// The number of incoming RATED relationships should also be returned as `ratingCount`
+// The number of incoming RATED relationships should also be returned as `ratingCount`,
+// along with their `averageRating`.
 //
 // If a userId value is supplied, a `favorite` boolean property should be returned to
 // signify whether the user has added the movie to their "My Favorites" list.
+//
+// The movie also holds its `recentReviews`, the texts of its most recent reviews along
+// with their reviewer, those of the users blocked by the user left out, and its
+// `featuredReview`, the most helpful of the reviews of the featuredReviewWindow, or of
+// all its reviews when none is that recent.
 // tag::findById[]
-func (ms *neo4jMovieService) FindOneById(id string, userId string) (_ Movie, err error) {
-	session := ms.driver.NewSession(neo4j.SessionConfig{})
+func (ms *neo4jMovieService) FindOneById(ctx context.Context, id string, userId string) (_ Movie, err error) {
+	ctx, cancel := ms.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := ms.driver.NewSession(ctx, ms.options.sessionConfig(ctx, neo4j.AccessModeRead))
 
 	defer func() {
-		err = ioutils.DeferredClose(session, err)
+		err = ioutils.DeferredContextClose(ctx, session, err)
 	}()
 
-	result, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		favorites, err := getUserFavorites(tx, userId)
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		favorites, err := ms.userFavorites(ctx, tx, userId)
 		if err != nil {
 			return nil, err
 		}
 
-		result, err := tx.Run(`
-			MATCH (m:Movie {tmdbId: $id})
-			RETURN m {
-			  .*,
-				actors: [ (a)-[r:ACTED_IN]->(m) | a { .*, role: r.role } ],
-				directors: [ (d)-[:DIRECTED]->(m) | d { .* } ],
-				genres: [ (m)-[:IN_GENRE]->(g) | g { .name }],
-				ratingCount: size((m)<-[:RATED]-()),
-				favorite: m.tmdbId IN $favorites
-			} AS movie
-			LIMIT 1`,
+		result, err := ms.options.run(ctx, tx, "movies/find_one_by_id", nil,
 			map[string]interface{}{
-				"id":        id,
-				"favorites": favorites,
+				"id":             id,
+				"favorites":      favorites,
+				"excludeFlagged": ms.options.excludeFlagged,
 			})
 		if err != nil {
 			return nil, err
 		}
 
-		record, err := result.Single()
+		record, err := result.Single(ctx)
 		if err != nil {
 			return nil, err
 		}
-		movie, _ := record.Get("movie")
+		value, _ := record.Get("movie")
+		movie := ms.options.properties.project("Movie", value.(map[string]interface{}))
+
+		reviews, err := ms.options.run(ctx, tx, "reviews/find_recent_by_movie_id", nil,
+			map[string]interface{}{"id": id, "userId": userId, "limit": recentReviews})
+		if err != nil {
+			return nil, err
+		}
+		recent := []interface{}{}
+		for reviews.Next(ctx) {
+			review, _ := reviews.Record().Get("review")
+			recent = append(recent, review)
+		}
+		if err := reviews.Err(); err != nil {
+			return nil, err
+		}
+		movie["recentReviews"] = recent
+
+		featured, err := ms.options.run(ctx, tx, "reviews/find_featured_by_movie_id", nil, map[string]interface{}{
+			"id":     id,
+			"userId": userId,
+			"since":  time.Now().Add(-featuredReviewWindow).UnixMilli(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		movie["featuredReview"] = nil
+		if featured.Next(ctx) {
+			movie["featuredReview"], _ = featured.Record().Get("review")
+		}
+		if err := featured.Err(); err != nil {
+			return nil, err
+		}
+
 		return movie, nil
-	})
+	}, ms.options.txConfig(ctx, FastLookup))
 
 	if err != nil {
 		return nil, err
@@ -354,102 +675,878 @@ func (ms *neo4jMovieService) FindOneById(id string, userId string) (_ Movie, err
 
 // end::findById[]
 
-// FindAllBySimilarity should return a paginated list of similar movies to the Movie with the
-// id supplied.  This similarity is calculated by finding movies that have many first
-// degree connections in common: Actors, Directors and Genres.
+// MaxBatchIds is the maximum number of movies FindManyByIds fetches at once
+const MaxBatchIds = 100
+
+// FindManyByIds fetches the movies with the IDs supplied in a single query, in the order
+// of the IDs, so that lists of IDs such as favorites can be hydrated at once.
+// Unknown IDs are left out, and repeated ones only returned once.
 //
-// Results should be ordered by the `sort` parameter, and in the direction specified
-// in the `order` parameter.
-// Results should be limited to the number passed as `limit`.
-// The `skip` variable should be used to skip a certain number of rows.
+// If a userId value is supplied, a `favorite` boolean property is returned to signify
+// whether the user has added the movie to their "My Favorites" list.
 //
-// If a userId value is supplied, a `favorite` boolean property should be returned to
-// signify whether the user has added the movie to their "My Favorites" list.
-// tag::getSimilarMovies[]
-func (ms *neo4jMovieService) FindAllBySimilarity(id string, userId string, page *paging.Paging) (_ []Movie, err error) {
-	session := ms.driver.NewSession(neo4j.SessionConfig{})
+// If more than MaxBatchIds IDs are supplied, a 400 error is returned.
+func (ms *neo4jMovieService) FindManyByIds(ctx context.Context, ids []string, userId string) (_ []Movie, err error) {
+	ids = distinctIds(ids)
+	if len(ids) > MaxBatchIds {
+		return nil, NewDomainError(400, "Too many movie IDs", map[string]interface{}{
+			"max": MaxBatchIds,
+		})
+	}
+	if len(ids) == 0 {
+		return []Movie{}, nil
+	}
+
+	ctx, cancel := ms.options.withDeadline(ctx, List)
+	defer cancel()
+	session := ms.driver.NewSession(ctx, ms.options.sessionConfig(ctx, neo4j.AccessModeRead))
+
 	defer func() {
-		err = ioutils.DeferredClose(session, err)
+		err = ioutils.DeferredContextClose(ctx, session, err)
 	}()
 
-	result, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		favorites, err := getUserFavorites(tx, userId)
+	results, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		favorites, err := ms.userFavorites(ctx, tx, userId)
 		if err != nil {
 			return nil, err
 		}
 
-		// Doesn't work in v5
-		result, err := tx.Run(`
-			MATCH (:Movie {tmdbId: $id})-[:IN_GENRE|ACTED_IN|DIRECTED]->()<-[:IN_GENRE|ACTED_IN|DIRECTED]-(m)
-			WHERE m.imdbRating IS NOT NULL
-
-			WITH m, count(*) AS inCommon
-			WITH m, inCommon, m.imdbRating * inCommon AS score
-			ORDER BY score DESC
-
-			SKIP $skip
-			LIMIT $limit
-
-			RETURN m {
-				.*,
-				score: score,
-				favorite: m.tmdbId IN $favorites
-			} AS movie
-		`, map[string]interface{}{
-			"id":        id,
+		result, err := ms.options.run(ctx, tx, "movies/find_many_by_ids", nil, map[string]interface{}{
+			"ids":       ids,
 			"favorites": favorites,
-			"skip":      page.Skip(),
-			"limit":     page.Limit(),
 		})
 		if err != nil {
 			return nil, err
 		}
-
-		records, err := result.Collect()
+		records, err := result.Collect(ctx)
 		if err != nil {
 			return nil, err
 		}
 
-		var results []map[string]interface{}
+		results := make([]Movie, 0, len(records))
 		for _, record := range records {
 			movie, _ := record.Get("movie")
-			results = append(results, movie.(map[string]interface{}))
+			results = append(results, ms.options.properties.project("Movie", movie.(map[string]interface{})))
 		}
-
 		return results, nil
-	})
+	}, ms.options.txConfig(ctx, List))
 
 	if err != nil {
 		return nil, err
 	}
-	return result.([]Movie), nil
+	return results.([]Movie), nil
 }
 
-// end::getSimilarMovies[]
-
-// getUserFavorites should return a list of tmdbId properties for the movies that
-// the user has added to their 'My Favorites' list.
-// tag::getUserFavorites[]
-func getUserFavorites(tx neo4j.Transaction, userId string) ([]string, error) {
-	if userId == "" {
-		return nil, nil
+// distinctIds returns the non-empty IDs, in order, without their repetitions
+func distinctIds(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	distinct := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		distinct = append(distinct, id)
 	}
+	return distinct
+}
+
+// PrefetchHints lists the IDs of the movies and people clients are likely to request
+// after the details of a Movie, for them to warm their caches
+type PrefetchHints struct {
+	Movies []string `json:"movies"`
+	People []string `json:"people"`
+}
+
+// prefetchMovies and prefetchPeople are the numbers of IDs of the prefetch hints
+const (
+	prefetchMovies = 5
+	prefetchPeople = 5
+)
+
+// FindPrefetchHintsById returns the IDs of the movies the most similar to the Movie,
+// according to the SIMILAR relationships materialized between movies, and of its best
+// known actors, i.e. those with the most credits.
+// Without SIMILAR relationships, no movies are hinted.
+//
+// If the Movie cannot be found, a 404 error is returned.
+func (ms *neo4jMovieService) FindPrefetchHintsById(ctx context.Context, id string) (_ PrefetchHints, err error) {
+	ctx, cancel := ms.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := ms.driver.NewSession(ctx, ms.options.sessionConfig(ctx, neo4j.AccessModeRead))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := ms.options.run(ctx, tx, "movies/find_prefetch_hints", nil, map[string]interface{}{
+			"id":     id,
+			"movies": prefetchMovies,
+			"people": prefetchPeople,
+		})
+		if err != nil {
+			return nil, err
+		}
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, NewDomainError(404, "Movie not found", map[string]interface{}{"id": id})
+		}
+		rawHints, _ := records[0].Get("hints")
+		hints := rawHints.(map[string]interface{})
+		return PrefetchHints{
+			Movies: toStrings(hints["movies"]),
+			People: toStrings(hints["people"]),
+		}, nil
+	}, ms.options.txConfig(ctx, FastLookup))
 
-	result, err := tx.Run(`
-		MATCH (u:User {userId: $userId})-[:HAS_FAVORITE]->(m)
-		RETURN m.tmdbId AS id
-	`, map[string]interface{}{"userId": userId})
 	if err != nil {
-		return nil, err
+		return PrefetchHints{}, err
+	}
+	return result.(PrefetchHints), nil
+}
+
+// summaryActors is the number of actors listed by movie summaries
+const summaryActors = 3
+
+// FindSummaryById returns a compact summary of the Movie, suited to voice and chat
+// assistants: its `title`, release `year`, the first sentence of its `plot`, its three
+// best known `actors`, its `director` and its IMDB `rating`.
+//
+// If the Movie cannot be found, a 404 error is returned.
+func (ms *neo4jMovieService) FindSummaryById(ctx context.Context, id string) (_ MovieSummary, err error) {
+	ctx, cancel := ms.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := ms.driver.NewSession(ctx, ms.options.sessionConfig(ctx, neo4j.AccessModeRead))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		fragments := map[string]string{}
+		for _, property := range []string{"title", "released", "plot"} {
+			fragments[property] = ms.options.properties.datasetProperty("Movie", property)
+		}
+		fragments["rating"] = ms.options.properties.datasetProperty("Movie", "imdbRating")
+		result, err := ms.options.run(ctx, tx, "movies/find_summary_by_id", fragments, map[string]interface{}{
+			"id":     id,
+			"actors": summaryActors,
+		})
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, NewDomainError(404, "Movie not found", map[string]interface{}{"id": id})
+		}
+		summary, _ := record.Get("summary")
+		return newMovieSummary(summary.(map[string]interface{})), nil
+	}, ms.options.txConfig(ctx, FastLookup))
+
+	if err != nil {
+		return nil, err
+	}
+	return result.(MovieSummary), nil
+}
+
+// FindAllBySimilarity should return a paginated list of similar movies to the Movie with the
+// id supplied.  This similarity is calculated by finding movies that have many first
+// degree connections in common: Actors, Directors and Genres, weighted by SimilarityWeights.
+//
+// Results should be ordered by the `sort` parameter, and in the direction specified
+// in the `order` parameter.
+// Results should be limited to the number passed as `limit`.
+// The `skip` variable should be used to skip a certain number of rows.
+//
+// If a userId value is supplied, a `favorite` boolean property should be returned to
+// signify whether the user has added the movie to their "My Favorites" list, and the
+// movies matching the taste of the user are ranked higher, see SimilarityWeights.
+// tag::getSimilarMovies[]
+func (ms *neo4jMovieService) FindAllBySimilarity(ctx context.Context, id string, userId string, page *paging.Paging, opts MovieSimilarityOptions) ([]Movie, error) {
+	return ms.findAllBySimilarity(ctx, "movies/find_all_by_similarity", id, userId, page, opts)
+}
+
+func (ms *neo4jMovieService) findAllBySimilarity(ctx context.Context, statement, id string, userId string, page *paging.Paging, opts MovieSimilarityOptions) (_ []Movie, err error) {
+	ctx, cancel := ms.options.withDeadline(ctx, Similarity)
+	defer cancel()
+	session := ms.driver.NewSession(ctx, ms.options.sessionConfig(ctx, neo4j.AccessModeRead))
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	weights := opts.apply(ms.options.similarityWeights)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		favorites, err := ms.userFavorites(ctx, tx, userId)
+		if err != nil {
+			return nil, err
+		}
+		excludedWarnings, err := getUserExcludedContentWarnings(ctx, tx, ms.options.catalog, userId)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := ms.options.run(ctx, tx, statement, nil, map[string]interface{}{
+			"id":               id,
+			"userId":           userId,
+			"minRating":        ms.options.recommendationMinRating,
+			"weights":          weights.params(),
+			"maxFanOut":        ms.options.maxFanOut,
+			"favorites":        favorites,
+			"excludedWarnings": excludedWarnings,
+			"skip":             page.Skip(),
+			"limit":            page.Limit(),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var results []map[string]interface{}
+		for _, record := range records {
+			movie, _ := record.Get("movie")
+			results = append(results, ms.options.properties.project("Movie", movie.(map[string]interface{})))
+		}
+
+		return results, nil
+	}, ms.options.txConfig(ctx, Similarity))
+
+	if err != nil {
+		return nil, err
+	}
+	return result.([]Movie), nil
+}
+
+// end::getSimilarMovies[]
+
+// FindAllBySimilarityGDS returns a paginated list of the movies similar to the Movie
+// with the id, like FindAllBySimilarity, scored by the Jaccard similarity of their
+// genres, actors and directors computed by the Graph Data Science library, as their
+// `similarity`, multiplied by their IMDB rating to the Rating weight.
+// Only the Rating weight applies, and the movies are not re-ranked by the taste of
+// the user.
+//
+// When the library is not available, see WithGds, the movies are scored by
+// FindAllBySimilarity instead.
+func (ms *neo4jMovieService) FindAllBySimilarityGDS(ctx context.Context, id string, userId string, page *paging.Paging, opts MovieSimilarityOptions) ([]Movie, error) {
+	if !ms.options.gds {
+		return ms.FindAllBySimilarity(ctx, id, userId, page, opts)
+	}
+	return ms.findAllBySimilarity(ctx, "movies/find_all_by_similarity_gds", id, userId, page, opts)
+}
+
+// FindAllHiddenGems returns a paginated list of highly rated movies which few people
+// rated or voted for, ordered by their `gemScore`: their IMDB rating divided by the
+// logarithm of their number of ratings and IMDB votes.
+//
+// If a userId value is supplied, the movies they already rated are left out.
+func (ms *neo4jMovieService) FindAllHiddenGems(ctx context.Context, userId string, page *paging.Paging) (_ PagedResult, err error) {
+	ctx, cancel := ms.options.withDeadline(ctx, List)
+	defer cancel()
+	session := ms.driver.NewSession(ctx, ms.options.sessionConfig(ctx, neo4j.AccessModeRead))
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		favorites, err := ms.userFavorites(ctx, tx, userId)
+		if err != nil {
+			return nil, err
+		}
+		excludedWarnings, err := getUserExcludedContentWarnings(ctx, tx, ms.options.catalog, userId)
+		if err != nil {
+			return nil, err
+		}
+
+		fragments := map[string]string{
+			"rating": ms.options.properties.datasetProperty("Movie", "imdbRating"),
+			"votes":  ms.options.properties.datasetProperty("Movie", "imdbVotes"),
+		}
+		params := map[string]interface{}{
+			"userId":           userId,
+			"minRating":        HiddenGemMinRating,
+			"favorites":        favorites,
+			"excludedWarnings": excludedWarnings,
+			"skip":             page.Skip(),
+			"limit":            page.Limit(),
+		}
+		if err := ms.options.countAll(ctx, tx, page, "movies/count_all_hidden_gems", fragments, params); err != nil {
+			return nil, err
+		}
+		result, err := ms.options.run(ctx, tx, "movies/find_all_hidden_gems", fragments, params)
+		if err != nil {
+			return nil, err
+		}
+
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var results []map[string]interface{}
+		for _, record := range records {
+			movie, _ := record.Get("movie")
+			results = append(results, ms.options.properties.project("Movie", movie.(map[string]interface{})))
+		}
+
+		return results, nil
+	}, ms.options.txConfig(ctx, List))
+
+	if err != nil {
+		return PagedResult{}, err
+	}
+	return newPagedResult(page, result.([]Movie)), nil
+}
+
+// FindAllUpcoming returns a paginated list of the announced movies which are not released
+// yet, i.e. labelled `:Upcoming`, ordered by release date.
+//
+// If a userId value is supplied, a `favorite` boolean property should be returned to
+// signify whether the user has added the movie to their "My Favorites" list.
+func (ms *neo4jMovieService) FindAllUpcoming(ctx context.Context, userId string, page *paging.Paging) (_ PagedResult, err error) {
+	ctx, cancel := ms.options.withDeadline(ctx, List)
+	defer cancel()
+	session := ms.driver.NewSession(ctx, ms.options.sessionConfig(ctx, neo4j.AccessModeRead))
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		favorites, err := ms.userFavorites(ctx, tx, userId)
+		if err != nil {
+			return nil, err
+		}
+		excludedWarnings, err := getUserExcludedContentWarnings(ctx, tx, ms.options.catalog, userId)
+		if err != nil {
+			return nil, err
+		}
+
+		fragments := ms.releasedFragment()
+		params := map[string]interface{}{
+			"favorites":        favorites,
+			"excludedWarnings": excludedWarnings,
+			"skip":             page.Skip(),
+			"limit":            page.Limit(),
+		}
+		if err := ms.options.countAll(ctx, tx, page, "movies/count_all_upcoming", fragments, params); err != nil {
+			return nil, err
+		}
+		result, err := ms.options.run(ctx, tx, "movies/find_all_upcoming", fragments, params)
+		if err != nil {
+			return nil, err
+		}
+
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var results []map[string]interface{}
+		for _, record := range records {
+			movie, _ := record.Get("movie")
+			results = append(results, ms.options.properties.project("Movie", movie.(map[string]interface{})))
+		}
+
+		return results, nil
+	}, ms.options.txConfig(ctx, List))
+
+	if err != nil {
+		return PagedResult{}, err
+	}
+	return newPagedResult(page, result.([]Movie)), nil
+}
+
+// FindAllBoxOffice returns a paginated leaderboard of the movies with a known revenue,
+// highest grossing first, along with their `profit` when their budget is known too.
+//
+// If a userId value is supplied, a `favorite` boolean property should be returned to
+// signify whether the user has added the movie to their "My Favorites" list.
+func (ms *neo4jMovieService) FindAllBoxOffice(ctx context.Context, userId string, page *paging.Paging) (_ PagedResult, err error) {
+	ctx, cancel := ms.options.withDeadline(ctx, List)
+	defer cancel()
+	session := ms.driver.NewSession(ctx, ms.options.sessionConfig(ctx, neo4j.AccessModeRead))
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		favorites, err := ms.userFavorites(ctx, tx, userId)
+		if err != nil {
+			return nil, err
+		}
+		excludedWarnings, err := getUserExcludedContentWarnings(ctx, tx, ms.options.catalog, userId)
+		if err != nil {
+			return nil, err
+		}
+
+		fragments := ms.boxOfficeFragments()
+		params := map[string]interface{}{
+			"favorites":        favorites,
+			"excludedWarnings": excludedWarnings,
+			"skip":             page.Skip(),
+			"limit":            page.Limit(),
+		}
+		if err := ms.options.countAll(ctx, tx, page, "movies/count_all_box_office", fragments, params); err != nil {
+			return nil, err
+		}
+		result, err := ms.options.run(ctx, tx, "movies/find_all_box_office", fragments, params)
+		if err != nil {
+			return nil, err
+		}
+
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var results []map[string]interface{}
+		for _, record := range records {
+			movie, _ := record.Get("movie")
+			results = append(results, ms.options.properties.project("Movie", movie.(map[string]interface{})))
+		}
+
+		return results, nil
+	}, ms.options.txConfig(ctx, List))
+
+	if err != nil {
+		return PagedResult{}, err
+	}
+	return newPagedResult(page, result.([]Movie)), nil
+}
+
+// SaveRelease sets the release date of the Movie, and labels it `:Upcoming` or
+// `:Released` depending on whether that date is in the future.
+//
+// If the Movie cannot be found, a 404 error is returned.
+func (ms *neo4jMovieService) SaveRelease(ctx context.Context, id string, released time.Time) (_ Movie, err error) {
+	ctx, cancel := ms.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := ms.driver.NewSession(ctx, ms.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := ms.options.run(ctx, tx, "movies/save_release", ms.releasedFragment(), map[string]interface{}{
+			"id":       id,
+			"released": released.Format(releaseDateLayout),
+			"today":    time.Now().UTC().Format(releaseDateLayout),
+		})
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, NewDomainError(404, "Movie not found", map[string]interface{}{"id": id})
+		}
+		movie, _ := record.Get("movie")
+		return ms.options.properties.project("Movie", movie.(map[string]interface{})), nil
+	}, ms.options.txConfig(ctx, FastLookup))
+
+	if err != nil {
+		return nil, err
+	}
+	return result.(Movie), nil
+}
+
+// RecomputeAggregates recalculates and stores the aggregates of the movies with the
+// provided IDs, and returns them along with their ID and title:
+// their `ratingCount`, their `ratingAvg`, which leaves out flagged ratings when
+// configured so, their `favoriteCount` and their `popularity`, i.e. the number of their
+// ratings, those of the last 30 days counting twice, plus twice their number of favorites.
+// Their `score` is updated by the next UpdateScores.
+//
+// Unknown IDs are ignored.
+func (ms *neo4jMovieService) RecomputeAggregates(ctx context.Context, ids []string, now time.Time) (_ []Movie, err error) {
+	ctx, cancel := ms.options.withDeadline(ctx, Export)
+	defer cancel()
+	session := ms.driver.NewSession(ctx, ms.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := ms.options.writeTransaction(ctx, session, Export, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := ms.options.run(ctx, tx, "movies/recompute_aggregates", nil, map[string]interface{}{
+			"ids":            ids,
+			"excludeFlagged": ms.options.excludeFlagged,
+			"recentSince":    now.Add(-recentPopularityWindow).UnixMilli(),
+			"favoriteWeight": popularityFavoriteWeight,
+		})
+		if err != nil {
+			return nil, err
+		}
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		movies := make([]Movie, 0, len(records))
+		for _, record := range records {
+			movie, _ := record.Get("movie")
+			movies = append(movies, movie.(map[string]interface{}))
+		}
+		return movies, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.([]Movie), nil
+}
+
+// CheckAggregates compares the stored `ratingCount`, `ratingAvg` and `favoriteCount` of
+// a random sample of movies whose aggregates were computed to the values computed from
+// their relationships, and repairs the drifting movies by recomputing their aggregates.
+func (ms *neo4jMovieService) CheckAggregates(ctx context.Context, sample int, now time.Time) (_ AggregateCheck, err error) {
+	ctx, cancel := ms.options.withDeadline(ctx, Export)
+	defer cancel()
+	session := ms.driver.NewSession(ctx, ms.options.sessionConfig(ctx, neo4j.AccessModeRead))
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := ms.options.run(ctx, tx, "movies/find_all_aggregates_sample", nil, map[string]interface{}{
+			"sample":         sample,
+			"excludeFlagged": ms.options.excludeFlagged,
+		})
+		if err != nil {
+			return nil, err
+		}
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		check := AggregateCheck{Checked: len(records), Drifts: []AggregateDrift{}}
+		for _, record := range records {
+			id, _ := record.Get("id")
+			stored, _ := record.Get("stored")
+			actual, _ := record.Get("actual")
+			check.Drifts = append(check.Drifts, aggregateDrifts(id.(string),
+				stored.(map[string]interface{}), actual.(map[string]interface{}))...)
+		}
+		return check, nil
+	}, ms.options.txConfig(ctx, Export))
+	if err != nil {
+		return AggregateCheck{}, err
+	}
+
+	check := result.(AggregateCheck)
+	if ids := driftedIds(check.Drifts); len(ids) > 0 {
+		repaired, err := ms.RecomputeAggregates(ctx, ids, now)
+		if err != nil {
+			return check, err
+		}
+		check.Repaired = len(repaired)
+	}
+	return check, nil
+}
+
+// SaveBoxOffice sets the budget and the revenue of the Movie, in US dollars.
+// A nil budget or revenue is left unchanged.
+//
+// If the Movie cannot be found, a 404 error is returned.
+func (ms *neo4jMovieService) SaveBoxOffice(ctx context.Context, id string, budget, revenue *int64) (_ Movie, err error) {
+	ctx, cancel := ms.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := ms.driver.NewSession(ctx, ms.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		params := map[string]interface{}{"id": id, "budget": nil, "revenue": nil}
+		if budget != nil {
+			params["budget"] = *budget
+		}
+		if revenue != nil {
+			params["revenue"] = *revenue
+		}
+		result, err := ms.options.run(ctx, tx, "movies/save_box_office", ms.boxOfficeFragments(), params)
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, NewDomainError(404, "Movie not found", map[string]interface{}{"id": id})
+		}
+		movie, _ := record.Get("movie")
+		return ms.options.properties.project("Movie", movie.(map[string]interface{})), nil
+	}, ms.options.txConfig(ctx, FastLookup))
+
+	if err != nil {
+		return nil, err
+	}
+	return result.(Movie), nil
+}
+
+// UpdateStatuses relabels `:Released` the upcoming movies whose release date is passed,
+// labels the movies without status yet, and returns the number of updated movies
+func (ms *neo4jMovieService) UpdateStatuses(ctx context.Context, today time.Time) (_ int64, err error) {
+	ctx, cancel := ms.options.withDeadline(ctx, Export)
+	defer cancel()
+	session := ms.driver.NewSession(ctx, ms.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := ms.options.run(ctx, tx, "movies/update_statuses", ms.releasedFragment(), map[string]interface{}{
+			"today": today.UTC().Format(releaseDateLayout),
+		})
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		updated, _ := record.Get("updated")
+		return updated, nil
+	}, ms.options.txConfig(ctx, Export))
+
+	if err != nil {
+		return 0, err
+	}
+	return result.(int64), nil
+}
+
+// UpdateSortTitles stores the sort keys of the titles of up to `limit` movies whose title
+// changed, or which were imported, since their keys were computed, in every language of
+// collation.Supported, and returns the number of updated movies.
+// Fewer updated movies than `limit` means all sort keys are up-to-date.
+func (ms *neo4jMovieService) UpdateSortTitles(ctx context.Context, limit int) (_ int, err error) {
+	ctx, cancel := ms.options.withDeadline(ctx, Export)
+	defer cancel()
+	session := ms.driver.NewSession(ctx, ms.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := ms.options.run(ctx, tx, "movies/find_all_unsorted_titles", map[string]string{
+			"title": ms.options.properties.datasetProperty("Movie", "title"),
+		}, map[string]interface{}{
+			"languages": collation.Supported,
+			"limit":     limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return 0, nil
+		}
+
+		movies := make([]map[string]interface{}, 0, len(records))
+		for _, record := range records {
+			id, _ := record.Get("id")
+			title, _ := record.Get("title")
+			sortTitles := map[string]interface{}{}
+			for _, language := range collation.Supported {
+				sortTitles[collation.SortProperty(language)] = collation.SortKey(title.(string), language)
+			}
+			movies = append(movies, map[string]interface{}{
+				"id":         id,
+				"title":      title,
+				"sortTitles": sortTitles,
+			})
+		}
+		if _, err := ms.options.run(ctx, tx, "movies/save_sort_titles", nil, map[string]interface{}{
+			"movies":    movies,
+			"languages": collation.Supported,
+		}); err != nil {
+			return nil, err
+		}
+		return len(movies), nil
+	}, ms.options.txConfig(ctx, Export))
+
+	if err != nil {
+		return 0, err
+	}
+	return result.(int), nil
+}
+
+func (ms *neo4jMovieService) releasedFragment() map[string]string {
+	return map[string]string{"released": ms.options.properties.datasetProperty("Movie", "released")}
+}
+
+func (ms *neo4jMovieService) boxOfficeFragments() map[string]string {
+	return map[string]string{
+		"budget":  ms.options.properties.datasetProperty("Movie", "budget"),
+		"revenue": ms.options.properties.datasetProperty("Movie", "revenue"),
+	}
+}
+
+// getUserFavorites should return a list of tmdbId properties for the movies that
+// the user has added to their 'My Favorites' list.
+// tag::getUserFavorites[]
+func getUserFavorites(ctx context.Context, tx neo4j.ManagedTransaction, catalog *queries.Catalog, userId string) ([]string, error) {
+	if userId == "" {
+		return nil, nil
+	}
+
+	result, err := tx.Run(trackQuery(ctx, "favorites/user_favorite_ids"), catalog.Get("favorites/user_favorite_ids").Render(nil), map[string]interface{}{"userId": userId})
+	if err != nil {
+		return nil, err
 	}
 
 	var ids []string
-	for result.Next() {
+	for result.Next(ctx) {
 		record := result.Record()
 		id, _ := record.Get("id")
 		ids = append(ids, id.(string))
 	}
-	return ids, nil
+	return ids, result.Err()
 }
 
 // end::getUserFavorites[]
+
+// userFavorites returns the IDs of the favorite movies of the user.
+// With lenient favorites, they are resolved in a transaction of their own, so that a
+// failure only gets logged and the movies listed as not favorite, rather than failing
+// the whole read.
+func (ms *neo4jMovieService) userFavorites(ctx context.Context, tx neo4j.ManagedTransaction, userId string) (_ []string, err error) {
+	if !ms.options.lenientFavorites || userId == "" {
+		return getUserFavorites(ctx, tx, ms.options.catalog, userId)
+	}
+
+	ctx, cancel := ms.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := ms.driver.NewSession(ctx, ms.options.sessionConfig(ctx, neo4j.AccessModeRead))
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	favorites, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		return getUserFavorites(ctx, tx, ms.options.catalog, userId)
+	}, ms.options.txConfig(ctx, FastLookup))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		ms.options.logger.Printf("could not resolve the favorites of user %s, listing movies as not favorite: %v", userId, err)
+		return nil, nil
+	}
+	return favorites.([]string), nil
+}
+
+// checkPersonExists returns a 404 error when no Person has the id, to tell unknown
+// people apart from those without movies
+func checkPersonExists(ctx context.Context, tx neo4j.ManagedTransaction, options serviceOptions, id string) error {
+	result, err := options.run(ctx, tx, "people/exists", nil, map[string]interface{}{"id": id})
+	if err != nil {
+		return err
+	}
+	record, err := result.Single(ctx)
+	if err != nil {
+		return err
+	}
+	if found, _ := record.Get("found"); found != true {
+		return NewDomainError(404, "Person not found", map[string]interface{}{"id": id})
+	}
+	return nil
+}
+
+// SaveAliases replaces the alternate titles of the Movie, which searches match as
+// well as its title.
+//
+// If the Movie cannot be found, a 404 error is returned.
+func (ms *neo4jMovieService) SaveAliases(ctx context.Context, id string, aliases []string) (_ Movie, err error) {
+	ctx, cancel := ms.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := ms.driver.NewSession(ctx, ms.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := ms.options.run(ctx, tx, "movies/save_aliases", nil, map[string]interface{}{
+			"id":      id,
+			"aliases": normalizeAliases(aliases),
+		})
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, NewDomainError(404, "Movie not found", map[string]interface{}{"id": id})
+		}
+		movie, _ := record.Get("movie")
+		return ms.options.properties.project("Movie", movie.(map[string]interface{})), nil
+	}, ms.options.txConfig(ctx, FastLookup))
+
+	if err != nil {
+		return nil, err
+	}
+	return result.(Movie), nil
+}
+
+// UpdateScores stores the `score` of up to `limit` movies whose IMDB rating or average
+// rating changed, or which were imported, since their score was computed, or since the
+// scale of the scores was configured, and returns the number of updated movies.
+// Fewer updated movies than `limit` means all scores are up-to-date.
+//
+// Storing the scores lets movies be sorted by score, which unifies their ratings from
+// all the sources, see movieScore.
+func (ms *neo4jMovieService) UpdateScores(ctx context.Context, limit int) (_ int, err error) {
+	ctx, cancel := ms.options.withDeadline(ctx, Export)
+	defer cancel()
+	session := ms.driver.NewSession(ctx, ms.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := ms.options.run(ctx, tx, "movies/find_all_unscored", map[string]string{
+			"rating": ms.options.properties.datasetProperty("Movie", "imdbRating"),
+		}, map[string]interface{}{
+			"scale": ms.options.scoreScale,
+			"limit": limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return 0, nil
+		}
+
+		movies := make([]map[string]interface{}, 0, len(records))
+		for _, record := range records {
+			id, _ := record.Get("id")
+			imdbRating, _ := record.Get("imdbRating")
+			ratingAvg, _ := record.Get("ratingAvg")
+			scoreOf, _ := record.Get("scoreOf")
+			movies = append(movies, map[string]interface{}{
+				"id":      id,
+				"score":   movieScore(imdbRating, ratingAvg, ms.options.scoreScale),
+				"scoreOf": scoreOf,
+			})
+		}
+		if _, err := ms.options.run(ctx, tx, "movies/save_scores", nil, map[string]interface{}{
+			"movies": movies,
+		}); err != nil {
+			return nil, err
+		}
+		return len(movies), nil
+	}, ms.options.txConfig(ctx, Export))
+
+	if err != nil {
+		return 0, err
+	}
+	return result.(int), nil
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j-graphacademy/neoflix/pkg/routes/paging"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+type Notification = map[string]interface{}
+
+// Notification types
+const (
+	// NotificationSavedSearch notifies a movie matching a saved search
+	NotificationSavedSearch = "saved_search"
+	// NotificationFollow notifies a new follower
+	NotificationFollow = "follow"
+	// NotificationModeration notifies the outcome of a moderation decision
+	NotificationModeration = "moderation"
+)
+
+type NotificationService interface {
+	Create(ctx context.Context, userId, notificationType, message, movieId string) (Notification, error)
+
+	FindAllByUserId(ctx context.Context, userId string, page *paging.Paging) ([]Notification, error)
+
+	MarkRead(ctx context.Context, userId, id string) (Notification, error)
+
+	MarkAllRead(ctx context.Context, userId string) (int64, error)
+}
+
+type neo4jNotificationService struct {
+	loader  *fixtures.FixtureLoader
+	driver  SessionFactory
+	options serviceOptions
+}
+
+func NewNotificationService(loader *fixtures.FixtureLoader, driver SessionFactory, opts ...Option) NotificationService {
+	return &neo4jNotificationService{
+		loader:  loader,
+		driver:  driver,
+		options: newServiceOptions(opts),
+	}
+}
+
+// Create notifies the User, optionally about the Movie with the provided ID.
+// Saved search matches and new followers are notified by the statements finding them.
+//
+// If the User cannot be found, a 404 error is returned.
+func (ns *neo4jNotificationService) Create(ctx context.Context, userId, notificationType, message, movieId string) (Notification, error) {
+	return ns.write(ctx, "notifications/create", map[string]interface{}{
+		"userId":  userId,
+		"type":    notificationType,
+		"message": message,
+		"movieId": movieId,
+	}, "User not found")
+}
+
+// FindAllByUserId returns a paginated list of the notifications of the User, unread ones
+// first then most recent first, each holding the `tmdbId`, `title` and `poster` of the
+// Movie it is about, if any.
+func (ns *neo4jNotificationService) FindAllByUserId(ctx context.Context, userId string, page *paging.Paging) (_ []Notification, err error) {
+	ctx, cancel := ns.options.withDeadline(ctx, List)
+	defer cancel()
+	session := ns.driver.NewSession(ctx, ns.options.sessionConfig(ctx, neo4j.AccessModeRead))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	results, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		params := map[string]interface{}{
+			"userId": userId,
+			"skip":   page.Skip(),
+			"limit":  page.Limit(),
+		}
+		if err := ns.options.countAll(ctx, tx, page, "notifications/count_all_by_user_id", nil, params); err != nil {
+			return nil, err
+		}
+		result, err := ns.options.run(ctx, tx, "notifications/find_all_by_user_id", nil, params)
+		if err != nil {
+			return nil, err
+		}
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		notifications := make([]Notification, 0, len(records))
+		for _, record := range records {
+			notification, _ := record.Get("notification")
+			notifications = append(notifications, notification.(map[string]interface{}))
+		}
+		return notifications, nil
+	}, ns.options.txConfig(ctx, List))
+
+	if err != nil {
+		return nil, err
+	}
+	return results.([]Notification), nil
+}
+
+// MarkRead marks a notification of the User as read.
+//
+// If the notification cannot be found, a 404 error is returned.
+func (ns *neo4jNotificationService) MarkRead(ctx context.Context, userId, id string) (Notification, error) {
+	return ns.write(ctx, "notifications/mark_read", map[string]interface{}{
+		"userId": userId,
+		"id":     id,
+	}, "Notification not found")
+}
+
+// MarkAllRead marks all the notifications of the User as read and returns the number of
+// notifications which were unread
+func (ns *neo4jNotificationService) MarkAllRead(ctx context.Context, userId string) (_ int64, err error) {
+	ctx, cancel := ns.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := ns.driver.NewSession(ctx, ns.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := ns.options.run(ctx, tx, "notifications/mark_all_read", nil, map[string]interface{}{
+			"userId": userId,
+		})
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		updated, _ := record.Get("updated")
+		return updated, nil
+	}, ns.options.txConfig(ctx, FastLookup))
+
+	if err != nil {
+		return 0, err
+	}
+	return result.(int64), nil
+}
+
+func (ns *neo4jNotificationService) write(ctx context.Context, statement string, params map[string]interface{}, notFound string) (_ Notification, err error) {
+	ctx, cancel := ns.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := ns.driver.NewSession(ctx, ns.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := ns.options.run(ctx, tx, statement, nil, params)
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, NewDomainError(404, notFound, nil)
+		}
+		notification, _ := record.Get("notification")
+		return notification.(map[string]interface{}), nil
+	}, ns.options.txConfig(ctx, FastLookup))
+
+	if err != nil {
+		return nil, err
+	}
+	return result.(Notification), nil
+}
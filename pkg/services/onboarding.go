@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// OnboardingRating is the implicit rating given to the movies picked during onboarding
+const OnboardingRating = 5
+
+// onboardingCandidatesPerGenre is the number of most voted movies of each genre
+// offered during onboarding
+const onboardingCandidatesPerGenre = 5
+
+// OnboardingService solves the cold start of the recommendations of new users, by
+// letting them pick the movies they like among well-known ones
+type OnboardingService interface {
+	FindAllCandidates(ctx context.Context, userId string, limit int) ([]Movie, error)
+
+	Save(ctx context.Context, userId string, movieIds []string, limit int) ([]Movie, error)
+}
+
+type neo4jOnboardingService struct {
+	loader          *fixtures.FixtureLoader
+	driver          SessionFactory
+	recommendations RecommendationService
+	options         serviceOptions
+}
+
+func NewOnboardingService(loader *fixtures.FixtureLoader, driver SessionFactory, recommendations RecommendationService, opts ...Option) OnboardingService {
+	return &neo4jOnboardingService{
+		loader:          loader,
+		driver:          driver,
+		recommendations: recommendations,
+		options:         newServiceOptions(opts),
+	}
+}
+
+// FindAllCandidates returns up to limit well-known movies the User did not rate yet,
+// the most voted of each genre, taking turns between the genres so that the sample
+// spans all of them
+func (ob *neo4jOnboardingService) FindAllCandidates(ctx context.Context, userId string, limit int) (_ []Movie, err error) {
+	ctx, cancel := ob.options.withDeadline(ctx, List)
+	defer cancel()
+	session := ob.driver.NewSession(ctx, ob.options.sessionConfig(ctx, neo4j.AccessModeRead))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	results, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		excludedWarnings, err := getUserExcludedContentWarnings(ctx, tx, ob.options.catalog, userId)
+		if err != nil {
+			return nil, err
+		}
+		fragments := map[string]string{
+			"votes": ob.options.properties.datasetProperty("Movie", "imdbVotes"),
+		}
+		result, err := ob.options.run(ctx, tx, "onboarding/find_candidates", fragments, map[string]interface{}{
+			"userId":           userId,
+			"excludedWarnings": excludedWarnings,
+			"perGenre":         onboardingCandidatesPerGenre,
+			"limit":            limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		movies := make([]Movie, 0, len(records))
+		for _, record := range records {
+			movie, _ := record.Get("movie")
+			movies = append(movies, ob.options.properties.project("Movie", movie.(map[string]interface{})))
+		}
+		return movies, nil
+	}, ob.options.txConfig(ctx, List))
+
+	if err != nil {
+		return nil, err
+	}
+	return results.([]Movie), nil
+}
+
+// Save records the movies picked by the User as implicit OnboardingRating ratings,
+// leaving the movies the User already rated untouched, and returns up to limit
+// movies recommended from then on.
+//
+// If the User cannot be found, a 404 error is returned.
+func (ob *neo4jOnboardingService) Save(ctx context.Context, userId string, movieIds []string, limit int) (_ []Movie, err error) {
+	ctx, cancel := ob.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := ob.driver.NewSession(ctx, ob.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	_, err = session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := ob.options.run(ctx, tx, "onboarding/save_selections", nil, map[string]interface{}{
+			"userId":   userId,
+			"movieIds": movieIds,
+			"rating":   OnboardingRating,
+		})
+		if err != nil {
+			return nil, err
+		}
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, NewDomainError(404, "User not found", map[string]interface{}{"userId": userId})
+		}
+		return nil, nil
+	}, ob.options.txConfig(ctx, FastLookup))
+
+	if err != nil {
+		return nil, err
+	}
+	return ob.recommendations.FindAllByUserId(ctx, userId, limit)
+}
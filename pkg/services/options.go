@@ -0,0 +1,97 @@
+package services
+
+import (
+	"log"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/embeddings"
+	"github.com/neo4j-graphacademy/neoflix/pkg/queries"
+)
+
+// Option customizes the behaviour of the services created by the New*Service constructors
+type Option func(*serviceOptions)
+
+type serviceOptions struct {
+	deadlines  Deadlines
+	catalog    *queries.Catalog
+	properties PropertyMapping
+	shadow     *shadowReads
+	logger     *log.Logger
+
+	similarityWeights SimilarityWeights
+	maxFanOut         int
+	excludeFlagged    bool
+	scoreScale        float64
+	lenientFavorites  bool
+	gds               bool
+	embedder          embeddings.Embedder
+
+	recommendationMinRating float64
+}
+
+// WithDeadlines overrides the default per endpoint class deadlines
+func WithDeadlines(deadlines Deadlines) Option {
+	return func(options *serviceOptions) {
+		options.deadlines = deadlines
+	}
+}
+
+// WithCatalog overrides the catalog the Cypher statements are read from
+func WithCatalog(catalog *queries.Catalog) Option {
+	return func(options *serviceOptions) {
+		options.catalog = catalog
+	}
+}
+
+// WithPropertyMapping configures the names of the dataset properties
+// which differ from the ones exposed by the API
+func WithPropertyMapping(properties PropertyMapping) Option {
+	return func(options *serviceOptions) {
+		options.properties = properties
+	}
+}
+
+// WithLogger overrides the logger the services report the failures they recover from
+// to, the standard logger by default
+func WithLogger(logger *log.Logger) Option {
+	return func(options *serviceOptions) {
+		options.logger = logger
+	}
+}
+
+// WithFlaggedRatingsExcluded leaves the ratings of the periods flagged as review bombing
+// out of the average rating of movies until the flags are resolved
+func WithFlaggedRatingsExcluded() Option {
+	return func(options *serviceOptions) {
+		options.excludeFlagged = true
+	}
+}
+
+// WithLenientFavorites makes a failure to resolve the favorites of the user log a
+// warning and list the movies as not favorite, rather than failing the whole read
+func WithLenientFavorites(lenient bool) Option {
+	return func(options *serviceOptions) {
+		options.lenientFavorites = lenient
+	}
+}
+
+func newServiceOptions(opts []Option) serviceOptions {
+	options := serviceOptions{
+		deadlines:         DefaultDeadlines(),
+		catalog:           queries.MustEmbedded(),
+		logger:            log.Default(),
+		similarityWeights: DefaultSimilarityWeights(),
+		maxFanOut:         DefaultSimilarityMaxFanOut,
+		scoreScale:        DefaultScoreScale,
+
+		recommendationMinRating: RecommendationMinRating,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// cypher returns the named statement of the catalog, rendered with the provided fragments
+func (o serviceOptions) cypher(name string, fragments map[string]string) string {
+	return o.catalog.Get(name).Render(fragments)
+}
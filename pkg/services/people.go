@@ -1,114 +1,559 @@
 package services
 
 import (
-	"fmt"
+	"context"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
 	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
 	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
 
 	"github.com/neo4j-graphacademy/neoflix/pkg/routes/paging"
-	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
 type Person = map[string]interface{}
 
+// Credit is a Movie a Person acted in or directed, along with their `role` in it
+type Credit = map[string]interface{}
+
 type PeopleService interface {
-	FindAll(page *paging.Paging) ([]Person, error)
+	FindAll(ctx context.Context, page *paging.Paging) (PagedResult, error)
+
+	FindAllFiltered(ctx context.Context, filter PersonFilter, page *paging.Paging) (PagedResult, error)
+
+	FindAllAfter(ctx context.Context, cursor paging.Cursor, filter PersonFilter, page *paging.Paging) (CursorPagedResult, error)
+
+	CountByInitial(ctx context.Context) ([]InitialCount, error)
+
+	FindFilmography(ctx context.Context, id string, page *paging.Paging) (PagedResult, error)
+
+	FindCoActors(ctx context.Context, id string, page *paging.Paging) (PagedResult, error)
+
+	FindOneById(ctx context.Context, id string) (Person, error)
+
+	FindAllBySimilarity(ctx context.Context, id string, page *paging.Paging, opts PersonSimilarityOptions) ([]Person, error)
+
+	FindAllByGenre(ctx context.Context, genre string, page *paging.Paging) (PagedResult, error)
+
+	SaveAliases(ctx context.Context, id string, aliases []string) (Person, error)
+}
+
+// PersonSimilarityOptions tunes how similar people are ranked and returned
+type PersonSimilarityOptions struct {
+	// ByPopularity breaks ties between people with the same number of movies in common
+	// by ordering them by their popularity, i.e. their number of credits
+	ByPopularity bool
+	// MaxInCommon caps the length of the returned `inCommon` list, 0 means no cap
+	MaxInCommon int
+	// Scope restricts the credits people are compared by, all credits by default
+	Scope PersonSimilarityScope
+}
+
+// PersonSimilarityScope is the kind of credits people are compared by
+type PersonSimilarityScope string
+
+const (
+	ScopeAll      PersonSimilarityScope = "all"
+	ScopeActed    PersonSimilarityScope = "acted"
+	ScopeDirected PersonSimilarityScope = "directed"
+)
+
+// scopeRelationships maps the similarity scopes to the relationship types they traverse
+var scopeRelationships = map[PersonSimilarityScope]string{
+	"":            "ACTED_IN|DIRECTED",
+	ScopeAll:      "ACTED_IN|DIRECTED",
+	ScopeActed:    "ACTED_IN",
+	ScopeDirected: "DIRECTED",
+}
+
+// PersonFilter restricts the people listed by FindAllFiltered and FindAllAfter
+type PersonFilter struct {
+	// StartsWith only keeps the people whose name starts with the value, its first letter
+	// being capitalized, or whose name does not start with a letter of A to Z when
+	// OtherInitials
+	StartsWith string
+	// Role only keeps the actors or the directors, all people by default
+	Role PersonRole
+}
+
+// PersonRole is the kind of credits a person must have to be listed
+type PersonRole string
+
+const (
+	RoleAll      PersonRole = "all"
+	RoleActor    PersonRole = "actor"
+	RoleDirector PersonRole = "director"
+)
+
+// rolePredicates maps the roles to the predicate the people must match, none for all
+var rolePredicates = map[PersonRole]string{
+	"":           "",
+	RoleAll:      "",
+	RoleActor:    "(p)-[:ACTED_IN]->(:Movie)",
+	RoleDirector: "(p)-[:DIRECTED]->(:Movie)",
+}
 
-	FindOneById(id string) (Person, error)
+// validate returns a 400 error when the role of the filter is unknown
+func (pf PersonFilter) validate() error {
+	if _, found := rolePredicates[pf.Role]; !found {
+		return NewDomainError(400, "Unsupported role", map[string]interface{}{
+			"role":  pf.Role,
+			"roles": []PersonRole{RoleAll, RoleActor, RoleDirector},
+		})
+	}
+	return nil
+}
+
+// OtherInitials groups, in the initials of the people, the names which do not start
+// with a letter of A to Z
+const OtherInitials = "#"
+
+// letters are the initials the people are indexed by, all other initials being counted
+// as OtherInitials
+var letters = strings.Split("ABCDEFGHIJKLMNOPQRSTUVWXYZ", "")
 
-	FindAllBySimilarity(id string, page *paging.Paging) ([]Person, error)
+// fragments returns the `filter` fragment of the statements listing people, which only
+// binds the values of the filter as parameters
+func (pf PersonFilter) fragments(fragments map[string]string) map[string]string {
+	var predicates []string
+	switch {
+	case pf.StartsWith == OtherInitials:
+		predicates = append(predicates, "NOT toUpper(left(p.name, 1)) IN $letters")
+	case pf.StartsWith != "":
+		predicates = append(predicates, "p.name STARTS WITH $startsWith")
+	}
+	if predicate := rolePredicates[pf.Role]; predicate != "" {
+		predicates = append(predicates, predicate)
+	}
+	if len(predicates) > 0 {
+		fragments["filter"] = strings.Join(predicates, " AND ")
+	}
+	return fragments
+}
+
+func (pf PersonFilter) params(params map[string]interface{}) map[string]interface{} {
+	switch {
+	case pf.StartsWith == OtherInitials:
+		params["letters"] = letters
+	case pf.StartsWith != "":
+		first, size := utf8.DecodeRuneInString(pf.StartsWith)
+		params["startsWith"] = string(unicode.ToUpper(first)) + pf.StartsWith[size:]
+	}
+	return params
+}
+
+// InitialCount is the number of people whose name starts with the Initial
+type InitialCount struct {
+	Initial string `json:"initial"`
+	Count   int64  `json:"count"`
 }
 
 type neo4jPeopleService struct {
-	loader *fixtures.FixtureLoader
-	driver neo4j.Driver
+	loader  *fixtures.FixtureLoader
+	driver  SessionFactory
+	options serviceOptions
 }
 
-func NewPeopleService(loader *fixtures.FixtureLoader, driver neo4j.Driver) PeopleService {
-	return &neo4jPeopleService{loader: loader, driver: driver}
+func NewPeopleService(loader *fixtures.FixtureLoader, driver SessionFactory, opts ...Option) PeopleService {
+	return &neo4jPeopleService{
+		loader:  loader,
+		driver:  driver,
+		options: newServiceOptions(opts),
+	}
 }
 
 // FindAll should return a paginated list of People (actors or directors),
-// with an optional filter on the person's name based on the `q` parameter.
+// with an optional filter on the person's name or aliases based on the `q` parameter,
+// matched in the `names` full-text index.
 //
 // Results should be ordered by the `sort` parameter and limited to the
 // number passed as `limit`.  The `skip` variable should be used to skip a
 // certain number of rows.
 // tag::all[]
-func (ps *neo4jPeopleService) FindAll(page *paging.Paging) (_ []Person, err error) {
-	session := ps.driver.NewSession(neo4j.SessionConfig{})
+func (ps *neo4jPeopleService) FindAll(ctx context.Context, page *paging.Paging) (PagedResult, error) {
+	return ps.FindAllFiltered(ctx, PersonFilter{}, page)
+}
+
+//end::all[]
+
+// FindAllFiltered returns the paginated list of FindAll, restricted to the people of
+// the filter.
+// Names are matched by prefix through the index on `Person.name`.
+//
+// If the role of the filter is unknown, a 400 error is returned.
+func (ps *neo4jPeopleService) FindAllFiltered(ctx context.Context, filter PersonFilter, page *paging.Paging) (_ PagedResult, err error) {
+	if err := filter.validate(); err != nil {
+		return PagedResult{}, err
+	}
+
+	ctx, cancel := ps.options.withDeadline(ctx, List)
+	defer cancel()
+	session := ps.driver.NewSession(ctx, ps.options.sessionConfig(ctx, neo4j.AccessModeRead))
 
 	defer func() {
-		err = ioutils.DeferredClose(session, err)
+		err = ioutils.DeferredContextClose(ctx, session, err)
 	}()
 
-	result, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		result, err := tx.Run(fmt.Sprintf(`
-			MATCH (p:Person)
-			WHERE $q IS NULL OR toLower(p.name) CONTAINS toLower($q)
-			RETURN p { .* } AS person
-			ORDER BY p.`+"`%s`"+` %s
-			SKIP $skip
-			LIMIT $limit`, page.Sort(), page.Order()),
-			map[string]interface{}{
-				"q":     page.Query(),
-				"skip":  page.Skip(),
-				"limit": page.Limit(),
-			})
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		fragments, err := ps.options.listFragments("Person", paging.PersonSortableAttributes(), page)
+		if err != nil {
+			return nil, err
+		}
+		fragments = filter.fragments(fragments)
+		params := filter.params(map[string]interface{}{
+			"skip":  page.Skip(),
+			"limit": page.Limit(),
+		})
+		find, count := "people/find_all", "people/count_all"
+		if page.Query() != "" {
+			find, count = "people/search", "people/count_search"
+			params["q"] = fulltextQuery(page.Query())
+		}
+		if err := ps.options.countAll(ctx, tx, page, count, fragments, params); err != nil {
+			return nil, err
+		}
+		result, err := ps.options.run(ctx, tx, find, fragments, params)
 		if err != nil {
 			return nil, err
 		}
-		records, err := result.Collect()
+		records, err := result.Collect(ctx)
 		if err != nil {
 			return nil, err
 		}
 		var results []map[string]interface{}
 		for _, record := range records {
 			person, _ := record.Get("person")
-			results = append(results, person.(map[string]interface{}))
+			results = append(results, ps.options.properties.project("Person", person.(map[string]interface{})))
 		}
 		return results, nil
-	})
+	}, ps.options.txConfig(ctx, List))
+
+	if err != nil {
+		return PagedResult{}, err
+	}
+	return newPagedResult(page, result.([]Person)), nil
+}
+
+// FindAllAfter returns the page of people following the cursor, in the order of the
+// `sort` and `order` parameters, by keyset rather than by skipping the previous pages.
+// People sharing a sort value are ordered by ID, and only the people of the filter are
+// listed.
+//
+// If the role of the filter is unknown, a 400 error is returned.
+func (ps *neo4jPeopleService) FindAllAfter(ctx context.Context, cursor paging.Cursor, filter PersonFilter, page *paging.Paging) (_ CursorPagedResult, err error) {
+	if err := filter.validate(); err != nil {
+		return CursorPagedResult{}, err
+	}
+
+	ctx, cancel := ps.options.withDeadline(ctx, List)
+	defer cancel()
+	session := ps.driver.NewSession(ctx, ps.options.sessionConfig(ctx, neo4j.AccessModeRead))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		fragments, err := ps.options.keysetFragments("Person", paging.PersonSortableAttributes(), page)
+		if err != nil {
+			return nil, err
+		}
+		result, err := ps.options.run(ctx, tx, "people/find_all_after", filter.fragments(fragments),
+			filter.params(keysetParams(cursor, page)))
+		if err != nil {
+			return nil, err
+		}
+		return ps.options.collectKeysetPage(ctx, result, "person", "Person", cursor, page)
+	}, ps.options.txConfig(ctx, List))
+
+	if err != nil {
+		return CursorPagedResult{}, err
+	}
+	return result.(CursorPagedResult), nil
+}
+
+// CountByInitial returns the number of people per initial of their name, for each
+// letter of A to Z, even when no name starts with it, then for OtherInitials when
+// some names start with another character
+func (ps *neo4jPeopleService) CountByInitial(ctx context.Context) (_ []InitialCount, err error) {
+	ctx, cancel := ps.options.withDeadline(ctx, List)
+	defer cancel()
+	session := ps.driver.NewSession(ctx, ps.options.sessionConfig(ctx, neo4j.AccessModeRead))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := ps.options.run(ctx, tx, "people/count_by_initial", nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		counts := make(map[string]int64, len(letters)+1)
+		for _, record := range records {
+			initial, _ := record.Get("initial")
+			count, _ := record.Get("count")
+			counts[initial.(string)] += count.(int64)
+		}
+		return countInitials(counts), nil
+	}, ps.options.txConfig(ctx, List))
 
 	if err != nil {
 		return nil, err
 	}
-	return result.([]Person), nil
+	return result.([]InitialCount), nil
 }
 
-//end::all[]
+// countInitials folds the counts of the initials other than the letters into
+// OtherInitials
+func countInitials(counts map[string]int64) []InitialCount {
+	initials := make([]InitialCount, 0, len(letters)+1)
+	for _, letter := range letters {
+		initials = append(initials, InitialCount{Initial: letter, Count: counts[letter]})
+		delete(counts, letter)
+	}
+	var others int64
+	for _, count := range counts {
+		others += count
+	}
+	if others > 0 {
+		initials = append(initials, InitialCount{Initial: OtherInitials, Count: others})
+	}
+	return initials
+}
+
+// FindFilmography returns a paginated list of the credits of the Person, each being a
+// Movie they acted in or directed along with their `role`, "actor" or "director", and
+// the `character` they played, when known.
+// A Person who both acted in and directed a Movie is credited twice for it.
+// Credits are sorted by the `sort` movie attribute, most recently released first by
+// default.
+//
+// If the Person cannot be found, a 404 error is returned.
+func (ps *neo4jPeopleService) FindFilmography(ctx context.Context, id string, page *paging.Paging) (_ PagedResult, err error) {
+	ctx, cancel := ps.options.withDeadline(ctx, List)
+	defer cancel()
+	session := ps.driver.NewSession(ctx, ps.options.sessionConfig(ctx, neo4j.AccessModeRead))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		fragments, err := ps.options.listFragments("Movie", paging.FilmographySortableAttributes(), page)
+		if err != nil {
+			return nil, err
+		}
+		params := map[string]interface{}{
+			"id":    id,
+			"skip":  page.Skip(),
+			"limit": page.Limit(),
+		}
+		if err := ps.options.countAll(ctx, tx, page, "people/count_filmography", fragments, params); err != nil {
+			return nil, err
+		}
+		result, err := ps.options.run(ctx, tx, "people/find_filmography", fragments, params)
+		if err != nil {
+			return nil, err
+		}
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			if err := checkPersonExists(ctx, tx, ps.options, id); err != nil {
+				return nil, err
+			}
+		}
+		credits := make([]Credit, 0, len(records))
+		for _, record := range records {
+			credit, _ := record.Get("credit")
+			credits = append(credits, ps.options.properties.project("Movie", credit.(map[string]interface{})))
+		}
+		return credits, nil
+	}, ps.options.txConfig(ctx, List))
+
+	if err != nil {
+		return PagedResult{}, err
+	}
+	return newPagedResult(page, result.([]Credit)), nil
+}
+
+// FindCoActors returns a paginated list of the people who acted in the same movies as
+// the Person, along with the number of their `sharedMovies` and the `sharedTitles` of
+// these movies, in release order.
+// Co-actors are ordered by their number of shared movies, the most first by default,
+// then by name.
+//
+// If the Person cannot be found, a 404 error is returned.
+func (ps *neo4jPeopleService) FindCoActors(ctx context.Context, id string, page *paging.Paging) (_ PagedResult, err error) {
+	ctx, cancel := ps.options.withDeadline(ctx, List)
+	defer cancel()
+	session := ps.driver.NewSession(ctx, ps.options.sessionConfig(ctx, neo4j.AccessModeRead))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		spec, err := page.SortSpec(paging.CoActorSortableAttributes())
+		if err != nil {
+			return nil, err
+		}
+		fragments := map[string]string{"order": spec.CypherOrder()}
+		params := map[string]interface{}{
+			"id":    id,
+			"skip":  page.Skip(),
+			"limit": page.Limit(),
+		}
+		if err := ps.options.countAll(ctx, tx, page, "people/count_co_actors", nil, params); err != nil {
+			return nil, err
+		}
+		result, err := ps.options.run(ctx, tx, "people/find_co_actors", fragments, params)
+		if err != nil {
+			return nil, err
+		}
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			if err := checkPersonExists(ctx, tx, ps.options, id); err != nil {
+				return nil, err
+			}
+		}
+		coActors := make([]Person, 0, len(records))
+		for _, record := range records {
+			coActor, _ := record.Get("coActor")
+			coActors = append(coActors, ps.options.properties.project("Person", coActor.(map[string]interface{})))
+		}
+		return coActors, nil
+	}, ps.options.txConfig(ctx, List))
+
+	if err != nil {
+		return PagedResult{}, err
+	}
+	return newPagedResult(page, result.([]Person)), nil
+}
+
+// FindAllByGenre returns a paginated list of the actors and directors of movies in the
+// Genre, with the most movies in the Genre first.
+// Each person holds their `movieCount` in the Genre, split into `actedCount` and
+// `directedCount`: a person who both acted in and directed a movie counts it in both.
+func (ps *neo4jPeopleService) FindAllByGenre(ctx context.Context, genre string, page *paging.Paging) (_ PagedResult, err error) {
+	ctx, cancel := ps.options.withDeadline(ctx, List)
+	defer cancel()
+	session := ps.driver.NewSession(ctx, ps.options.sessionConfig(ctx, neo4j.AccessModeRead))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		params := map[string]interface{}{
+			"name":  genre,
+			"skip":  page.Skip(),
+			"limit": page.Limit(),
+		}
+		if err := ps.options.countAll(ctx, tx, page, "people/count_all_by_genre", nil, params); err != nil {
+			return nil, err
+		}
+		result, err := ps.options.run(ctx, tx, "people/find_all_by_genre", nil, params)
+		if err != nil {
+			return nil, err
+		}
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		results := make([]Person, 0, len(records))
+		for _, record := range records {
+			person, _ := record.Get("person")
+			results = append(results, ps.options.properties.project("Person", person.(map[string]interface{})))
+		}
+		return results, nil
+	}, ps.options.txConfig(ctx, List))
+
+	if err != nil {
+		return PagedResult{}, err
+	}
+	return newPagedResult(page, result.([]Person)), nil
+}
+
+// frequentCollaborators is the number of frequent collaborators listed with the details
+// of people
+const frequentCollaborators = 10
 
 // FindOneById finds a user by their ID.
 // If no user is found, an error should be thrown.
+//
+// The person also holds `creditsByDecade`, the number of movies they acted in and
+// directed per decade, e.g. `[{decade: 1970, acted: 1, directed: 4}, ...]`, and their
+// `frequentCollaborators`, the people they acted with or directed, or were directed
+// by, in the most movies, along with the number of their `sharedMovies`.
 // tag::findById[]
-func (ps *neo4jPeopleService) FindOneById(id string) (_ Person, err error) {
-	session := ps.driver.NewSession(neo4j.SessionConfig{})
+func (ps *neo4jPeopleService) FindOneById(ctx context.Context, id string) (_ Person, err error) {
+	ctx, cancel := ps.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := ps.driver.NewSession(ctx, ps.options.sessionConfig(ctx, neo4j.AccessModeRead))
 
 	defer func() {
-		err = ioutils.DeferredClose(session, err)
+		err = ioutils.DeferredContextClose(ctx, session, err)
 	}()
 
-	result, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		result, err := tx.Run(`
-				MATCH (p:Person { tmdbId: $id })
-				RETURN p {
-					.*,
-					actedCount: size((p)-[:ACTED_IN]->()),
-					directedCount: size((p)-[:DIRECTED]->())
-				} AS person`,
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := ps.options.run(ctx, tx, "people/find_one_by_id", nil,
 			map[string]interface{}{"id": id})
 		if err != nil {
 			return nil, err
 		}
 
-		record, err := result.Single()
+		record, err := result.Single(ctx)
 		if err != nil {
 			return nil, err
 		}
 
-		person, _ := record.Get("person")
-		return person.(map[string]interface{}), nil
-	})
+		value, _ := record.Get("person")
+		person := ps.options.properties.project("Person", value.(map[string]interface{}))
+
+		credits, err := ps.options.run(ctx, tx, "people/credits_by_decade", nil,
+			map[string]interface{}{"id": id})
+		if err != nil {
+			return nil, err
+		}
+		creditsByDecade := []interface{}{}
+		for credits.Next(ctx) {
+			decade, _ := credits.Record().Get("credits")
+			creditsByDecade = append(creditsByDecade, decade)
+		}
+		if err := credits.Err(); err != nil {
+			return nil, err
+		}
+		person["creditsByDecade"] = creditsByDecade
+
+		collaborators, err := ps.options.run(ctx, tx, "people/frequent_collaborators", nil,
+			map[string]interface{}{"id": id, "limit": frequentCollaborators})
+		if err != nil {
+			return nil, err
+		}
+		frequent := []interface{}{}
+		for collaborators.Next(ctx) {
+			collaborator, _ := collaborators.Record().Get("collaborator")
+			frequent = append(frequent, ps.options.properties.project("Person", collaborator.(map[string]interface{})))
+		}
+		if err := collaborators.Err(); err != nil {
+			return nil, err
+		}
+		person["frequentCollaborators"] = frequent
+
+		return person, nil
+	}, ps.options.txConfig(ctx, FastLookup))
 	if err != nil {
 		return nil, err
 	}
@@ -120,36 +565,51 @@ func (ps *neo4jPeopleService) FindOneById(id string) (_ Person, err error) {
 
 // FindAllBySimilarity gets a list of similar people to a Person, ordered by their similarity score
 // in descending order.
+// The number of movies in common is aggregated explicitly and returned as `inCommonCount`,
+// while the `inCommon` list itself can be capped with PersonSimilarityOptions.MaxInCommon.
+// With PersonSimilarityOptions.Scope, actors are only compared by the movies they acted in,
+// or directors by the movies they directed.
+//
+// If the scope is unknown, a 400 error is returned.
 // tag::getSimilarPeople[]
-func (ps *neo4jPeopleService) FindAllBySimilarity(id string, page *paging.Paging) (_ []Person, err error) {
-	session := ps.driver.NewSession(neo4j.SessionConfig{})
+func (ps *neo4jPeopleService) FindAllBySimilarity(ctx context.Context, id string, page *paging.Paging, opts PersonSimilarityOptions) (_ []Person, err error) {
+	relationships, found := scopeRelationships[opts.Scope]
+	if !found {
+		return nil, NewDomainError(400, "Unsupported similarity scope", map[string]interface{}{
+			"scope":  opts.Scope,
+			"scopes": []PersonSimilarityScope{ScopeAll, ScopeActed, ScopeDirected},
+		})
+	}
+
+	ctx, cancel := ps.options.withDeadline(ctx, Similarity)
+	defer cancel()
+	session := ps.driver.NewSession(ctx, ps.options.sessionConfig(ctx, neo4j.AccessModeRead))
 
 	defer func() {
-		err = ioutils.DeferredClose(session, err)
+		err = ioutils.DeferredContextClose(ctx, session, err)
 	}()
 
-	result, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		result, err := tx.Run(`
-			MATCH (:Person {tmdbId: $id})-[:ACTED_IN|DIRECTED]->(m)<-[r:ACTED_IN|DIRECTED]-(p)
-			RETURN p {
-				.*,
-				actedCount: size((p)-[:ACTED_IN]->()),
-				directedCount: size((p)-[:DIRECTED]->()),
-				inCommon: collect(m {.tmdbId, .title, type: type(r)})
-			} AS person
-			ORDER BY size(person.inCommon) DESC
-			SKIP $skip
-			LIMIT $limit`,
+	orderBy := "inCommonCount DESC"
+	if opts.ByPopularity {
+		orderBy += ", popularity DESC"
+	}
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := ps.options.run(ctx, tx, "people/find_all_by_similarity", map[string]string{
+			"orderBy":       orderBy,
+			"relationships": relationships,
+		},
 			map[string]interface{}{
-				"id":    id,
-				"skip":  page.Skip(),
-				"limit": page.Limit(),
+				"id":          id,
+				"maxInCommon": opts.MaxInCommon,
+				"skip":        page.Skip(),
+				"limit":       page.Limit(),
 			})
 		if err != nil {
 			return nil, err
 		}
 
-		records, err := result.Collect()
+		records, err := result.Collect(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -157,10 +617,10 @@ func (ps *neo4jPeopleService) FindAllBySimilarity(id string, page *paging.Paging
 		var results []map[string]interface{}
 		for _, record := range records {
 			person, _ := record.Get("person")
-			results = append(results, person.(map[string]interface{}))
+			results = append(results, ps.options.properties.project("Person", person.(map[string]interface{})))
 		}
 		return results, nil
-	})
+	}, ps.options.txConfig(ctx, Similarity))
 	if err != nil {
 		return nil, err
 	}
@@ -169,3 +629,37 @@ func (ps *neo4jPeopleService) FindAllBySimilarity(id string, page *paging.Paging
 }
 
 // end::getSimilarPeople[]
+
+// SaveAliases replaces the alternate names of the Person, such as stage names,
+// which searches match as well as their name.
+//
+// If the Person cannot be found, a 404 error is returned.
+func (ps *neo4jPeopleService) SaveAliases(ctx context.Context, id string, aliases []string) (_ Person, err error) {
+	ctx, cancel := ps.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := ps.driver.NewSession(ctx, ps.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := ps.options.run(ctx, tx, "people/save_aliases", nil, map[string]interface{}{
+			"id":      id,
+			"aliases": normalizeAliases(aliases),
+		})
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, NewDomainError(404, "Person not found", map[string]interface{}{"id": id})
+		}
+		person, _ := record.Get("person")
+		return ps.options.properties.project("Person", person.(map[string]interface{})), nil
+	}, ps.options.txConfig(ctx, FastLookup))
+
+	if err != nil {
+		return nil, err
+	}
+	return result.(Person), nil
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCountInitials(t *testing.T) {
+	initials := countInitials(map[string]int64{"A": 3, "Z": 1, "É": 2, "1": 1})
+
+	if len(initials) != 27 {
+		t.Fatalf("expected 26 letters and other initials, got %v", initials)
+	}
+	if initials[0] != (InitialCount{Initial: "A", Count: 3}) || initials[1] != (InitialCount{Initial: "B"}) {
+		t.Errorf("unexpected letters %v", initials[:2])
+	}
+	if initials[26] != (InitialCount{Initial: OtherInitials, Count: 3}) {
+		t.Errorf("expected other initials to be folded, got %v", initials[26])
+	}
+	if len(countInitials(map[string]int64{"K": 1})) != 26 {
+		t.Errorf("expected no other initials without other names")
+	}
+}
+
+func TestPersonFilterCapitalizesPrefix(t *testing.T) {
+	params := PersonFilter{StartsWith: "éd"}.params(map[string]interface{}{})
+
+	if !reflect.DeepEqual(params, map[string]interface{}{"startsWith": "Éd"}) {
+		t.Errorf("unexpected params %v", params)
+	}
+}
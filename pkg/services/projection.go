@@ -0,0 +1,104 @@
+package services
+
+import (
+	"github.com/neo4j-graphacademy/neoflix/pkg/collation"
+	"github.com/neo4j-graphacademy/neoflix/pkg/routes/paging"
+)
+
+// PropertyMapping maps, per node label, the property names exposed by the API to the
+// ones used by the dataset, e.g. `{"Movie": {"released": "year", "poster": "posterUrl"}}`,
+// so that the application can run against variants of the movie dataset.
+// Properties missing from the mapping keep their name.
+type PropertyMapping map[string]map[string]string
+
+// nestedLabels lists the properties holding nested entities, per label
+var nestedLabels = map[string]map[string]string{
+	"Movie": {"actors": "Person", "directors": "Person"},
+}
+
+// internalProperties lists the properties kept out of the API, per label, such as the
+// plot embeddings of the semantic search, which are large and of no use to clients
+var internalProperties = map[string][]string{
+	"Movie": {"plotEmbedding", "plotEmbeddingOf"},
+}
+
+// datasetProperty returns the name of the dataset property backing the API property
+func (pm PropertyMapping) datasetProperty(label, property string) string {
+	if name, found := pm[label][property]; found {
+		return name
+	}
+	return property
+}
+
+// sortVariables names the variable the entities of each label are bound to in the
+// statements listing them
+var sortVariables = map[string]string{
+	"Movie":  "m",
+	"Person": "p",
+	"Rating": "r",
+}
+
+// sortProperties returns the properties backing the sortable attributes of the entities
+// of the label, i.e. the dataset properties they are mapped to.
+// Movie titles are sorted by their sort key in the collation of the page, if any.
+func (o serviceOptions) sortProperties(label string, page *paging.Paging) map[string]string {
+	properties := map[string]string{}
+	for attribute, property := range o.properties[label] {
+		properties[attribute] = property
+	}
+	if label == "Movie" && page.Collation() != "" {
+		properties["title"] = collation.SortProperty(page.Collation())
+	}
+	return properties
+}
+
+// listFragments returns the fragments of the statements listing entities of the label,
+// sorted by the paging.SortSpec of the page: the property backing the sort attribute as
+// `sort`, and the `orderBy` item rendered by the spec.
+// Sorts outside of the sortable attributes of the list are rejected with a
+// paging.InvalidSortError, as the values provided by clients are never interpolated.
+func (o serviceOptions) listFragments(label string, sortable *paging.SortableAttributes, page *paging.Paging) (map[string]string, error) {
+	spec, err := page.SortSpec(sortable)
+	if err != nil {
+		return nil, err
+	}
+	properties := o.sortProperties(label, page)
+	property, found := properties[spec.Attribute()]
+	if !found {
+		property = spec.Attribute()
+	}
+	return map[string]string{
+		"sort":    property,
+		"orderBy": spec.CypherOrderBy(sortVariables[label], properties),
+	}, nil
+}
+
+// project renames the dataset properties of the entity to the names exposed by the API,
+// and drops its internal properties.
+// Nested entities, such as the actors of a movie, are projected as well.
+func (pm PropertyMapping) project(label string, entity map[string]interface{}) map[string]interface{} {
+	if entity == nil {
+		return entity
+	}
+	for _, property := range internalProperties[label] {
+		delete(entity, property)
+	}
+	if len(pm) == 0 {
+		return entity
+	}
+	for property, datasetName := range pm[label] {
+		if value, found := entity[datasetName]; found {
+			delete(entity, datasetName)
+			entity[property] = value
+		}
+	}
+	for property, nestedLabel := range nestedLabels[label] {
+		nested, _ := entity[property].([]interface{})
+		for _, value := range nested {
+			if nestedEntity, ok := value.(map[string]interface{}); ok {
+				pm.project(nestedLabel, nestedEntity)
+			}
+		}
+	}
+	return entity
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/collation"
+	"github.com/neo4j-graphacademy/neoflix/pkg/routes/paging"
+)
+
+func FuzzListFragments(f *testing.F) {
+	f.Add("title", "asc", "matrix", "en-US")
+	f.Add("title` DETACH DELETE m //", "ASC; MATCH (n) DETACH DELETE n", "' OR 1=1 //", "fr")
+	f.Add("name", "desc", "{{sort}}", "de;q=0.5,es")
+	f.Add("timestamp", "", "$skip", "")
+
+	options := newServiceOptions(nil)
+	statements := []struct {
+		name     string
+		label    string
+		sortable *paging.SortableAttributes
+		sorts    []string
+	}{
+		{"movies/find_all", "Movie", paging.MovieSortableAttributes(), []string{"title", "released", "imdbRating", "score", "revenue"}},
+		{"people/find_all", "Person", paging.PersonSortableAttributes(), []string{"name", "born", "movieCount"}},
+		{"ratings/find_all_by_movie_id", "Rating", paging.RatingSortableAttributes(), []string{"rating", "timestamp"}},
+	}
+	allowed := map[string]bool{}
+	for _, statement := range statements {
+		sorts := statement.sorts
+		if statement.label == "Movie" {
+			for _, language := range collation.Supported {
+				sorts = append(sorts, collation.SortProperty(language))
+			}
+		}
+		for _, sort := range sorts {
+			for _, order := range []paging.Order{paging.Asc, paging.Desc} {
+				allowed[options.cypher(statement.name, map[string]string{
+					"sort":    sort,
+					"orderBy": fmt.Sprintf("%s.`%s` %s", sortVariables[statement.label], sort, order),
+				})] = true
+			}
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, sort, order, q, acceptLanguage string) {
+		query := url.Values{"sort": {sort}, "order": {order}, "q": {q}}
+		request := httptest.NewRequest("GET", "/?"+query.Encode(), nil)
+		request.Header.Set("Accept-Language", acceptLanguage)
+		for _, statement := range statements {
+			page, err := paging.ParsePaging(request, statement.sortable)
+			if err != nil {
+				return
+			}
+			fragments, err := options.listFragments(statement.label, statement.sortable, page)
+			if err != nil {
+				t.Fatalf("%s rejected the sort %q parsed by its sortable attributes: %v", statement.name, page.Sort(), err)
+			}
+			rendered := options.cypher(statement.name, fragments)
+			if !allowed[rendered] {
+				t.Fatalf("%s rendered outside of the whitelist for sort %q and order %q:\n%s",
+					statement.name, sort, order, rendered)
+			}
+		}
+	})
+}
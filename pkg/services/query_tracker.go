@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"sync"
+)
+
+// QueryTracker lists the Cypher statements of the catalog run on behalf of a single
+// request, by name, e.g. "movies/find_all", in the order they ran.
+// Statements run again by retried transactions are listed again.
+type QueryTracker struct {
+	mutex sync.Mutex
+	names []string
+}
+
+// Names returns the names of the statements run so far
+func (qt *QueryTracker) Names() []string {
+	qt.mutex.Lock()
+	defer qt.mutex.Unlock()
+	return append([]string(nil), qt.names...)
+}
+
+type queryTrackerKey struct{}
+
+type queryNameKey struct{}
+
+// ContextWithQueryTracker returns a copy of the context in which the statements run by
+// the services are listed by the tracker
+func ContextWithQueryTracker(ctx context.Context, tracker *QueryTracker) context.Context {
+	return context.WithValue(ctx, queryTrackerKey{}, tracker)
+}
+
+// QueryNameFromContext returns the name of the statement of the catalog the context is
+// run with, e.g. by decorators of the transactions naming their traces
+func QueryNameFromContext(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(queryNameKey{}).(string)
+	return name, ok
+}
+
+// trackQuery adds the named statement to the QueryTracker of the context, if any, and
+// returns a copy of the context to run the statement with, holding its name
+func trackQuery(ctx context.Context, name string) context.Context {
+	if tracker, found := ctx.Value(queryTrackerKey{}).(*QueryTracker); found {
+		tracker.mutex.Lock()
+		tracker.names = append(tracker.names, name)
+		tracker.mutex.Unlock()
+	}
+	return context.WithValue(ctx, queryNameKey{}, name)
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j-graphacademy/neoflix/pkg/routes/paging"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// RatingFlag flags a Movie whose recent ratings look like review bombing for moderator review
+type RatingFlag = map[string]interface{}
+
+// RatingAnomalyThresholds tunes the detection of review bombing: a Movie is flagged
+// when it received at least MinRatings ratings within Window, and the share of extreme
+// ratings (1 or 5) among them exceeds the share among its older ratings by MinShift.
+type RatingAnomalyThresholds struct {
+	Window     time.Duration
+	MinRatings int
+	MinShift   float64
+}
+
+// DefaultRatingAnomalyThresholds returns thresholds suited to a catalog rated by thousands of users
+func DefaultRatingAnomalyThresholds() RatingAnomalyThresholds {
+	return RatingAnomalyThresholds{
+		Window:     24 * time.Hour,
+		MinRatings: 50,
+		MinShift:   0.4,
+	}
+}
+
+const (
+	lowestRating  = 1
+	highestRating = 5
+)
+
+type RatingFlagService interface {
+	Detect(ctx context.Context, now time.Time) (int64, error)
+
+	FindAllOpen(ctx context.Context, page *paging.Paging) ([]RatingFlag, error)
+
+	Resolve(ctx context.Context, id, userId string) (RatingFlag, error)
+}
+
+type neo4jRatingFlagService struct {
+	loader     *fixtures.FixtureLoader
+	driver     SessionFactory
+	thresholds RatingAnomalyThresholds
+	options    serviceOptions
+}
+
+func NewRatingFlagService(loader *fixtures.FixtureLoader, driver SessionFactory, thresholds RatingAnomalyThresholds, opts ...Option) RatingFlagService {
+	return &neo4jRatingFlagService{
+		loader:     loader,
+		driver:     driver,
+		thresholds: thresholds,
+		options:    newServiceOptions(opts),
+	}
+}
+
+// Detect flags the movies not flagged yet whose ratings of the window preceding now
+// are anomalous, and returns the number of flagged movies.
+// The flagged period starts at the beginning of the window.
+func (rfs *neo4jRatingFlagService) Detect(ctx context.Context, now time.Time) (_ int64, err error) {
+	ctx, cancel := rfs.options.withDeadline(ctx, Export)
+	defer cancel()
+	session := rfs.driver.NewSession(ctx, rfs.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := rfs.options.run(ctx, tx, "rating_flags/detect", nil, map[string]interface{}{
+			"since":      now.Add(-rfs.thresholds.Window).UnixMilli(),
+			"minRatings": rfs.thresholds.MinRatings,
+			"minShift":   rfs.thresholds.MinShift,
+			"lowRating":  lowestRating,
+			"highRating": highestRating,
+		})
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		flagged, _ := record.Get("flagged")
+		return flagged, nil
+	}, rfs.options.txConfig(ctx, Export))
+
+	if err != nil {
+		return 0, err
+	}
+	return result.(int64), nil
+}
+
+// FindAllOpen returns a paginated list of the flags awaiting moderator review, most
+// recent first, each holding the `tmdbId`, `title` and `poster` of the flagged Movie
+func (rfs *neo4jRatingFlagService) FindAllOpen(ctx context.Context, page *paging.Paging) (_ []RatingFlag, err error) {
+	ctx, cancel := rfs.options.withDeadline(ctx, List)
+	defer cancel()
+	session := rfs.driver.NewSession(ctx, rfs.options.sessionConfig(ctx, neo4j.AccessModeRead))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	results, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		params := map[string]interface{}{
+			"skip":  page.Skip(),
+			"limit": page.Limit(),
+		}
+		if err := rfs.options.countAll(ctx, tx, page, "rating_flags/count_all_open", nil, params); err != nil {
+			return nil, err
+		}
+		result, err := rfs.options.run(ctx, tx, "rating_flags/find_all_open", nil, params)
+		if err != nil {
+			return nil, err
+		}
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		flags := make([]RatingFlag, 0, len(records))
+		for _, record := range records {
+			flag, _ := record.Get("flag")
+			flags = append(flags, flag.(map[string]interface{}))
+		}
+		return flags, nil
+	}, rfs.options.txConfig(ctx, List))
+
+	if err != nil {
+		return nil, err
+	}
+	return results.([]RatingFlag), nil
+}
+
+// Resolve closes a flag once reviewed by the moderator with the provided ID, which
+// brings the ratings of the flagged period back into the average rating of the Movie.
+//
+// If the flag cannot be found, a 404 error is returned.
+func (rfs *neo4jRatingFlagService) Resolve(ctx context.Context, id, userId string) (_ RatingFlag, err error) {
+	ctx, cancel := rfs.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := rfs.driver.NewSession(ctx, rfs.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := rfs.options.run(ctx, tx, "rating_flags/resolve", nil, map[string]interface{}{
+			"id":     id,
+			"userId": userId,
+		})
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, NewDomainError(404, "Rating flag not found", map[string]interface{}{"id": id})
+		}
+		flag, _ := record.Get("flag")
+		return flag.(map[string]interface{}), nil
+	}, rfs.options.txConfig(ctx, FastLookup))
+
+	if err != nil {
+		return nil, err
+	}
+	return result.(RatingFlag), nil
+}
@@ -1,29 +1,58 @@
 package services
 
 import (
-	"fmt"
+	"context"
+
 	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
 	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
 
 	"github.com/neo4j-graphacademy/neoflix/pkg/routes/paging"
-	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
 type Rating = map[string]interface{}
 
+// RatingHistorySize is the maximum number of previous values kept for each rating
+const RatingHistorySize = 10
+
 type RatingService interface {
-	FindAllByMovieId(id string, page *paging.Paging) ([]Rating, error)
+	FindAllByMovieId(ctx context.Context, id string, userId string, page *paging.Paging) ([]Rating, error)
+
+	Save(ctx context.Context, rating int, movieId string, userId string) (Movie, error)
+
+	Update(ctx context.Context, rating int, movieId string, userId string) (Movie, error)
 
-	Save(rating int, movieId string, userId string) (Movie, error)
+	Delete(ctx context.Context, movieId string, userId string) (Movie, error)
+
+	FindOneByUserId(ctx context.Context, movieId string, userId string) (Rating, error)
+
+	FindAllReviewsByUserId(ctx context.Context, userId, viewerId string, page *paging.Paging) ([]Rating, error)
+
+	SaveReviewsPrivate(ctx context.Context, userId string, private bool) (bool, error)
+}
+
+// reviewSortExpressions maps the sortable attributes of the reviews of a user to
+// their Cypher expression
+var reviewSortExpressions = map[string]string{
+	"timestamp":   "r.timestamp",
+	"helpfulness": "coalesce(r.helpfulCount, 0)",
+	// ratings last written before they were maintained fall back to their timestamps
+	"createdAt": "coalesce(r.createdAt, head(r.previousTimestamps), r.timestamp)",
+	"updatedAt": "coalesce(r.updatedAt, r.timestamp)",
 }
 
 type neo4jRatingService struct {
-	loader *fixtures.FixtureLoader
-	driver neo4j.Driver
+	loader  *fixtures.FixtureLoader
+	driver  SessionFactory
+	options serviceOptions
 }
 
-func NewRatingService(loader *fixtures.FixtureLoader, driver neo4j.Driver) RatingService {
-	return &neo4jRatingService{loader: loader, driver: driver}
+func NewRatingService(loader *fixtures.FixtureLoader, driver SessionFactory, opts ...Option) RatingService {
+	return &neo4jRatingService{
+		loader:  loader,
+		driver:  driver,
+		options: newServiceOptions(opts),
+	}
 }
 
 // FindAllByMovieId returns a paginated list of reviews for a Movie.
@@ -32,33 +61,36 @@ func NewRatingService(loader *fixtures.FixtureLoader, driver neo4j.Driver) Ratin
 // in the `order` parameter.
 // Results should be limited to the number passed as `limit`.
 // The `skip` variable should be used to skip a certain number of rows.
+//
+// If a userId value is supplied, the reviews of the users they blocked are left out.
 // tag::forMovie[]
-func (rs *neo4jRatingService) FindAllByMovieId(movieId string, page *paging.Paging) (_ []Rating, err error) {
-	session := rs.driver.NewSession(neo4j.SessionConfig{})
+func (rs *neo4jRatingService) FindAllByMovieId(ctx context.Context, movieId string, userId string, page *paging.Paging) (_ []Rating, err error) {
+	ctx, cancel := rs.options.withDeadline(ctx, List)
+	defer cancel()
+	session := rs.driver.NewSession(ctx, rs.options.sessionConfig(ctx, neo4j.AccessModeRead))
 	defer func() {
-		err = ioutils.DeferredClose(session, err)
+		err = ioutils.DeferredContextClose(ctx, session, err)
 	}()
 
-	results, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		result, err := tx.Run(fmt.Sprintf(`
-			MATCH (u:User)-[r:RATED]->(m:Movie {tmdbId: $id})
-			RETURN r {
-				.rating,
-				.timestamp,
-			     user: u { .id, .name }
-			} AS review
-			ORDER BY r.`+"`%s`"+` %s
-			SKIP $skip
-			LIMIT $limit`, page.Sort(), page.Order()),
-			map[string]interface{}{
-				"id":    movieId,
-				"skip":  page.Skip(),
-				"limit": page.Limit(),
-			})
+	results, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		fragments, err := rs.options.listFragments("Rating", paging.RatingSortableAttributes(), page)
+		if err != nil {
+			return nil, err
+		}
+		params := map[string]interface{}{
+			"id":     movieId,
+			"userId": userId,
+			"skip":   page.Skip(),
+			"limit":  page.Limit(),
+		}
+		if err := rs.options.countAll(ctx, tx, page, "ratings/count_all_by_movie_id", fragments, params); err != nil {
+			return nil, err
+		}
+		result, err := rs.options.run(ctx, tx, "ratings/find_all_by_movie_id", fragments, params)
 		if err != nil {
 			return nil, err
 		}
-		records, err := result.Collect()
+		records, err := result.Collect(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -68,7 +100,7 @@ func (rs *neo4jRatingService) FindAllByMovieId(movieId string, page *paging.Pagi
 			results = append(results, review.(map[string]interface{}))
 		}
 		return results, nil
-	})
+	}, rs.options.txConfig(ctx, List))
 
 	if err != nil {
 		return nil, err
@@ -80,46 +112,253 @@ func (rs *neo4jRatingService) FindAllByMovieId(movieId string, page *paging.Pagi
 
 // Save adds a relationship between a User and Movie with a `rating` property.
 // The `rating` parameter should be converted to a Neo4j Integer.
+// If either the User or the Movie cannot be found, a 404 error is returned.
 //
-// If the User or Movie cannot be found, a NotFoundError should be thrown
+// When the rating changes, its previous value is kept in a history capped to
+// RatingHistorySize entries, alongside the very first rating.
+// Only the latest value is stored as `rating` and used for aggregates.
 // tag::add[]
-func (rs *neo4jRatingService) Save(rating int, movieId string, userId string) (_ Movie, err error) {
-	session := rs.driver.NewSession(neo4j.SessionConfig{})
+func (rs *neo4jRatingService) Save(ctx context.Context, rating int, movieId string, userId string) (_ Movie, err error) {
+	ctx, cancel := rs.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := rs.driver.NewSession(ctx, rs.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := rs.options.run(ctx, tx, "ratings/save", nil, map[string]interface{}{
+			"userId":      userId,
+			"movieId":     movieId,
+			"rating":      rating,
+			"historySize": RatingHistorySize,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !result.Next(ctx) {
+			if err := result.Err(); err != nil {
+				return nil, err
+			}
+			return nil, NewDomainError(404, "User or movie not found", map[string]interface{}{
+				"movieId": movieId,
+			})
+		}
+
+		movie, _ := result.Record().Get("movie")
+		return rs.options.properties.project("Movie", movie.(map[string]interface{})), nil
+	}, rs.options.txConfig(ctx, FastLookup))
+	if err != nil {
+		return nil, err
+	}
+
+	return result.(Movie), nil
+}
+
+// end::add[]
+
+// Update changes the rating the User already gave to the Movie, keeping its
+// previous value in the history as Save does.
+//
+// If the User has not rated the Movie, a 404 error is returned.
+func (rs *neo4jRatingService) Update(ctx context.Context, rating int, movieId string, userId string) (Movie, error) {
+	return rs.writeRating(ctx, "ratings/update", movieId, userId, map[string]interface{}{
+		"userId":      userId,
+		"movieId":     movieId,
+		"rating":      rating,
+		"historySize": RatingHistorySize,
+	})
+}
+
+// Delete removes the rating the User gave to the Movie, along with its history.
+//
+// If the User has not rated the Movie, a 404 error is returned.
+func (rs *neo4jRatingService) Delete(ctx context.Context, movieId string, userId string) (Movie, error) {
+	return rs.writeRating(ctx, "ratings/delete", movieId, userId, map[string]interface{}{
+		"userId":  userId,
+		"movieId": movieId,
+	})
+}
+
+func (rs *neo4jRatingService) writeRating(ctx context.Context, statement, movieId, userId string, params map[string]interface{}) (_ Movie, err error) {
+	ctx, cancel := rs.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := rs.driver.NewSession(ctx, rs.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := rs.options.run(ctx, tx, statement, nil, params)
+		if err != nil {
+			return nil, err
+		}
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, NewDomainError(404, "Rating not found", map[string]interface{}{
+				"movieId": movieId,
+			})
+		}
+
+		movie, _ := records[0].Get("movie")
+		return rs.options.properties.project("Movie", movie.(map[string]interface{})), nil
+	}, rs.options.txConfig(ctx, FastLookup))
+	if err != nil {
+		return nil, err
+	}
+
+	return result.(Movie), nil
+}
+
+// FindOneByUserId returns the rating the User gave to the Movie, along with
+// the `originalRating` and the `history` of previous values, most recent first.
+//
+// If the User has not rated the Movie, a 404 error is returned.
+func (rs *neo4jRatingService) FindOneByUserId(ctx context.Context, movieId string, userId string) (_ Rating, err error) {
+	ctx, cancel := rs.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := rs.driver.NewSession(ctx, rs.options.sessionConfig(ctx, neo4j.AccessModeRead))
 
 	defer func() {
-		err = ioutils.DeferredClose(session, err)
+		err = ioutils.DeferredContextClose(ctx, session, err)
 	}()
 
-	result, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		result, err := tx.Run(`
-			MATCH (u:User {userId: $userId})
-			MATCH (m:Movie {tmdbId: $movieId})
-			
-			MERGE (u)-[r:RATED]->(m)
-			SET r.rating = $rating, r.timestamp = timestamp()
-			
-			RETURN m { .*, rating: r.rating } AS movie
-		`, map[string]interface{}{
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := rs.options.run(ctx, tx, "ratings/find_one_by_user_id", nil, map[string]interface{}{
 			"userId":  userId,
 			"movieId": movieId,
-			"rating":  rating,
 		})
 		if err != nil {
 			return nil, err
 		}
-		record, err := result.Single()
+		records, err := result.Collect(ctx)
 		if err != nil {
 			return nil, err
 		}
+		if len(records) == 0 {
+			return nil, NewDomainError(404, "Rating not found", map[string]interface{}{
+				"movieId": movieId,
+			})
+		}
 
-		movie, _ := record.Get("movie")
-		return movie.(map[string]interface{}), nil
-	})
+		rating, _ := records[0].Get("rating")
+		return rating.(map[string]interface{}), nil
+	}, rs.options.txConfig(ctx, FastLookup))
 	if err != nil {
 		return nil, err
 	}
 
-	return result.(Movie), nil
+	return result.(Rating), nil
 }
 
-// end::add[]
+// FindAllReviewsByUserId returns a paginated list of the reviews of a User, each
+// holding the `tmdbId`, `title` and `poster` of the reviewed Movie.
+//
+// Reviews are sorted by `timestamp`, `helpfulness`, `createdAt` or `updatedAt`, most
+// recent first by default.
+// Reviews of a User who made them private are only visible to that User: a 404
+// error is returned to everyone else, as it is when the User does not exist
+// or when the viewer blocked them.
+func (rs *neo4jRatingService) FindAllReviewsByUserId(ctx context.Context, userId, viewerId string, page *paging.Paging) (_ []Rating, err error) {
+	ctx, cancel := rs.options.withDeadline(ctx, List)
+	defer cancel()
+	session := rs.driver.NewSession(ctx, rs.options.sessionConfig(ctx, neo4j.AccessModeRead))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	spec, err := page.SortSpec(paging.ReviewSortableAttributes())
+	if err != nil {
+		return nil, err
+	}
+	fragments := map[string]string{
+		"sort":  reviewSortExpressions[spec.Attribute()],
+		"order": spec.CypherOrder(),
+	}
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		params := map[string]interface{}{
+			"userId":   userId,
+			"viewerId": viewerId,
+			"skip":     page.Skip(),
+			"limit":    page.Limit(),
+		}
+		// the count only finds the User whose reviews are visible to the viewer
+		count, err := rs.options.run(ctx, tx, "ratings/count_all_by_user_id", nil, params)
+		if err != nil {
+			return nil, err
+		}
+		if !count.Next(ctx) {
+			if err := count.Err(); err != nil {
+				return nil, err
+			}
+			return nil, NewDomainError(404, "User not found", map[string]interface{}{
+				"userId": userId,
+			})
+		}
+		total, _ := count.Record().Get("total")
+		page.SetTotal(total.(int64))
+
+		result, err := rs.options.run(ctx, tx, "ratings/find_all_by_user_id", fragments, params)
+		if err != nil {
+			return nil, err
+		}
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		results := []Rating{}
+		for _, record := range records {
+			value, _ := record.Get("review")
+			review := value.(map[string]interface{})
+			if movie, ok := review["movie"].(map[string]interface{}); ok {
+				review["movie"] = rs.options.properties.project("Movie", movie)
+			}
+			results = append(results, review)
+		}
+		return results, nil
+	}, rs.options.txConfig(ctx, List))
+	if err != nil {
+		return nil, err
+	}
+
+	return result.([]Rating), nil
+}
+
+// SaveReviewsPrivate sets whether the reviews of the User are hidden from other users
+func (rs *neo4jRatingService) SaveReviewsPrivate(ctx context.Context, userId string, private bool) (_ bool, err error) {
+	ctx, cancel := rs.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := rs.driver.NewSession(ctx, rs.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := rs.options.run(ctx, tx, "ratings/save_reviews_private", nil, map[string]interface{}{
+			"userId":  userId,
+			"private": private,
+		})
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, NewDomainError(404, "User not found", map[string]interface{}{"userId": userId})
+		}
+		saved, _ := record.Get("private")
+		return saved, nil
+	}, rs.options.txConfig(ctx, FastLookup))
+	if err != nil {
+		return false, err
+	}
+
+	return result.(bool), nil
+}
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"context"
+	"hash/fnv"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// RecommendationMinRating is the minimum rating of the movies recommendations are based
+// on, unless configured otherwise
+const RecommendationMinRating = 4
+
+// WithRecommendationMinRating overrides the minimum rating of the movies recommendations
+// are based on, from 1 to 5: a lower one bases the recommendations on more ratings,
+// a higher one on the movies the users liked the most only
+func WithRecommendationMinRating(rating float64) Option {
+	return func(options *serviceOptions) {
+		options.recommendationMinRating = rating
+	}
+}
+
+// recommendationPeers is the number of most similar users collaborative recommendations
+// are based on
+const recommendationPeers = 50
+
+// RecommendationStrategy recommends movies a User did not rate yet
+type RecommendationStrategy interface {
+	// Name identifies the strategy in the exposure log
+	Name() string
+
+	// Recommend returns up to limit movies for the User, best first
+	Recommend(ctx context.Context, tx neo4j.ManagedTransaction, userId string, limit int) ([]Movie, error)
+}
+
+// cypherStrategy recommends the movies returned by a statement of the catalog
+type cypherStrategy struct {
+	name      string
+	statement string
+	params    map[string]interface{}
+	options   serviceOptions
+}
+
+// NewContentBasedStrategy recommends the movies sharing the most genres, actors and
+// directors with the movies the User rated the best
+func NewContentBasedStrategy(opts ...Option) RecommendationStrategy {
+	return &cypherStrategy{
+		name:      "content",
+		statement: "recommendations/content_based",
+		options:   newServiceOptions(opts),
+	}
+}
+
+// NewCollaborativeStrategy recommends the movies rated the best by the users who rated
+// the best the same movies as the User
+func NewCollaborativeStrategy(opts ...Option) RecommendationStrategy {
+	return &cypherStrategy{
+		name:      "collaborative",
+		statement: "recommendations/collaborative",
+		params:    map[string]interface{}{"peers": recommendationPeers},
+		options:   newServiceOptions(opts),
+	}
+}
+
+// NewGdsStrategy recommends the movies rated the best by the users most similar to the
+// User, according to the SIMILAR relationships written by the node similarity algorithm
+// of the Graph Data Science library
+func NewGdsStrategy(opts ...Option) RecommendationStrategy {
+	return &cypherStrategy{
+		name:      "gds",
+		statement: "recommendations/gds",
+		options:   newServiceOptions(opts),
+	}
+}
+
+func (cs *cypherStrategy) Name() string {
+	return cs.name
+}
+
+func (cs *cypherStrategy) Recommend(ctx context.Context, tx neo4j.ManagedTransaction, userId string, limit int) ([]Movie, error) {
+	favorites, err := getUserFavorites(ctx, tx, cs.options.catalog, userId)
+	if err != nil {
+		return nil, err
+	}
+	excludedWarnings, err := getUserExcludedContentWarnings(ctx, tx, cs.options.catalog, userId)
+	if err != nil {
+		return nil, err
+	}
+	params := map[string]interface{}{
+		"userId":           userId,
+		"minRating":        cs.options.recommendationMinRating,
+		"favorites":        favorites,
+		"excludedWarnings": excludedWarnings,
+		"limit":            limit,
+	}
+	for name, value := range cs.params {
+		params[name] = value
+	}
+	result, err := cs.options.run(ctx, tx, cs.statement, nil, params)
+	if err != nil {
+		return nil, err
+	}
+	records, err := result.Collect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	movies := make([]Movie, 0, len(records))
+	for _, record := range records {
+		movie, _ := record.Get("movie")
+		movies = append(movies, cs.options.properties.project("Movie", movie.(map[string]interface{})))
+	}
+	return movies, nil
+}
+
+// RecommendationArm is a strategy of an experiment along with its share of the users
+type RecommendationArm struct {
+	Strategy RecommendationStrategy
+	Weight   int
+}
+
+// RecommendationExperiment compares recommendation strategies by assigning each User
+// to one of its arms, proportionally to their weights
+type RecommendationExperiment struct {
+	Name string
+	Arms []RecommendationArm
+}
+
+// Assign returns the strategy of the User, which only changes when the name or the arms
+// of the experiment change. Arms without weight are never assigned.
+func (re RecommendationExperiment) Assign(userId string) RecommendationStrategy {
+	total := 0
+	for _, arm := range re.Arms {
+		if arm.Weight > 0 {
+			total += arm.Weight
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(re.Name + "|" + userId))
+	bucket := int(hash.Sum32() % uint32(total))
+	for _, arm := range re.Arms {
+		if arm.Weight <= 0 {
+			continue
+		}
+		if bucket < arm.Weight {
+			return arm.Strategy
+		}
+		bucket -= arm.Weight
+	}
+	return nil
+}
+
+// Bucket returns the name of the strategy of the User, or an empty string when
+// no strategy is enabled
+func (re RecommendationExperiment) Bucket(userId string) string {
+	if strategy := re.Assign(userId); strategy != nil {
+		return strategy.Name()
+	}
+	return ""
+}
+
+type RecommendationService interface {
+	FindAllByUserId(ctx context.Context, userId string, limit int) ([]Movie, error)
+
+	CompareStrategies(ctx context.Context) ([]map[string]interface{}, error)
+}
+
+type neo4jRecommendationService struct {
+	loader     *fixtures.FixtureLoader
+	driver     SessionFactory
+	experiment RecommendationExperiment
+	options    serviceOptions
+}
+
+func NewRecommendationService(loader *fixtures.FixtureLoader, driver SessionFactory, experiment RecommendationExperiment, opts ...Option) RecommendationService {
+	return &neo4jRecommendationService{
+		loader:     loader,
+		driver:     driver,
+		experiment: experiment,
+		options:    newServiceOptions(opts),
+	}
+}
+
+// FindAllByUserId returns up to limit movies recommended to the User by the strategy the
+// User is assigned to, and logs their exposure so that strategies can be compared.
+// Failing to log the exposure does not fail the recommendations.
+//
+// If no strategy is enabled, a 503 error is returned.
+func (rs *neo4jRecommendationService) FindAllByUserId(ctx context.Context, userId string, limit int) (_ []Movie, err error) {
+	strategy := rs.experiment.Assign(userId)
+	if strategy == nil {
+		return nil, NewDomainError(503, "Recommendations are disabled", nil)
+	}
+
+	ctx, cancel := rs.options.withDeadline(ctx, Similarity)
+	defer cancel()
+	session := rs.driver.NewSession(ctx, rs.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		return strategy.Recommend(ctx, tx, userId, limit)
+	}, rs.options.txConfig(ctx, Similarity))
+
+	if err != nil {
+		return nil, err
+	}
+	movies := result.([]Movie)
+	if err := rs.logExposures(ctx, session, userId, strategy.Name(), movies); err != nil {
+		rs.options.logger.Printf("failed to log the exposure of %s recommendations: %v", strategy.Name(), err)
+	}
+	return movies, nil
+}
+
+func (rs *neo4jRecommendationService) logExposures(ctx context.Context, session neo4j.SessionWithContext, userId, strategy string, movies []Movie) error {
+	if len(movies) == 0 {
+		return nil
+	}
+	movieIds := make([]interface{}, 0, len(movies))
+	for _, movie := range movies {
+		movieIds = append(movieIds, movie["tmdbId"])
+	}
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := rs.options.run(ctx, tx, "recommendations/log_exposures", nil, map[string]interface{}{
+			"userId":     userId,
+			"movieIds":   movieIds,
+			"experiment": rs.experiment.Name,
+			"strategy":   strategy,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result.Consume(ctx)
+	}, rs.options.txConfig(ctx, FastLookup))
+	return err
+}
+
+// CompareStrategies returns, for each strategy of the experiment, the number of users
+// and movies exposed to its recommendations, and how many of those movies were then
+// rated or added to the favorites
+func (rs *neo4jRecommendationService) CompareStrategies(ctx context.Context) (_ []map[string]interface{}, err error) {
+	ctx, cancel := rs.options.withDeadline(ctx, Export)
+	defer cancel()
+	session := rs.driver.NewSession(ctx, rs.options.sessionConfig(ctx, neo4j.AccessModeRead))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	results, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := rs.options.run(ctx, tx, "recommendations/compare_strategies", nil, map[string]interface{}{
+			"experiment": rs.experiment.Name,
+		})
+		if err != nil {
+			return nil, err
+		}
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		comparisons := make([]map[string]interface{}, 0, len(records))
+		for _, record := range records {
+			comparison, _ := record.Get("comparison")
+			comparisons = append(comparisons, comparison.(map[string]interface{}))
+		}
+		return comparisons, nil
+	}, rs.options.txConfig(ctx, Export))
+
+	if err != nil {
+		return nil, err
+	}
+	return results.([]map[string]interface{}), nil
+}
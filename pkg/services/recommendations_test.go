@@ -0,0 +1,40 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestAssign(t *testing.T) {
+	content := NewContentBasedStrategy()
+	collaborative := NewCollaborativeStrategy()
+	gds := NewGdsStrategy()
+	experiment := RecommendationExperiment{
+		Name: "recommendations",
+		Arms: []RecommendationArm{
+			{Strategy: content, Weight: 1},
+			{Strategy: collaborative, Weight: 1},
+			{Strategy: gds, Weight: 0},
+		},
+	}
+
+	assigned := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		userId := fmt.Sprintf("user-%d", i)
+		strategy := experiment.Assign(userId)
+		if strategy != experiment.Assign(userId) {
+			t.Fatalf("expected %s to be assigned the same strategy", userId)
+		}
+		assigned[strategy.Name()]++
+	}
+	if assigned["gds"] != 0 {
+		t.Errorf("expected arms without weight not to be assigned, got %d users", assigned["gds"])
+	}
+	if assigned["content"] < 400 || assigned["collaborative"] < 400 {
+		t.Errorf("expected users to be split evenly, got %v", assigned)
+	}
+
+	if strategy := (RecommendationExperiment{Name: "disabled"}).Assign("user-1"); strategy != nil {
+		t.Errorf("expected no strategy, got %s", strategy.Name())
+	}
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j-graphacademy/neoflix/pkg/routes/paging"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Report flags incorrect data of a Movie, reported by a User for moderator review
+type Report = map[string]interface{}
+
+// Report reasons
+const (
+	ReportWrongYear    = "wrong_year"
+	ReportDuplicate    = "duplicate"
+	ReportBrokenPoster = "broken_poster"
+)
+
+// Report resolutions
+const (
+	ReportFixed     = "fixed"
+	ReportDismissed = "dismissed"
+)
+
+type ReportService interface {
+	Create(ctx context.Context, userId, movieId, reason, comment, duplicateOf string) (Report, error)
+
+	FindAllOpen(ctx context.Context, page *paging.Paging) ([]Report, error)
+
+	FindOneById(ctx context.Context, id string) (Report, error)
+
+	Resolve(ctx context.Context, id, userId, status string) (Report, error)
+}
+
+type neo4jReportService struct {
+	loader  *fixtures.FixtureLoader
+	driver  SessionFactory
+	options serviceOptions
+}
+
+func NewReportService(loader *fixtures.FixtureLoader, driver SessionFactory, opts ...Option) ReportService {
+	return &neo4jReportService{
+		loader:  loader,
+		driver:  driver,
+		options: newServiceOptions(opts),
+	}
+}
+
+// Create reports incorrect data of the Movie with the provided ID for the provided reason,
+// with an optional comment and, for duplicates, the ID of the Movie it duplicates.
+// Reporting the same Movie again for the same reason updates the open report of the User.
+//
+// If the reason is unknown, a 400 error is returned.
+// If the User or the Movie cannot be found, a 404 error is returned.
+func (rs *neo4jReportService) Create(ctx context.Context, userId, movieId, reason, comment, duplicateOf string) (Report, error) {
+	switch reason {
+	case ReportWrongYear, ReportBrokenPoster:
+		duplicateOf = ""
+	case ReportDuplicate:
+		if duplicateOf == "" {
+			return nil, NewDomainError(400, "duplicateOf is required for duplicate reports", nil)
+		}
+	default:
+		return nil, NewDomainError(400, "Unsupported report reason", map[string]interface{}{
+			"reason":  reason,
+			"reasons": []string{ReportWrongYear, ReportDuplicate, ReportBrokenPoster},
+		})
+	}
+	params := map[string]interface{}{
+		"userId":      userId,
+		"movieId":     movieId,
+		"reason":      reason,
+		"comment":     nil,
+		"duplicateOf": nil,
+	}
+	if comment != "" {
+		params["comment"] = comment
+	}
+	if duplicateOf != "" {
+		params["duplicateOf"] = duplicateOf
+	}
+	return rs.write(ctx, "reports/create", params, "Movie not found")
+}
+
+// FindAllOpen returns a paginated list of the reports awaiting moderator review,
+// oldest first, each holding the reported Movie and the reporting User
+func (rs *neo4jReportService) FindAllOpen(ctx context.Context, page *paging.Paging) (_ []Report, err error) {
+	ctx, cancel := rs.options.withDeadline(ctx, List)
+	defer cancel()
+	session := rs.driver.NewSession(ctx, rs.options.sessionConfig(ctx, neo4j.AccessModeRead))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	results, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		params := map[string]interface{}{
+			"skip":  page.Skip(),
+			"limit": page.Limit(),
+		}
+		if err := rs.options.countAll(ctx, tx, page, "reports/count_all_open", nil, params); err != nil {
+			return nil, err
+		}
+		result, err := rs.options.run(ctx, tx, "reports/find_all_open", nil, params)
+		if err != nil {
+			return nil, err
+		}
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		reports := make([]Report, 0, len(records))
+		for _, record := range records {
+			report, _ := record.Get("report")
+			reports = append(reports, report.(map[string]interface{}))
+		}
+		return reports, nil
+	}, rs.options.txConfig(ctx, List))
+
+	if err != nil {
+		return nil, err
+	}
+	return results.([]Report), nil
+}
+
+// FindOneById returns the report with the provided ID, open or not.
+//
+// If the report cannot be found, a 404 error is returned.
+func (rs *neo4jReportService) FindOneById(ctx context.Context, id string) (_ Report, err error) {
+	ctx, cancel := rs.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := rs.driver.NewSession(ctx, rs.options.sessionConfig(ctx, neo4j.AccessModeRead))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := rs.options.run(ctx, tx, "reports/find_one_by_id", nil, map[string]interface{}{
+			"id": id,
+		})
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, NewDomainError(404, "Report not found", map[string]interface{}{"id": id})
+		}
+		report, _ := record.Get("report")
+		return report.(map[string]interface{}), nil
+	}, rs.options.txConfig(ctx, FastLookup))
+
+	if err != nil {
+		return nil, err
+	}
+	return result.(Report), nil
+}
+
+// Resolve closes an open report as fixed or dismissed by the moderator with the
+// provided ID, and notifies the reporting User of the outcome.
+//
+// If the status is neither fixed nor dismissed, a 400 error is returned.
+// If the open report cannot be found, a 404 error is returned.
+func (rs *neo4jReportService) Resolve(ctx context.Context, id, userId, status string) (Report, error) {
+	if status != ReportFixed && status != ReportDismissed {
+		return nil, NewDomainError(400, "Unsupported report status", map[string]interface{}{
+			"status":   status,
+			"statuses": []string{ReportFixed, ReportDismissed},
+		})
+	}
+	return rs.write(ctx, "reports/resolve", map[string]interface{}{
+		"id":     id,
+		"userId": userId,
+		"status": status,
+	}, "Report not found")
+}
+
+func (rs *neo4jReportService) write(ctx context.Context, statement string, params map[string]interface{}, notFound string) (_ Report, err error) {
+	ctx, cancel := rs.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := rs.driver.NewSession(ctx, rs.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := rs.options.run(ctx, tx, statement, nil, params)
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, NewDomainError(404, notFound, nil)
+		}
+		report, _ := record.Get("report")
+		return report.(map[string]interface{}), nil
+	}, rs.options.txConfig(ctx, FastLookup))
+
+	if err != nil {
+		return nil, err
+	}
+	return result.(Report), nil
+}
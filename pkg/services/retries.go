@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Retries sums the transaction attempts the driver retried after transient failures,
+// and the time spent before their last attempt
+type Retries struct {
+	Transactions int64 `json:"transactions"`
+	Retried      int64 `json:"retried"`
+	Attempts     int64 `json:"attempts"`
+	RetryTimeMs  int64 `json:"retryTimeMs"`
+}
+
+func (r *Retries) add(attempts int, retryTime time.Duration) {
+	r.Transactions++
+	r.Attempts += int64(attempts)
+	if attempts > 1 {
+		r.Retried++
+		r.RetryTimeMs += retryTime.Milliseconds()
+	}
+}
+
+// RetryTracker sums the retries of the transactions of a single request
+type RetryTracker struct {
+	mutex   sync.Mutex
+	retries Retries
+}
+
+// Retries returns the retries of the transactions run so far
+func (rt *RetryTracker) Retries() Retries {
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+	return rt.retries
+}
+
+type retryTrackerKey struct{}
+
+// ContextWithRetryTracker returns a copy of the context in which the retries of the
+// transactions are summed by the tracker
+func ContextWithRetryTracker(ctx context.Context, tracker *RetryTracker) context.Context {
+	return context.WithValue(ctx, retryTrackerKey{}, tracker)
+}
+
+// RetryMetrics sums the retries of the transactions per route, as found in the
+// RequestMetadata of their context
+type RetryMetrics struct {
+	mutex   sync.Mutex
+	byRoute map[string]*Retries
+}
+
+func NewRetryMetrics() *RetryMetrics {
+	return &RetryMetrics{byRoute: map[string]*Retries{}}
+}
+
+// RouteRetries are the retries of the transactions of a route
+type RouteRetries struct {
+	Route string `json:"route"`
+	Retries
+}
+
+// FindAll returns the retries of the routes whose transactions were retried at least
+// once, the most retried first
+func (rm *RetryMetrics) FindAll() []RouteRetries {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+	result := []RouteRetries{}
+	for route, retries := range rm.byRoute {
+		if retries.Retried > 0 {
+			result = append(result, RouteRetries{Route: route, Retries: *retries})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Retried != result[j].Retried {
+			return result[i].Retried > result[j].Retried
+		}
+		return result[i].Route < result[j].Route
+	})
+	return result
+}
+
+func (rm *RetryMetrics) add(route string, attempts int, retryTime time.Duration) {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+	retries, found := rm.byRoute[route]
+	if !found {
+		retries = &Retries{}
+		rm.byRoute[route] = retries
+	}
+	retries.add(attempts, retryTime)
+}
+
+// NewRetryCountingDriver decorates the driver so that the attempts of the managed
+// transactions of its sessions are summed in the RetryTracker of their context, if any,
+// and per route in the metrics
+func NewRetryCountingDriver(driver neo4j.DriverWithContext, metrics *RetryMetrics) neo4j.DriverWithContext {
+	return &retryCountingDriver{DriverWithContext: driver, metrics: metrics}
+}
+
+type retryCountingDriver struct {
+	neo4j.DriverWithContext
+	metrics *RetryMetrics
+}
+
+func (rd *retryCountingDriver) NewSession(ctx context.Context, config neo4j.SessionConfig) neo4j.SessionWithContext {
+	return &retryCountingSession{
+		SessionWithContext: rd.DriverWithContext.NewSession(ctx, config),
+		metrics:            rd.metrics,
+	}
+}
+
+type retryCountingSession struct {
+	neo4j.SessionWithContext
+	metrics *RetryMetrics
+}
+
+func (rs *retryCountingSession) ExecuteRead(ctx context.Context, work neo4j.ManagedTransactionWork, configurers ...func(*neo4j.TransactionConfig)) (interface{}, error) {
+	counted, done := rs.count(ctx, work)
+	defer done()
+	return rs.SessionWithContext.ExecuteRead(ctx, counted, configurers...)
+}
+
+func (rs *retryCountingSession) ExecuteWrite(ctx context.Context, work neo4j.ManagedTransactionWork, configurers ...func(*neo4j.TransactionConfig)) (interface{}, error) {
+	counted, done := rs.count(ctx, work)
+	defer done()
+	return rs.SessionWithContext.ExecuteWrite(ctx, counted, configurers...)
+}
+
+// count wraps the work to count the attempts the driver makes to run it, and returns
+// a function recording them once the transaction is over
+func (rs *retryCountingSession) count(ctx context.Context, work neo4j.ManagedTransactionWork) (neo4j.ManagedTransactionWork, func()) {
+	attempts := 0
+	var firstAttempt, lastAttempt time.Time
+	counted := func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		lastAttempt = time.Now()
+		if attempts == 0 {
+			firstAttempt = lastAttempt
+		}
+		attempts++
+		return work(tx)
+	}
+	return counted, func() {
+		if attempts == 0 {
+			return
+		}
+		retryTime := lastAttempt.Sub(firstAttempt)
+		if tracker, found := ctx.Value(retryTrackerKey{}).(*RetryTracker); found {
+			tracker.mutex.Lock()
+			tracker.retries.add(attempts, retryTime)
+			tracker.mutex.Unlock()
+		}
+		if metadata, found := RequestMetadataFromContext(ctx); found && metadata.Route != "" {
+			rs.metrics.add(metadata.Route, attempts, retryTime)
+		}
+	}
+}
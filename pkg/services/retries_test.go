@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// flakySession runs the work of managed transactions until it succeeds, as the driver
+// does with transient failures
+type flakySession struct {
+	neo4j.SessionWithContext
+}
+
+func (fs *flakySession) ExecuteRead(_ context.Context, work neo4j.ManagedTransactionWork, _ ...func(*neo4j.TransactionConfig)) (interface{}, error) {
+	for {
+		if result, err := work(nil); err == nil {
+			return result, nil
+		}
+	}
+}
+
+func TestRetryCountingSession(t *testing.T) {
+	metrics := NewRetryMetrics()
+	session := &retryCountingSession{SessionWithContext: &flakySession{}, metrics: metrics}
+	tracker := &RetryTracker{}
+	ctx := ContextWithRetryTracker(ContextWithRequestMetadata(context.Background(),
+		RequestMetadata{Route: "GET /api/movies"}), tracker)
+
+	failures := 2
+	work := func(neo4j.ManagedTransaction) (interface{}, error) {
+		if failures > 0 {
+			failures--
+			return nil, errors.New("transient failure")
+		}
+		return "done", nil
+	}
+	if _, err := session.ExecuteRead(ctx, work); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.ExecuteRead(ctx, work); err != nil {
+		t.Fatal(err)
+	}
+
+	retries := tracker.Retries()
+	if retries.Transactions != 2 || retries.Retried != 1 || retries.Attempts != 4 {
+		t.Errorf("unexpected retries %+v", retries)
+	}
+	routes := metrics.FindAll()
+	if len(routes) != 1 || routes[0].Route != "GET /api/movies" || routes[0].Retries != retries {
+		t.Errorf("unexpected route retries %+v", routes)
+	}
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// RetryPolicy retries the managed transactions of the services failing with transient
+// errors, such as a leader switch or a dropped connection, once the driver gave up on
+// them, so that brief outages are not reported as server errors.
+// The waits between the attempts grow exponentially, from InitialBackoff up to
+// MaxBackoff.
+type RetryPolicy struct {
+	// MaxAttempts is the number of times a transaction is run at most, 1 disabling the
+	// retries
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Jitter is the fraction of each wait which is randomized, between 0 and 1, so that
+	// the requests failing together are not retried together
+	Jitter float64
+}
+
+// DefaultRetryPolicy makes 3 attempts, waiting from 100ms up to 2s, half of it randomized
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+		Jitter:         0.5,
+	}
+}
+
+// backoff returns the wait before the attempt following the given one, counted from 1
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < attempt && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+	jitter := time.Duration(float64(backoff) * p.Jitter * rand.Float64())
+	return backoff - jitter
+}
+
+// isTransient reports whether the error is one neo4j.IsRetryable reports as such, or
+// the driver gave up retrying after one
+func isTransient(err error) bool {
+	var limit *neo4j.TransactionExecutionLimit
+	if errors.As(err, &limit) && len(limit.Errors) > 0 {
+		err = limit.Errors[len(limit.Errors)-1]
+	}
+	return neo4j.IsRetryable(err)
+}
+
+// NewRetryingDriver decorates the driver so that the managed transactions of its
+// sessions are retried according to the policy.
+// Explicit transactions, such as the ones of the streams, are never retried, as their
+// results may have been read already.
+// Every attempt is retried by the driver as well, for up to its MaxTransactionRetryTime,
+// and NewRetryCountingDriver must wrap the returned driver to count them all.
+func NewRetryingDriver(driver neo4j.DriverWithContext, policy RetryPolicy) neo4j.DriverWithContext {
+	return &retryingDriver{DriverWithContext: driver, policy: policy}
+}
+
+type retryingDriver struct {
+	neo4j.DriverWithContext
+	policy RetryPolicy
+}
+
+func (rd *retryingDriver) NewSession(ctx context.Context, config neo4j.SessionConfig) neo4j.SessionWithContext {
+	return &retryingSession{
+		SessionWithContext: rd.DriverWithContext.NewSession(ctx, config),
+		policy:             rd.policy,
+	}
+}
+
+type retryingSession struct {
+	neo4j.SessionWithContext
+	policy RetryPolicy
+}
+
+func (rs *retryingSession) ExecuteRead(ctx context.Context, work neo4j.ManagedTransactionWork, configurers ...func(*neo4j.TransactionConfig)) (interface{}, error) {
+	return rs.retry(ctx, func() (interface{}, error) {
+		return rs.SessionWithContext.ExecuteRead(ctx, work, configurers...)
+	})
+}
+
+func (rs *retryingSession) ExecuteWrite(ctx context.Context, work neo4j.ManagedTransactionWork, configurers ...func(*neo4j.TransactionConfig)) (interface{}, error) {
+	return rs.retry(ctx, func() (interface{}, error) {
+		return rs.SessionWithContext.ExecuteWrite(ctx, work, configurers...)
+	})
+}
+
+// retry runs the transaction until it succeeds, fails with an error which is not
+// transient, runs out of attempts, or the context is done
+func (rs *retryingSession) retry(ctx context.Context, execute func() (interface{}, error)) (interface{}, error) {
+	for attempt := 1; ; attempt++ {
+		result, err := execute()
+		if err == nil || attempt >= rs.policy.MaxAttempts || !isTransient(err) {
+			return result, err
+		}
+		timer := time.NewTimer(rs.policy.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+	}
+}
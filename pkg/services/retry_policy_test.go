@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// failingSession runs the work of the managed transactions, then fails them with the
+// errors, one per attempt, as when their commit fails
+type failingSession struct {
+	neo4j.SessionWithContext
+	errors   []error
+	attempts int
+}
+
+func (fs *failingSession) ExecuteWrite(_ context.Context, work neo4j.ManagedTransactionWork, _ ...func(*neo4j.TransactionConfig)) (interface{}, error) {
+	fs.attempts++
+	result, err := work(nil)
+	if len(fs.errors) > 0 {
+		err := fs.errors[0]
+		fs.errors = fs.errors[1:]
+		return nil, err
+	}
+	return result, err
+}
+
+func TestRetryingSession(t *testing.T) {
+	transient := &neo4j.Neo4jError{Code: "Neo.TransientError.Transaction.DeadlockDetected"}
+	leaderSwitch := &neo4j.TransactionExecutionLimit{Errors: []error{&neo4j.ConnectivityError{Inner: errors.New("EOF")}}}
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	work := func(neo4j.ManagedTransaction) (interface{}, error) { return "done", nil }
+
+	tests := []struct {
+		name     string
+		errors   []error
+		attempts int
+		failed   bool
+	}{
+		{name: "transient errors", errors: []error{transient, leaderSwitch}, attempts: 3},
+		{name: "too many transient errors", errors: []error{transient, transient, transient}, attempts: 3, failed: true},
+		{name: "other errors", errors: []error{NewDomainError(404, "Movie not found", nil)}, attempts: 1, failed: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			inner := &failingSession{errors: test.errors}
+			session := &retryingSession{SessionWithContext: inner, policy: policy}
+
+			result, err := session.ExecuteWrite(context.Background(), work)
+
+			if test.failed != (err != nil) || !test.failed && result != "done" {
+				t.Errorf("unexpected result %v, error %v", result, err)
+			}
+			if inner.attempts != test.attempts {
+				t.Errorf("expected %d attempts, got %d", test.attempts, inner.attempts)
+			}
+		})
+	}
+}
+
+func TestRetryingSessionAttemptsAreCounted(t *testing.T) {
+	transient := &neo4j.Neo4jError{Code: "Neo.TransientError.Transaction.DeadlockDetected"}
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	tracker := &RetryTracker{}
+	ctx := ContextWithRetryTracker(context.Background(), tracker)
+	session := &retryCountingSession{
+		SessionWithContext: &retryingSession{
+			SessionWithContext: &failingSession{errors: []error{transient, transient}},
+			policy:             policy,
+		},
+		metrics: NewRetryMetrics(),
+	}
+
+	_, err := session.ExecuteWrite(ctx, func(neo4j.ManagedTransaction) (interface{}, error) { return "done", nil })
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	retries := tracker.Retries()
+	if retries.Transactions != 1 || retries.Retried != 1 || retries.Attempts != 3 {
+		t.Errorf("expected 1 transaction retried over 3 attempts, got %+v", retries)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Jitter: 0.5}
+
+	for attempt, expected := range map[int]time.Duration{1: 100 * time.Millisecond, 3: 400 * time.Millisecond, 10: time.Second} {
+		backoff := policy.backoff(attempt)
+		if backoff > expected || backoff < expected/2 {
+			t.Errorf("expected the backoff of attempt %d to be between %s and %s, got %s", attempt, expected/2, expected, backoff)
+		}
+	}
+}
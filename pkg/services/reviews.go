@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+type Review = map[string]interface{}
+
+// MaxReviewLength is the maximum number of characters of the text of a review
+const MaxReviewLength = 5000
+
+// ReviewService manages the text reviews users write along with their ratings
+type ReviewService interface {
+	Save(ctx context.Context, movieId, userId, text string) (Review, error)
+
+	Delete(ctx context.Context, movieId, userId string) (Movie, error)
+}
+
+type neo4jReviewService struct {
+	loader  *fixtures.FixtureLoader
+	driver  SessionFactory
+	options serviceOptions
+}
+
+func NewReviewService(loader *fixtures.FixtureLoader, driver SessionFactory, opts ...Option) ReviewService {
+	return &neo4jReviewService{
+		loader:  loader,
+		driver:  driver,
+		options: newServiceOptions(opts),
+	}
+}
+
+// Save posts the text of the review of the Movie by the User, or replaces it.
+// Reviews are stored on the RATED relationship, so that the Movie must be rated first.
+// The review keeps the time it was first posted as `reviewedAt`, and the time of its
+// last edit as `editedAt`.
+//
+// If the text is empty or longer than MaxReviewLength, a 400 error is returned, and
+// if the Movie was not rated by the User, a 404 error.
+func (rs *neo4jReviewService) Save(ctx context.Context, movieId, userId, text string) (_ Review, err error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, NewDomainError(400, "Review text is required", nil)
+	}
+	if utf8.RuneCountInString(text) > MaxReviewLength {
+		return nil, NewDomainError(400, "Review text is too long", map[string]interface{}{
+			"maxLength": MaxReviewLength,
+		})
+	}
+
+	ctx, cancel := rs.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := rs.driver.NewSession(ctx, rs.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := rs.options.run(ctx, tx, "reviews/save", nil, map[string]interface{}{
+			"movieId": movieId,
+			"userId":  userId,
+			"text":    text,
+		})
+		if err != nil {
+			return nil, err
+		}
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, NewDomainError(404, "Rating not found", map[string]interface{}{
+				"movieId": movieId,
+			})
+		}
+		review, _ := records[0].Get("review")
+		return review.(map[string]interface{}), nil
+	}, rs.options.txConfig(ctx, FastLookup))
+	if err != nil {
+		return nil, err
+	}
+	return result.(Review), nil
+}
+
+// Delete removes the text of the review of the Movie by the User, keeping their rating.
+//
+// If the User did not review the Movie, a 404 error is returned.
+func (rs *neo4jReviewService) Delete(ctx context.Context, movieId, userId string) (_ Movie, err error) {
+	ctx, cancel := rs.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := rs.driver.NewSession(ctx, rs.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := rs.options.run(ctx, tx, "reviews/delete", nil, map[string]interface{}{
+			"movieId": movieId,
+			"userId":  userId,
+		})
+		if err != nil {
+			return nil, err
+		}
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, NewDomainError(404, "Review not found", map[string]interface{}{
+				"movieId": movieId,
+			})
+		}
+		movie, _ := records[0].Get("movie")
+		return rs.options.properties.project("Movie", movie.(map[string]interface{})), nil
+	}, rs.options.txConfig(ctx, FastLookup))
+	if err != nil {
+		return nil, err
+	}
+	return result.(Movie), nil
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+type SavedSearch = map[string]interface{}
+
+// SearchFilter describes the movies a saved search matches.
+// Empty or zero fields match any movie.
+type SearchFilter struct {
+	Name      string
+	Genre     string
+	MinRating float64
+	FromYear  int
+	ToYear    int
+}
+
+func (sf SearchFilter) params() map[string]interface{} {
+	params := map[string]interface{}{
+		"name":      sf.Name,
+		"genre":     nil,
+		"minRating": nil,
+		"fromYear":  nil,
+		"toYear":    nil,
+	}
+	if sf.Genre != "" {
+		params["genre"] = sf.Genre
+	}
+	if sf.MinRating > 0 {
+		params["minRating"] = sf.MinRating
+	}
+	if sf.FromYear > 0 {
+		params["fromYear"] = sf.FromYear
+	}
+	if sf.ToYear > 0 {
+		params["toYear"] = sf.ToYear
+	}
+	return params
+}
+
+type SavedSearchService interface {
+	FindAllByUserId(ctx context.Context, userId string) ([]SavedSearch, error)
+
+	Save(ctx context.Context, userId string, filter SearchFilter) (SavedSearch, error)
+
+	Delete(ctx context.Context, userId, id string) (SavedSearch, error)
+
+	NotifyNewMatches(ctx context.Context, today time.Time, limit int) (int64, int64, error)
+}
+
+type neo4jSavedSearchService struct {
+	loader  *fixtures.FixtureLoader
+	driver  SessionFactory
+	options serviceOptions
+}
+
+func NewSavedSearchService(loader *fixtures.FixtureLoader, driver SessionFactory, opts ...Option) SavedSearchService {
+	return &neo4jSavedSearchService{
+		loader:  loader,
+		driver:  driver,
+		options: newServiceOptions(opts),
+	}
+}
+
+// FindAllByUserId returns the searches saved by the User, most recent first
+func (ss *neo4jSavedSearchService) FindAllByUserId(ctx context.Context, userId string) (_ []SavedSearch, err error) {
+	ctx, cancel := ss.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := ss.driver.NewSession(ctx, ss.options.sessionConfig(ctx, neo4j.AccessModeRead))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	results, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := ss.options.run(ctx, tx, "saved_searches/find_all_by_user_id", nil, map[string]interface{}{
+			"userId": userId,
+		})
+		if err != nil {
+			return nil, err
+		}
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		searches := make([]SavedSearch, 0, len(records))
+		for _, record := range records {
+			search, _ := record.Get("search")
+			searches = append(searches, search.(map[string]interface{}))
+		}
+		return searches, nil
+	}, ss.options.txConfig(ctx, FastLookup))
+
+	if err != nil {
+		return nil, err
+	}
+	return results.([]SavedSearch), nil
+}
+
+// Save stores a named search of the User.
+// Only the movies released from the next day onwards are notified, not the existing ones.
+//
+// If the User cannot be found, a 404 error is returned.
+func (ss *neo4jSavedSearchService) Save(ctx context.Context, userId string, filter SearchFilter) (_ SavedSearch, err error) {
+	if filter.Name == "" {
+		return nil, NewDomainError(400, "Saved searches must be named", nil)
+	}
+	if filter.FromYear > 0 && filter.ToYear > 0 && filter.FromYear > filter.ToYear {
+		return nil, NewDomainError(400, "Invalid year range", map[string]interface{}{
+			"fromYear": filter.FromYear,
+			"toYear":   filter.ToYear,
+		})
+	}
+	params := filter.params()
+	params["userId"] = userId
+	params["today"] = time.Now().UTC().Format(releaseDateLayout)
+	return ss.write(ctx, "saved_searches/save", params)
+}
+
+// Delete removes a search saved by the User.
+//
+// If the search cannot be found, a 404 error is returned.
+func (ss *neo4jSavedSearchService) Delete(ctx context.Context, userId, id string) (SavedSearch, error) {
+	return ss.write(ctx, "saved_searches/delete", map[string]interface{}{
+		"userId": userId,
+		"id":     id,
+	})
+}
+
+func (ss *neo4jSavedSearchService) write(ctx context.Context, statement string, params map[string]interface{}) (_ SavedSearch, err error) {
+	ctx, cancel := ss.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := ss.driver.NewSession(ctx, ss.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := ss.options.run(ctx, tx, statement, nil, params)
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, NewDomainError(404, "Saved search not found", nil)
+		}
+		search, _ := record.Get("search")
+		return search.(map[string]interface{}), nil
+	}, ss.options.txConfig(ctx, FastLookup))
+
+	if err != nil {
+		return nil, err
+	}
+	return result.(SavedSearch), nil
+}
+
+// NotifyNewMatches checks up to `limit` saved searches not checked yet today against the
+// movies released since their previous check, creates a notification for each match and
+// returns the number of checked searches and of created notifications.
+// Fewer checked searches than `limit` means all searches are up-to-date.
+func (ss *neo4jSavedSearchService) NotifyNewMatches(ctx context.Context, today time.Time, limit int) (_ int64, _ int64, err error) {
+	ctx, cancel := ss.options.withDeadline(ctx, Export)
+	defer cancel()
+	session := ss.driver.NewSession(ctx, ss.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := ss.options.run(ctx, tx, "saved_searches/notify", map[string]string{
+			"released": ss.options.properties.datasetProperty("Movie", "released"),
+		}, map[string]interface{}{
+			"today": today.UTC().Format(releaseDateLayout),
+			"limit": limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		searches, _ := record.Get("searches")
+		notifications, _ := record.Get("notifications")
+		return [2]int64{searches.(int64), notifications.(int64)}, nil
+	}, ss.options.txConfig(ctx, Export))
+
+	if err != nil {
+		return 0, 0, err
+	}
+	counts := result.([2]int64)
+	return counts[0], counts[1], nil
+}
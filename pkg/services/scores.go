@@ -0,0 +1,66 @@
+package services
+
+import (
+	"math"
+)
+
+// RatingScale is the range of the ratings of a rating source
+type RatingScale struct {
+	Min float64
+	Max float64
+}
+
+var (
+	// ImdbRatingScale is the scale of the `imdbRating` of movies
+	ImdbRatingScale = RatingScale{Min: 0, Max: 10}
+	// AppRatingScale is the scale of the ratings of the users of the app, averaged in
+	// the `ratingAvg` of movies
+	AppRatingScale = RatingScale{Min: 1, Max: 5}
+)
+
+// DefaultScoreScale is the maximum of the `score` of movies, unless configured otherwise
+const DefaultScoreScale = 10.0
+
+// WithScoreScale overrides the maximum of the `score` of movies
+func WithScoreScale(scale float64) Option {
+	return func(options *serviceOptions) {
+		options.scoreScale = scale
+	}
+}
+
+// normalize returns the position of the rating in the scale, from 0 to 1
+func (rs RatingScale) normalize(rating float64) float64 {
+	return math.Max(0, math.Min(1, (rating-rs.Min)/(rs.Max-rs.Min)))
+}
+
+// movieScore unifies the IMDB rating and the average rating of the users of a movie,
+// either of which may be missing, into a score from 0 to scale: the mean of the
+// ratings available, each normalized to its own scale, rounded to 2 decimals.
+// It returns nil when the movie has no rating at all.
+func movieScore(imdbRating, ratingAvg interface{}, scale float64) interface{} {
+	var normalized []float64
+	if rating, ok := toFloat(imdbRating); ok {
+		normalized = append(normalized, ImdbRatingScale.normalize(rating))
+	}
+	if rating, ok := toFloat(ratingAvg); ok {
+		normalized = append(normalized, AppRatingScale.normalize(rating))
+	}
+	if len(normalized) == 0 {
+		return nil
+	}
+	total := 0.0
+	for _, value := range normalized {
+		total += value
+	}
+	return math.Round(total/float64(len(normalized))*scale*100) / 100
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch number := value.(type) {
+	case float64:
+		return number, true
+	case int64:
+		return float64(number), true
+	}
+	return 0, false
+}
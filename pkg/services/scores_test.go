@@ -0,0 +1,26 @@
+package services
+
+import "testing"
+
+func TestMovieScore(t *testing.T) {
+	for _, example := range []struct {
+		imdbRating interface{}
+		ratingAvg  interface{}
+		scale      float64
+		expected   interface{}
+	}{
+		{imdbRating: 8.0, ratingAvg: nil, scale: 10, expected: 8.0},
+		{imdbRating: nil, ratingAvg: 5.0, scale: 10, expected: 10.0},
+		{imdbRating: nil, ratingAvg: int64(3), scale: 10, expected: 5.0},
+		{imdbRating: 8.0, ratingAvg: 3.0, scale: 10, expected: 6.5},
+		{imdbRating: 8.0, ratingAvg: 3.0, scale: 5, expected: 3.25},
+		{imdbRating: 7.3, ratingAvg: nil, scale: 100, expected: 73.0},
+		{imdbRating: nil, ratingAvg: nil, scale: 10, expected: nil},
+	} {
+		score := movieScore(example.imdbRating, example.ratingAvg, example.scale)
+		if score != example.expected {
+			t.Errorf("expected %v for %v and %v on a scale of %v, got %v",
+				example.expected, example.imdbRating, example.ratingAvg, example.scale, score)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j-graphacademy/neoflix/pkg/routes/paging"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// maxSearchTermLength bounds the length of the recorded search terms
+const maxSearchTermLength = 100
+
+// Lists whose searches are recorded
+const (
+	SearchedMovies = "movies"
+	SearchedPeople = "people"
+)
+
+type SearchStatistics = map[string]interface{}
+
+type SearchAnalyticsService interface {
+	Record(ctx context.Context, userId, list, term string, results int64) error
+
+	FindTop(ctx context.Context, since time.Time, limit int) ([]SearchStatistics, error)
+
+	FindFailing(ctx context.Context, since time.Time, limit int) ([]SearchStatistics, error)
+}
+
+type neo4jSearchAnalyticsService struct {
+	loader  *fixtures.FixtureLoader
+	driver  SessionFactory
+	options serviceOptions
+}
+
+func NewSearchAnalyticsService(loader *fixtures.FixtureLoader, driver SessionFactory, opts ...Option) SearchAnalyticsService {
+	return &neo4jSearchAnalyticsService{
+		loader:  loader,
+		driver:  driver,
+		options: newServiceOptions(opts),
+	}
+}
+
+// Record stores a search of the list, along with its number of results.
+// Terms are normalized, and users are only identified by a hash of their ID,
+// anonymous users being left empty.
+func (ss *neo4jSearchAnalyticsService) Record(ctx context.Context, userId, list, term string, results int64) (err error) {
+	term = normalizeSearchTerm(term)
+	if term == "" {
+		return nil
+	}
+	userHash := ""
+	if userId != "" {
+		userHash = hashUserId(userId)
+	}
+
+	ctx, cancel := ss.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := ss.driver.NewSession(ctx, ss.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	_, err = session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := ss.options.run(ctx, tx, "search_analytics/record", nil, map[string]interface{}{
+			"list":     list,
+			"term":     term,
+			"userHash": userHash,
+			"results":  results,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result.Consume(ctx)
+	}, ss.options.txConfig(ctx, FastLookup))
+	return err
+}
+
+// FindTop returns the most searched terms since the provided time, along with their
+// number of `searches`, of distinct `users`, their `averageResults` and the number
+// of their searches returning `zeroResults`
+func (ss *neo4jSearchAnalyticsService) FindTop(ctx context.Context, since time.Time, limit int) ([]SearchStatistics, error) {
+	return ss.findAll(ctx, "search_analytics/find_top", since, limit)
+}
+
+// FindFailing returns the terms most often searched without results since the provided
+// time, along with their number of `searches`, of distinct `users` and when they were
+// last searched
+func (ss *neo4jSearchAnalyticsService) FindFailing(ctx context.Context, since time.Time, limit int) ([]SearchStatistics, error) {
+	return ss.findAll(ctx, "search_analytics/find_failing", since, limit)
+}
+
+func (ss *neo4jSearchAnalyticsService) findAll(ctx context.Context, name string, since time.Time, limit int) (_ []SearchStatistics, err error) {
+	ctx, cancel := ss.options.withDeadline(ctx, List)
+	defer cancel()
+	session := ss.driver.NewSession(ctx, ss.options.sessionConfig(ctx, neo4j.AccessModeRead))
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := ss.options.run(ctx, tx, name, nil, map[string]interface{}{
+			"since": since.UnixMilli(),
+			"limit": limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		searches := make([]SearchStatistics, 0, len(records))
+		for _, record := range records {
+			search, _ := record.Get("search")
+			searches = append(searches, search.(map[string]interface{}))
+		}
+		return searches, nil
+	}, ss.options.txConfig(ctx, List))
+	if err != nil {
+		return nil, err
+	}
+	return result.([]SearchStatistics), nil
+}
+
+// normalizeSearchTerm lowercases the term and collapses its whitespace, so that
+// searches differing by case or spacing are counted together
+func normalizeSearchTerm(term string) string {
+	term = strings.Join(strings.Fields(strings.ToLower(term)), " ")
+	if runes := []rune(term); len(runes) > maxSearchTermLength {
+		term = string(runes[:maxSearchTermLength])
+	}
+	return term
+}
+
+// recordSearch records the search of the first page of a list in the background,
+// so that the analytics never slow down nor fail the search itself
+func recordSearch(ctx context.Context, searches SearchAnalyticsService, list string, page *paging.Paging, results int64) {
+	if page.Query() == "" || page.Skip() > 0 {
+		return
+	}
+	metadata, _ := RequestMetadataFromContext(ctx)
+	go func() {
+		err := searches.Record(context.Background(), metadata.UserId, list, page.Query(), results)
+		if err != nil {
+			log.Printf("could not record the search of %s: %v", list, err)
+		}
+	}()
+}
+
+type searchRecordingMovieService struct {
+	MovieService
+	searches SearchAnalyticsService
+}
+
+// NewSearchRecordingMovieService decorates the provided MovieService to record the
+// searches of the movie list
+func NewSearchRecordingMovieService(inner MovieService, searches SearchAnalyticsService) MovieService {
+	return &searchRecordingMovieService{MovieService: inner, searches: searches}
+}
+
+func (ss *searchRecordingMovieService) FindAll(ctx context.Context, userId string, page *paging.Paging) (PagedResult, error) {
+	result, err := ss.MovieService.FindAll(ctx, userId, page)
+	if err == nil {
+		recordSearch(ctx, ss.searches, SearchedMovies, page, result.Total)
+	}
+	return result, err
+}
+
+type searchRecordingPeopleService struct {
+	PeopleService
+	searches SearchAnalyticsService
+}
+
+// NewSearchRecordingPeopleService decorates the provided PeopleService to record the
+// searches of the people list
+func NewSearchRecordingPeopleService(inner PeopleService, searches SearchAnalyticsService) PeopleService {
+	return &searchRecordingPeopleService{PeopleService: inner, searches: searches}
+}
+
+func (ss *searchRecordingPeopleService) FindAll(ctx context.Context, page *paging.Paging) (PagedResult, error) {
+	result, err := ss.PeopleService.FindAll(ctx, page)
+	if err == nil {
+		recordSearch(ctx, ss.searches, SearchedPeople, page, result.Total)
+	}
+	return result, err
+}
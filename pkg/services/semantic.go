@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/embeddings"
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j-graphacademy/neoflix/pkg/routes/paging"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// MaxSemanticResults is the number of movies nearest to the query the semantic search
+// pages through
+const MaxSemanticResults = 100
+
+// WithEmbedder lets SearchSemantic rank the movies by the similarity of their plot to
+// the query, and UpdatePlotEmbeddings embed their plots, once the vector index of the
+// plots exists, see migrations.EnsurePlotIndex
+func WithEmbedder(embedder embeddings.Embedder) Option {
+	return func(options *serviceOptions) {
+		options.embedder = embedder
+	}
+}
+
+// SearchSemantic returns a paginated list of the movies whose plot is the closest in
+// meaning to the query, among the MaxSemanticResults nearest ones in the vector index of
+// the plot embeddings, along with their `relevance` score, the most relevant first.
+//
+// Without an embedder, see WithEmbedder, the movies are searched by Search instead.
+//
+// If a userId value is supplied, a `favorite` boolean property should be returned to
+// signify whether the user has added the movie to their "My Favorites" list.
+func (ms *neo4jMovieService) SearchSemantic(ctx context.Context, query, userId string, page *paging.Paging) (_ PagedResult, err error) {
+	if ms.options.embedder == nil {
+		return ms.Search(ctx, query, userId, page)
+	}
+	// the query is embedded before the transaction, which the driver may retry
+	embedded, err := ms.options.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return PagedResult{}, err
+	}
+	if isZeroVector(embedded[0]) {
+		// e.g. a query without words, which no plot is similar to
+		return ms.Search(ctx, query, userId, page)
+	}
+
+	ctx, cancel := ms.options.withDeadline(ctx, List)
+	defer cancel()
+	session := ms.driver.NewSession(ctx, ms.options.sessionConfig(ctx, neo4j.AccessModeRead))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	results, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		favorites, err := ms.userFavorites(ctx, tx, userId)
+		if err != nil {
+			return nil, err
+		}
+		excludedWarnings, err := getUserExcludedContentWarnings(ctx, tx, ms.options.catalog, userId)
+		if err != nil {
+			return nil, err
+		}
+
+		fragments := map[string]string{
+			"title": ms.options.properties.datasetProperty("Movie", "title"),
+		}
+		params := map[string]interface{}{
+			"k":                MaxSemanticResults,
+			"embedding":        embedded[0],
+			"skip":             page.Skip(),
+			"limit":            page.Limit(),
+			"favorites":        favorites,
+			"excludedWarnings": excludedWarnings,
+		}
+		if err := ms.options.countAll(ctx, tx, page, "movies/count_search_semantic", fragments, params); err != nil {
+			return nil, err
+		}
+		result, err := ms.options.run(ctx, tx, "movies/search_semantic", fragments, params)
+		if err != nil {
+			return nil, err
+		}
+
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		results := make([]Movie, 0, len(records))
+		for _, record := range records {
+			movie, _ := record.Get("movie")
+			results = append(results, ms.options.properties.project("Movie", movie.(map[string]interface{})))
+		}
+
+		return results, nil
+	}, ms.options.txConfig(ctx, List))
+
+	if err != nil {
+		return PagedResult{}, err
+	}
+	return newPagedResult(page, results.([]Movie)), nil
+}
+
+// UpdatePlotEmbeddings stores the embedding of the plot of up to limit movies whose plot
+// was not embedded yet, or was edited since, as their `plotEmbedding`, and returns the
+// number of updated movies.
+// Without an embedder, see WithEmbedder, no movie is updated.
+func (ms *neo4jMovieService) UpdatePlotEmbeddings(ctx context.Context, limit int) (_ int, err error) {
+	if ms.options.embedder == nil {
+		return 0, nil
+	}
+	ctx, cancel := ms.options.withDeadline(ctx, Export)
+	defer cancel()
+	session := ms.driver.NewSession(ctx, ms.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	fragments := map[string]string{
+		"plot": ms.options.properties.datasetProperty("Movie", "plot"),
+	}
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := ms.options.run(ctx, tx, "movies/find_all_unembedded", fragments, map[string]interface{}{
+			"limit": limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result.Collect(ctx)
+	}, ms.options.txConfig(ctx, Export))
+	if err != nil {
+		return 0, err
+	}
+	records := result.([]*neo4j.Record)
+	if len(records) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]interface{}, 0, len(records))
+	plots := make([]string, 0, len(records))
+	for _, record := range records {
+		id, _ := record.Get("id")
+		plot, _ := record.Get("plot")
+		ids = append(ids, id)
+		plots = append(plots, plot.(string))
+	}
+	// the plots are embedded between the transactions, so that a retry of the write does
+	// not embed them again
+	embedded, err := ms.options.embedder.Embed(ctx, plots)
+	if err != nil {
+		return 0, err
+	}
+	movies := make([]map[string]interface{}, 0, len(records))
+	for i, id := range ids {
+		movies = append(movies, map[string]interface{}{
+			"id":        id,
+			"plot":      plots[i],
+			"embedding": embedded[i],
+		})
+	}
+
+	_, err = session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := ms.options.run(ctx, tx, "movies/save_plot_embeddings", fragments, map[string]interface{}{
+			"movies": movies,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result.Consume(ctx)
+	}, ms.options.txConfig(ctx, Export))
+	if err != nil {
+		return 0, err
+	}
+	return len(movies), nil
+}
+
+func isZeroVector(vector []float64) bool {
+	for _, value := range vector {
+		if value != 0 {
+			return false
+		}
+	}
+	return true
+}
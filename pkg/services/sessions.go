@@ -0,0 +1,31 @@
+package services
+
+import (
+	"context"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// SessionFactory opens the sessions the services run their transactions in.
+// neo4j.DriverWithContext implements it, so that applications embedding the services
+// can pass their own driver, configured as they see fit, or decorate it, e.g. to trace
+// the sessions or pin them to a database.
+type SessionFactory interface {
+	NewSession(ctx context.Context, config neo4j.SessionConfig) neo4j.SessionWithContext
+}
+
+// sessionConfig returns the configuration of the sessions running the transactions of
+// the access mode, so that the reads are routed to the followers and read replicas of a
+// cluster, and the writes to its leader.
+// The sessions wait for the bookmarks of the BookmarkManager of the context, if any, and
+// the write sessions hand it the bookmarks of their transactions.
+func (o serviceOptions) sessionConfig(ctx context.Context, mode neo4j.AccessMode) neo4j.SessionConfig {
+	config := neo4j.SessionConfig{AccessMode: mode}
+	if manager, found := BookmarkManagerFromContext(ctx); found {
+		config.BookmarkManager = manager
+		if mode == neo4j.AccessModeRead {
+			config.BookmarkManager = readOnlyBookmarkManager{manager}
+		}
+	}
+	return config
+}
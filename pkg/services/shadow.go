@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"reflect"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// ShadowMismatch describes a shadow statement whose results differ from its primary statement
+type ShadowMismatch struct {
+	Statement string
+	Diff      string
+}
+
+type shadowReads struct {
+	driver     SessionFactory
+	sampleRate float64
+	report     func(ShadowMismatch)
+}
+
+// WithShadowReads enables the shadow-read mode: for the provided fraction of the calls
+// running a statement with a shadow rewrite (between 0 and 1), the rewrite is run as well
+// in a separate read transaction and any difference between both results is logged.
+// The result of the primary statement is always the one returned.
+func WithShadowReads(driver SessionFactory, sampleRate float64) Option {
+	return func(options *serviceOptions) {
+		options.shadow = &shadowReads{
+			driver:     driver,
+			sampleRate: sampleRate,
+			// the logger is looked up when reporting, as a later option may override it
+			report: func(mismatch ShadowMismatch) {
+				options.logger.Printf("shadow mismatch for %s: %s", mismatch.Statement, mismatch.Diff)
+			},
+		}
+	}
+}
+
+// run runs the named statement rendered with the fragments in the transaction,
+// and shadows it with its rewrite when sampled
+func (o serviceOptions) run(ctx context.Context,
+	tx neo4j.ManagedTransaction,
+	name string,
+	fragments map[string]string,
+	params map[string]interface{}) (neo4j.ResultWithContext, error) {
+
+	result, err := tx.Run(trackQuery(ctx, name), o.cypher(name, fragments), params)
+	if err != nil || o.shadow == nil || rand.Float64() >= o.shadow.sampleRate {
+		return result, err
+	}
+	shadow, found := o.catalog.Shadow(name)
+	if !found {
+		return result, nil
+	}
+
+	keys, err := result.Keys()
+	if err != nil {
+		return nil, err
+	}
+	records, err := result.Collect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := result.Consume(ctx)
+	if err != nil {
+		return nil, err
+	}
+	metadata, _ := RequestMetadataFromContext(ctx)
+	go o.shadow.compare(ContextWithRequestMetadata(context.Background(), metadata),
+		name, shadow.Render(fragments), params, records)
+	return &replayedResult{ResultWithContext: result, keys: keys, records: records, summary: summary}, nil
+}
+
+func (sr *shadowReads) compare(ctx context.Context,
+	name, shadowText string,
+	params map[string]interface{},
+	expected []*neo4j.Record) {
+
+	actual, err := sr.collect(ctx, shadowText, params)
+	if err != nil {
+		sr.report(ShadowMismatch{Statement: name, Diff: fmt.Sprintf("shadow failed: %v", err)})
+		return
+	}
+	if diff := diffRecords(expected, actual); diff != "" {
+		sr.report(ShadowMismatch{Statement: name, Diff: diff})
+	}
+}
+
+func (sr *shadowReads) collect(ctx context.Context, text string, params map[string]interface{}) (_ []*neo4j.Record, err error) {
+	session := sr.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	records, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := tx.Run(ctx, text, params)
+		if err != nil {
+			return nil, err
+		}
+		return result.Collect(ctx)
+	}, neo4j.WithTxMetadata(txMetadata(ctx)))
+	if err != nil {
+		return nil, err
+	}
+	return records.([]*neo4j.Record), nil
+}
+
+// diffRecords describes the first difference between both record lists,
+// or returns an empty string if they are equal
+func diffRecords(expected, actual []*neo4j.Record) string {
+	if len(expected) != len(actual) {
+		return fmt.Sprintf("expected %d record(s), got %d", len(expected), len(actual))
+	}
+	for i := range expected {
+		expectedValues, actualValues := recordValues(expected[i]), recordValues(actual[i])
+		if !reflect.DeepEqual(expectedValues, actualValues) {
+			return fmt.Sprintf("record %d: expected %s, got %s", i, toJson(expectedValues), toJson(actualValues))
+		}
+	}
+	return ""
+}
+
+func recordValues(record *neo4j.Record) map[string]interface{} {
+	values := make(map[string]interface{}, len(record.Keys))
+	for i, key := range record.Keys {
+		values[key] = record.Values[i]
+	}
+	return values
+}
+
+func toJson(value interface{}) string {
+	result, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(result)
+}
+
+// replayedResult is a neo4j.ResultWithContext over records which were already fetched.
+// It embeds the fully consumed result it replays, which only provides the unexported
+// methods of the interface.
+type replayedResult struct {
+	neo4j.ResultWithContext
+	keys    []string
+	records []*neo4j.Record
+	summary neo4j.ResultSummary
+	current *neo4j.Record
+	err     error
+}
+
+func (rr *replayedResult) Keys() ([]string, error) {
+	return rr.keys, nil
+}
+
+func (rr *replayedResult) Next(ctx context.Context) bool {
+	return rr.NextRecord(ctx, nil)
+}
+
+func (rr *replayedResult) NextRecord(_ context.Context, record **neo4j.Record) bool {
+	rr.current = nil
+	if len(rr.records) > 0 {
+		rr.current, rr.records = rr.records[0], rr.records[1:]
+	}
+	if record != nil {
+		*record = rr.current
+	}
+	return rr.current != nil
+}
+
+func (rr *replayedResult) Peek(ctx context.Context) bool {
+	return rr.PeekRecord(ctx, nil)
+}
+
+func (rr *replayedResult) PeekRecord(_ context.Context, record **neo4j.Record) bool {
+	if len(rr.records) == 0 {
+		return false
+	}
+	if record != nil {
+		*record = rr.records[0]
+	}
+	return true
+}
+
+func (rr *replayedResult) Err() error {
+	return rr.err
+}
+
+func (rr *replayedResult) Record() *neo4j.Record {
+	return rr.current
+}
+
+func (rr *replayedResult) Records(ctx context.Context) func(yield func(*neo4j.Record, error) bool) {
+	return func(yield func(*neo4j.Record, error) bool) {
+		for rr.Next(ctx) {
+			if !yield(rr.current, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (rr *replayedResult) Collect(context.Context) ([]*neo4j.Record, error) {
+	if rr.err != nil {
+		return nil, rr.err
+	}
+	records := rr.records
+	rr.records, rr.current = nil, nil
+	return records, nil
+}
+
+func (rr *replayedResult) Single(context.Context) (*neo4j.Record, error) {
+	if rr.err != nil {
+		return nil, rr.err
+	}
+	records := rr.records
+	rr.records = nil
+	switch len(records) {
+	case 0:
+		rr.err = &neo4j.UsageError{Message: "Result contains no more records"}
+		return nil, rr.err
+	case 1:
+		rr.current = records[0]
+		return rr.current, nil
+	default:
+		rr.err = &neo4j.UsageError{Message: "Result contains more than one record"}
+		rr.current = nil
+		return nil, rr.err
+	}
+}
+
+func (rr *replayedResult) Consume(context.Context) (neo4j.ResultSummary, error) {
+	if rr.err != nil {
+		return nil, rr.err
+	}
+	rr.records, rr.current = nil, nil
+	return rr.summary, nil
+}
+
+func (rr *replayedResult) IsOpen() bool {
+	return len(rr.records) > 0
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+func TestDiffRecords(t *testing.T) {
+	record := func(title string) *neo4j.Record {
+		return &neo4j.Record{Keys: []string{"title"}, Values: []interface{}{title}}
+	}
+
+	if diff := diffRecords([]*neo4j.Record{record("Heat")}, []*neo4j.Record{record("Heat")}); diff != "" {
+		t.Errorf("expected no diff, got %q", diff)
+	}
+	if diff := diffRecords([]*neo4j.Record{record("Heat")}, nil); diff != "expected 1 record(s), got 0" {
+		t.Errorf("unexpected diff %q", diff)
+	}
+	diff := diffRecords([]*neo4j.Record{record("Heat")}, []*neo4j.Record{record("Ronin")})
+	if !strings.Contains(diff, `{"title":"Heat"}`) || !strings.Contains(diff, `{"title":"Ronin"}`) {
+		t.Errorf("unexpected diff %q", diff)
+	}
+}
+
+func TestReplayedResult(t *testing.T) {
+	records := []*neo4j.Record{
+		{Keys: []string{"n"}, Values: []interface{}{int64(1)}},
+		{Keys: []string{"n"}, Values: []interface{}{int64(2)}},
+	}
+
+	ctx := context.Background()
+	var values []interface{}
+	result := &replayedResult{records: records}
+	for result.Next(ctx) {
+		values = append(values, result.Record().Values[0])
+	}
+	if len(values) != 2 || result.Err() != nil {
+		t.Errorf("unexpected iteration: %v %v", values, result.Err())
+	}
+
+	if _, err := (&replayedResult{records: records}).Single(ctx); err == nil {
+		t.Error("expected Single to fail on several records")
+	}
+	if single, err := (&replayedResult{records: records[:1]}).Single(ctx); err != nil || single != records[0] {
+		t.Errorf("unexpected Single: %v %v", single, err)
+	}
+}
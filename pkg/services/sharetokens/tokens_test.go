@@ -0,0 +1,44 @@
+package sharetokens_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/services/sharetokens"
+)
+
+func TestVerify(outer *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	signer := sharetokens.New("secret")
+	claims := sharetokens.Claims{UserId: "user-1", List: "favorites", ExpiresAt: now.Add(time.Hour)}
+	token, err := signer.Sign(claims)
+	if err != nil {
+		outer.Fatal(err)
+	}
+
+	outer.Run("signed tokens are verified until they expire", func(t *testing.T) {
+		verified, err := signer.Verify(token, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if verified.UserId != claims.UserId || verified.List != claims.List || !verified.ExpiresAt.Equal(claims.ExpiresAt) {
+			t.Fatalf("expected %v, got %v", claims, verified)
+		}
+		if _, err := signer.Verify(token, now.Add(time.Hour)); err != sharetokens.ErrExpired {
+			t.Fatalf("expected expired token, got %v", err)
+		}
+	})
+
+	outer.Run("tampered tokens are rejected", func(t *testing.T) {
+		forged, _ := sharetokens.New("other").Sign(claims)
+		payload, signature, _ := strings.Cut(token, ".")
+		other, _ := signer.Sign(sharetokens.Claims{UserId: "user-2", List: "favorites", ExpiresAt: claims.ExpiresAt})
+		otherPayload, _, _ := strings.Cut(other, ".")
+		for _, invalid := range []string{forged, payload, otherPayload + "." + signature, token + "x", ""} {
+			if _, err := signer.Verify(invalid, now); err != sharetokens.ErrInvalid {
+				t.Fatalf("expected invalid token for %q, got %v", invalid, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package services
+
+// SimilarityWeights tunes the score of similar movies:
+//
+//	score = (Genre * genres + Actor * actors + Director * directors) * imdbRating ^ Rating
+//
+// where genres, actors and directors are the numbers of each in common with the movie.
+// A zero Rating weight ignores the rating, the default of 1 multiplies by it.
+//
+// For a User, the score is personalized by adding, before multiplying by the rating,
+// Taste times the genres, actors and directors the movie shares with the movies the
+// User rated at least the minimum rating of the recommendations, weighted the same way.
+// A zero Taste weight ranks the movies the same for all users.
+type SimilarityWeights struct {
+	Genre    float64
+	Actor    float64
+	Director float64
+	Rating   float64
+	Taste    float64
+}
+
+// DefaultSimilarityWeights returns weights giving the same importance to genres, actors and directors
+func DefaultSimilarityWeights() SimilarityWeights {
+	return SimilarityWeights{
+		Genre:    1,
+		Actor:    1,
+		Director: 1,
+		Rating:   1,
+		Taste:    1,
+	}
+}
+
+// MovieSimilarityOptions tunes how similar movies are ranked.
+// Each weight set overrides the configured one.
+type MovieSimilarityOptions struct {
+	Genre    *float64
+	Actor    *float64
+	Director *float64
+	Rating   *float64
+	Taste    *float64
+}
+
+// IsZero returns true when no weight is overridden
+func (mso MovieSimilarityOptions) IsZero() bool {
+	return mso.Genre == nil && mso.Actor == nil && mso.Director == nil && mso.Rating == nil && mso.Taste == nil
+}
+
+func (mso MovieSimilarityOptions) apply(weights SimilarityWeights) SimilarityWeights {
+	for _, override := range []struct {
+		value  *float64
+		weight *float64
+	}{
+		{mso.Genre, &weights.Genre},
+		{mso.Actor, &weights.Actor},
+		{mso.Director, &weights.Director},
+		{mso.Rating, &weights.Rating},
+		{mso.Taste, &weights.Taste},
+	} {
+		if override.value != nil {
+			*override.weight = *override.value
+		}
+	}
+	return weights
+}
+
+// WithSimilarityWeights overrides the default weights used to score similar movies
+func WithSimilarityWeights(weights SimilarityWeights) Option {
+	return func(options *serviceOptions) {
+		options.similarityWeights = weights
+	}
+}
+
+// DefaultSimilarityMaxFanOut is the number of movies each genre, actor and director of
+// a movie contributes at most to its similar movies, unless configured otherwise
+const DefaultSimilarityMaxFanOut = 500
+
+// WithSimilarityMaxFanOut caps the number of movies each genre, actor and director of
+// a movie contributes to its similar movies, the best rated ones, so that hubs such as
+// popular genres keep the cost of the similarity queries predictable
+func WithSimilarityMaxFanOut(max int) Option {
+	return func(options *serviceOptions) {
+		options.maxFanOut = max
+	}
+}
+
+// WithGds lets FindAllBySimilarityGDS score similar movies with the Graph Data Science
+// library, when it is available on the server
+func WithGds(available bool) Option {
+	return func(options *serviceOptions) {
+		options.gds = available
+	}
+}
+
+func (sw SimilarityWeights) params() map[string]interface{} {
+	return map[string]interface{}{
+		"genre":    sw.Genre,
+		"actor":    sw.Actor,
+		"director": sw.Director,
+		"rating":   sw.Rating,
+		"taste":    sw.Taste,
+	}
+}
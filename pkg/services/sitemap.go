@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// SitemapLabel is a node label whose nodes are listed in the sitemap
+type SitemapLabel string
+
+const (
+	SitemapMovies SitemapLabel = "Movie"
+	SitemapPeople SitemapLabel = "Person"
+)
+
+type SitemapService interface {
+	Count(ctx context.Context, label SitemapLabel) (int64, error)
+
+	FindAllIds(ctx context.Context, label SitemapLabel, skip, limit int) ([]string, error)
+}
+
+type neo4jSitemapService struct {
+	loader  *fixtures.FixtureLoader
+	driver  SessionFactory
+	options serviceOptions
+}
+
+func NewSitemapService(loader *fixtures.FixtureLoader, driver SessionFactory, opts ...Option) SitemapService {
+	return &neo4jSitemapService{
+		loader:  loader,
+		driver:  driver,
+		options: newServiceOptions(opts),
+	}
+}
+
+// Count returns the number of nodes with the provided label and a `tmdbId`
+func (ss *neo4jSitemapService) Count(ctx context.Context, label SitemapLabel) (_ int64, err error) {
+	ctx, cancel := ss.options.withDeadline(ctx, FastLookup)
+	defer cancel()
+	session := ss.driver.NewSession(ctx, ss.options.sessionConfig(ctx, neo4j.AccessModeRead))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := ss.options.run(ctx, tx, "sitemap/count", map[string]string{"label": string(label)}, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		count, _ := record.Get("count")
+		return count, nil
+	}, ss.options.txConfig(ctx, FastLookup))
+
+	if err != nil {
+		return 0, err
+	}
+	return result.(int64), nil
+}
+
+// FindAllIds returns a page of `tmdbId` of the nodes with the provided label,
+// in a stable order so that consecutive pages do not overlap
+func (ss *neo4jSitemapService) FindAllIds(ctx context.Context, label SitemapLabel, skip, limit int) (_ []string, err error) {
+	ctx, cancel := ss.options.withDeadline(ctx, List)
+	defer cancel()
+	session := ss.driver.NewSession(ctx, ss.options.sessionConfig(ctx, neo4j.AccessModeRead))
+
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := ss.options.run(ctx, tx, "sitemap/find_all_ids", map[string]string{"label": string(label)},
+			map[string]interface{}{
+				"skip":  skip,
+				"limit": limit,
+			})
+		if err != nil {
+			return nil, err
+		}
+
+		var ids []string
+		for result.Next(ctx) {
+			id, _ := result.Record().Get("id")
+			ids = append(ids, id.(string))
+		}
+		return ids, result.Err()
+	}, ss.options.txConfig(ctx, List))
+
+	if err != nil {
+		return nil, err
+	}
+	return result.([]string), nil
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j-graphacademy/neoflix/pkg/routes/paging"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// MovieStreamOptions configures the stream of MovieService.StreamAll
+type MovieStreamOptions struct {
+	// Sort is one of the sortable movie attributes, `title` by default
+	Sort  string
+	Order paging.Order
+	// FetchSize is the number of records pulled from the server at a time, left to the
+	// driver when 0
+	FetchSize int
+}
+
+// MovieIterator yields the movies of a stream one at a time.
+// It holds a session and a transaction open until it is closed, which it must be
+// once done with, whether or not all the movies were read.
+type MovieIterator interface {
+	// Next advances to the next movie, and returns false once the stream is exhausted
+	// or failed
+	Next(ctx context.Context) bool
+
+	// Movie returns the current movie
+	Movie() Movie
+
+	// Err returns the error the stream failed with, if any
+	Err() error
+
+	Close(ctx context.Context) error
+}
+
+// StreamAll streams all the movies in the order of the options, with a `favorite` flag
+// for the user, without holding them in memory, so that exports can walk the whole
+// catalog.
+// The movies with content warnings excluded by the user are skipped, as in FindAll.
+//
+// If the sort is not one of the sortable movie attributes, a 400 error is returned.
+func (ms *neo4jMovieService) StreamAll(ctx context.Context, userId string, opts MovieStreamOptions) (_ MovieIterator, err error) {
+	page := paging.NewPaging("", opts.Sort, opts.Order, 0, 0)
+	fragments, err := ms.options.listFragments("Movie", paging.MovieSortableAttributes(), page)
+	if err != nil {
+		return nil, err
+	}
+
+	config := ms.options.sessionConfig(ctx, neo4j.AccessModeRead)
+	config.FetchSize = opts.FetchSize
+	session := ms.driver.NewSession(ctx, config)
+	tx, err := session.BeginTransaction(ctx, ms.options.txConfig(ctx, Export))
+	if err != nil {
+		return nil, ioutils.DeferredContextClose(ctx, session, err)
+	}
+	iterator := &movieIterator{session: session, tx: tx, properties: ms.options.properties}
+	defer func() {
+		if err != nil {
+			err = ioutils.DeferredContextClose(ctx, iterator, err)
+		}
+	}()
+
+	favorites, err := ms.userFavorites(ctx, tx, userId)
+	if err != nil {
+		return nil, err
+	}
+	excludedWarnings, err := getUserExcludedContentWarnings(ctx, tx, ms.options.catalog, userId)
+	if err != nil {
+		return nil, err
+	}
+	// streams are never shadowed, as comparing their results would collect them
+	result, err := tx.Run(trackQuery(ctx, "movies/stream_all"), ms.options.cypher("movies/stream_all", fragments),
+		map[string]interface{}{
+			"favorites":        favorites,
+			"excludedWarnings": excludedWarnings,
+		})
+	if err != nil {
+		return nil, err
+	}
+	iterator.result = result
+	return iterator, nil
+}
+
+type movieIterator struct {
+	session    neo4j.SessionWithContext
+	tx         neo4j.ExplicitTransaction
+	result     neo4j.ResultWithContext
+	properties PropertyMapping
+	movie      Movie
+}
+
+func (mi *movieIterator) Next(ctx context.Context) bool {
+	if !mi.result.Next(ctx) {
+		mi.movie = nil
+		return false
+	}
+	movie, _ := mi.result.Record().Get("movie")
+	mi.movie = mi.properties.project("Movie", movie.(map[string]interface{}))
+	return true
+}
+
+func (mi *movieIterator) Movie() Movie {
+	return mi.movie
+}
+
+func (mi *movieIterator) Err() error {
+	return mi.result.Err()
+}
+
+// Close rolls the read transaction back and closes the session
+func (mi *movieIterator) Close(ctx context.Context) error {
+	return ioutils.DeferredContextClose(ctx, mi.session, mi.tx.Close(ctx))
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/dbtype"
+)
+
+// MovieSummary is a flat description of a Movie, short enough to be read aloud
+type MovieSummary = map[string]interface{}
+
+// newMovieSummary flattens the summary returned by the movies/find_summary_by_id statement
+func newMovieSummary(raw map[string]interface{}) MovieSummary {
+	plot, _ := raw["plot"].(string)
+	directors := toStrings(raw["directors"])
+	var director interface{}
+	if len(directors) > 0 {
+		director = strings.Join(directors, " and ")
+	}
+	return MovieSummary{
+		"tmdbId":   raw["tmdbId"],
+		"title":    raw["title"],
+		"year":     releaseYear(raw["released"]),
+		"plot":     firstSentence(plot),
+		"actors":   toStrings(raw["actors"]),
+		"director": director,
+		"rating":   raw["rating"],
+	}
+}
+
+// releaseYear returns the year of a release date, stored as a YYYY-MM-DD string, a date
+// or a year depending on the dataset, or nil when unknown
+func releaseYear(released interface{}) interface{} {
+	switch value := released.(type) {
+	case string:
+		if len(value) >= 4 {
+			return value[:4]
+		}
+	case dbtype.Date:
+		return value.Time().Format("2006")
+	case int64:
+		return value
+	}
+	return nil
+}
+
+// abbreviations lists the words ending with a period which do not end sentences
+var abbreviations = map[string]bool{
+	"Mr": true, "Mrs": true, "Ms": true, "Dr": true, "St": true, "Jr": true, "Sr": true,
+	"Lt": true, "Capt": true, "Col": true, "Gen": true, "Prof": true, "vs": true,
+}
+
+// firstSentence returns the text up to the end of its first sentence
+func firstSentence(text string) string {
+	text = strings.TrimSpace(text)
+	for i, r := range text {
+		if r != '.' && r != '!' && r != '?' {
+			continue
+		}
+		next := i + 1
+		if next < len(text) && !unicode.IsSpace(rune(text[next])) {
+			continue
+		}
+		if r == '.' && abbreviations[lastWord(text[:i])] {
+			continue
+		}
+		return text[:next]
+	}
+	return text
+}
+
+func lastWord(text string) string {
+	return text[strings.LastIndexFunc(text, unicode.IsSpace)+1:]
+}
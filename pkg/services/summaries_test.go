@@ -0,0 +1,17 @@
+package services
+
+import "testing"
+
+func TestFirstSentence(t *testing.T) {
+	for text, expected := range map[string]string{
+		"A thief steals secrets. He is offered a chance.": "A thief steals secrets.",
+		"Who is Keyser Söze? Nobody knows.":               "Who is Keyser Söze?",
+		"Set in 1.5 worlds at once":                       "Set in 1.5 worlds at once",
+		" Mr. Smith goes to Washington! Again.":           "Mr. Smith goes to Washington!",
+		"":                                                "",
+	} {
+		if sentence := firstSentence(text); sentence != expected {
+			t.Errorf("expected %q for %q, got %q", expected, text, sentence)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/fixtures"
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// UserExport is the neighborhood of a User exported for support investigations
+type UserExport = map[string]interface{}
+
+// RedactableFields are the personal fields of the users an export can redact
+var RedactableFields = []string{"email", "name"}
+
+// redactedValue replaces the redacted fields of the exported users
+const redactedValue = "[redacted]"
+
+type SupportService interface {
+	ExportUser(ctx context.Context, userId, adminId string, redacted []string) (UserExport, error)
+}
+
+type neo4jSupportService struct {
+	loader  *fixtures.FixtureLoader
+	driver  SessionFactory
+	options serviceOptions
+}
+
+func NewSupportService(loader *fixtures.FixtureLoader, driver SessionFactory, opts ...Option) SupportService {
+	return &neo4jSupportService{
+		loader:  loader,
+		driver:  driver,
+		options: newServiceOptions(opts),
+	}
+}
+
+// ExportUser returns the User along with their favorites, ratings and reviews, the users
+// they follow, are followed by and blocked, and records the export in the audit log
+// along with the admin who made it, in the same transaction.
+// The redacted fields, among RedactableFields, are hidden for all the exported users,
+// and passwords are never exported.
+//
+// If the User cannot be found, a 404 error is returned.
+func (ss *neo4jSupportService) ExportUser(ctx context.Context, userId, adminId string, redacted []string) (_ UserExport, err error) {
+	for _, field := range redacted {
+		if !isRedactable(field) {
+			return nil, NewDomainError(400, "Only email and name can be redacted", map[string]interface{}{
+				"field": field,
+			})
+		}
+	}
+
+	ctx, cancel := ss.options.withDeadline(ctx, Export)
+	defer cancel()
+	session := ss.driver.NewSession(ctx, ss.options.sessionConfig(ctx, neo4j.AccessModeWrite))
+	defer func() {
+		err = ioutils.DeferredContextClose(ctx, session, err)
+	}()
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := ss.options.run(ctx, tx, "support/export_user", nil, map[string]interface{}{
+			"userId": userId,
+		})
+		if err != nil {
+			return nil, err
+		}
+		records, err := result.Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, NewDomainError(404, "User not found", map[string]interface{}{"userId": userId})
+		}
+		rawExport, _ := records[0].Get("export")
+		export := rawExport.(map[string]interface{})
+
+		result, err = ss.options.run(ctx, tx, "support/audit_export", nil, map[string]interface{}{
+			"userId":   userId,
+			"adminId":  adminId,
+			"redacted": redacted,
+		})
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		entry, _ := record.Get("entry")
+		export["audit"] = entry
+		return redactExport(export, redacted), nil
+	}, ss.options.txConfig(ctx, Export))
+
+	if err != nil {
+		return nil, err
+	}
+	return result.(UserExport), nil
+}
+
+func isRedactable(field string) bool {
+	for _, redactable := range RedactableFields {
+		if field == redactable {
+			return true
+		}
+	}
+	return false
+}
+
+// redactExport drops the password of the exported User and hides the redacted fields
+// of all the exported users
+func redactExport(export UserExport, redacted []string) UserExport {
+	redact := func(user interface{}) {
+		properties, ok := user.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for _, field := range redacted {
+			if _, found := properties[field]; found {
+				properties[field] = redactedValue
+			}
+		}
+	}
+
+	if user, ok := export["user"].(map[string]interface{}); ok {
+		delete(user, "password")
+		redact(user)
+	}
+	for _, relationship := range []string{"follows", "followers"} {
+		entries, _ := export[relationship].([]interface{})
+		for _, entry := range entries {
+			if properties, ok := entry.(map[string]interface{}); ok {
+				redact(properties["user"])
+			}
+		}
+	}
+	blocks, _ := export["blocks"].([]interface{})
+	for _, blocked := range blocks {
+		redact(blocked)
+	}
+	return export
+}
@@ -0,0 +1,30 @@
+package services
+
+import "testing"
+
+func TestRedactExport(t *testing.T) {
+	export := redactExport(UserExport{
+		"user": map[string]interface{}{"userId": "1", "email": "ada@example.com", "name": "Ada", "password": "hash"},
+		"follows": []interface{}{
+			map[string]interface{}{"user": map[string]interface{}{"userId": "2", "email": "bob@example.com", "name": "Bob"}},
+		},
+		"followers": []interface{}{},
+		"blocks":    []interface{}{map[string]interface{}{"userId": "3", "email": nil, "name": "Eve"}},
+	}, []string{"email"})
+
+	user := export["user"].(map[string]interface{})
+	if _, found := user["password"]; found {
+		t.Error("expected the password to be dropped")
+	}
+	if user["email"] != redactedValue || user["name"] != "Ada" {
+		t.Errorf("unexpected user %v", user)
+	}
+	followed := export["follows"].([]interface{})[0].(map[string]interface{})["user"].(map[string]interface{})
+	if followed["email"] != redactedValue || followed["userId"] != "2" {
+		t.Errorf("unexpected followed user %v", followed)
+	}
+	blocked := export["blocks"].([]interface{})[0].(map[string]interface{})
+	if blocked["email"] != redactedValue || blocked["name"] != "Eve" {
+		t.Errorf("unexpected blocked user %v", blocked)
+	}
+}
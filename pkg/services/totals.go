@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/routes/paging"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// PagedResult is a page of a list, along with the total number of results across all
+// pages, counted in the same transaction as the page
+type PagedResult struct {
+	Items []map[string]interface{} `json:"items"`
+	Total int64                    `json:"total"`
+	Skip  int                      `json:"skip"`
+	Limit int                      `json:"limit"`
+}
+
+// newPagedResult returns the items of the page along with the total recorded by countAll
+func newPagedResult(page *paging.Paging, items []map[string]interface{}) PagedResult {
+	total, _ := page.Total()
+	return PagedResult{
+		Items: items,
+		Total: total,
+		Skip:  page.Skip(),
+		Limit: page.Limit(),
+	}
+}
+
+// countAll runs the named statement counting the `total` results of a list across all
+// pages, and records it on the page so that the routes can link to the last page.
+// Count statements are named after the list they count, e.g. `movies/count_all` for
+// `movies/find_all`, and take the same fragments and parameters.
+func (o serviceOptions) countAll(ctx context.Context, tx neo4j.ManagedTransaction, page *paging.Paging, name string, fragments map[string]string, params map[string]interface{}) error {
+	result, err := o.run(ctx, tx, name, fragments, params)
+	if err != nil {
+		return err
+	}
+	record, err := result.Single(ctx)
+	if err != nil {
+		return err
+	}
+	total, _ := record.Get("total")
+	page.SetTotal(total.(int64))
+	return nil
+}
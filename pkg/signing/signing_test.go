@@ -0,0 +1,27 @@
+package signing_test
+
+import (
+	"testing"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/signing"
+)
+
+func TestVerify(t *testing.T) {
+	shares := signing.New("secret", "share-tokens")
+	flags := signing.New("secret", "flags")
+	token, err := shares.Sign(map[string]string{"list": "favorites"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var value map[string]string
+	if err := shares.Verify(token, &value); err != nil || value["list"] != "favorites" {
+		t.Errorf("expected the token to be verified, got %v (%v)", value, err)
+	}
+	if err := flags.Verify(token, &value); err != signing.ErrInvalid {
+		t.Errorf("expected the token to be rejected for another purpose, got %v", err)
+	}
+	if err := shares.Verify(token[1:], &value); err != signing.ErrInvalid {
+		t.Errorf("expected a tampered token to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/ioutils"
+)
+
+type localStorage struct {
+	directory string
+	urlPrefix string
+}
+
+// NewLocalStorage stores files in the provided directory.
+// Files are expected to be served by the application under urlPrefix.
+func NewLocalStorage(directory, urlPrefix string) Storage {
+	return &localStorage{directory: directory, urlPrefix: urlPrefix}
+}
+
+func (ls *localStorage) Put(key string, _ string, content io.Reader) (_ string, err error) {
+	target := filepath.Join(ls.directory, filepath.FromSlash(path.Clean("/"+key)))
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return "", err
+	}
+	file, err := os.Create(target)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		err = ioutils.DeferredClose(file, err)
+	}()
+	if _, err := io.Copy(file, content); err != nil {
+		return "", err
+	}
+	return path.Join(ls.urlPrefix, key), nil
+}
@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type S3Config struct {
+	Bucket          string
+	Region          string
+	AccessKeyId     string
+	SecretAccessKey string
+	// Endpoint overrides the AWS endpoint, e.g. for S3 compatible services like MinIO.
+	// Path-style addressing is used when it is set.
+	Endpoint string
+	// PublicUrl overrides the base URL of the stored objects, e.g. a CDN
+	PublicUrl string
+}
+
+type s3Storage struct {
+	config S3Config
+	client *http.Client
+}
+
+// NewS3Storage stores files in an S3 bucket, with requests signed with AWS Signature Version 4
+func NewS3Storage(config S3Config) Storage {
+	return &s3Storage{config: config, client: http.DefaultClient}
+}
+
+func (s *s3Storage) Put(key string, contentType string, content io.Reader) (string, error) {
+	body, err := ioutil.ReadAll(content)
+	if err != nil {
+		return "", err
+	}
+	objectUrl := s.objectUrl(key)
+	request, err := http.NewRequest("PUT", objectUrl, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Content-Type", contentType)
+	s.sign(request, body, time.Now().UTC())
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+	if response.StatusCode != http.StatusOK {
+		message, _ := ioutil.ReadAll(response.Body)
+		return "", fmt.Errorf("could not upload %s to S3 (status %d): %s", key, response.StatusCode, message)
+	}
+
+	if s.config.PublicUrl != "" {
+		return strings.TrimSuffix(s.config.PublicUrl, "/") + "/" + key, nil
+	}
+	return objectUrl, nil
+}
+
+func (s *s3Storage) objectUrl(key string) string {
+	if s.config.Endpoint != "" {
+		return fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(s.config.Endpoint, "/"), s.config.Bucket, key)
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.config.Bucket, s.config.Region, key)
+}
+
+// sign adds the AWS Signature Version 4 headers to the request
+// See https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+func (s *s3Storage) sign(request *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "content-type;host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		request.URL.RawQuery,
+		"content-type:" + request.Header.Get("Content-Type"),
+		"host:" + request.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", date, s.config.Region)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSha256([]byte("AWS4"+s.config.SecretAccessKey), date)
+	key = hmacSha256(key, s.config.Region)
+	key = hmacSha256(key, "s3")
+	key = hmacSha256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSha256(key, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKeyId, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,8 @@
+package storage
+
+import "io"
+
+// Storage persists uploaded files and returns the public URL they can be fetched from
+type Storage interface {
+	Put(key string, contentType string, content io.Reader) (string, error)
+}
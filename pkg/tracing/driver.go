@@ -0,0 +1,219 @@
+package tracing
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/neo4j-graphacademy/neoflix/pkg/services"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewTracingDriver decorates the driver so that every session is traced by a span named
+// after the service method which opened it, e.g. "neo4jMovieService.FindAll", and
+// every statement its transactions run by a child span named after the statement of the
+// catalog, e.g. "movies/find_all", holding its parameterized text and the number of
+// records read from its result.
+// Parameter values are never recorded.
+func NewTracingDriver(driver neo4j.DriverWithContext) neo4j.DriverWithContext {
+	return &tracingDriver{DriverWithContext: driver}
+}
+
+type tracingDriver struct {
+	neo4j.DriverWithContext
+}
+
+func (td *tracingDriver) NewSession(ctx context.Context, config neo4j.SessionConfig) neo4j.SessionWithContext {
+	attributes := []attribute.KeyValue{attribute.String("db.system", "neo4j")}
+	if config.DatabaseName != "" {
+		attributes = append(attributes, attribute.String("db.name", config.DatabaseName))
+	}
+	ctx, span := Tracer().Start(ctx, callerName(), trace.WithAttributes(attributes...))
+	return &tracingSession{
+		SessionWithContext: td.DriverWithContext.NewSession(ctx, config),
+		ctx:                ctx,
+		span:               span,
+	}
+}
+
+// callerName returns the name of the function which called NewSession, without its
+// package, e.g. "neo4jMovieService.FindAll"
+func callerName() string {
+	pc, _, _, ok := runtime.Caller(2)
+	if !ok {
+		return "session"
+	}
+	name := runtime.FuncForPC(pc).Name()
+	name = name[strings.LastIndex(name, "/")+1:]
+	name = name[strings.Index(name, ".")+1:]
+	return strings.NewReplacer("(*", "", ")", "").Replace(name)
+}
+
+type tracingSession struct {
+	neo4j.SessionWithContext
+	// ctx holds the span of the session, the parent of the spans of its statements
+	ctx  context.Context
+	span trace.Span
+}
+
+func (ts *tracingSession) ExecuteRead(ctx context.Context, work neo4j.ManagedTransactionWork, configurers ...func(*neo4j.TransactionConfig)) (interface{}, error) {
+	result, err := ts.SessionWithContext.ExecuteRead(ctx, ts.trace(work), configurers...)
+	recordError(ts.span, err)
+	return result, err
+}
+
+func (ts *tracingSession) ExecuteWrite(ctx context.Context, work neo4j.ManagedTransactionWork, configurers ...func(*neo4j.TransactionConfig)) (interface{}, error) {
+	result, err := ts.SessionWithContext.ExecuteWrite(ctx, ts.trace(work), configurers...)
+	recordError(ts.span, err)
+	return result, err
+}
+
+// trace runs the work with a transaction tracing its statements, once per attempt
+func (ts *tracingSession) trace(work neo4j.ManagedTransactionWork) neo4j.ManagedTransactionWork {
+	return func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		traced := &tracingTransaction{ManagedTransaction: tx, session: ts}
+		defer traced.end()
+		return work(traced)
+	}
+}
+
+func (ts *tracingSession) BeginTransaction(ctx context.Context, configurers ...func(*neo4j.TransactionConfig)) (neo4j.ExplicitTransaction, error) {
+	tx, err := ts.SessionWithContext.BeginTransaction(ctx, configurers...)
+	if err != nil {
+		recordError(ts.span, err)
+		return nil, err
+	}
+	return &tracingExplicitTransaction{
+		ExplicitTransaction: tx,
+		tracingTransaction:  &tracingTransaction{ManagedTransaction: tx, session: ts},
+	}, nil
+}
+
+func (ts *tracingSession) Close(ctx context.Context) error {
+	err := ts.SessionWithContext.Close(ctx)
+	recordError(ts.span, err)
+	ts.span.End()
+	return err
+}
+
+// tracingTransaction traces the statements it runs, and ends the spans of the results
+// left unread once over
+type tracingTransaction struct {
+	neo4j.ManagedTransaction
+	session *tracingSession
+
+	mutex sync.Mutex
+	spans []trace.Span
+}
+
+func (tt *tracingTransaction) Run(ctx context.Context, cypher string, params map[string]interface{}) (neo4j.ResultWithContext, error) {
+	name, found := services.QueryNameFromContext(ctx)
+	if !found {
+		name = "cypher"
+	}
+	_, span := Tracer().Start(tt.session.ctx, name, trace.WithAttributes(
+		attribute.String("db.system", "neo4j"),
+		attribute.String("db.query.text", cypher),
+	))
+	tt.mutex.Lock()
+	tt.spans = append(tt.spans, span)
+	tt.mutex.Unlock()
+
+	result, err := tt.ManagedTransaction.Run(ctx, cypher, params)
+	if err != nil {
+		recordError(span, err)
+		span.End()
+		return nil, err
+	}
+	return &tracingResult{ResultWithContext: result, span: span}, nil
+}
+
+func (tt *tracingTransaction) end() {
+	tt.mutex.Lock()
+	defer tt.mutex.Unlock()
+	for _, span := range tt.spans {
+		span.End()
+	}
+	tt.spans = nil
+}
+
+type tracingExplicitTransaction struct {
+	neo4j.ExplicitTransaction
+	*tracingTransaction
+}
+
+func (te *tracingExplicitTransaction) Run(ctx context.Context, cypher string, params map[string]interface{}) (neo4j.ResultWithContext, error) {
+	return te.tracingTransaction.Run(ctx, cypher, params)
+}
+
+func (te *tracingExplicitTransaction) Commit(ctx context.Context) error {
+	defer te.end()
+	return te.ExplicitTransaction.Commit(ctx)
+}
+
+func (te *tracingExplicitTransaction) Rollback(ctx context.Context) error {
+	defer te.end()
+	return te.ExplicitTransaction.Rollback(ctx)
+}
+
+func (te *tracingExplicitTransaction) Close(ctx context.Context) error {
+	defer te.end()
+	return te.ExplicitTransaction.Close(ctx)
+}
+
+// tracingResult counts the records read from the result, and ends the span of its
+// statement once the result is exhausted or consumed
+type tracingResult struct {
+	neo4j.ResultWithContext
+	span    trace.Span
+	records int
+}
+
+func (tr *tracingResult) Next(ctx context.Context) bool {
+	if tr.ResultWithContext.Next(ctx) {
+		tr.records++
+		return true
+	}
+	tr.end(tr.ResultWithContext.Err())
+	return false
+}
+
+func (tr *tracingResult) NextRecord(ctx context.Context, record **neo4j.Record) bool {
+	if tr.ResultWithContext.NextRecord(ctx, record) {
+		tr.records++
+		return true
+	}
+	tr.end(tr.ResultWithContext.Err())
+	return false
+}
+
+func (tr *tracingResult) Collect(ctx context.Context) ([]*neo4j.Record, error) {
+	records, err := tr.ResultWithContext.Collect(ctx)
+	tr.records += len(records)
+	tr.end(err)
+	return records, err
+}
+
+func (tr *tracingResult) Single(ctx context.Context) (*neo4j.Record, error) {
+	record, err := tr.ResultWithContext.Single(ctx)
+	if record != nil {
+		tr.records++
+	}
+	tr.end(err)
+	return record, err
+}
+
+func (tr *tracingResult) Consume(ctx context.Context) (neo4j.ResultSummary, error) {
+	summary, err := tr.ResultWithContext.Consume(ctx)
+	tr.end(err)
+	return summary, err
+}
+
+func (tr *tracingResult) end(err error) {
+	tr.span.SetAttributes(attribute.Int("db.response.returned_rows", tr.records))
+	recordError(tr.span, err)
+	tr.span.End()
+}
@@ -0,0 +1,87 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type fakeDriver struct {
+	neo4j.DriverWithContext
+}
+
+func (fd *fakeDriver) NewSession(context.Context, neo4j.SessionConfig) neo4j.SessionWithContext {
+	return &fakeSession{}
+}
+
+type fakeSession struct {
+	neo4j.SessionWithContext
+}
+
+func (fs *fakeSession) ExecuteRead(_ context.Context, work neo4j.ManagedTransactionWork, _ ...func(*neo4j.TransactionConfig)) (interface{}, error) {
+	return work(&fakeTransaction{})
+}
+
+func (fs *fakeSession) Close(context.Context) error {
+	return nil
+}
+
+type fakeTransaction struct {
+	neo4j.ManagedTransaction
+}
+
+func (ft *fakeTransaction) Run(context.Context, string, map[string]interface{}) (neo4j.ResultWithContext, error) {
+	return &fakeResult{}, nil
+}
+
+type fakeResult struct {
+	neo4j.ResultWithContext
+}
+
+func (fr *fakeResult) Collect(context.Context) ([]*neo4j.Record, error) {
+	return []*neo4j.Record{{}, {}}, nil
+}
+
+func TestTracingDriverNamesSpansAfterCallers(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	ctx := context.Background()
+
+	session := NewTracingDriver(&fakeDriver{}).NewSession(ctx, neo4j.SessionConfig{})
+	_, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := tx.Run(ctx, "MATCH (m:Movie) RETURN m", nil)
+		if err != nil {
+			return nil, err
+		}
+		return result.Collect(ctx)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := session.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected a session and a statement span, got %d", len(spans))
+	}
+	statement, sessionSpan := spans[0], spans[1]
+	if sessionSpan.Name() != "TestTracingDriverNamesSpansAfterCallers" {
+		t.Errorf("expected the session span to be named after its caller, got %s", sessionSpan.Name())
+	}
+	if statement.Parent().SpanID() != sessionSpan.SpanContext().SpanID() {
+		t.Errorf("expected the statement span to be a child of the session span")
+	}
+	attributes := map[string]interface{}{}
+	for _, attribute := range statement.Attributes() {
+		attributes[string(attribute.Key)] = attribute.Value.AsInterface()
+	}
+	if attributes["db.query.text"] != "MATCH (m:Movie) RETURN m" || attributes["db.response.returned_rows"] != int64(2) {
+		t.Errorf("unexpected statement attributes %v", attributes)
+	}
+}
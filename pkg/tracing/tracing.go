@@ -0,0 +1,65 @@
+// Package tracing traces the requests served by the app, the service methods they call
+// and the Cypher statements those run, and exports the spans over OTLP.
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the name of the tracer of the spans of the app
+const TracerName = "github.com/neo4j-graphacademy/neoflix"
+
+// Tracer returns the tracer of the app, from the global tracer provider which Setup
+// configures, a no-op one otherwise
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+type Config struct {
+	ServiceName string
+	// SampleRate is the fraction of the traces started by the app which are recorded,
+	// between 0 and 1. Traces started by the callers follow their sampling decision.
+	SampleRate float64
+}
+
+// Setup exports the spans of the app over OTLP/HTTP, to the collector configured by the
+// standard OTEL_EXPORTER_OTLP_* environment variables, http://localhost:4318 by default,
+// and propagates the W3C trace context of the requests.
+// It returns the function flushing the pending spans and stopping the export, to call
+// before the app exits.
+func Setup(ctx context.Context, config Config) (func(context.Context) error, error) {
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRate))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", config.ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// recordError marks the span as failed with the error, unless it is a client error such
+// as a missing movie
+func recordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	if withStatusCode, ok := err.(interface{ StatusCode() int }); ok && withStatusCode.StatusCode() < 500 {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
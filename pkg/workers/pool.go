@@ -0,0 +1,169 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrQueueFull is returned by TrySubmit when all workers are busy and the queue is full
+	ErrQueueFull = errors.New("worker pool queue is full")
+	// ErrDraining is returned when submitting tasks to a pool being drained
+	ErrDraining = errors.New("worker pool is draining")
+)
+
+// Task is run by one of the workers of a Pool.
+// Its context is cancelled when the pool drain times out.
+type Task func(ctx context.Context) error
+
+// Options configures a Pool
+type Options struct {
+	// Size is the number of tasks run concurrently, at least 1
+	Size int
+	// QueueDepth is the number of tasks waiting for a worker, beyond which submissions
+	// block or are rejected
+	QueueDepth int
+	// OnError is called with the errors returned by the tasks, if set
+	OnError func(error)
+}
+
+// Stats is a snapshot of the activity of a Pool
+type Stats struct {
+	Running   int64
+	Queued    int64
+	Completed int64
+	Failed    int64
+	Rejected  int64
+}
+
+// Pool runs tasks with bounded concurrency, for outbound calls such as the enrichment
+// of movies from third-party APIs which must not overwhelm those APIs.
+// Callers are pushed back when the queue is full: Submit blocks and TrySubmit fails.
+type Pool struct {
+	options Options
+	tasks   chan Task
+	ctx     context.Context
+	cancel  context.CancelFunc
+	workers sync.WaitGroup
+
+	// mutex guards draining, so that no task is sent to the closed tasks channel
+	mutex    sync.RWMutex
+	draining bool
+
+	running   int64
+	completed int64
+	failed    int64
+	rejected  int64
+}
+
+// New starts a Pool of options.Size workers
+func New(options Options) *Pool {
+	if options.Size < 1 {
+		options.Size = 1
+	}
+	if options.QueueDepth < 0 {
+		options.QueueDepth = 0
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	pool := &Pool{
+		options: options,
+		tasks:   make(chan Task, options.QueueDepth),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	pool.workers.Add(options.Size)
+	for i := 0; i < options.Size; i++ {
+		go pool.work()
+	}
+	return pool
+}
+
+// Submit queues the task, waiting for room in the queue until the context is done
+func (p *Pool) Submit(ctx context.Context, task Task) error {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	if p.draining {
+		atomic.AddInt64(&p.rejected, 1)
+		return ErrDraining
+	}
+	select {
+	case p.tasks <- task:
+		return nil
+	case <-ctx.Done():
+		atomic.AddInt64(&p.rejected, 1)
+		return ctx.Err()
+	}
+}
+
+// TrySubmit queues the task if there is room in the queue, and returns ErrQueueFull otherwise
+func (p *Pool) TrySubmit(task Task) error {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	if p.draining {
+		atomic.AddInt64(&p.rejected, 1)
+		return ErrDraining
+	}
+	select {
+	case p.tasks <- task:
+		return nil
+	default:
+		atomic.AddInt64(&p.rejected, 1)
+		return ErrQueueFull
+	}
+}
+
+// Stats returns the current activity of the pool
+func (p *Pool) Stats() Stats {
+	return Stats{
+		Running:   atomic.LoadInt64(&p.running),
+		Queued:    int64(len(p.tasks)),
+		Completed: atomic.LoadInt64(&p.completed),
+		Failed:    atomic.LoadInt64(&p.failed),
+		Rejected:  atomic.LoadInt64(&p.rejected),
+	}
+}
+
+// Drain stops accepting tasks and waits for the queued and running ones to complete.
+// When the context is done first, the context of the tasks is cancelled and the
+// context error is returned without waiting for them any further.
+func (p *Pool) Drain(ctx context.Context) error {
+	p.mutex.Lock()
+	if !p.draining {
+		p.draining = true
+		close(p.tasks)
+	}
+	p.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		return ctx.Err()
+	}
+}
+
+func (p *Pool) work() {
+	defer p.workers.Done()
+	for task := range p.tasks {
+		atomic.AddInt64(&p.running, 1)
+		err := task(p.ctx)
+		atomic.AddInt64(&p.running, -1)
+		if err != nil {
+			atomic.AddInt64(&p.failed, 1)
+			if p.options.OnError != nil {
+				p.options.OnError(err)
+			}
+			continue
+		}
+		atomic.AddInt64(&p.completed, 1)
+	}
+}
@@ -0,0 +1,107 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunsAtMostSizeTasksConcurrently(t *testing.T) {
+	pool := New(Options{Size: 2, QueueDepth: 10})
+	var running, maxRunning int32
+	for i := 0; i < 10; i++ {
+		err := pool.Submit(context.Background(), func(ctx context.Context) error {
+			current := atomic.AddInt32(&running, 1)
+			for {
+				observed := atomic.LoadInt32(&maxRunning)
+				if current <= observed || atomic.CompareAndSwapInt32(&maxRunning, observed, current) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := pool.Drain(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if maxRunning > 2 {
+		t.Fatalf("expected at most 2 concurrent tasks, got %d", maxRunning)
+	}
+	if stats := pool.Stats(); stats.Completed != 10 {
+		t.Fatalf("expected 10 completed tasks, got %+v", stats)
+	}
+}
+
+func TestRejectsTasksWhenQueueIsFull(t *testing.T) {
+	pool := New(Options{Size: 1, QueueDepth: 1})
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	blocking := func(ctx context.Context) error {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		return nil
+	}
+	_ = pool.TrySubmit(blocking)
+	<-started
+	if err := pool.TrySubmit(blocking); err != nil {
+		t.Fatalf("expected the task to be queued, got %v", err)
+	}
+
+	if err := pool.TrySubmit(blocking); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := pool.Submit(ctx, blocking); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the submission to time out, got %v", err)
+	}
+
+	close(release)
+	_ = pool.Drain(context.Background())
+	if stats := pool.Stats(); stats.Rejected != 2 || stats.Completed != 2 {
+		t.Fatalf("expected 2 rejected and 2 completed tasks, got %+v", stats)
+	}
+}
+
+func TestDrainCancelsTasksOnTimeout(t *testing.T) {
+	var failures int32
+	pool := New(Options{Size: 1, OnError: func(error) { atomic.AddInt32(&failures, 1) }})
+	cancelled := make(chan struct{})
+	_ = pool.Submit(context.Background(), func(ctx context.Context) error {
+		<-ctx.Done()
+		close(cancelled)
+		return ctx.Err()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := pool.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the drain to time out, got %v", err)
+	}
+	<-cancelled
+	waitFor(t, func() bool { return atomic.LoadInt32(&failures) == 1 })
+	if err := pool.TrySubmit(func(context.Context) error { return nil }); !errors.Is(err, ErrDraining) {
+		t.Fatalf("expected ErrDraining, got %v", err)
+	}
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}